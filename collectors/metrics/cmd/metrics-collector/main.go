@@ -4,6 +4,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	stdlog "log"
 	"net"
@@ -32,6 +33,7 @@ import (
 func main() {
 	opt := &Options{
 		From:             "http://localhost:9090",
+		SourceType:       string(forwarder.SourceFederate),
 		Listen:           "localhost:9002",
 		LimitBytes:       200 * 1024,
 		Rules:            []string{`{__name__="up"}`},
@@ -63,6 +65,12 @@ func main() {
 		"from",
 		opt.From,
 		"The Prometheus server to federate from.")
+	cmd.Flags().StringVar(
+		&opt.SourceType,
+		"source-type",
+		opt.SourceType,
+		`The API used to retrieve the metrics from the --from URL, one of federate, remote-read
+		 or query. The query source runs one instant query per match rule.`)
 	cmd.Flags().StringVar(
 		&opt.FromQuery,
 		"from-query",
@@ -147,6 +155,27 @@ func main() {
 		"collectrule",
 		opt.CollectRules,
 		"Define metrics collect rule is to collect additional metrics based on specified event.")
+	cmd.Flags().IntVar(
+		&opt.NamespaceSeriesLimit,
+		"namespace-series-limit",
+		opt.NamespaceSeriesLimit,
+		"The maximum number of series forwarded for each of the --limit-namespace namespaces, 0 means no limit.")
+	cmd.Flags().StringArrayVar(
+		&opt.LimitedNamespaces,
+		"limit-namespace",
+		opt.LimitedNamespaces,
+		"A namespace whose number of forwarded series is limited by --namespace-series-limit.")
+	cmd.Flags().StringVar(
+		&opt.StatusConditionType,
+		"status-condition-type",
+		opt.StatusConditionType,
+		"The dedicated condition of the observability addon the status is reported in, used by the collectors of "+
+			"the additional metrics sources. The status is merged in the addon status condition when empty.")
+	cmd.Flags().StringArrayVar(
+		&opt.ExemplarRules,
+		"exemplar-match",
+		opt.ExemplarRules,
+		"Series selectors of the metrics whose exemplars are queried and forwarded with the federated metrics.")
 	cmd.Flags().StringVar(
 		&opt.RecordingRulesFile,
 		"collect-file",
@@ -236,6 +265,7 @@ type Options struct {
 	Verbose    bool
 
 	From          string
+	SourceType    string
 	FromQuery     string
 	ToUpload      string
 	FromCAFile    string
@@ -260,6 +290,12 @@ type Options struct {
 	RecordingRulesFile string
 	CollectRules       []string
 	CollectRulesFile   string
+	ExemplarRules      []string
+
+	NamespaceSeriesLimit int
+	LimitedNamespaces    []string
+
+	StatusConditionType string
 
 	LabelFlag []string
 	Labels    map[string]string
@@ -279,12 +315,18 @@ type Options struct {
 	// how many threads are running
 	// for production, it is always 1
 	WorkerNum int64
+
+	// the mapping of the hosted clusters on HyperShift management clusters
+	hostedClusters metricfamily.HostedClusterMapper
+	// stop is closed on the shutdown of the forwarder, it stops the informers of the transformers
+	stop chan struct{}
 }
 
 func (o *Options) Run() error {
 
 	var g run.Group
 
+	o.stop = make(chan struct{})
 	err, cfg := initConfig(o)
 	if err != nil {
 		return err
@@ -310,6 +352,7 @@ func (o *Options) Run() error {
 			return nil
 		}, func(error) {
 			cancel()
+			close(o.stop)
 		})
 	}
 
@@ -344,6 +387,9 @@ func (o *Options) Run() error {
 			return worker.Reconfigure(*cfg)
 		})
 		handlers.Handle("/federate", serveLastMetrics(o.Logger, worker))
+		if o.hostedClusters != nil {
+			handlers.Handle("/debug/hostedclusters", serveHostedClusters(o.Logger, o.hostedClusters))
+		}
 		l, err := net.Listen("tcp", o.Listen)
 		if err != nil {
 			return fmt.Errorf("failed to listen: %v", err)
@@ -392,6 +438,7 @@ func runMultiWorkers(o *Options) error {
 	for i := 1; i < int(o.WorkerNum); i++ {
 		opt := &Options{
 			From:                    o.From,
+			SourceType:              o.SourceType,
 			FromQuery:               o.FromQuery,
 			ToUpload:                o.ToUpload,
 			FromCAFile:              o.FromCAFile,
@@ -406,6 +453,7 @@ func runMultiWorkers(o *Options) error {
 			Labels:                  map[string]string{},
 			SimulatedTimeseriesFile: o.SimulatedTimeseriesFile,
 			Logger:                  o.Logger,
+			stop:                    make(chan struct{}),
 		}
 		for _, flag := range o.LabelFlag {
 			values := strings.SplitN(flag, "=", 2)
@@ -424,6 +472,8 @@ func runMultiWorkers(o *Options) error {
 		if err != nil {
 			return err
 		}
+		// the simulated workers do not report their telemetry as the one of the managed cluster
+		forwardCfg.ReportTelemetry = false
 
 		forwardWorker, err := forwarder.New(*forwardCfg)
 		if err != nil {
@@ -434,6 +484,7 @@ func runMultiWorkers(o *Options) error {
 		go func() {
 			forwardWorker.Run(ctx)
 			cancel()
+			close(opt.stop)
 		}()
 
 	}
@@ -476,7 +527,7 @@ func initConfig(o *Options) (error, *forwarder.Config) {
 	}
 	from.Path = strings.TrimRight(from.Path, "/")
 	if len(from.Path) == 0 {
-		from.Path = "/federate"
+		from.Path = forwarder.DefaultSourcePath(forwarder.SourceType(o.SourceType))
 	}
 
 	fromQuery, err := url.Parse(o.FromQuery)
@@ -488,6 +539,15 @@ func initConfig(o *Options) (error, *forwarder.Config) {
 		fromQuery.Path = "/api/v1/query"
 	}
 
+	var fromExemplars *url.URL
+	if len(o.ExemplarRules) > 0 {
+		fromExemplars, err = url.Parse(o.FromQuery)
+		if err != nil {
+			return fmt.Errorf("--from-query is not a valid URL: %v", err), nil
+		}
+		fromExemplars.Path = "/api/v1/query_exemplars"
+	}
+
 	var toUpload *url.URL
 	if len(o.ToUpload) > 0 {
 		toUpload, err = url.Parse(o.ToUpload)
@@ -537,18 +597,22 @@ func initConfig(o *Options) (error, *forwarder.Config) {
 		return err, nil
 	}
 	if isHypershift {
-		hyperTransformer, err := metricfamily.NewHypershiftTransformer(o.Logger, nil, o.Labels)
+		hyperTransformer, err := metricfamily.NewHypershiftTransformer(o.Logger, nil, o.Labels, o.stop)
 		if err != nil {
 			return err, nil
 		}
 		transformer.WithFunc(func() metricfamily.Transformer {
 			return hyperTransformer
 		})
+		if mapper, ok := hyperTransformer.(metricfamily.HostedClusterMapper); ok {
+			o.hostedClusters = mapper
+		}
 	}
 
 	return nil, &forwarder.Config{
 		From:          from,
 		FromQuery:     fromQuery,
+		FromExemplars: fromExemplars,
 		ToUpload:      toUpload,
 		FromToken:     o.FromToken,
 		FromTokenFile: o.FromTokenFile,
@@ -568,7 +632,14 @@ func initConfig(o *Options) (error, *forwarder.Config) {
 		RulesFile:         o.RulesFile,
 		RecordingRules:    o.RecordingRules,
 		CollectRules:      o.CollectRules,
+		ExemplarRules:     o.ExemplarRules,
 		Transformer:       transformer,
+		SourceType:        forwarder.SourceType(o.SourceType),
+		ReportTelemetry:   o.StatusConditionType == "",
+
+		NamespaceSeriesLimit: o.NamespaceSeriesLimit,
+		LimitedNamespaces:    o.LimitedNamespaces,
+		StatusConditionType:  o.StatusConditionType,
 
 		Logger:                  o.Logger,
 		SimulatedTimeseriesFile: o.SimulatedTimeseriesFile,
@@ -596,3 +667,25 @@ func serveLastMetrics(l log.Logger, worker *forwarder.Worker) http.Handler {
 		}
 	})
 }
+
+// serveHostedClusters returns the hosted clusters known by the HyperShift transformer and the ids
+// found in the metrics which match no hosted cluster
+func serveHostedClusters(l log.Logger, mapper metricfamily.HostedClusterMapper) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "GET" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(struct {
+			HostedClusters map[string]string `json:"hostedClusters"`
+			Unresolved     []string          `json:"unresolved"`
+		}{
+			HostedClusters: mapper.HostedClusters(),
+			Unresolved:     mapper.UnresolvedIDs(),
+		})
+		if err != nil {
+			logger.Log(l, logger.Error, "msg", "unable to write the hosted clusters", "err", err)
+		}
+	})
+}
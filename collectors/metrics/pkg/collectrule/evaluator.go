@@ -21,6 +21,8 @@ import (
 	"github.com/stolostron/multicluster-observability-operator/collectors/metrics/pkg/forwarder"
 	rlogger "github.com/stolostron/multicluster-observability-operator/collectors/metrics/pkg/logger"
 	"github.com/stolostron/multicluster-observability-operator/collectors/metrics/pkg/metricsclient"
+	"github.com/stolostron/multicluster-observability-operator/collectors/metrics/pkg/status"
+	oav1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
 )
 
 const (
@@ -60,12 +62,15 @@ type Evaluator struct {
 	lock        sync.Mutex
 	reconfigure chan struct{}
 
+	status status.StatusReport
+
 	logger log.Logger
 }
 
 func New(cfg forwarder.Config) (*Evaluator, error) {
 	config = forwarder.Config{
 		From:          cfg.From,
+		SourceType:    cfg.SourceType,
 		FromToken:     cfg.FromToken,
 		FromTokenFile: cfg.FromTokenFile,
 		FromCAFile:    cfg.FromCAFile,
@@ -83,6 +88,8 @@ func New(cfg forwarder.Config) (*Evaluator, error) {
 		LimitBytes:        cfg.LimitBytes,
 		Transformer:       cfg.Transformer,
 
+		StatusConditionType: cfg.StatusConditionType,
+
 		Logger: cfg.Logger,
 	}
 	from := &url.URL{
@@ -112,6 +119,12 @@ func New(cfg forwarder.Config) (*Evaluator, error) {
 	}
 	evaluator.fromClient = fromClient
 
+	s, err := status.NewWithConditionType(evaluator.logger, cfg.StatusConditionType)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create StatusReport: %v", err)
+	}
+	evaluator.status = *s
+
 	return &evaluator, nil
 }
 
@@ -361,5 +374,12 @@ func (e *Evaluator) evaluate(ctx context.Context) {
 				rlogger.Log(e.logger, rlogger.Info, "msg", "forwarder started/reconfigued to collect metrics")
 			}
 		}
+
+		err := e.status.UpdateCollectorStatus(func(s *oav1beta1.MetricsCollectorStatus) {
+			s.CollectRuleMetrics = config.Rules
+		})
+		if err != nil {
+			rlogger.Log(e.logger, rlogger.Warn, "msg", "failed to report collect rule metrics", "err", err)
+		}
 	}
 }
@@ -13,14 +13,18 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log"
+	"github.com/gogo/protobuf/proto"
 
 	"github.com/prometheus/client_golang/prometheus"
 	clientmodel "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	metricshttp "github.com/stolostron/multicluster-observability-operator/collectors/metrics/pkg/http"
 	rlogger "github.com/stolostron/multicluster-observability-operator/collectors/metrics/pkg/logger"
@@ -28,10 +32,15 @@ import (
 	"github.com/stolostron/multicluster-observability-operator/collectors/metrics/pkg/metricsclient"
 	"github.com/stolostron/multicluster-observability-operator/collectors/metrics/pkg/simulator"
 	"github.com/stolostron/multicluster-observability-operator/collectors/metrics/pkg/status"
+	oav1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
 )
 
 const (
 	failedStatusReportMsg = "Failed to report status"
+
+	// telemetryReportInterval is the maximum frequency of telemetry updates in the addon status
+	// while the health of the worker does not change.
+	telemetryReportInterval = 5 * time.Minute
 )
 
 var (
@@ -64,6 +73,7 @@ func init() {
 type Config struct {
 	From          *url.URL
 	FromQuery     *url.URL
+	FromExemplars *url.URL
 	ToUpload      *url.URL
 	FromToken     string
 	FromTokenFile string
@@ -85,8 +95,24 @@ type Config struct {
 	RecordingRulesFile string
 	CollectRules       []string
 	CollectRulesFile   string
+	ExemplarRules      []string
 	Transformer        metricfamily.Transformer
 
+	// NamespaceSeriesLimit is the maximum number of series forwarded for each of the
+	// LimitedNamespaces, a value of 0 disables the limit.
+	NamespaceSeriesLimit int
+	LimitedNamespaces    []string
+
+	// SourceType is the API used to retrieve the metrics from `From`, defaults to SourceFederate.
+	SourceType SourceType
+
+	// ReportTelemetry enables reporting the forwarding telemetry in the addon status.
+	ReportTelemetry bool
+
+	// StatusConditionType is the dedicated addon condition the status is reported in, empty
+	// to merge the status in the addon status condition.
+	StatusConditionType string
+
 	Logger                  log.Logger
 	SimulatedTimeseriesFile string
 }
@@ -95,16 +121,21 @@ type Config struct {
 // A Worker should be configured with a `Config` and instantiated with the `New` func.
 // Workers are thread safe; all access to shared fields are synchronized.
 type Worker struct {
-	fromClient *metricsclient.Client
-	toClient   *metricsclient.Client
-	from       *url.URL
-	fromQuery  *url.URL
-	to         *url.URL
+	fromClient    *metricsclient.Client
+	toClient      *metricsclient.Client
+	source        Source
+	fromQuery     *url.URL
+	fromExemplars *url.URL
+	to            *url.URL
 
 	interval       time.Duration
 	transformer    metricfamily.Transformer
 	rules          []string
 	recordingRules []string
+	exemplarRules  []string
+
+	namespaceSeriesLimit int
+	limitedNamespaces    map[string]bool
 
 	lastMetrics []*clientmodel.MetricFamily
 	lock        sync.Mutex
@@ -114,7 +145,15 @@ type Worker struct {
 
 	simulatedTimeseriesFile string
 
-	status status.StatusReport
+	status          status.StatusReport
+	reportTelemetry bool
+
+	// telemetry of the last forwarding attempts
+	seriesSent          int64
+	bytesSent           int64
+	lastPushTime        *time.Time
+	consecutiveFailures int64
+	lastTelemetryReport time.Time
 }
 
 func CreateFromClient(cfg Config, interval time.Duration, name string,
@@ -226,13 +265,14 @@ func New(cfg Config) (*Worker, error) {
 	logger := log.With(cfg.Logger, "component", "forwarder")
 	rlogger.Log(logger, rlogger.Warn, "msg", cfg.ToUpload)
 	w := Worker{
-		from:                    cfg.From,
 		fromQuery:               cfg.FromQuery,
+		fromExemplars:           cfg.FromExemplars,
 		interval:                cfg.Interval,
 		reconfigure:             make(chan struct{}),
 		to:                      cfg.ToUpload,
 		logger:                  log.With(cfg.Logger, "component", "forwarder/worker"),
 		simulatedTimeseriesFile: cfg.SimulatedTimeseriesFile,
+		reportTelemetry:         cfg.ReportTelemetry,
 	}
 
 	if w.interval == 0 {
@@ -247,6 +287,12 @@ func New(cfg Config) (*Worker, error) {
 	w.toClient = toClient
 	w.transformer = transformer
 
+	source, err := NewSource(cfg.SourceType, cfg.From, fromClient)
+	if err != nil {
+		return nil, err
+	}
+	w.source = source
+
 	// Configure the matching rules.
 	rules := cfg.Rules
 	if len(cfg.RulesFile) > 0 {
@@ -280,7 +326,35 @@ func New(cfg Config) (*Worker, error) {
 	}
 	w.recordingRules = recordingRules
 
-	s, err := status.New(logger)
+	// Configure the exemplar rules.
+	exemplarRules := cfg.ExemplarRules
+	for i := 0; i < len(exemplarRules); {
+		s := strings.TrimSpace(exemplarRules[i])
+		if len(s) == 0 {
+			exemplarRules = append(exemplarRules[:i], exemplarRules[i+1:]...)
+			continue
+		}
+		exemplarRules[i] = s
+		i++
+	}
+	if len(exemplarRules) > 0 && w.fromExemplars == nil {
+		return nil, errors.New("a URL from which to query exemplars is required when exemplar rules are set")
+	}
+	w.exemplarRules = exemplarRules
+
+	// Configure the namespace series limit.
+	if cfg.NamespaceSeriesLimit < 0 {
+		return nil, errors.New("the namespace series limit must not be negative")
+	}
+	w.namespaceSeriesLimit = cfg.NamespaceSeriesLimit
+	w.limitedNamespaces = map[string]bool{}
+	for _, ns := range cfg.LimitedNamespaces {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			w.limitedNamespaces[ns] = true
+		}
+	}
+
+	s, err := status.NewWithConditionType(logger, cfg.StatusConditionType)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create StatusReport: %v", err)
 	}
@@ -303,11 +377,16 @@ func (w *Worker) Reconfigure(cfg Config) error {
 	w.fromClient = worker.fromClient
 	w.toClient = worker.toClient
 	w.interval = worker.interval
-	w.from = worker.from
+	w.source = worker.source
+	w.fromExemplars = worker.fromExemplars
 	w.to = worker.to
 	w.transformer = worker.transformer
 	w.rules = worker.rules
 	w.recordingRules = worker.recordingRules
+	w.exemplarRules = worker.exemplarRules
+	w.namespaceSeriesLimit = worker.namespaceSeriesLimit
+	w.limitedNamespaces = worker.limitedNamespaces
+	w.reportTelemetry = worker.reportTelemetry
 
 	// Signal a restart to Run func.
 	// Do this in a goroutine since we do not care if restarting the Run loop is asynchronous.
@@ -329,11 +408,13 @@ func (w *Worker) Run(ctx context.Context) {
 		// The critical section ends here.
 		w.lock.Unlock()
 
-		if err := w.forward(ctx); err != nil {
+		err := w.forward(ctx)
+		if err != nil {
 			gaugeFederateErrors.Inc()
 			rlogger.Log(w.logger, rlogger.Error, "msg", "unable to forward results", "err", err)
 			wait = time.Minute
 		}
+		w.updateTelemetry(err)
 
 		select {
 		// If the context is cancelled, then we're done.
@@ -351,6 +432,7 @@ func (w *Worker) forward(ctx context.Context) error {
 	defer w.lock.Unlock()
 
 	var families []*clientmodel.MetricFamily
+	var exemplars metricsclient.Exemplars
 	var err error
 	if w.simulatedTimeseriesFile != "" {
 		families, err = simulator.FetchSimulatedTimeseries(w.simulatedTimeseriesFile)
@@ -360,7 +442,7 @@ func (w *Worker) forward(ctx context.Context) error {
 	} else if os.Getenv("SIMULATE") == "true" {
 		families = simulator.SimulateMetrics(w.logger)
 	} else {
-		families, err = w.getFederateMetrics(ctx)
+		families, err = w.getSourceMetrics(ctx)
 		if err != nil {
 			statusErr := w.status.UpdateStatus("Degraded", "Degraded", "Failed to retrieve metrics")
 			if statusErr != nil {
@@ -379,6 +461,12 @@ func (w *Worker) forward(ctx context.Context) error {
 		} else {
 			families = append(families, rfamilies...)
 		}
+
+		// Exemplars are best effort, failing to retrieve them must not block forwarding the metrics.
+		exemplars, err = w.getExemplars(ctx)
+		if err != nil {
+			rlogger.Log(w.logger, rlogger.Warn, "msg", "Failed to retrieve exemplars", "err", err)
+		}
 	}
 
 	before := metricfamily.MetricsCount(families)
@@ -391,6 +479,20 @@ func (w *Worker) forward(ctx context.Context) error {
 	}
 
 	families = metricfamily.Pack(families)
+	if w.namespaceSeriesLimit > 0 && len(w.limitedNamespaces) > 0 {
+		dropped := metricfamily.LimitSeriesPerLabelValue(families, "namespace", w.limitedNamespaces,
+			w.namespaceSeriesLimit)
+		for ns, count := range dropped {
+			rlogger.Log(w.logger, rlogger.Warn, "msg", "namespace series quota exceeded, dropping series",
+				"namespace", ns, "quota", w.namespaceSeriesLimit, "dropped", count)
+		}
+		if len(dropped) > 0 {
+			for _, family := range families {
+				metricfamily.PackMetrics(family)
+			}
+			families = metricfamily.Pack(families)
+		}
+	}
 	after := metricfamily.MetricsCount(families)
 
 	gaugeFederateSamples.Set(float64(before))
@@ -417,7 +519,13 @@ func (w *Worker) forward(ctx context.Context) error {
 	}
 
 	req := &http.Request{Method: "POST", URL: w.to}
-	err = w.toClient.RemoteWrite(ctx, req, families, w.interval)
+	sent, err := w.toClient.RemoteWrite(ctx, req, families, exemplars, w.interval)
+	if err == nil {
+		now := time.Now()
+		w.lastPushTime = &now
+		w.seriesSent = int64(after)
+		w.bytesSent = sent
+	}
 	if err != nil {
 		statusErr := w.status.UpdateStatus("Degraded", "Degraded", "Failed to send metrics")
 		if statusErr != nil {
@@ -433,21 +541,52 @@ func (w *Worker) forward(ctx context.Context) error {
 	return err
 }
 
-func (w *Worker) getFederateMetrics(ctx context.Context) ([]*clientmodel.MetricFamily, error) {
-	var families []*clientmodel.MetricFamily
-	var err error
+// updateTelemetry records the result of the last forwarding attempt and reports the telemetry
+// in the addon status. To limit the writes, the telemetry is only reported when the worker
+// starts or recovers from failing, or when the last report is older than telemetryReportInterval.
+func (w *Worker) updateTelemetry(forwardErr error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
 
-	// reset query from last invocation, otherwise match rules will be appended
-	from := w.from
-	from.RawQuery = ""
-	v := from.Query()
-	for _, rule := range w.rules {
-		v.Add("match[]", rule)
+	healthChanged := false
+	if forwardErr != nil {
+		w.consecutiveFailures++
+		healthChanged = w.consecutiveFailures == 1
+	} else {
+		healthChanged = w.consecutiveFailures != 0
+		w.consecutiveFailures = 0
+	}
+	if !w.reportTelemetry || (!healthChanged && time.Since(w.lastTelemetryReport) < telemetryReportInterval) {
+		return
 	}
-	from.RawQuery = v.Encode()
 
-	req := &http.Request{Method: "GET", URL: from}
-	families, err = w.fromClient.Retrieve(ctx, req)
+	err := w.status.UpdateCollectorStatus(func(s *oav1beta1.MetricsCollectorStatus) {
+		if w.lastPushTime != nil {
+			t := metav1.NewTime(*w.lastPushTime)
+			s.LastSuccessfulPushTime = &t
+		}
+		s.SeriesSent = w.seriesSent
+		s.BytesSent = w.bytesSent
+		s.ConsecutiveFailures = w.consecutiveFailures
+		s.MemoryUsageBytes = getMemoryUsage()
+	})
+	if err != nil {
+		rlogger.Log(w.logger, rlogger.Warn, "msg", failedStatusReportMsg, "err", err)
+		return
+	}
+	w.lastTelemetryReport = time.Now()
+}
+
+// getMemoryUsage returns the memory obtained from the OS and not yet released, which approximates
+// the resident memory of the collector.
+func getMemoryUsage() int64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return int64(m.Sys - m.HeapReleased)
+}
+
+func (w *Worker) getSourceMetrics(ctx context.Context) ([]*clientmodel.MetricFamily, error) {
+	families, err := w.source.Retrieve(ctx, w.rules)
 	if err != nil {
 		rlogger.Log(w.logger, rlogger.Warn, "msg", "Failed to retrieve metrics", "err", err)
 		return families, err
@@ -492,3 +631,87 @@ func (w *Worker) getRecordingMetrics(ctx context.Context) ([]*clientmodel.Metric
 
 	return families, e
 }
+
+// getExemplars retrieves the exemplars of the series matching the exemplar rules within the
+// last interval. The series labels are passed through the same transformer as the federated
+// metrics so that the exemplars can be matched against the outgoing time series.
+func (w *Worker) getExemplars(ctx context.Context) (metricsclient.Exemplars, error) {
+	if len(w.exemplarRules) == 0 {
+		return nil, nil
+	}
+
+	var e error
+	exemplars := metricsclient.Exemplars{}
+	end := time.Now()
+	start := end.Add(-w.interval)
+	from := w.fromExemplars
+	for _, rule := range w.exemplarRules {
+		// reset query from last invocation, otherwise the query will be appended
+		from.RawQuery = ""
+		v := from.Query()
+		v.Add("query", rule)
+		v.Add("start", strconv.FormatInt(start.Unix(), 10))
+		v.Add("end", strconv.FormatInt(end.Unix(), 10))
+		from.RawQuery = v.Encode()
+
+		req := &http.Request{Method: "GET", URL: from}
+		series, err := w.fromClient.RetrieveExemplars(ctx, req)
+		if err != nil {
+			rlogger.Log(w.logger, rlogger.Warn, "msg", "Failed to retrieve exemplars", "err", err, "rule", rule)
+			e = err
+			continue
+		}
+
+		for _, s := range series {
+			family := exemplarSeriesToFamily(s.SeriesLabels, end)
+			if family == nil {
+				continue
+			}
+			ok, err := w.transformer.Transform(family)
+			if err != nil {
+				rlogger.Log(w.logger, rlogger.Warn, "msg", "Failed to transform exemplar series", "err", err)
+				e = err
+				continue
+			}
+			if !ok {
+				continue
+			}
+			for _, m := range family.Metric {
+				if m == nil {
+					continue
+				}
+				key := metricsclient.SeriesKey(family.GetName(), m.Label)
+				exemplars[key] = append(exemplars[key], s.Exemplars...)
+			}
+		}
+	}
+
+	return exemplars, e
+}
+
+// exemplarSeriesToFamily builds a single-sample metric family from the labels of the series
+// returned by the query_exemplars API.
+func exemplarSeriesToFamily(seriesLabels map[string]string, now time.Time) *clientmodel.MetricFamily {
+	name := seriesLabels["__name__"]
+	if name == "" {
+		return nil
+	}
+	metric := &clientmodel.Metric{
+		Untyped:     &clientmodel.Untyped{Value: proto.Float64(0)},
+		TimestampMs: proto.Int64(now.UnixNano() / int64(time.Millisecond)),
+	}
+	for k, v := range seriesLabels {
+		if k == "__name__" || v == "" {
+			continue
+		}
+		metric.Label = append(metric.Label, &clientmodel.LabelPair{
+			Name:  proto.String(k),
+			Value: proto.String(v),
+		})
+	}
+	return &clientmodel.MetricFamily{
+		Name:   proto.String(name),
+		Type:   clientmodel.MetricType_UNTYPED.Enum(),
+		Metric: []*clientmodel.Metric{metric},
+	}
+}
@@ -111,6 +111,43 @@ func TestNew(t *testing.T) {
 			},
 			err: false,
 		},
+		{
+			// Providing `ExemplarRules` without `FromExemplars` should error.
+			c: Config{
+				From:          from,
+				ExemplarRules: []string{`{__name__="foo"}`},
+				Logger:        log.NewNopLogger(),
+			},
+			err: true,
+		},
+		{
+			// Providing `ExemplarRules` and `FromExemplars` should not error.
+			c: Config{
+				From:          from,
+				FromExemplars: from,
+				ExemplarRules: []string{`{__name__="foo"}`},
+				Logger:        log.NewNopLogger(),
+			},
+			err: false,
+		},
+		{
+			// Providing a supported `SourceType` should not error.
+			c: Config{
+				From:       from,
+				SourceType: SourceRemoteRead,
+				Logger:     log.NewNopLogger(),
+			},
+			err: false,
+		},
+		{
+			// Providing an unsupported `SourceType` should error.
+			c: Config{
+				From:       from,
+				SourceType: "graphite",
+				Logger:     log.NewNopLogger(),
+			},
+			err: true,
+		},
 		{
 			// Providing an invalid `FromCAFile` should error.
 			c: Config{
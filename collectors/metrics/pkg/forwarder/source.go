@@ -0,0 +1,164 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package forwarder
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	clientmodel "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/stolostron/multicluster-observability-operator/collectors/metrics/pkg/metricsclient"
+)
+
+// SourceType is the kind of API the metrics are retrieved from.
+type SourceType string
+
+const (
+	// SourceFederate retrieves the metrics from the Prometheus /federate endpoint.
+	SourceFederate SourceType = "federate"
+	// SourceRemoteRead retrieves the metrics through the Prometheus remote read API.
+	SourceRemoteRead SourceType = "remote-read"
+	// SourceQuery retrieves the metrics with one PromQL instant query per match rule,
+	// for the backends which only implement the Prometheus query API.
+	SourceQuery SourceType = "query"
+
+	// remoteReadLookback is the time range of the remote read queries. It matches the
+	// lookback delta used by Prometheus to answer federation requests.
+	remoteReadLookback = 5 * time.Minute
+)
+
+// Source retrieves the metric families matching the match rules from a metrics backend.
+type Source interface {
+	Retrieve(ctx context.Context, rules []string) ([]*clientmodel.MetricFamily, error)
+}
+
+// DefaultSourcePath returns the API path used when the source URL does not define one.
+func DefaultSourcePath(sourceType SourceType) string {
+	switch sourceType {
+	case SourceRemoteRead:
+		return "/api/v1/read"
+	case SourceQuery:
+		return "/api/v1/query"
+	default:
+		return "/federate"
+	}
+}
+
+// NewSource creates the Source of the given type retrieving the metrics from the URL.
+// An empty type defaults to SourceFederate.
+func NewSource(sourceType SourceType, from *url.URL, client *metricsclient.Client) (Source, error) {
+	switch sourceType {
+	case "", SourceFederate:
+		return &federateSource{from: from, client: client}, nil
+	case SourceRemoteRead:
+		return &remoteReadSource{from: from, client: client}, nil
+	case SourceQuery:
+		return &querySource{from: from, client: client}, nil
+	default:
+		return nil, fmt.Errorf("unsupported source type %q", sourceType)
+	}
+}
+
+type federateSource struct {
+	from   *url.URL
+	client *metricsclient.Client
+}
+
+func (s *federateSource) Retrieve(ctx context.Context, rules []string) ([]*clientmodel.MetricFamily, error) {
+	// reset query from last invocation, otherwise match rules will be appended
+	from := *s.from
+	from.RawQuery = ""
+	v := from.Query()
+	for _, rule := range rules {
+		v.Add("match[]", rule)
+	}
+	from.RawQuery = v.Encode()
+
+	req := &http.Request{Method: "GET", URL: &from}
+	return s.client.Retrieve(ctx, req)
+}
+
+type remoteReadSource struct {
+	from   *url.URL
+	client *metricsclient.Client
+}
+
+func (s *remoteReadSource) Retrieve(ctx context.Context, rules []string) ([]*clientmodel.MetricFamily, error) {
+	end := time.Now()
+	start := end.Add(-remoteReadLookback)
+	queries := make([]*prompb.Query, 0, len(rules))
+	for _, rule := range rules {
+		matchers, err := parser.ParseMetricSelector(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match rule %q: %v", rule, err)
+		}
+		query, err := toRemoteReadQuery(start, end, matchers)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match rule %q: %v", rule, err)
+		}
+		queries = append(queries, query)
+	}
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	from := *s.from
+	req := &http.Request{Method: "POST", URL: &from}
+	return s.client.RemoteRead(ctx, req, queries)
+}
+
+func toRemoteReadQuery(start, end time.Time, matchers []*labels.Matcher) (*prompb.Query, error) {
+	query := &prompb.Query{
+		StartTimestampMs: start.UnixNano() / int64(time.Millisecond),
+		EndTimestampMs:   end.UnixNano() / int64(time.Millisecond),
+	}
+	for _, m := range matchers {
+		var t prompb.LabelMatcher_Type
+		switch m.Type {
+		case labels.MatchEqual:
+			t = prompb.LabelMatcher_EQ
+		case labels.MatchNotEqual:
+			t = prompb.LabelMatcher_NEQ
+		case labels.MatchRegexp:
+			t = prompb.LabelMatcher_RE
+		case labels.MatchNotRegexp:
+			t = prompb.LabelMatcher_NRE
+		default:
+			return nil, fmt.Errorf("unsupported matcher type %v", m.Type)
+		}
+		query.Matchers = append(query.Matchers, &prompb.LabelMatcher{Type: t, Name: m.Name, Value: m.Value})
+	}
+	return query, nil
+}
+
+type querySource struct {
+	from   *url.URL
+	client *metricsclient.Client
+}
+
+func (s *querySource) Retrieve(ctx context.Context, rules []string) ([]*clientmodel.MetricFamily, error) {
+	var families []*clientmodel.MetricFamily
+	for _, rule := range rules {
+		// reset query from last invocation, otherwise the query will be appended
+		from := *s.from
+		from.RawQuery = ""
+		v := from.Query()
+		v.Add("query", rule)
+		from.RawQuery = v.Encode()
+
+		req := &http.Request{Method: "GET", URL: &from}
+		rfamilies, err := s.client.RetrieveQueryMetrics(ctx, req)
+		if err != nil {
+			return families, err
+		}
+		families = append(families, rfamilies...)
+	}
+	return families, nil
+}
@@ -0,0 +1,159 @@
+// Copyright Contributors to the Open Cluster Management project
+package forwarder
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/stolostron/multicluster-observability-operator/collectors/metrics/pkg/metricsclient"
+)
+
+func newTestSource(t *testing.T, sourceType SourceType, handler http.HandlerFunc) Source {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	from, err := url.Parse(ts.URL + DefaultSourcePath(sourceType))
+	if err != nil {
+		t.Fatalf("failed to parse source URL: %v", err)
+	}
+	client := metricsclient.New(log.NewNopLogger(), ts.Client(), 200*1024, time.Minute, "test")
+	source, err := NewSource(sourceType, from, client)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	return source
+}
+
+func TestFederateSource(t *testing.T) {
+	source := newTestSource(t, SourceFederate, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/federate" {
+			t.Errorf("request path (%v) is not the expected: (/federate)", r.URL.Path)
+		}
+		if match := r.URL.Query()["match[]"]; len(match) != 1 || match[0] != `{__name__="up"}` {
+			t.Errorf("match rules (%v) are not the expected: ([{__name__=\"up\"}])", match)
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = w.Write([]byte("up{job=\"foo\"} 1\n"))
+	})
+
+	// retrieve twice to ensure the match rules of the previous call are not kept
+	for i := 0; i < 2; i++ {
+		families, err := source.Retrieve(context.Background(), []string{`{__name__="up"}`})
+		if err != nil {
+			t.Fatalf("failed to retrieve metrics: %v", err)
+		}
+		if len(families) == 0 || families[0].GetName() != "up" {
+			t.Errorf("retrieved metrics (%v) are not the expected: (up)", families)
+		}
+	}
+}
+
+func TestRemoteReadSource(t *testing.T) {
+	source := newTestSource(t, SourceRemoteRead, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/read" {
+			t.Errorf("request (%v %v) is not the expected: (POST /api/v1/read)", r.Method, r.URL.Path)
+		}
+		compressed, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read request: %v", err)
+		}
+		data, err := snappy.Decode(nil, compressed)
+		if err != nil {
+			t.Fatalf("failed to decompress request: %v", err)
+		}
+		var req prompb.ReadRequest
+		if err := proto.Unmarshal(data, &req); err != nil {
+			t.Fatalf("failed to unmarshal request: %v", err)
+		}
+		if len(req.Queries) != 1 || len(req.Queries[0].Matchers) != 2 {
+			t.Fatalf("queries (%v) are not the expected: (1 query with 2 matchers)", req.Queries)
+		}
+		found := false
+		for _, m := range req.Queries[0].Matchers {
+			if m.Type == prompb.LabelMatcher_RE && m.Name == "job" && m.Value == "foo.*" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("matchers (%v) do not contain the expected: (job=~\"foo.*\")", req.Queries[0].Matchers)
+		}
+
+		resp := &prompb.ReadResponse{
+			Results: []*prompb.QueryResult{{
+				Timeseries: []*prompb.TimeSeries{{
+					Labels: []prompb.Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "foo"}},
+					Samples: []prompb.Sample{
+						{Value: 0, Timestamp: 1000},
+						{Value: 1, Timestamp: 2000},
+					},
+				}},
+			}},
+		}
+		data, err = proto.Marshal(resp)
+		if err != nil {
+			t.Fatalf("failed to marshal response: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Header().Set("Content-Encoding", "snappy")
+		_, _ = w.Write(snappy.Encode(nil, data))
+	})
+
+	families, err := source.Retrieve(context.Background(), []string{`up{job=~"foo.*"}`})
+	if err != nil {
+		t.Fatalf("failed to retrieve metrics: %v", err)
+	}
+	if len(families) != 1 || families[0].GetName() != "up" || len(families[0].Metric) != 1 {
+		t.Fatalf("retrieved metrics (%v) are not the expected: (up)", families)
+	}
+	m := families[0].Metric[0]
+	if m.GetUntyped().GetValue() != 1 || m.GetTimestampMs() != 2000 {
+		t.Errorf("retrieved sample (%v) is not the expected latest sample: (1 @ 2000)", m)
+	}
+	if len(m.Label) != 1 || m.Label[0].GetName() != "job" {
+		t.Errorf("retrieved labels (%v) are not the expected: ([job=foo])", m.Label)
+	}
+
+	if _, err := source.Retrieve(context.Background(), []string{`up{`}); err == nil {
+		t.Errorf("expected an error for an invalid match rule")
+	}
+}
+
+func TestQuerySource(t *testing.T) {
+	source := newTestSource(t, SourceQuery, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			t.Errorf("request path (%v) is not the expected: (/api/v1/query)", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("query") {
+		case `{__name__="up"}`:
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"__name__":"up","job":"foo"},"value":[1435781451.781,"1"]}]}}`))
+		default:
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"job":"foo"},"value":[1435781451.781,"3"]}]}}`))
+		}
+	})
+
+	families, err := source.Retrieve(context.Background(), []string{`{__name__="up"}`, `count(up)`})
+	if err != nil {
+		t.Fatalf("failed to retrieve metrics: %v", err)
+	}
+	if len(families) != 1 || families[0].GetName() != "up" {
+		t.Fatalf("retrieved metrics (%v) are not the expected: (up)", families)
+	}
+	for _, l := range families[0].Metric[0].Label {
+		if l.GetName() == "__name__" {
+			t.Errorf("retrieved labels (%v) should not contain the metric name", families[0].Metric[0].Label)
+		}
+	}
+}
@@ -4,14 +4,21 @@ package metricfamily
 import (
 	"context"
 	"errors"
-	"fmt"
 	"os"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	hyperv1 "github.com/openshift/hypershift/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
 	clientmodel "github.com/prometheus/client_model/go"
 	prom "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -26,19 +33,49 @@ var (
 	CLUSTER_ID_LABEL            = "clusterID"
 	MANAGEMENT_CLUSTER_LABEL    = "managementcluster"
 	MANAGEMENT_CLUSTER_ID_LABEL = "managementclusterID"
+	UNRESOLVED_CLUSTER_LABEL    = "unresolvedcluster"
 )
 
+var (
+	unresolvedSeries = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "metricscollector_hypershift_unresolved_series_total",
+		Help: "Number of series of the hosted clusters forwarded before their HostedCluster is known",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(unresolvedSeries)
+}
+
+// hostedClusterSyncTimeout bounds the initial listing of the hosted clusters.
+const hostedClusterSyncTimeout = time.Minute
+
+// clusterIDIndex indexes the hosted clusters by their cluster id.
+const clusterIDIndex = "clusterID"
+
+// HostedClusterMapper exposes the mapping of the hosted cluster ids to their names.
+type HostedClusterMapper interface {
+	// HostedClusters returns the names of the known hosted clusters by cluster id.
+	HostedClusters() map[string]string
+	// UnresolvedIDs returns the ids found in the metrics which match no hosted cluster.
+	UnresolvedIDs() []string
+}
+
 type hypershiftTransformer struct {
-	kubeClient          client.Client
 	logger              log.Logger
-	hostedClusters      map[string]string
+	hostedClusters      cache.Indexer
 	managementCluster   string
 	managementClusterID string
+
+	mu         sync.Mutex
+	unresolved map[string]struct{}
 }
 
-func NewHypershiftTransformer(l log.Logger, c client.Client, labels map[string]string) (Transformer, error) {
+// NewHypershiftTransformer returns the transformer which labels the metrics of the hosted clusters with their
+// name, the informer of the hosted clusters runs until stop is closed.
+func NewHypershiftTransformer(l log.Logger, c client.WithWatch, labels map[string]string,
+	stop <-chan struct{}) (Transformer, error) {
 
-	clusters := map[string]string{}
 	hClient := c
 	if hClient == nil {
 		if os.Getenv("UNIT_TEST") != "true" {
@@ -50,7 +87,7 @@ func NewHypershiftTransformer(l log.Logger, c client.Client, labels map[string]s
 			if err := hyperv1.AddToScheme(s); err != nil {
 				return nil, errors.New("Failed to add observabilityaddon into scheme")
 			}
-			hClient, err = client.New(config, client.Options{Scheme: s})
+			hClient, err = client.NewWithWatch(config, client.Options{Scheme: s})
 			if err != nil {
 				return nil, errors.New("Failed to create the kube client")
 			}
@@ -64,17 +101,17 @@ func NewHypershiftTransformer(l log.Logger, c client.Client, labels map[string]s
 		}
 	}
 
-	clusters, err := getHostedClusters(hClient, l)
+	indexer, err := startHostedClusterInformer(hClient, l, stop)
 	if err != nil {
 		return nil, err
 	}
 
 	return &hypershiftTransformer{
-		kubeClient:          hClient,
 		logger:              l,
-		hostedClusters:      clusters,
+		hostedClusters:      indexer,
 		managementCluster:   labels[CLUSTER_LABEL],
 		managementClusterID: labels[CLUSTER_ID_LABEL],
+		unresolved:          map[string]struct{}{},
 	}, nil
 }
 
@@ -90,16 +127,21 @@ func (h *hypershiftTransformer) Transform(family *prom.MetricFamily) (bool, erro
 			if family.Metric[i].Label[j].GetName() == HYPERSHIFT_ID {
 				isHypershift = true
 				id := family.Metric[i].Label[j].GetValue()
-				clusterName, err := getClusterName(h, id)
-				if err != nil {
-					return false, err
-				}
+				clusterName, ok := h.getClusterName(id)
 				overrides := map[string]*clientmodel.LabelPair{
 					MANAGEMENT_CLUSTER_LABEL:    {Name: &MANAGEMENT_CLUSTER_LABEL, Value: &h.managementCluster},
 					MANAGEMENT_CLUSTER_ID_LABEL: {Name: &MANAGEMENT_CLUSTER_ID_LABEL, Value: &h.managementClusterID},
 					CLUSTER_ID_LABEL:            {Name: &CLUSTER_ID_LABEL, Value: &id},
 					CLUSTER_LABEL:               {Name: &CLUSTER_LABEL, Value: &clusterName},
 				}
+				if !ok {
+					// the series of an unknown hosted cluster are forwarded under its id rather than
+					// failing the whole batch, until the informer knows the hosted cluster
+					unresolved := "true"
+					overrides[UNRESOLVED_CLUSTER_LABEL] = &clientmodel.LabelPair{
+						Name: &UNRESOLVED_CLUSTER_LABEL, Value: &unresolved}
+					unresolvedSeries.Inc()
+				}
 
 				labels = appendLabels(labels, overrides)
 
@@ -121,20 +163,44 @@ func (h *hypershiftTransformer) Transform(family *prom.MetricFamily) (bool, erro
 	return true, nil
 }
 
-func getClusterName(h *hypershiftTransformer, id string) (string, error) {
-	clusterName, ok := h.hostedClusters[id]
-	if !ok {
-		clusters, err := getHostedClusters(h.kubeClient, h.logger)
-		h.hostedClusters = clusters
-		if err != nil {
-			return "", err
-		}
-		clusterName, ok = h.hostedClusters[id]
-		if !ok {
-			return "", errors.New(fmt.Sprintf("Failed to find HosteCluster with id: %s", id))
-		}
+// getClusterName returns the name of the hosted cluster with the id, or the id itself and false if
+// no such hosted cluster is known.
+func (h *hypershiftTransformer) getClusterName(id string) (string, bool) {
+	objs, err := h.hostedClusters.ByIndex(clusterIDIndex, id)
+	if err == nil && len(objs) > 0 {
+		h.mu.Lock()
+		delete(h.unresolved, id)
+		h.mu.Unlock()
+		return objs[0].(*hyperv1.HostedCluster).Name, true
 	}
-	return clusterName, nil
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.unresolved[id]; !ok {
+		logger.Log(h.logger, logger.Warn, "msg", "Failed to find HostedCluster, the metrics are labelled as unresolved",
+			"id", id)
+		h.unresolved[id] = struct{}{}
+	}
+	return id, false
+}
+
+func (h *hypershiftTransformer) HostedClusters() map[string]string {
+	clusters := map[string]string{}
+	for _, obj := range h.hostedClusters.List() {
+		hCluster := obj.(*hyperv1.HostedCluster)
+		clusters[hCluster.Spec.ClusterID] = hCluster.Name
+	}
+	return clusters
+}
+
+func (h *hypershiftTransformer) UnresolvedIDs() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ids := make([]string, 0, len(h.unresolved))
+	for id := range h.unresolved {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
 }
 
 func CheckCRDExist(l log.Logger) (bool, error) {
@@ -148,17 +214,48 @@ func CheckCRDExist(l log.Logger) (bool, error) {
 	return util.CheckCRDExist(c, "hostedclusters.hypershift.openshift.io")
 }
 
-func getHostedClusters(c client.Client, l log.Logger) (map[string]string, error) {
-	hList := &hyperv1.HostedClusterList{}
-	err := c.List(context.TODO(), hList, &client.ListOptions{})
-	if err != nil {
-		logger.Log(l, logger.Error, "msg", "Failed to list HyperShiftDeployment", "error", err)
-		return nil, err
+// startHostedClusterInformer starts an informer on the hosted clusters, so that the hosted clusters
+// created after the start of the collector are mapped as soon as they are known. The informer runs
+// until stop is closed.
+func startHostedClusterInformer(c client.WithWatch, l log.Logger, stop <-chan struct{}) (cache.Indexer, error) {
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			hList := &hyperv1.HostedClusterList{}
+			err := c.List(context.TODO(), hList, &client.ListOptions{Raw: &options})
+			if err != nil {
+				logger.Log(l, logger.Error, "msg", "Failed to list HostedCluster", "error", err)
+			}
+			return hList, err
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return c.Watch(context.TODO(), &hyperv1.HostedClusterList{}, &client.ListOptions{Raw: &options})
+		},
 	}
-	logger.Log(l, logger.Info, "msg", "NewHypershiftTransformer", "HostedCluster size", len(hList.Items))
-	clusters := map[string]string{}
-	for _, hCluster := range hList.Items {
-		clusters[hCluster.Spec.ClusterID] = hCluster.ObjectMeta.Name
+	indexer, informer := cache.NewIndexerInformer(lw, &hyperv1.HostedCluster{}, 0,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				hCluster := obj.(*hyperv1.HostedCluster)
+				logger.Log(l, logger.Info, "msg", "HostedCluster added", "name", hCluster.Name,
+					"id", hCluster.Spec.ClusterID)
+			},
+			DeleteFunc: func(obj interface{}) {
+				if hCluster, ok := obj.(*hyperv1.HostedCluster); ok {
+					logger.Log(l, logger.Info, "msg", "HostedCluster deleted", "name", hCluster.Name,
+						"id", hCluster.Spec.ClusterID)
+				}
+			},
+		},
+		cache.Indexers{clusterIDIndex: func(obj interface{}) ([]string, error) {
+			return []string{obj.(*hyperv1.HostedCluster).Spec.ClusterID}, nil
+		}},
+	)
+	go informer.Run(stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), hostedClusterSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return nil, errors.New("Failed to sync the HostedCluster informer")
 	}
-	return clusters, nil
+	logger.Log(l, logger.Info, "msg", "NewHypershiftTransformer", "HostedCluster size", len(indexer.List()))
+	return indexer, nil
 }
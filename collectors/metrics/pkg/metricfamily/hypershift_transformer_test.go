@@ -5,11 +5,14 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
 	hyperv1 "github.com/openshift/hypershift/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	prom "github.com/prometheus/client_model/go"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -60,7 +63,9 @@ func TestTransform(t *testing.T) {
 		"cluster":   "test-cluster",
 		"clusterID": "test-clusterID",
 	}
-	h, err := NewHypershiftTransformer(l, c, labels)
+	stop := make(chan struct{})
+	defer close(stop)
+	h, err := NewHypershiftTransformer(l, c, labels, stop)
 	if err != nil {
 		t.Fatal("Failed to new HyperShiftTransformer", err)
 	}
@@ -85,6 +90,9 @@ func TestTransform(t *testing.T) {
 	if err != nil {
 		t.Fatal("Failed to transform metrics", err)
 	}
+	if cluster := getLabelValue(family.Metric[0], CLUSTER_LABEL); cluster != hostedClusterName {
+		t.Errorf("cluster label (%s) is not the expected: (%s)", cluster, hostedClusterName)
+	}
 	family.Metric = append(family.Metric, &prom.Metric{
 		Label: []*prom.LabelPair{
 			{
@@ -93,12 +101,57 @@ func TestTransform(t *testing.T) {
 			},
 		},
 	})
+	_, err = h.Transform(family)
+	if err != nil {
+		t.Fatal("The unknown hosted cluster should not fail the transform", err)
+	}
+	if len(family.Metric) != 2 {
+		t.Errorf("the metric of the unknown hosted cluster should be forwarded: (%v)", family.Metric)
+	}
+	if unresolved := getLabelValue(family.Metric[1], UNRESOLVED_CLUSTER_LABEL); unresolved != "true" {
+		t.Errorf("the metric of the unknown hosted cluster should be labelled as unresolved: (%v)", family.Metric[1])
+	}
+	if cluster := getLabelValue(family.Metric[1], CLUSTER_LABEL); cluster != hostedClusterID_1 {
+		t.Errorf("cluster label (%s) is not the expected: (%s)", cluster, hostedClusterID_1)
+	}
+	if count := testutil.ToFloat64(unresolvedSeries); count != 1 {
+		t.Errorf("unresolved series (%v) is not the expected: (1)", count)
+	}
+	mapper := h.(HostedClusterMapper)
+	if ids := mapper.UnresolvedIDs(); len(ids) != 1 || ids[0] != hostedClusterID_1 {
+		t.Errorf("unresolved ids (%v) are not the expected: (%s)", ids, hostedClusterID_1)
+	}
+
 	err = c.Create(context.TODO(), hCluster_1, &client.CreateOptions{})
 	if err != nil {
 		t.Fatal("Failed to create HostedCluster", err)
 	}
+	err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return mapper.HostedClusters()[hostedClusterID_1] == hostedClusterName_1, nil
+	})
+	if err != nil {
+		t.Fatalf("the created hosted cluster is not mapped: (%v)", mapper.HostedClusters())
+	}
+	family.Metric = append(family.Metric, &prom.Metric{
+		Label: []*prom.LabelPair{{Name: &idLabel, Value: &hostedClusterID_1}},
+	})
 	_, err = h.Transform(family)
 	if err != nil {
 		t.Fatal("Failed to transform metrics", err)
 	}
+	if cluster := getLabelValue(family.Metric[2], CLUSTER_LABEL); cluster != hostedClusterName_1 {
+		t.Errorf("cluster label (%s) is not the expected: (%s)", cluster, hostedClusterName_1)
+	}
+	if ids := mapper.UnresolvedIDs(); len(ids) != 0 {
+		t.Errorf("no id should be unresolved: (%v)", ids)
+	}
+}
+
+func getLabelValue(metric *prom.Metric, name string) string {
+	for _, label := range metric.Label {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
 }
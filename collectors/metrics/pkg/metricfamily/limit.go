@@ -0,0 +1,43 @@
+package metricfamily
+
+import clientmodel "github.com/prometheus/client_model/go"
+
+// LimitSeriesPerLabelValue drops the series which exceed the limit for each of the given
+// values of the label, series are kept in the order of the families. Series without the
+// label or with another value are never dropped. The dropped series are set to nil so the
+// families must be packed afterwards. It returns the number of dropped series per value.
+func LimitSeriesPerLabelValue(families []*clientmodel.MetricFamily, label string,
+	values map[string]bool, limit int) map[string]int {
+	counts := map[string]int{}
+	dropped := map[string]int{}
+	for _, family := range families {
+		if family == nil {
+			continue
+		}
+		for i, m := range family.Metric {
+			if m == nil {
+				continue
+			}
+			value, ok := labelValue(m, label)
+			if !ok || !values[value] {
+				continue
+			}
+			if counts[value] >= limit {
+				family.Metric[i] = nil
+				dropped[value]++
+				continue
+			}
+			counts[value]++
+		}
+	}
+	return dropped
+}
+
+func labelValue(m *clientmodel.Metric, name string) (string, bool) {
+	for _, l := range m.Label {
+		if l != nil && l.GetName() == name {
+			return l.GetValue(), true
+		}
+	}
+	return "", false
+}
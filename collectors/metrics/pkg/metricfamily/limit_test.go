@@ -0,0 +1,39 @@
+package metricfamily
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	clientmodel "github.com/prometheus/client_model/go"
+)
+
+func TestLimitSeriesPerLabelValue(t *testing.T) {
+	metric := func(namespace string) *clientmodel.Metric {
+		m := &clientmodel.Metric{Untyped: &clientmodel.Untyped{Value: proto.Float64(1)}}
+		if namespace != "" {
+			m.Label = []*clientmodel.LabelPair{{Name: proto.String("namespace"), Value: proto.String(namespace)}}
+		}
+		return m
+	}
+	families := []*clientmodel.MetricFamily{
+		{Name: proto.String("a"), Metric: []*clientmodel.Metric{metric("ns1"), metric("ns2"), metric("")}},
+		{Name: proto.String("b"), Metric: []*clientmodel.Metric{metric("ns1"), metric("ns1"), metric("ns2")}},
+	}
+
+	dropped := LimitSeriesPerLabelValue(families, "namespace", map[string]bool{"ns1": true}, 2)
+	if !reflect.DeepEqual(dropped, map[string]int{"ns1": 1}) {
+		t.Errorf("dropped series (%v) are not the expected: (map[ns1:1])", dropped)
+	}
+
+	families = Pack(families)
+	for _, f := range families {
+		PackMetrics(f)
+	}
+	if count := MetricsCount(families); count != 5 {
+		t.Errorf("remaining series (%d) are not the expected: (5)", count)
+	}
+	if len(families[1].Metric) != 2 {
+		t.Errorf("series of family b (%v) are not the expected: (ns1 and ns2)", families[1].Metric)
+	}
+}
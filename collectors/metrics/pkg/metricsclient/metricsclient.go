@@ -11,10 +11,12 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -67,7 +69,26 @@ type Client struct {
 }
 
 type PartitionedMetrics struct {
-	Families []*clientmodel.MetricFamily
+	Families  []*clientmodel.MetricFamily
+	Exemplars Exemplars
+}
+
+// Exemplars maps a series, identified by SeriesKey, to the exemplars which
+// should be attached to it when it is forwarded.
+type Exemplars map[string][]prompb.Exemplar
+
+// SeriesKey returns a stable identifier for the series with the given metric
+// name and labels, independent of the order of the labels.
+func SeriesKey(name string, labels []*clientmodel.LabelPair) string {
+	pairs := make([]string, 0, len(labels))
+	for _, l := range labels {
+		if l == nil || l.GetName() == nameLabelName {
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%s=%q", l.GetName(), l.GetValue()))
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ",") + "}"
 }
 
 func New(logger log.Logger, client *http.Client, maxBytes int64, timeout time.Duration, metricsName string) *Client {
@@ -95,6 +116,93 @@ type MetricsResult struct {
 	Value  []interface{}     `json:"value"`
 }
 
+type ExemplarsJson struct {
+	Status string          `json:"status"`
+	Data   []ExemplarsData `json:"data"`
+}
+
+type ExemplarsData struct {
+	SeriesLabels map[string]string `json:"seriesLabels"`
+	Exemplars    []ExemplarResult  `json:"exemplars"`
+}
+
+type ExemplarResult struct {
+	Labels    map[string]string `json:"labels"`
+	Value     string            `json:"value"`
+	Timestamp float64           `json:"timestamp"`
+}
+
+// SeriesExemplars holds the exemplars returned by the query_exemplars API for one series.
+type SeriesExemplars struct {
+	SeriesLabels map[string]string
+	Exemplars    []prompb.Exemplar
+}
+
+// RetrieveExemplars queries the Prometheus query_exemplars API and returns the
+// exemplars grouped by the series they belong to.
+func (c *Client) RetrieveExemplars(ctx context.Context, req *http.Request) ([]SeriesExemplars, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	req = req.WithContext(ctx)
+	defer cancel()
+
+	var series []SeriesExemplars
+	err := withCancel(ctx, c.client, req, func(resp *http.Response) error {
+		switch resp.StatusCode {
+		case http.StatusOK:
+			gaugeRequestRetrieve.WithLabelValues(c.metricsName, "200").Inc()
+		case http.StatusUnauthorized:
+			gaugeRequestRetrieve.WithLabelValues(c.metricsName, "401").Inc()
+			return fmt.Errorf("Prometheus server requires authentication: %s", resp.Request.URL)
+		case http.StatusForbidden:
+			gaugeRequestRetrieve.WithLabelValues(c.metricsName, "403").Inc()
+			return fmt.Errorf("Prometheus server forbidden: %s", resp.Request.URL)
+		case http.StatusBadRequest:
+			gaugeRequestRetrieve.WithLabelValues(c.metricsName, "400").Inc()
+			return fmt.Errorf("bad request: %s", resp.Request.URL)
+		default:
+			gaugeRequestRetrieve.WithLabelValues(c.metricsName, strconv.Itoa(resp.StatusCode)).Inc()
+			return fmt.Errorf("Prometheus server reported unexpected error code: %d", resp.StatusCode)
+		}
+
+		r := &reader.LimitedReader{R: resp.Body, N: c.maxBytes}
+		var data ExemplarsJson
+		if err := json.NewDecoder(r).Decode(&data); err != nil {
+			return fmt.Errorf("failed to decode exemplars: %v", err)
+		}
+		for _, d := range data.Data {
+			s := SeriesExemplars{SeriesLabels: d.SeriesLabels}
+			for _, e := range d.Exemplars {
+				v, err := strconv.ParseFloat(e.Value, 64)
+				if err != nil {
+					logger.Log(c.logger, logger.Warn, "msg", "invalid exemplar value", "value", e.Value, "err", err)
+					continue
+				}
+				exemplar := prompb.Exemplar{
+					Value:     v,
+					Timestamp: int64(math.Round(e.Timestamp * 1000)),
+				}
+				for name, value := range e.Labels {
+					exemplar.Labels = append(exemplar.Labels, prompb.Label{Name: name, Value: value})
+				}
+				sort.Slice(exemplar.Labels, func(i, j int) bool {
+					return exemplar.Labels[i].Name < exemplar.Labels[j].Name
+				})
+				s.Exemplars = append(s.Exemplars, exemplar)
+			}
+			if len(s.Exemplars) > 0 {
+				series = append(series, s)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
 func (c *Client) RetrievRecordingMetrics(
 	ctx context.Context,
 	req *http.Request,
@@ -185,6 +293,152 @@ func (c *Client) RetrievRecordingMetrics(
 	return families, nil
 }
 
+// RetrieveQueryMetrics retrieves the result of an instant query. Unlike RetrievRecordingMetrics,
+// the metric families are named after the __name__ label of the returned series.
+func (c *Client) RetrieveQueryMetrics(ctx context.Context, req *http.Request) ([]*clientmodel.MetricFamily, error) {
+	families, err := c.RetrievRecordingMetrics(ctx, req, "")
+	if err != nil {
+		return nil, err
+	}
+
+	named := make([]*clientmodel.MetricFamily, 0, len(families))
+	for _, family := range families {
+		for _, m := range family.Metric {
+			labels := make([]*clientmodel.LabelPair, 0, len(m.Label))
+			for _, l := range m.Label {
+				if l.GetName() == nameLabelName {
+					family.Name = proto.String(l.GetValue())
+					continue
+				}
+				labels = append(labels, l)
+			}
+			m.Label = labels
+		}
+		if family.GetName() == "" {
+			// Series without a name, e.g. the result of an aggregation, cannot be forwarded.
+			continue
+		}
+		named = append(named, family)
+	}
+	return named, nil
+}
+
+// RemoteRead retrieves the series matching the queries through the Prometheus remote read API.
+// Only the latest sample of each series is kept, the same way the federate endpoint does.
+func (c *Client) RemoteRead(ctx context.Context, req *http.Request,
+	queries []*prompb.Query) ([]*clientmodel.MetricFamily, error) {
+
+	data, err := proto.Marshal(&prompb.ReadRequest{
+		Queries:               queries,
+		AcceptedResponseTypes: []prompb.ReadRequest_ResponseType{prompb.ReadRequest_SAMPLES},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal read request: %v", err)
+	}
+	req.Method = http.MethodPost
+	compressed := snappy.Encode(nil, data)
+	req.Body = ioutil.NopCloser(bytes.NewReader(compressed))
+	req.ContentLength = int64(len(compressed))
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Read-Version", "0.1.0")
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	req = req.WithContext(ctx)
+	defer cancel()
+
+	families := make([]*clientmodel.MetricFamily, 0, 100)
+	err = withCancel(ctx, c.client, req, func(resp *http.Response) error {
+		switch resp.StatusCode {
+		case http.StatusOK:
+			gaugeRequestRetrieve.WithLabelValues(c.metricsName, "200").Inc()
+		case http.StatusUnauthorized:
+			gaugeRequestRetrieve.WithLabelValues(c.metricsName, "401").Inc()
+			return fmt.Errorf("Prometheus server requires authentication: %s", resp.Request.URL)
+		case http.StatusForbidden:
+			gaugeRequestRetrieve.WithLabelValues(c.metricsName, "403").Inc()
+			return fmt.Errorf("Prometheus server forbidden: %s", resp.Request.URL)
+		case http.StatusBadRequest:
+			gaugeRequestRetrieve.WithLabelValues(c.metricsName, "400").Inc()
+			return fmt.Errorf("bad request: %s", resp.Request.URL)
+		default:
+			gaugeRequestRetrieve.WithLabelValues(c.metricsName, strconv.Itoa(resp.StatusCode)).Inc()
+			return fmt.Errorf("Prometheus server reported unexpected error code: %d", resp.StatusCode)
+		}
+
+		compressed, err := ioutil.ReadAll(&reader.LimitedReader{R: resp.Body, N: c.maxBytes})
+		if err != nil {
+			return fmt.Errorf("failed to read remote read response: %v", err)
+		}
+		body, err := snappy.Decode(nil, compressed)
+		if err != nil {
+			return fmt.Errorf("failed to decompress remote read response: %v", err)
+		}
+		var readResp prompb.ReadResponse
+		if err := proto.Unmarshal(body, &readResp); err != nil {
+			return fmt.Errorf("failed to unmarshal remote read response: %v", err)
+		}
+
+		for _, result := range readResp.Results {
+			for _, ts := range result.Timeseries {
+				if family := timeseriesToFamily(ts); family != nil {
+					families = append(families, family)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return families, nil
+}
+
+// timeseriesToFamily converts the latest sample of a remote read time series to a metric family.
+func timeseriesToFamily(ts *prompb.TimeSeries) *clientmodel.MetricFamily {
+	if ts == nil || len(ts.Samples) == 0 {
+		return nil
+	}
+	latest := ts.Samples[0]
+	for _, s := range ts.Samples[1:] {
+		if s.Timestamp > latest.Timestamp {
+			latest = s
+		}
+	}
+
+	var name string
+	metric := &clientmodel.Metric{
+		Untyped:     &clientmodel.Untyped{Value: proto.Float64(latest.Value)},
+		TimestampMs: proto.Int64(latest.Timestamp),
+	}
+	for _, l := range ts.Labels {
+		if l.Name == nameLabelName {
+			name = l.Value
+			continue
+		}
+		if l.Value == "" {
+			continue
+		}
+		metric.Label = append(metric.Label, &clientmodel.LabelPair{
+			Name:  proto.String(l.Name),
+			Value: proto.String(l.Value),
+		})
+	}
+	if name == "" {
+		return nil
+	}
+
+	return &clientmodel.MetricFamily{
+		Name:   proto.String(name),
+		Type:   clientmodel.MetricType_UNTYPED.Enum(),
+		Metric: []*clientmodel.Metric{metric},
+	}
+}
+
 func (c *Client) Retrieve(ctx context.Context, req *http.Request) ([]*clientmodel.MetricFamily, error) {
 	if req.Header == nil {
 		req.Header = make(http.Header)
@@ -463,6 +717,9 @@ func convertToTimeseries(p *PartitionedMetrics, now time.Time) ([]prompb.TimeSer
 
 			ts.Labels = append(ts.Labels, labelpairs...)
 			ts.Samples = append(ts.Samples, s)
+			if len(p.Exemplars) > 0 {
+				ts.Exemplars = p.Exemplars[SeriesKey(*f.Name, m.Label)]
+			}
 
 			timeseries = append(timeseries, ts)
 		}
@@ -471,20 +728,22 @@ func convertToTimeseries(p *PartitionedMetrics, now time.Time) ([]prompb.TimeSer
 	return timeseries, nil
 }
 
-// RemoteWrite is used to push the metrics to remote thanos endpoint
+// RemoteWrite is used to push the metrics to remote thanos endpoint.
+// Exemplars are attached to the time series they were retrieved for, if any.
+// It returns the number of compressed bytes which were sent.
 func (c *Client) RemoteWrite(ctx context.Context, req *http.Request,
-	families []*clientmodel.MetricFamily, interval time.Duration) error {
+	families []*clientmodel.MetricFamily, exemplars Exemplars, interval time.Duration) (int64, error) {
 
-	timeseries, err := convertToTimeseries(&PartitionedMetrics{Families: families}, time.Now())
+	timeseries, err := convertToTimeseries(&PartitionedMetrics{Families: families, Exemplars: exemplars}, time.Now())
 	if err != nil {
 		msg := "failed to convert timeseries"
 		logger.Log(c.logger, logger.Warn, "msg", msg, "err", err)
-		return fmt.Errorf(msg)
+		return 0, fmt.Errorf(msg)
 	}
 
 	if len(timeseries) == 0 {
 		logger.Log(c.logger, logger.Info, "msg", "no time series to forward to receive endpoint")
-		return nil
+		return 0, nil
 	}
 	logger.Log(c.logger, logger.Debug, "timeseries number", len(timeseries))
 
@@ -501,6 +760,7 @@ func (c *Client) RemoteWrite(ctx context.Context, req *http.Request,
 		}
 	*/
 
+	var sent int64
 	for i := 0; i < len(timeseries); i += maxSeriesLength {
 		length := len(timeseries)
 		if i+maxSeriesLength < length {
@@ -513,7 +773,7 @@ func (c *Client) RemoteWrite(ctx context.Context, req *http.Request,
 		if err != nil {
 			msg := "failed to marshal proto"
 			logger.Log(c.logger, logger.Warn, "msg", msg, "err", err)
-			return fmt.Errorf(msg)
+			return sent, fmt.Errorf(msg)
 		}
 		compressed := snappy.Encode(nil, data)
 
@@ -534,12 +794,13 @@ func (c *Client) RemoteWrite(ctx context.Context, req *http.Request,
 		}
 		err = backoff.RetryNotify(retryable, b, notify)
 		if err != nil {
-			return err
+			return sent, err
 		}
+		sent += int64(len(compressed))
 	}
 	msg := fmt.Sprintf("Metrics pushed successfully")
 	logger.Log(c.logger, logger.Info, "msg", msg)
-	return nil
+	return sent, nil
 }
 
 func (c *Client) sendRequest(serverURL string, body []byte) error {
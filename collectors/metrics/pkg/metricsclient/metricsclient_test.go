@@ -2,8 +2,11 @@
 package metricsclient
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"testing"
 	"time"
@@ -182,6 +185,93 @@ func Test_convertToTimeseries(t *testing.T) {
 	}
 }
 
+func Test_convertToTimeseriesWithExemplars(t *testing.T) {
+	counter := clientmodel.MetricType_COUNTER
+	fooMetricName := "foo_metric"
+	fooLabelName := "foo"
+	fooLabelValue1 := "bar"
+	fooLabelValue2 := "baz"
+	value42 := 42.0
+	timestamp := int64(1596948588956)
+
+	exemplar := prompb.Exemplar{
+		Labels:    []prompb.Label{{Name: "trace_id", Value: "abc"}},
+		Value:     1,
+		Timestamp: timestamp,
+	}
+	in := &PartitionedMetrics{
+		Families: []*clientmodel.MetricFamily{{
+			Name: &fooMetricName,
+			Type: &counter,
+			Metric: []*clientmodel.Metric{{
+				Label:       []*clientmodel.LabelPair{{Name: &fooLabelName, Value: &fooLabelValue1}},
+				Counter:     &clientmodel.Counter{Value: &value42},
+				TimestampMs: &timestamp,
+			}, {
+				Label:       []*clientmodel.LabelPair{{Name: &fooLabelName, Value: &fooLabelValue2}},
+				Counter:     &clientmodel.Counter{Value: &value42},
+				TimestampMs: &timestamp,
+			}},
+		}},
+		Exemplars: Exemplars{
+			SeriesKey(fooMetricName, []*clientmodel.LabelPair{{Name: &fooLabelName, Value: &fooLabelValue1}}): {exemplar},
+		},
+	}
+
+	out, err := convertToTimeseries(in, time.Now())
+	if err != nil {
+		t.Fatalf("converting timeseries errored: %v", err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("expected 2 timeseries, got %d", len(out))
+	}
+	if !reflect.DeepEqual(out[0].Exemplars, []prompb.Exemplar{exemplar}) {
+		t.Errorf("exemplars not attached to the matching series: %v", out[0].Exemplars)
+	}
+	if len(out[1].Exemplars) != 0 {
+		t.Errorf("exemplars attached to a series without exemplars: %v", out[1].Exemplars)
+	}
+}
+
+func TestRetrieveExemplars(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query_exemplars" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":[{
+			"seriesLabels":{"__name__":"foo_bucket","le":"0.5"},
+			"exemplars":[
+				{"labels":{"trace_id":"abc"},"value":"0.3","timestamp":1600096945.479},
+				{"labels":{"trace_id":"def"},"value":"invalid","timestamp":1600096945.479}
+			]},{
+			"seriesLabels":{"__name__":"foo_bucket","le":"1"},
+			"exemplars":[]}]}`))
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL + "/api/v1/query_exemplars")
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+	c := New(log.NewNopLogger(), ts.Client(), 1024*1024, time.Minute, "test")
+	series, err := c.RetrieveExemplars(context.Background(), &http.Request{Method: "GET", URL: u})
+	if err != nil {
+		t.Fatalf("failed to retrieve exemplars: %v", err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("expected 1 series with exemplars, got %d", len(series))
+	}
+	want := []prompb.Exemplar{{
+		Labels:    []prompb.Label{{Name: "trace_id", Value: "abc"}},
+		Value:     0.3,
+		Timestamp: 1600096945479,
+	}}
+	if !reflect.DeepEqual(series[0].Exemplars, want) {
+		t.Errorf("exemplars %v don't match the expected %v", series[0].Exemplars, want)
+	}
+}
+
 func timeseriesEqual(t1 []prompb.TimeSeries, t2 []prompb.TimeSeries) (bool, error) {
 	if len(t1) != len(t2) {
 		return false, fmt.Errorf("timeseries don't match amount of series: %d != %d", len(t1), len(t2))
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"reflect"
 	"strings"
 	"time"
 
@@ -28,12 +29,31 @@ const (
 	uwlPromURL = "https://prometheus-user-workload.openshift-user-workload-monitoring.svc:9092"
 )
 
+// statusConditionTypes are the types of the status condition shared by the platform and user
+// workload collectors, the other conditions of the addon are left untouched.
+var statusConditionTypes = map[string]bool{
+	"Available":    true,
+	"Degraded":     true,
+	"Progressing":  true,
+	"Disabled":     true,
+	"NotSupported": true,
+	"Ready":        true,
+}
+
 type StatusReport struct {
 	statusClient client.Client
 	logger       log.Logger
+	// conditionType is the condition dedicated to the collector of an additional metrics source,
+	// empty for the platform and user workload collectors.
+	conditionType string
 }
 
 func New(logger log.Logger) (*StatusReport, error) {
+	return NewWithConditionType(logger, "")
+}
+
+// NewWithConditionType creates a StatusReport which reports the status in its own condition.
+func NewWithConditionType(logger log.Logger, conditionType string) (*StatusReport, error) {
 	testMode := os.Getenv("UNIT_TEST") != ""
 	standaloneMode := os.Getenv("STANDALONE") == "true"
 	var kubeClient client.Client
@@ -57,8 +77,9 @@ func New(logger log.Logger) (*StatusReport, error) {
 	}
 
 	return &StatusReport{
-		statusClient: kubeClient,
-		logger:       log.With(logger, "component", "statusclient"),
+		statusClient:  kubeClient,
+		logger:        log.With(logger, "component", "statusclient"),
+		conditionType: conditionType,
 	}, nil
 }
 
@@ -66,6 +87,9 @@ func (s *StatusReport) UpdateStatus(t string, r string, m string) error {
 	if s.statusClient == nil {
 		return nil
 	}
+	if s.conditionType != "" {
+		return s.updateCondition(t, r, m)
+	}
 	isUwl := false
 	if strings.Contains(os.Getenv("FROM"), uwlPromURL) {
 		isUwl = true
@@ -83,9 +107,18 @@ func (s *StatusReport) UpdateStatus(t string, r string, m string) error {
 	found := false
 	conditions := []oav1beta1.StatusCondition{}
 	latestC := oav1beta1.StatusCondition{}
-	message, conditionType, reason := mergeCondtion(isUwl, t, r, m,
-		addon.Status.Conditions[len(addon.Status.Conditions)-1])
+	lastStatusC := oav1beta1.StatusCondition{}
+	for _, c := range addon.Status.Conditions {
+		if statusConditionTypes[c.Type] {
+			lastStatusC = c
+		}
+	}
+	message, conditionType, reason := mergeCondtion(isUwl, t, r, m, lastStatusC)
 	for _, c := range addon.Status.Conditions {
+		if !statusConditionTypes[c.Type] {
+			conditions = append(conditions, c)
+			continue
+		}
 		if c.Status == metav1.ConditionTrue {
 			if c.Type != conditionType {
 				c.Status = metav1.ConditionFalse
@@ -138,6 +171,89 @@ func (s *StatusReport) UpdateStatus(t string, r string, m string) error {
 	return nil
 }
 
+// updateCondition sets the dedicated condition of the collector, its status is True while the
+// metrics are forwarded.
+func (s *StatusReport) updateCondition(t string, r string, m string) error {
+	addon := &oav1beta1.ObservabilityAddon{}
+	err := s.statusClient.Get(context.TODO(), types.NamespacedName{
+		Name:      name,
+		Namespace: namespace,
+	}, addon)
+	if err != nil {
+		logger.Log(s.logger, logger.Error, "err", err)
+		return err
+	}
+	status := metav1.ConditionTrue
+	if t != "Available" {
+		status = metav1.ConditionFalse
+	}
+	condition := oav1beta1.StatusCondition{
+		Type:               s.conditionType,
+		Status:             status,
+		Reason:             r,
+		Message:            m,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}
+	conditions := []oav1beta1.StatusCondition{}
+	for _, c := range addon.Status.Conditions {
+		if c.Type != s.conditionType {
+			conditions = append(conditions, c)
+			continue
+		}
+		if c.Status == condition.Status && c.Reason == condition.Reason && c.Message == condition.Message {
+			return nil
+		}
+		if c.Status == condition.Status {
+			condition.LastTransitionTime = c.LastTransitionTime
+		}
+	}
+	addon.Status.Conditions = append(conditions, condition)
+	err = s.statusClient.Status().Update(context.TODO(), addon)
+	if err != nil {
+		logger.Log(s.logger, logger.Error, "err", err)
+	}
+	return err
+}
+
+// UpdateCollectorStatus applies update to the telemetry of this metrics collector in the
+// ObservabilityAddon status, the status is only written when the telemetry changed.
+func (s *StatusReport) UpdateCollectorStatus(update func(*oav1beta1.MetricsCollectorStatus)) error {
+	if s.statusClient == nil || s.conditionType != "" {
+		// the telemetry is only reported for the platform and user workload collectors
+		return nil
+	}
+	addon := &oav1beta1.ObservabilityAddon{}
+	err := s.statusClient.Get(context.TODO(), types.NamespacedName{
+		Name:      name,
+		Namespace: namespace,
+	}, addon)
+	if err != nil {
+		logger.Log(s.logger, logger.Error, "err", err)
+		return err
+	}
+
+	collectorStatus := &addon.Status.MetricsCollector
+	if strings.Contains(os.Getenv("FROM"), uwlPromURL) {
+		collectorStatus = &addon.Status.UWLMetricsCollector
+	}
+	original := *collectorStatus
+	if *collectorStatus == nil {
+		*collectorStatus = &oav1beta1.MetricsCollectorStatus{}
+	} else {
+		*collectorStatus = (*collectorStatus).DeepCopy()
+	}
+	update(*collectorStatus)
+	if reflect.DeepEqual(original, *collectorStatus) {
+		return nil
+	}
+
+	err = s.statusClient.Status().Update(context.TODO(), addon)
+	if err != nil {
+		logger.Log(s.logger, logger.Error, "err", err)
+	}
+	return err
+}
+
 func mergeCondtion(isUwl bool, t, r, m string, condition oav1beta1.StatusCondition) (string, string, string) {
 	messages := strings.Split(condition.Message, " ; ")
 	if len(messages) == 1 {
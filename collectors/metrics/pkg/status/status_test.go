@@ -9,6 +9,7 @@ import (
 
 	"github.com/go-kit/kit/log"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 
 	oav1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
@@ -89,3 +90,114 @@ func TestUpdateStatus(t *testing.T) {
 		t.Fatalf("Failed to update status: (%v)", err)
 	}
 }
+
+func TestUpdateCollectorStatus(t *testing.T) {
+	s, err := New(log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("Failed to create new Status struct: (%v)", err)
+	}
+
+	addon := &oav1beta1.ObservabilityAddon{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	err = s.statusClient.Create(context.TODO(), addon)
+	if err != nil {
+		t.Fatalf("Failed to create observabilityAddon: (%v)", err)
+	}
+
+	err = s.UpdateCollectorStatus(func(cs *oav1beta1.MetricsCollectorStatus) {
+		cs.SeriesSent = 10
+		cs.BytesSent = 100
+	})
+	if err != nil {
+		t.Fatalf("Failed to update collector status: (%v)", err)
+	}
+
+	os.Setenv("FROM", uwlPromURL)
+	err = s.UpdateCollectorStatus(func(cs *oav1beta1.MetricsCollectorStatus) {
+		cs.ConsecutiveFailures = 2
+	})
+	os.Setenv("FROM", "")
+	if err != nil {
+		t.Fatalf("Failed to update collector status: (%v)", err)
+	}
+
+	err = s.statusClient.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, addon)
+	if err != nil {
+		t.Fatalf("Failed to get observabilityAddon: (%v)", err)
+	}
+	if addon.Status.MetricsCollector == nil || addon.Status.MetricsCollector.SeriesSent != 10 ||
+		addon.Status.MetricsCollector.BytesSent != 100 || addon.Status.MetricsCollector.ConsecutiveFailures != 0 {
+		t.Errorf("metrics collector status not updated correctly: (%v)", addon.Status.MetricsCollector)
+	}
+	if addon.Status.UWLMetricsCollector == nil || addon.Status.UWLMetricsCollector.ConsecutiveFailures != 2 {
+		t.Errorf("uwl metrics collector status not updated correctly: (%v)", addon.Status.UWLMetricsCollector)
+	}
+}
+
+func TestUpdateStatusWithConditionType(t *testing.T) {
+	s, err := New(log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("Failed to create new Status struct: (%v)", err)
+	}
+	sourceStatus, err := NewWithConditionType(log.NewNopLogger(), "MetricsSource-istio")
+	if err != nil {
+		t.Fatalf("Failed to create new Status struct: (%v)", err)
+	}
+	sourceStatus.statusClient = s.statusClient
+
+	addon := &oav1beta1.ObservabilityAddon{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Status: oav1beta1.ObservabilityAddonStatus{
+			Conditions: []oav1beta1.StatusCondition{
+				{
+					Type:               "Available",
+					Status:             metav1.ConditionTrue,
+					Reason:             "Available",
+					Message:            "Cluster metrics sent successfully",
+					LastTransitionTime: metav1.NewTime(time.Now()),
+				},
+			},
+		},
+	}
+	err = s.statusClient.Create(context.TODO(), addon)
+	if err != nil {
+		t.Fatalf("Failed to create observabilityAddon: (%v)", err)
+	}
+
+	err = sourceStatus.UpdateStatus("Degraded", "Degraded", "Failed to retrieve metrics")
+	if err != nil {
+		t.Fatalf("Failed to update status: (%v)", err)
+	}
+	// the condition of the source is not changed by the platform collector
+	err = s.UpdateStatus("Degraded", "Degraded", "Failed to send metrics")
+	if err != nil {
+		t.Fatalf("Failed to update status: (%v)", err)
+	}
+
+	err = s.statusClient.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, addon)
+	if err != nil {
+		t.Fatalf("Failed to get observabilityAddon: (%v)", err)
+	}
+	found := false
+	for _, c := range addon.Status.Conditions {
+		if c.Type == "MetricsSource-istio" {
+			found = true
+			if c.Status != metav1.ConditionFalse || c.Message != "Failed to retrieve metrics" {
+				t.Errorf("the source condition is not the expected: (%v)", c)
+			}
+		}
+		if c.Type == "Degraded" && c.Message != "Failed to send metrics" {
+			t.Errorf("the status condition is not the expected: (%v)", c)
+		}
+	}
+	if !found {
+		t.Errorf("the source condition is not reported: (%v)", addon.Status.Conditions)
+	}
+}
@@ -0,0 +1,436 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/promql/parser"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-observability-operator/operators/endpointmetrics/pkg/util"
+	oav1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	operatorutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
+)
+
+const (
+	diagnosticSourcePrometheus  = operatorconfig.DiagnosticConditionPrefix + "SourcePrometheus"
+	diagnosticHubEndpoint       = operatorconfig.DiagnosticConditionPrefix + "HubEndpoint"
+	diagnosticClientCertificate = operatorconfig.DiagnosticConditionPrefix + "ClientCertificate"
+	diagnosticClockSkew         = operatorconfig.DiagnosticConditionPrefix + "ClockSkew"
+	diagnosticAllowlist         = operatorconfig.DiagnosticConditionPrefix + "Allowlist"
+	diagnosticCollectorRestarts = operatorconfig.DiagnosticConditionPrefix + "CollectorRestarts"
+
+	diagnosticTimeout    = 10 * time.Second
+	certExpiryWarning    = 7 * 24 * time.Hour
+	maxClockSkew         = 30 * time.Second
+	maxCollectorRestarts = 5
+)
+
+var serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// Diagnostics periodically checks the path of the metrics from the source Prometheus to the hub,
+// each check is reported as a condition of the observabilityaddon.
+type Diagnostics struct {
+	Client   client.Client
+	Interval time.Duration
+}
+
+// Start runs the diagnostics until the context is done, they are disabled if the interval is not positive.
+func (d *Diagnostics) Start(ctx context.Context) error {
+	if d.Interval <= 0 {
+		log.Info("The diagnostics are disabled")
+		return nil
+	}
+	wait.UntilWithContext(ctx, d.run, d.Interval)
+	return nil
+}
+
+func (d *Diagnostics) run(ctx context.Context) {
+	obsAddon := &oav1beta1.ObservabilityAddon{}
+	err := d.Client.Get(ctx, types.NamespacedName{Name: obAddonName, Namespace: namespace}, obsAddon)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "Failed to get observabilityaddon", "namespace", namespace)
+		}
+		return
+	}
+	if !obsAddon.Spec.EnableMetrics || obsAddon.DeletionTimestamp != nil {
+		return
+	}
+	hubInfo, err := getHubInfo(ctx, d.Client)
+	if err != nil {
+		log.Error(err, "Failed to get the hub info for the diagnostics")
+		return
+	}
+	util.SetStatusConditions(ctx, d.Client, obsAddon, runDiagnostics(ctx, d.Client, hubInfo)...)
+}
+
+func runDiagnostics(ctx context.Context, c client.Client, hubInfo *operatorconfig.HubInfo) []oav1beta1.StatusCondition {
+	conditions := []oav1beta1.StatusCondition{checkSourcePrometheus(ctx, c)}
+
+	cert, pool, err := getClientCertificate(ctx, c)
+	conditions = append(conditions, checkClientCertificate(cert, err, time.Now()))
+	if err != nil {
+		conditions = append(conditions,
+			newDiagnosticCondition(diagnosticHubEndpoint, metav1.ConditionUnknown, "CertificateUnavailable",
+				"the client certificate is required to connect to the hub"),
+			checkClockSkew(nil, time.Now()))
+	} else {
+		tlsConfig := &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{*cert},
+			RootCAs:      pool,
+		}
+		hubCondition, hubTime := probeHubEndpoint(ctx, hubInfo.ObservatoriumAPIEndpoint, tlsConfig)
+		conditions = append(conditions, hubCondition, checkClockSkew(hubTime, time.Now()))
+	}
+
+	return append(conditions, checkAllowlist(ctx, c), checkCollectorRestarts(ctx, c))
+}
+
+func newDiagnosticCondition(conditionType string, status metav1.ConditionStatus,
+	reason, message string) oav1beta1.StatusCondition {
+	return oav1beta1.StatusCondition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// getSourcePrometheusURL returns the url of the Prometheus the platform metrics are collected from.
+func getSourcePrometheusURL(ctx context.Context, c client.Client) (string, error) {
+	if !installPrometheus {
+		return ocpPromURL, nil
+	}
+	existing, err := findExistingPrometheus(ctx, c)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		return existing.url, nil
+	}
+	return promURL, nil
+}
+
+// checkSourcePrometheus queries the source Prometheus the way the metrics-collector does.
+func checkSourcePrometheus(ctx context.Context, c client.Client) oav1beta1.StatusCondition {
+	sourceURL, err := getSourcePrometheusURL(ctx, c)
+	if err != nil {
+		return newDiagnosticCondition(diagnosticSourcePrometheus, metav1.ConditionUnknown, "CheckFailed", err.Error())
+	}
+	/* #nosec G402*/
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: true,
+	}
+	if !installPrometheus {
+		cm := &corev1.ConfigMap{}
+		err = c.Get(ctx, types.NamespacedName{Name: caConfigmapName, Namespace: namespace}, cm)
+		if err != nil {
+			return newDiagnosticCondition(diagnosticSourcePrometheus, metav1.ConditionFalse, "CAUnavailable",
+				fmt.Sprintf("failed to get the configmap %s: %v", caConfigmapName, err))
+		}
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM([]byte(cm.Data["service-ca.crt"]))
+		tlsConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			RootCAs:    pool,
+		}
+	}
+	token := ""
+	if data, err := os.ReadFile(serviceAccountTokenFile); err == nil {
+		token = strings.TrimSpace(string(data))
+	}
+	return probeSourcePrometheus(ctx, sourceURL, token, tlsConfig)
+}
+
+func probeSourcePrometheus(ctx context.Context, sourceURL, token string,
+	tlsConfig *tls.Config) oav1beta1.StatusCondition {
+	httpClient := &http.Client{
+		Timeout:   diagnosticTimeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL+"/api/v1/query?query=vector(1)", nil)
+	if err != nil {
+		return newDiagnosticCondition(diagnosticSourcePrometheus, metav1.ConditionFalse, "InvalidURL", err.Error())
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return newDiagnosticCondition(diagnosticSourcePrometheus, metav1.ConditionFalse, "Unreachable", err.Error())
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return newDiagnosticCondition(diagnosticSourcePrometheus, metav1.ConditionFalse, "Unauthorized",
+			fmt.Sprintf("%s rejected the credentials: %s", sourceURL, resp.Status))
+	case resp.StatusCode >= http.StatusMultipleChoices:
+		return newDiagnosticCondition(diagnosticSourcePrometheus, metav1.ConditionFalse, "UnexpectedResponse",
+			fmt.Sprintf("%s responded with %s", sourceURL, resp.Status))
+	}
+	return newDiagnosticCondition(diagnosticSourcePrometheus, metav1.ConditionTrue, "Reachable",
+		fmt.Sprintf("%s is reachable", sourceURL))
+}
+
+// getClientCertificate reads the client certificate and the hub CA used to forward the metrics.
+func getClientCertificate(ctx context.Context, c client.Client) (*tls.Certificate, *x509.CertPool, error) {
+	certSecret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: mtlsCertName, Namespace: namespace}, certSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := tls.X509KeyPair(certSecret.Data["tls.crt"], certSecret.Data["tls.key"])
+	if err != nil {
+		return nil, nil, err
+	}
+	caSecret := &corev1.Secret{}
+	err = c.Get(ctx, types.NamespacedName{Name: mtlsCaName, Namespace: namespace}, caSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caSecret.Data["ca.crt"]) {
+		return nil, nil, fmt.Errorf("no certificate found in the secret %s", mtlsCaName)
+	}
+	return &cert, pool, nil
+}
+
+func checkClientCertificate(cert *tls.Certificate, err error, now time.Time) oav1beta1.StatusCondition {
+	if err != nil {
+		return newDiagnosticCondition(diagnosticClientCertificate, metav1.ConditionFalse, "Invalid", err.Error())
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return newDiagnosticCondition(diagnosticClientCertificate, metav1.ConditionFalse, "Invalid", err.Error())
+	}
+	expiry := leaf.NotAfter.UTC().Format(time.RFC3339)
+	switch {
+	case now.After(leaf.NotAfter):
+		return newDiagnosticCondition(diagnosticClientCertificate, metav1.ConditionFalse, "Expired",
+			"the client certificate expired at "+expiry)
+	case now.Before(leaf.NotBefore):
+		return newDiagnosticCondition(diagnosticClientCertificate, metav1.ConditionFalse, "NotYetValid",
+			"the client certificate is valid from "+leaf.NotBefore.UTC().Format(time.RFC3339))
+	case leaf.NotAfter.Sub(now) < certExpiryWarning:
+		return newDiagnosticCondition(diagnosticClientCertificate, metav1.ConditionFalse, "ExpiringSoon",
+			"the client certificate expires at "+expiry)
+	}
+	return newDiagnosticCondition(diagnosticClientCertificate, metav1.ConditionTrue, "Valid",
+		"the client certificate expires at "+expiry)
+}
+
+// probeHubEndpoint resolves the hub endpoint and performs the TLS handshake with the client certificate,
+// the time of the hub is returned if it responds to a request.
+func probeHubEndpoint(ctx context.Context, endpoint string,
+	tlsConfig *tls.Config) (oav1beta1.StatusCondition, *time.Time) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return newDiagnosticCondition(diagnosticHubEndpoint, metav1.ConditionFalse, "InvalidEndpoint",
+			fmt.Sprintf("invalid endpoint %q", endpoint)), nil
+	}
+	host := u.Hostname()
+	if net.ParseIP(host) == nil {
+		_, err = net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			return newDiagnosticCondition(diagnosticHubEndpoint, metav1.ConditionFalse, "DNSFailed",
+				err.Error()), nil
+		}
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	dialer := &net.Dialer{Timeout: diagnosticTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return newDiagnosticCondition(diagnosticHubEndpoint, metav1.ConditionFalse, "Unreachable", err.Error()), nil
+	}
+	defer conn.Close()
+	if u.Scheme == "https" {
+		config := tlsConfig.Clone()
+		config.ServerName = host
+		handshakeCtx, cancel := context.WithTimeout(ctx, diagnosticTimeout)
+		defer cancel()
+		err = tls.Client(conn, config).HandshakeContext(handshakeCtx)
+		if err != nil {
+			return newDiagnosticCondition(diagnosticHubEndpoint, metav1.ConditionFalse, "TLSHandshakeFailed",
+				err.Error()), nil
+		}
+	}
+	condition := newDiagnosticCondition(diagnosticHubEndpoint, metav1.ConditionTrue, "Reachable",
+		fmt.Sprintf("%s is reachable", u.Host))
+
+	httpClient := &http.Client{
+		Timeout:   diagnosticTimeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint, nil)
+	if err != nil {
+		return condition, nil
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		log.Info("Failed to get the time of the hub", "endpoint", endpoint, "error", err.Error())
+		return condition, nil
+	}
+	defer resp.Body.Close()
+	hubTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return condition, nil
+	}
+	return condition, &hubTime
+}
+
+func checkClockSkew(hubTime *time.Time, now time.Time) oav1beta1.StatusCondition {
+	if hubTime == nil {
+		return newDiagnosticCondition(diagnosticClockSkew, metav1.ConditionUnknown, "HubTimeUnavailable",
+			"the time of the hub could not be retrieved")
+	}
+	// the measured skew is only logged, it would change the message of the condition at every check
+	skew := now.Sub(*hubTime).Round(time.Second)
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		log.Info("The clock differs from the hub", "skew", skew.String())
+		return newDiagnosticCondition(diagnosticClockSkew, metav1.ConditionFalse, "ClockSkewed",
+			fmt.Sprintf("the clock differs from the hub by more than %s", maxClockSkew))
+	}
+	return newDiagnosticCondition(diagnosticClockSkew, metav1.ConditionTrue, "InSync",
+		fmt.Sprintf("the clock differs from the hub by less than %s", maxClockSkew))
+}
+
+// checkAllowlist reports the allowlist configmaps which cannot be parsed and the invalid matches.
+func checkAllowlist(ctx context.Context, c client.Client) oav1beta1.StatusCondition {
+	problems := []string{}
+	cm := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Name: operatorconfig.AllowlistConfigMapName, Namespace: namespace}, cm)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("%s: %v", operatorconfig.AllowlistConfigMapName, err))
+	} else {
+		for _, key := range []string{operatorconfig.MetricsConfigMapKey, operatorconfig.UwlMetricsConfigMapKey} {
+			list := &operatorconfig.MetricsAllowlist{}
+			err = yaml.Unmarshal([]byte(cm.Data[key]), list)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s/%s: %v", cm.Name, key, err))
+				continue
+			}
+			problems = append(problems, getInvalidMatches(cm.Name+"/"+key, list)...)
+		}
+	}
+
+	cmList := &corev1.ConfigMapList{}
+	err = c.List(ctx, cmList, &client.ListOptions{})
+	if err != nil {
+		return newDiagnosticCondition(diagnosticAllowlist, metav1.ConditionUnknown, "CheckFailed", err.Error())
+	}
+	for _, allowlistCM := range cmList.Items {
+		if allowlistCM.Name != operatorconfig.AllowlistCustomConfigMapName {
+			continue
+		}
+		source := allowlistCM.Namespace + "/" + allowlistCM.Name
+		list, _, uwlList, err := operatorutil.ParseAllowlistConfigMap(allowlistCM)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", source, err))
+			continue
+		}
+		problems = append(problems, getInvalidMatches(source, list)...)
+		problems = append(problems, getInvalidMatches(source, uwlList)...)
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return newDiagnosticCondition(diagnosticAllowlist, metav1.ConditionFalse, "ParseFailed",
+			strings.Join(problems, "; "))
+	}
+	return newDiagnosticCondition(diagnosticAllowlist, metav1.ConditionTrue, "Valid", "the allowlists are valid")
+}
+
+func getInvalidMatches(source string, list *operatorconfig.MetricsAllowlist) []string {
+	problems := []string{}
+	for _, match := range list.MatchList {
+		if _, err := parser.ParseMetricSelector("{" + match + "}"); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid match %q", source, match))
+		}
+	}
+	return problems
+}
+
+// isCollectorPod returns true for the pods of the platform collectors, of the metrics source collectors
+// and of the hosted cluster collectors, each of them is selected by its own labels.
+func isCollectorPod(podLabels map[string]string) bool {
+	if podLabels[selectorKey] == selectorValue {
+		return true
+	}
+	for _, key := range []string{metricsSourceLabelKey, hostedClusterLabelKey} {
+		if _, ok := podLabels[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCollectorRestarts reports the metrics-collector containers which keep restarting.
+func checkCollectorRestarts(ctx context.Context, c client.Client) oav1beta1.StatusCondition {
+	pods := &corev1.PodList{}
+	err := c.List(ctx, pods, client.InNamespace(namespace))
+	if err != nil {
+		return newDiagnosticCondition(diagnosticCollectorRestarts, metav1.ConditionUnknown, "CheckFailed", err.Error())
+	}
+	collectorPods := []corev1.Pod{}
+	for _, pod := range pods.Items {
+		if isCollectorPod(pod.Labels) {
+			collectorPods = append(collectorPods, pod)
+		}
+	}
+	if len(collectorPods) == 0 {
+		return newDiagnosticCondition(diagnosticCollectorRestarts, metav1.ConditionUnknown, "NoCollector",
+			"no metrics-collector pod found")
+	}
+	restarts := []string{}
+	unstable := false
+	for _, pod := range collectorPods {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.RestartCount == 0 {
+				continue
+			}
+			restarts = append(restarts, fmt.Sprintf("%s: %d", pod.Name, status.RestartCount))
+			if status.RestartCount >= maxCollectorRestarts ||
+				(status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff") {
+				unstable = true
+			}
+		}
+	}
+	sort.Strings(restarts)
+	if unstable {
+		return newDiagnosticCondition(diagnosticCollectorRestarts, metav1.ConditionFalse, "Restarting",
+			"metrics-collector restarts: "+strings.Join(restarts, ", "))
+	}
+	if len(restarts) > 0 {
+		return newDiagnosticCondition(diagnosticCollectorRestarts, metav1.ConditionTrue, "Stable",
+			"metrics-collector restarts: "+strings.Join(restarts, ", "))
+	}
+	return newDiagnosticCondition(diagnosticCollectorRestarts, metav1.ConditionTrue, "Stable",
+		"no metrics-collector restart")
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+)
+
+func newTestCertificate(t *testing.T, notBefore, notAfter time.Time) *tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the key: (%v)", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create the certificate: (%v)", err)
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestProbeSourcePrometheus(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"success"}`))
+	}))
+	defer server.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: pool}
+
+	caseList := []struct {
+		name   string
+		url    string
+		token  string
+		reason string
+	}{
+		{name: "reachable", url: server.URL, token: "test-token", reason: "Reachable"},
+		{name: "unauthorized", url: server.URL, token: "invalid-token", reason: "Unauthorized"},
+		{name: "unreachable", url: "https://127.0.0.1:1", token: "test-token", reason: "Unreachable"},
+	}
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			condition := probeSourcePrometheus(context.TODO(), c.url, c.token, tlsConfig)
+			if condition.Type != diagnosticSourcePrometheus || condition.Reason != c.reason {
+				t.Errorf("condition (%v) is not the expected: (%s)", condition, c.reason)
+			}
+		})
+	}
+}
+
+func TestProbeHubEndpoint(t *testing.T) {
+	hubTime := time.Now().Add(-2 * time.Minute).UTC()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", hubTime.Format(http.TimeFormat))
+	}))
+	defer server.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	condition, receivedTime := probeHubEndpoint(context.TODO(), server.URL,
+		&tls.Config{MinVersion: tls.VersionTLS12, RootCAs: pool})
+	if condition.Status != metav1.ConditionTrue || receivedTime == nil {
+		t.Fatalf("the hub endpoint should be reachable: (%v)", condition)
+	}
+	if skew := checkClockSkew(receivedTime, time.Now()); skew.Reason != "ClockSkewed" {
+		t.Errorf("the clock skew should be reported: (%v)", skew)
+	}
+
+	condition, _ = probeHubEndpoint(context.TODO(), server.URL, &tls.Config{MinVersion: tls.VersionTLS12})
+	if condition.Reason != "TLSHandshakeFailed" {
+		t.Errorf("the handshake with an unknown CA should fail: (%v)", condition)
+	}
+	condition, _ = probeHubEndpoint(context.TODO(), "test-endpoint", &tls.Config{MinVersion: tls.VersionTLS12})
+	if condition.Reason != "InvalidEndpoint" {
+		t.Errorf("the endpoint should be invalid: (%v)", condition)
+	}
+}
+
+func TestCheckClientCertificate(t *testing.T) {
+	now := time.Now()
+	caseList := []struct {
+		name   string
+		cert   *tls.Certificate
+		reason string
+	}{
+		{name: "valid", cert: newTestCertificate(t, now.Add(-time.Hour), now.Add(30*24*time.Hour)), reason: "Valid"},
+		{name: "expiring", cert: newTestCertificate(t, now.Add(-time.Hour), now.Add(time.Hour)),
+			reason: "ExpiringSoon"},
+		{name: "expired", cert: newTestCertificate(t, now.Add(-2*time.Hour), now.Add(-time.Hour)), reason: "Expired"},
+	}
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			condition := checkClientCertificate(c.cert, nil, now)
+			if condition.Reason != c.reason {
+				t.Errorf("condition (%v) is not the expected: (%s)", condition, c.reason)
+			}
+		})
+	}
+}
+
+func TestCheckClockSkew(t *testing.T) {
+	now := time.Now()
+	if condition := checkClockSkew(nil, now); condition.Status != metav1.ConditionUnknown {
+		t.Errorf("condition (%v) should be unknown without the hub time", condition)
+	}
+	hubTime := now.Add(-10 * time.Second)
+	condition := checkClockSkew(&hubTime, now)
+	if condition.Status != metav1.ConditionTrue {
+		t.Errorf("condition (%v) should be true", condition)
+	}
+	hubTime = now.Add(-20 * time.Second)
+	if skewed := checkClockSkew(&hubTime, now); skewed.Message != condition.Message {
+		t.Errorf("message (%s) is not the expected: (%s)", skewed.Message, condition.Message)
+	}
+	hubTime = now.Add(time.Minute)
+	if condition := checkClockSkew(&hubTime, now); condition.Status != metav1.ConditionFalse {
+		t.Errorf("condition (%v) should be false", condition)
+	}
+}
+
+func TestCheckAllowlist(t *testing.T) {
+	ctx := context.TODO()
+	c := fake.NewClientBuilder().WithRuntimeObjects(getAllowlistCM()).Build()
+	if condition := checkAllowlist(ctx, c); condition.Status != metav1.ConditionTrue {
+		t.Fatalf("the allowlists should be valid: (%v)", condition)
+	}
+
+	// the custom allowlist contains the invalid match __name__=test
+	c = fake.NewClientBuilder().WithRuntimeObjects(getAllowlistCM(), getCustomAllowlistCM()).Build()
+	if condition := checkAllowlist(ctx, c); condition.Status != metav1.ConditionFalse {
+		t.Errorf("the invalid match should be reported: (%v)", condition)
+	}
+
+	invalidCM := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      operatorconfig.AllowlistCustomConfigMapName,
+			Namespace: "test-ns",
+		},
+		Data: map[string]string{operatorconfig.MetricsConfigMapKey: "names: [a"},
+	}
+	c = fake.NewClientBuilder().WithRuntimeObjects(getAllowlistCM(), invalidCM).Build()
+	condition := checkAllowlist(ctx, c)
+	if condition.Status != metav1.ConditionFalse || condition.Reason != "ParseFailed" {
+		t.Errorf("the invalid allowlist should be reported: (%v)", condition)
+	}
+	list, _, err := getMetricsAllowlist(ctx, c, "")
+	if err != nil || len(list.NameList) == 0 {
+		t.Errorf("the invalid custom allowlist should be ignored: (%v)", err)
+	}
+}
+
+func TestCheckCollectorRestarts(t *testing.T) {
+	newPod := func(name string, restarts int32) runtime.Object {
+		podLabels := map[string]string{selectorKey: selectorValue}
+		if strings.HasPrefix(name, "source-") {
+			podLabels = map[string]string{metricsSourceLabelKey: name}
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    podLabels,
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "metrics-collector", RestartCount: restarts}},
+			},
+		}
+	}
+	caseList := []struct {
+		name   string
+		objs   []runtime.Object
+		status metav1.ConditionStatus
+	}{
+		{name: "no collector", status: metav1.ConditionUnknown},
+		{name: "stable", objs: []runtime.Object{newPod("collector-a", 0), newPod("collector-b", 1)},
+			status: metav1.ConditionTrue},
+		{name: "restarting", objs: []runtime.Object{newPod("collector-a", 0), newPod("collector-b", 6)},
+			status: metav1.ConditionFalse},
+		{name: "restarting source", objs: []runtime.Object{newPod("collector-a", 0), newPod("source-istio", 6)},
+			status: metav1.ConditionFalse},
+	}
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.NewClientBuilder().WithRuntimeObjects(c.objs...).Build()
+			condition := checkCollectorRestarts(context.TODO(), client)
+			if condition.Status != c.status {
+				t.Errorf("condition (%v) is not the expected: (%s)", condition, c.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,448 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-observability-operator/operators/endpointmetrics/pkg/rendering"
+	oashared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/operators/pkg/deploying"
+	rendererutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/rendering"
+)
+
+const (
+	// existingPrometheusConfigMapName configures the existing Prometheus reused on *KS, it is optional
+	// if the Prometheus is managed by a prometheus-operator
+	existingPrometheusConfigMapName = "observability-existing-prometheus"
+	// existingPrometheusURLKey is the url the metrics are federated from
+	existingPrometheusURLKey = "url"
+	// existingPrometheusKey is the <namespace>/<name> of the Prometheus resource to configure
+	existingPrometheusKey = "prometheus"
+	// existingPrometheusAmConfigName is the additional alertmanager config of the existing Prometheus
+	existingPrometheusAmConfigName = "observability-prometheus-alertmanager"
+	existingPrometheusAmConfigKey  = "alertmanager.yaml"
+	bundledPrometheusName          = "k8s"
+	prometheusOperatedURL          = "http://prometheus-operated.%s.svc:9090"
+	// existingPrometheusRulePrefix prefixes the default rules deployed for the existing Prometheus
+	existingPrometheusRulePrefix = "observability-"
+)
+
+// existingPrometheusAmConfig is the hub alertmanager config, the secrets are mounted into
+// /etc/prometheus/secrets by the prometheus-operator.
+const existingPrometheusAmConfig = `- authorization:
+    type: Bearer
+    credentials_file: /etc/prometheus/secrets/%s/%s
+  tls_config:
+    ca_file: /etc/prometheus/secrets/%s/%s
+    server_name: ""
+    insecure_skip_verify: false
+  follow_redirects: true
+  scheme: https
+  path_prefix: /
+  timeout: 10s
+  api_version: v2
+  static_configs:
+  - targets:
+    - %s`
+
+// existingPrometheus is a Prometheus installed on the *KS cluster by the user, which is
+// reused instead of the bundled one.
+type existingPrometheus struct {
+	// url is the url the metrics are federated from
+	url string
+	// prometheus is the Prometheus resource configured with the external label and the alerting,
+	// nil if only the url is configured
+	prometheus *types.NamespacedName
+}
+
+// findExistingPrometheus returns the Prometheus configured in the observability-existing-prometheus
+// configmap or else the first Prometheus resource found out of the addon namespace. It returns
+// nil if there is none, then the bundled Prometheus is deployed.
+func findExistingPrometheus(ctx context.Context, c client.Client) (*existingPrometheus, error) {
+	cm := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Name: existingPrometheusConfigMapName, Namespace: namespace}, cm)
+	if err == nil {
+		return parseExistingPrometheusConfigMap(cm)
+	}
+	if !errors.IsNotFound(err) {
+		log.Error(err, "Failed to get the configmap", "name", existingPrometheusConfigMapName)
+		return nil, err
+	}
+
+	promList := &promv1.PrometheusList{}
+	err = c.List(ctx, promList)
+	if err != nil {
+		if meta.IsNoMatchError(err) || errors.IsNotFound(err) {
+			return nil, nil
+		}
+		log.Error(err, "Failed to list the prometheus resources")
+		return nil, err
+	}
+	found := []types.NamespacedName{}
+	for _, prom := range promList.Items {
+		if prom.Namespace == namespace {
+			// the bundled Prometheus
+			continue
+		}
+		found = append(found, types.NamespacedName{Name: prom.Name, Namespace: prom.Namespace})
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].String() < found[j].String()
+	})
+	if len(found) > 1 {
+		log.Info("More than one prometheus found, the first one is reused", "prometheus", found[0].String())
+	}
+	return &existingPrometheus{
+		url:        fmt.Sprintf(prometheusOperatedURL, found[0].Namespace),
+		prometheus: &found[0],
+	}, nil
+}
+
+func parseExistingPrometheusConfigMap(cm *corev1.ConfigMap) (*existingPrometheus, error) {
+	existing := &existingPrometheus{url: cm.Data[existingPrometheusURLKey]}
+	if ref := cm.Data[existingPrometheusKey]; ref != "" {
+		parts := strings.Split(ref, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid %s %q in configmap %s, should be <namespace>/<name>",
+				existingPrometheusKey, ref, existingPrometheusConfigMapName)
+		}
+		existing.prometheus = &types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+		if existing.url == "" {
+			existing.url = fmt.Sprintf(prometheusOperatedURL, parts[0])
+		}
+	}
+	if existing.url == "" {
+		return nil, fmt.Errorf("neither %s nor %s is set in configmap %s",
+			existingPrometheusURLKey, existingPrometheusKey, existingPrometheusConfigMapName)
+	}
+	return existing, nil
+}
+
+// createOrUpdatePrometheusAlerting configures the alerting of the Prometheus on *KS clusters. The
+// bundled Prometheus is configured when it is rendered, the existing Prometheus gets the hub
+// alertmanager config and the default rules.
+func createOrUpdatePrometheusAlerting(ctx context.Context, c client.Client, hubInfo *operatorconfig.HubInfo) error {
+	existing, err := findExistingPrometheus(ctx, c)
+	if err != nil || existing == nil {
+		return err
+	}
+	if err := updateExistingPrometheus(ctx, c, existing, hubInfo); err != nil {
+		return err
+	}
+	if existing.prometheus == nil {
+		return nil
+	}
+	return createOrUpdateExistingPrometheusRules(ctx, c, *existing.prometheus)
+}
+
+// updateExistingPrometheus adds the managed_cluster external label and the hub alertmanager
+// config to the existing Prometheus resource, the rest of its configuration is left to the user.
+func updateExistingPrometheus(ctx context.Context, c client.Client, existing *existingPrometheus,
+	hubInfo *operatorconfig.HubInfo) error {
+	if existing.prometheus == nil {
+		log.Info("No prometheus resource is configured, the alerts are not forwarded", "url", existing.url)
+		return nil
+	}
+	targetNamespace := existing.prometheus.Namespace
+	alertForwarding := hubInfo.AlertmanagerEndpoint != ""
+	if alertForwarding {
+		if err := createHubAmRouterCASecret(ctx, hubInfo, c, targetNamespace); err != nil {
+			return err
+		}
+		if err := createHubAmAccessorTokenSecret(ctx, c, targetNamespace); err != nil {
+			return err
+		}
+		if err := createOrUpdateExistingPrometheusAmConfig(ctx, c, targetNamespace, hubInfo); err != nil {
+			return err
+		}
+	} else if err := deleteExistingPrometheusSecrets(ctx, c, targetNamespace); err != nil {
+		return err
+	}
+
+	return updatePrometheusResource(ctx, c, *existing.prometheus, func(spec *promv1.PrometheusSpec) {
+		if spec.ExternalLabels == nil {
+			spec.ExternalLabels = map[string]string{}
+		}
+		spec.ExternalLabels[operatorconfig.ClusterLabelKeyForAlerts] = hubInfo.ClusterName
+		setPrometheusAlerting(spec, alertForwarding)
+	})
+}
+
+// revertExistingPrometheus removes the changes made to the existing Prometheus.
+func revertExistingPrometheus(ctx context.Context, c client.Client) error {
+	existing, err := findExistingPrometheus(ctx, c)
+	if err != nil || existing == nil || existing.prometheus == nil {
+		return err
+	}
+	if err := deleteExistingPrometheusSecrets(ctx, c, existing.prometheus.Namespace); err != nil {
+		return err
+	}
+	if err := deleteExistingPrometheusRules(ctx, c, existing.prometheus.Namespace); err != nil {
+		return err
+	}
+	return updatePrometheusResource(ctx, c, *existing.prometheus, func(spec *promv1.PrometheusSpec) {
+		delete(spec.ExternalLabels, operatorconfig.ClusterLabelKeyForAlerts)
+		setPrometheusAlerting(spec, false)
+	})
+}
+
+// setPrometheusAlerting sets or removes the hub alertmanager config and its secrets, an additional
+// alertmanager config set by the user is kept.
+func setPrometheusAlerting(spec *promv1.PrometheusSpec, alertForwarding bool) {
+	owned := spec.AdditionalAlertManagerConfigs == nil ||
+		spec.AdditionalAlertManagerConfigs.Name == existingPrometheusAmConfigName
+	secrets := []string{}
+	for _, s := range spec.Secrets {
+		if s != hubAmRouterCASecretName && s != hubAmAccessorSecretName {
+			secrets = append(secrets, s)
+		}
+	}
+	if !owned {
+		log.Info("The additional alertmanager configs are set by the user, the alerts are not forwarded",
+			"secret", spec.AdditionalAlertManagerConfigs.Name)
+	} else if alertForwarding {
+		spec.AdditionalAlertManagerConfigs = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: existingPrometheusAmConfigName},
+			Key:                  existingPrometheusAmConfigKey,
+		}
+		secrets = append(secrets, hubAmRouterCASecretName, hubAmAccessorSecretName)
+	} else {
+		spec.AdditionalAlertManagerConfigs = nil
+	}
+	if len(secrets) == 0 {
+		secrets = nil
+	}
+	spec.Secrets = secrets
+}
+
+func updatePrometheusResource(ctx context.Context, c client.Client, name types.NamespacedName,
+	mutate func(spec *promv1.PrometheusSpec)) error {
+	found := &promv1.Prometheus{}
+	err := c.Get(ctx, name, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("The prometheus resource does not exist", "prometheus", name.String())
+			return nil
+		}
+		log.Error(err, "Failed to get the prometheus resource", "prometheus", name.String())
+		return err
+	}
+	updated := found.DeepCopy()
+	mutate(&updated.Spec)
+	if reflect.DeepEqual(found.Spec, updated.Spec) {
+		return nil
+	}
+	err = c.Update(ctx, updated)
+	if err != nil {
+		log.Error(err, "Failed to update the prometheus resource", "prometheus", name.String())
+		return err
+	}
+	log.Info("The prometheus resource is updated", "prometheus", name.String())
+	return nil
+}
+
+func createOrUpdateExistingPrometheusAmConfig(ctx context.Context, c client.Client, targetNamespace string,
+	hubInfo *operatorconfig.HubInfo) error {
+	config := fmt.Sprintf(existingPrometheusAmConfig, hubAmAccessorSecretName, hubAmAccessorSecretKey,
+		hubAmRouterCASecretName, hubAmRouterCASecretKey, strings.TrimLeft(hubInfo.AlertmanagerEndpoint, "https://"))
+	data := map[string][]byte{existingPrometheusAmConfigKey: []byte(config)}
+
+	found := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: existingPrometheusAmConfigName, Namespace: targetNamespace}, found)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "Failed to check the secret", "name", existingPrometheusAmConfigName)
+			return err
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      existingPrometheusAmConfigName,
+				Namespace: targetNamespace,
+			},
+			Data: data,
+		}
+		err = c.Create(ctx, secret)
+		if err != nil {
+			log.Error(err, "Failed to create the secret", "name", existingPrometheusAmConfigName)
+			return err
+		}
+		log.Info("The secret is created", "name", existingPrometheusAmConfigName, "namespace", targetNamespace)
+		return nil
+	}
+	if reflect.DeepEqual(found.Data, data) {
+		return nil
+	}
+	found.Data = data
+	err = c.Update(ctx, found)
+	if err != nil {
+		log.Error(err, "Failed to update the secret", "name", existingPrometheusAmConfigName)
+		return err
+	}
+	log.Info("The secret is updated", "name", existingPrometheusAmConfigName, "namespace", targetNamespace)
+	return nil
+}
+
+func deleteExistingPrometheusSecrets(ctx context.Context, c client.Client, targetNamespace string) error {
+	if targetNamespace == namespace {
+		// the secrets of the addon namespace are managed with the bundled Prometheus
+		return nil
+	}
+	if err := deleteHubAmRouterCASecret(ctx, c, targetNamespace); err != nil {
+		return err
+	}
+	if err := deleteHubAmAccessorTokenSecret(ctx, c, targetNamespace); err != nil {
+		return err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      existingPrometheusAmConfigName,
+			Namespace: targetNamespace,
+		},
+	}
+	err := c.Delete(ctx, secret)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Failed to delete the secret", "name", existingPrometheusAmConfigName)
+		return err
+	}
+	return nil
+}
+
+// createOrUpdateExistingPrometheusRules deploys the default rules for *KS clusters in the namespace
+// of the existing Prometheus, labeled to be selected by its rule selector.
+func createOrUpdateExistingPrometheusRules(ctx context.Context, c client.Client, name types.NamespacedName) error {
+	prom := &promv1.Prometheus{}
+	err := c.Get(ctx, name, prom)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		log.Error(err, "Failed to get the prometheus resource", "prometheus", name.String())
+		return err
+	}
+	if prom.Spec.RuleSelector == nil {
+		log.Info("The prometheus selects no rules, the default rules are not deployed", "prometheus", name.String())
+		return nil
+	}
+	if len(prom.Spec.RuleSelector.MatchExpressions) != 0 {
+		log.Info("Only the match labels of the rule selector are set on the default rules",
+			"prometheus", name.String())
+	}
+
+	rules, err := rendering.RenderPrometheusRules(rendererutil.NewRenderer())
+	if err != nil {
+		log.Error(err, "Failed to render the prometheus rules")
+		return err
+	}
+	deployer := deploying.NewDeployer(c)
+	for _, rule := range newExistingPrometheusRules(rules, prom) {
+		if err := deployer.Deploy(rule); err != nil {
+			log.Error(err, "Failed to deploy the prometheus rule", "name", rule.GetName(),
+				"namespace", rule.GetNamespace())
+			return err
+		}
+	}
+	return nil
+}
+
+// newExistingPrometheusRules returns the default rules to deploy for the existing Prometheus.
+func newExistingPrometheusRules(rules []*unstructured.Unstructured,
+	prom *promv1.Prometheus) []*unstructured.Unstructured {
+	result := []*unstructured.Unstructured{}
+	for _, rule := range rules {
+		if rule.GetKind() != "PrometheusRule" {
+			continue
+		}
+		r := rule.DeepCopy()
+		r.SetName(existingPrometheusRulePrefix + rule.GetName())
+		r.SetNamespace(prom.Namespace)
+		labels := r.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		if prom.Spec.RuleSelector != nil {
+			for k, v := range prom.Spec.RuleSelector.MatchLabels {
+				labels[k] = v
+			}
+		}
+		r.SetLabels(labels)
+		result = append(result, r)
+	}
+	return result
+}
+
+func deleteExistingPrometheusRules(ctx context.Context, c client.Client, targetNamespace string) error {
+	rules, err := rendering.RenderPrometheusRules(rendererutil.NewRenderer())
+	if err != nil {
+		log.Error(err, "Failed to render the prometheus rules")
+		return err
+	}
+	for _, rule := range rules {
+		if rule.GetKind() != "PrometheusRule" {
+			continue
+		}
+		r := &promv1.PrometheusRule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      existingPrometheusRulePrefix + rule.GetName(),
+				Namespace: targetNamespace,
+			},
+		}
+		err = c.Delete(ctx, r)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete the prometheus rule", "name", r.Name, "namespace", targetNamespace)
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteBundledPrometheus deletes the bundled Prometheus stack once an existing Prometheus is
+// reused. The CRDs are kept since they are shared with the existing Prometheus.
+func deleteBundledPrometheus(ctx context.Context, c client.Client, hubInfo *operatorconfig.HubInfo) error {
+	bundled := &promv1.Prometheus{}
+	err := c.Get(ctx, types.NamespacedName{Name: bundledPrometheusName, Namespace: namespace}, bundled)
+	if err != nil {
+		if meta.IsNoMatchError(err) || errors.IsNotFound(err) {
+			return nil
+		}
+		log.Error(err, "Failed to check the bundled prometheus")
+		return err
+	}
+
+	// the spec does not change the names of the bundled resources
+	toDelete, err := rendering.Render(rendererutil.NewRenderer(), c, hubInfo, oashared.ObservabilityAddonSpec{})
+	if err != nil {
+		log.Error(err, "Failed to render prometheus templates")
+		return err
+	}
+	for _, res := range toDelete {
+		if res.GetKind() == "CustomResourceDefinition" {
+			continue
+		}
+		err = c.Delete(ctx, res)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete the bundled prometheus resource",
+				"kind", res.GetKind(), "name", res.GetName())
+			return err
+		}
+	}
+	log.Info("The bundled prometheus is deleted since an existing prometheus is reused")
+	return nil
+}
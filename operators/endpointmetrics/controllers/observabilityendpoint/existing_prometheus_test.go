@@ -0,0 +1,256 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+)
+
+func newPrometheus(name, ns string) *promv1.Prometheus {
+	return &promv1.Prometheus{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Spec: promv1.PrometheusSpec{
+			ExternalLabels: map[string]string{"env": "prod"},
+			Secrets:        []string{"user-secret"},
+		},
+	}
+}
+
+func newExistingPrometheusConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      existingPrometheusConfigMapName,
+			Namespace: testNamespace,
+		},
+		Data: data,
+	}
+}
+
+func TestFindExistingPrometheus(t *testing.T) {
+	caseList := []struct {
+		name     string
+		objs     []runtime.Object
+		expected *existingPrometheus
+		wantErr  bool
+	}{
+		{
+			name:     "no existing prometheus",
+			objs:     []runtime.Object{newPrometheus(bundledPrometheusName, testNamespace)},
+			expected: nil,
+		},
+		{
+			name: "prometheus resources",
+			objs: []runtime.Object{
+				newPrometheus(bundledPrometheusName, testNamespace),
+				newPrometheus("kube-prometheus", "monitoring"),
+				newPrometheus("other", "user-monitoring"),
+			},
+			expected: &existingPrometheus{
+				url:        "http://prometheus-operated.monitoring.svc:9090",
+				prometheus: &types.NamespacedName{Name: "kube-prometheus", Namespace: "monitoring"},
+			},
+		},
+		{
+			name: "configured url",
+			objs: []runtime.Object{
+				newPrometheus("kube-prometheus", "monitoring"),
+				newExistingPrometheusConfigMap(map[string]string{
+					existingPrometheusURLKey: "http://prometheus.monitoring.svc:9090",
+				}),
+			},
+			expected: &existingPrometheus{url: "http://prometheus.monitoring.svc:9090"},
+		},
+		{
+			name: "configured prometheus resource",
+			objs: []runtime.Object{
+				newExistingPrometheusConfigMap(map[string]string{
+					existingPrometheusKey: "user-monitoring/other",
+				}),
+			},
+			expected: &existingPrometheus{
+				url:        "http://prometheus-operated.user-monitoring.svc:9090",
+				prometheus: &types.NamespacedName{Name: "other", Namespace: "user-monitoring"},
+			},
+		},
+		{
+			name: "invalid prometheus resource",
+			objs: []runtime.Object{
+				newExistingPrometheusConfigMap(map[string]string{existingPrometheusKey: "other"}),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.NewClientBuilder().WithRuntimeObjects(c.objs...).Build()
+			existing, err := findExistingPrometheus(context.TODO(), client)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("an error is expected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to find the existing prometheus: (%v)", err)
+			}
+			if !reflect.DeepEqual(existing, c.expected) {
+				t.Errorf("existing prometheus (%v) is not the expected: (%v)", existing, c.expected)
+			}
+		})
+	}
+}
+
+func TestUpdateExistingPrometheus(t *testing.T) {
+	ctx := context.TODO()
+	hubInfo := &operatorconfig.HubInfo{
+		ClusterName:          "test-cluster",
+		AlertmanagerEndpoint: "https://test-alertmanager-endpoint",
+		AlertmanagerRouterCA: "ca",
+	}
+	objs := []runtime.Object{newPrometheus("kube-prometheus", "monitoring"), newAMAccessorSecret()}
+	c := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+	existing, err := findExistingPrometheus(ctx, c)
+	if err != nil || existing == nil {
+		t.Fatalf("Failed to find the existing prometheus: (%v)", err)
+	}
+
+	err = updateExistingPrometheus(ctx, c, existing, hubInfo)
+	if err != nil {
+		t.Fatalf("Failed to update the existing prometheus: (%v)", err)
+	}
+	prom := &promv1.Prometheus{}
+	err = c.Get(ctx, *existing.prometheus, prom)
+	if err != nil {
+		t.Fatalf("Failed to get the prometheus: (%v)", err)
+	}
+	if prom.Spec.ExternalLabels[operatorconfig.ClusterLabelKeyForAlerts] != "test-cluster" ||
+		prom.Spec.ExternalLabels["env"] != "prod" {
+		t.Errorf("external labels (%v) are not the expected", prom.Spec.ExternalLabels)
+	}
+	expectedSecrets := []string{"user-secret", hubAmRouterCASecretName, hubAmAccessorSecretName}
+	if !reflect.DeepEqual(prom.Spec.Secrets, expectedSecrets) {
+		t.Errorf("secrets (%v) is not the expected: (%v)", prom.Spec.Secrets, expectedSecrets)
+	}
+	if prom.Spec.AdditionalAlertManagerConfigs == nil ||
+		prom.Spec.AdditionalAlertManagerConfigs.Name != existingPrometheusAmConfigName {
+		t.Errorf("additional alertmanager configs (%v) is not the expected", prom.Spec.AdditionalAlertManagerConfigs)
+	}
+	for _, name := range []string{hubAmRouterCASecretName, hubAmAccessorSecretName, existingPrometheusAmConfigName} {
+		err = c.Get(ctx, types.NamespacedName{Name: name, Namespace: "monitoring"}, &corev1.Secret{})
+		if err != nil {
+			t.Fatalf("the secret %s should be created in the prometheus namespace: (%v)", name, err)
+		}
+	}
+
+	err = revertExistingPrometheus(ctx, c)
+	if err != nil {
+		t.Fatalf("Failed to revert the existing prometheus: (%v)", err)
+	}
+	prom = &promv1.Prometheus{}
+	err = c.Get(ctx, *existing.prometheus, prom)
+	if err != nil {
+		t.Fatalf("Failed to get the prometheus: (%v)", err)
+	}
+	if !reflect.DeepEqual(prom.Spec, newPrometheus("kube-prometheus", "monitoring").Spec) {
+		t.Errorf("prometheus spec (%v) should be reverted", prom.Spec)
+	}
+	for _, name := range []string{hubAmRouterCASecretName, hubAmAccessorSecretName, existingPrometheusAmConfigName} {
+		err = c.Get(ctx, types.NamespacedName{Name: name, Namespace: "monitoring"}, &corev1.Secret{})
+		if err == nil || !errors.IsNotFound(err) {
+			t.Fatalf("the secret %s should be deleted from the prometheus namespace", name)
+		}
+	}
+}
+
+func TestSetPrometheusAlertingUserConfig(t *testing.T) {
+	userConfig := &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "user-alertmanager"},
+		Key:                  "alertmanager.yaml",
+	}
+	spec := &promv1.PrometheusSpec{AdditionalAlertManagerConfigs: userConfig}
+	setPrometheusAlerting(spec, true)
+	if !reflect.DeepEqual(spec.AdditionalAlertManagerConfigs, userConfig) || spec.Secrets != nil {
+		t.Errorf("the additional alertmanager configs of the user (%v) should be kept",
+			spec.AdditionalAlertManagerConfigs)
+	}
+}
+
+func TestClusterMonitoringConfigExistingPrometheus(t *testing.T) {
+	ctx := context.TODO()
+	hubInfo := &operatorconfig.HubInfo{
+		ClusterName:          "test-cluster",
+		AlertmanagerEndpoint: "https://test-alertmanager-endpoint",
+		AlertmanagerRouterCA: "ca",
+	}
+	objs := []runtime.Object{newPrometheus("kube-prometheus", "monitoring"), newAMAccessorSecret()}
+	c := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+
+	_, err := createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to configure the alerting of the existing prometheus: (%v)", err)
+	}
+	prom := &promv1.Prometheus{}
+	err = c.Get(ctx, types.NamespacedName{Name: "kube-prometheus", Namespace: "monitoring"}, prom)
+	if err != nil {
+		t.Fatalf("Failed to get the prometheus: (%v)", err)
+	}
+	if prom.Spec.AdditionalAlertManagerConfigs == nil ||
+		prom.Spec.ExternalLabels[operatorconfig.ClusterLabelKeyForAlerts] != "test-cluster" {
+		t.Errorf("the alerting of the existing prometheus (%v) is not configured", prom.Spec)
+	}
+
+	err = revertClusterMonitoringConfig(ctx, c, true)
+	if err != nil {
+		t.Fatalf("Failed to revert the alerting of the existing prometheus: (%v)", err)
+	}
+	prom = &promv1.Prometheus{}
+	err = c.Get(ctx, types.NamespacedName{Name: "kube-prometheus", Namespace: "monitoring"}, prom)
+	if err != nil {
+		t.Fatalf("Failed to get the prometheus: (%v)", err)
+	}
+	if prom.Spec.AdditionalAlertManagerConfigs != nil {
+		t.Errorf("the alerting of the existing prometheus (%v) is not reverted", prom.Spec)
+	}
+}
+
+func TestNewExistingPrometheusRules(t *testing.T) {
+	prom := newPrometheus("kube-prometheus", "monitoring")
+	prom.Spec.RuleSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"release": "kube-prometheus"}}
+	rule := &unstructured.Unstructured{}
+	rule.SetKind("PrometheusRule")
+	rule.SetName("watchdog-rules")
+	rule.SetNamespace(testNamespace)
+	rule.SetLabels(map[string]string{"app": "test"})
+
+	rules := newExistingPrometheusRules([]*unstructured.Unstructured{rule}, prom)
+	if len(rules) != 1 {
+		t.Fatalf("the number of rules (%d) is not the expected: (1)", len(rules))
+	}
+	if rules[0].GetName() != "observability-watchdog-rules" || rules[0].GetNamespace() != "monitoring" {
+		t.Errorf("rule %s/%s is not the expected", rules[0].GetNamespace(), rules[0].GetName())
+	}
+	expectedLabels := map[string]string{"app": "test", "release": "kube-prometheus"}
+	if !reflect.DeepEqual(rules[0].GetLabels(), expectedLabels) {
+		t.Errorf("rule labels (%v) is not the expected: (%v)", rules[0].GetLabels(), expectedLabels)
+	}
+	if rule.GetNamespace() != testNamespace {
+		t.Errorf("the rendered rule should not be modified")
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"reflect"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+)
+
+const (
+	// hostedClusterCSRAnnotation holds the name of the pending certificate signing request on the hub
+	hostedClusterCSRAnnotation = "observability.open-cluster-management.io/certificate-signing-request"
+	// the labels the addon manager of the hub selects the certificate signing requests with
+	addonLabelKey   = "open-cluster-management.io/addon-name"
+	clusterLabelKey = "open-cluster-management.io/cluster-name"
+	addonName       = "observability-controller"
+	// the certificate is renewed once this ratio of its validity elapsed
+	certRenewalRatio = 0.8
+	// the interval the pending certificate signing requests are checked
+	hostedClusterCertPendingInterval = 30 * time.Second
+	// the interval the issued certificates are checked for renewal
+	hostedClusterCertRenewalInterval = time.Hour
+)
+
+func getHostedClusterCertName(name string) string {
+	return "hosted-" + name + "-observability-client-cert"
+}
+
+// getClientCertName returns the secret of the client certificate the collector uploads the metrics with.
+func getClientCertName(params CollectorParams) string {
+	if params.hostedCluster != nil {
+		return getHostedClusterCertName(params.hostedCluster.name)
+	}
+	return mtlsCertName
+}
+
+// parseCertificate returns the client certificate held by the secret, nil if none or invalid.
+func parseCertificate(secret *corev1.Secret) *x509.Certificate {
+	data := secret.Data[corev1.TLSCertKey]
+	if len(data) == 0 {
+		return nil
+	}
+	certs, err := certutil.ParseCertsPEM(data)
+	if err != nil || len(certs) == 0 {
+		return nil
+	}
+	return certs[0]
+}
+
+// isRenewalDue returns true once the renewal ratio of the validity of the certificate elapsed.
+func isRenewalDue(cert *x509.Certificate) bool {
+	validity := cert.NotAfter.Sub(cert.NotBefore)
+	return time.Now().After(cert.NotBefore.Add(time.Duration(float64(validity) * certRenewalRatio)))
+}
+
+// getClusterGroup returns the group of the client certificate of the managed cluster, which is the group of the
+// tenant the managed cluster is mapped to on the hub. The hosted clusters are requested in the same group.
+func getClusterGroup(ctx context.Context, c client.Client) string {
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: mtlsCertName, Namespace: namespace}, secret)
+	if err != nil {
+		log.Error(err, "Failed to get the client certificate of the managed cluster", "name", mtlsCertName)
+		return operatorconfig.ManagedClusterOU
+	}
+	cert := parseCertificate(secret)
+	if cert == nil || len(cert.Subject.OrganizationalUnit) == 0 {
+		return operatorconfig.ManagedClusterOU
+	}
+	return cert.Subject.OrganizationalUnit[0]
+}
+
+// isCSRFailed returns true if the certificate signing request will never be issued.
+func isCSRFailed(csr *certificatesv1.CertificateSigningRequest) bool {
+	for _, condition := range csr.Status.Conditions {
+		if condition.Type == certificatesv1.CertificateDenied || condition.Type == certificatesv1.CertificateFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// updateHostedClusterCertificate issues the client certificate of the collector of a hosted cluster. The
+// private key stays in the secret of the hosted cluster, the certificate is requested with a CSR to the
+// hub, which approves the identity of the hosted cluster only. It returns true if a valid certificate is
+// available, and true as second value if the certificate was just issued.
+func updateHostedClusterCertificate(ctx context.Context, c client.Client, hubClient client.Client,
+	clusterName string, hostedCluster string) (bool, bool, error) {
+	name := getHostedClusterCertName(hostedCluster)
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, secret)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "Failed to get the certificate secret of the hosted cluster", "name", name)
+			return false, false, err
+		}
+		key, err := keyutil.MakeEllipticPrivateKeyPEM()
+		if err != nil {
+			log.Error(err, "Failed to generate the private key of the hosted cluster", "name", name)
+			return false, false, err
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{hostedClusterLabelKey: hostedCluster},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{corev1.TLSPrivateKeyKey: key},
+		}
+		err = c.Create(ctx, secret)
+		if err != nil {
+			log.Error(err, "Failed to create the certificate secret of the hosted cluster", "name", name)
+			return false, false, err
+		}
+	}
+	cert := parseCertificate(secret)
+	valid := cert != nil && time.Now().Before(cert.NotAfter)
+	group := getClusterGroup(ctx, c)
+
+	if csrName := secret.Annotations[hostedClusterCSRAnnotation]; csrName != "" {
+		csr := &certificatesv1.CertificateSigningRequest{}
+		err = hubClient.Get(ctx, types.NamespacedName{Name: csrName}, csr)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to get the certificate signing request of the hosted cluster", "name", csrName)
+			return valid, false, err
+		}
+		switch {
+		case err == nil && len(csr.Status.Certificate) != 0:
+			secret.Data[corev1.TLSCertKey] = csr.Status.Certificate
+			delete(secret.Annotations, hostedClusterCSRAnnotation)
+			err = c.Update(ctx, secret)
+			if err != nil {
+				log.Error(err, "Failed to update the certificate secret of the hosted cluster", "name", name)
+				return valid, false, err
+			}
+			log.Info("The client certificate of the hosted cluster is issued", "name", name)
+			return true, true, nil
+		case err == nil && !isCSRFailed(csr):
+			return valid, false, nil
+		default:
+			log.Info("The certificate signing request of the hosted cluster is not issued, requesting again",
+				"name", csrName)
+		}
+	} else if cert != nil && !isRenewalDue(cert) && reflect.DeepEqual(cert.Subject.OrganizationalUnit, []string{group}) {
+		return true, false, nil
+	}
+
+	csrName, err := requestHostedClusterCertificate(ctx, hubClient, secret, clusterName, hostedCluster, group)
+	if err != nil {
+		return valid, false, err
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[hostedClusterCSRAnnotation] = csrName
+	err = c.Update(ctx, secret)
+	if err != nil {
+		log.Error(err, "Failed to update the certificate secret of the hosted cluster", "name", name)
+	}
+	return valid, false, err
+}
+
+// requestHostedClusterCertificate creates the certificate signing request of the hosted cluster in the group on
+// the hub and returns its name.
+func requestHostedClusterCertificate(ctx context.Context, hubClient client.Client, secret *corev1.Secret,
+	clusterName string, hostedCluster string, group string) (string, error) {
+	key, err := keyutil.ParsePrivateKeyPEM(secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		log.Error(err, "Failed to parse the private key of the hosted cluster", "name", secret.Name)
+		return "", err
+	}
+	request, err := certutil.MakeCSR(key, &pkix.Name{
+		CommonName:         operatorconfig.GetHostedClusterUser(clusterName, hostedCluster),
+		OrganizationalUnit: []string{group},
+	}, nil, nil)
+	if err != nil {
+		log.Error(err, "Failed to create the certificate request of the hosted cluster", "name", secret.Name)
+		return "", err
+	}
+	csr := &certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			// the addon manager of the hub only handles the requests prefixed with addon
+			GenerateName: "addon-" + clusterName + "-" + addonName + "-hosted-",
+			Labels: map[string]string{
+				addonLabelKey:         addonName,
+				clusterLabelKey:       clusterName,
+				hostedClusterLabelKey: hostedCluster,
+			},
+		},
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request:    request,
+			SignerName: operatorconfig.ObservabilitySignerName,
+			Usages: []certificatesv1.KeyUsage{
+				certificatesv1.UsageDigitalSignature,
+				certificatesv1.UsageKeyEncipherment,
+				certificatesv1.UsageClientAuth,
+			},
+		},
+	}
+	err = hubClient.Create(ctx, csr)
+	if err != nil {
+		log.Error(err, "Failed to create the certificate signing request of the hosted cluster", "name", secret.Name)
+		return "", err
+	}
+	log.Info("Requested the client certificate of the hosted cluster", "csr", csr.Name)
+	return csr.Name, nil
+}
+
+// getHostedClusterCertificateRequeue returns the interval the certificates of the hosted clusters are
+// checked at, 0 if there is none.
+func getHostedClusterCertificateRequeue(ctx context.Context, c client.Client) time.Duration {
+	secrets := &corev1.SecretList{}
+	err := c.List(ctx, secrets, client.InNamespace(namespace), client.HasLabels{hostedClusterLabelKey})
+	if err != nil {
+		log.Error(err, "Failed to list the certificate secrets of the hosted clusters")
+		return hostedClusterCertPendingInterval
+	}
+	if len(secrets.Items) == 0 {
+		return 0
+	}
+	for _, secret := range secrets.Items {
+		if secret.Annotations[hostedClusterCSRAnnotation] != "" {
+			return hostedClusterCertPendingInterval
+		}
+	}
+	return hostedClusterCertRenewalInterval
+}
+
+// deleteHostedClusterCertificates deletes the certificate secrets of the hosted clusters not listed in keep.
+func deleteHostedClusterCertificates(ctx context.Context, c client.Client, keep []string) error {
+	secrets := &corev1.SecretList{}
+	err := c.List(ctx, secrets, client.InNamespace(namespace), client.HasLabels{hostedClusterLabelKey})
+	if err != nil {
+		log.Error(err, "Failed to list the certificate secrets of the hosted clusters")
+		return err
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if contains(keep, secret.Labels[hostedClusterLabelKey]) {
+			continue
+		}
+		err = c.Delete(ctx, secret)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete the certificate secret of the hosted cluster", "name", secret.Name)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oashared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	oav1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+)
+
+const (
+	// hostedClusterLabelKey labels the metrics-collector deployments of the hosted clusters
+	hostedClusterLabelKey = operatorconfig.HostedClusterLabelKey
+	// hostedClusterMetricsAnnotation selects the allowlisted metrics collected for a hosted cluster,
+	// a comma separated list of metric names, all the allowlisted metrics are collected if not set
+	hostedClusterMetricsAnnotation = "observability.open-cluster-management.io/hosted-cluster-metrics"
+	// hypershiftIDLabel holds the cluster id of the hosted cluster in the metrics of its control plane
+	hypershiftIDLabel = "_id"
+)
+
+type hostedClusterInfo struct {
+	name string
+	id   string
+	// the metric names selected for the hosted cluster, empty if all the allowlisted metrics are collected
+	metrics []string
+}
+
+func getHostedClusterCollectorName(name string) string {
+	return "hosted-" + name + "-" + metricsCollectorName
+}
+
+func getHostedClusterConditionType(name string) string {
+	return operatorconfig.HostedClusterConditionPrefix + name
+}
+
+// excludeHostedClusters returns true if the metrics of the hosted clusters are to be filtered out of
+// the platform metrics, as they are forwarded by the collectors of the hosted clusters.
+func excludeHostedClusters(params CollectorParams) bool {
+	return params.obsAddonSpec.HostedClusterCollectors && !params.isUWL && params.source == nil &&
+		params.hostedCluster == nil
+}
+
+// listHostedClusters returns the hosted clusters whose metrics are forwarded by their own collector,
+// none if the collectors are disabled or HyperShift is not installed.
+func listHostedClusters(ctx context.Context, c client.Client,
+	obsAddonSpec oashared.ObservabilityAddonSpec) ([]hostedClusterInfo, error) {
+	hostedClusters := []hostedClusterInfo{}
+	if !obsAddonSpec.HostedClusterCollectors {
+		return hostedClusters, nil
+	}
+	hList := &hyperv1.HostedClusterList{}
+	err := c.List(ctx, hList, &client.ListOptions{})
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return hostedClusters, nil
+		}
+		log.Error(err, "Failed to list the hosted clusters")
+		return nil, err
+	}
+	for _, cluster := range hList.Items {
+		if cluster.Spec.ClusterID == "" {
+			log.Info("The hosted cluster has no cluster id yet", "name", cluster.Name)
+			continue
+		}
+		info := hostedClusterInfo{name: cluster.Name, id: cluster.Spec.ClusterID}
+		for _, name := range strings.Split(cluster.Annotations[hostedClusterMetricsAnnotation], ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				info.metrics = append(info.metrics, name)
+			}
+		}
+		hostedClusters = append(hostedClusters, info)
+	}
+	return hostedClusters, nil
+}
+
+// getHostedClusterAllowlist restricts the platform allowlist to the metrics of the hosted cluster, the
+// collect rules and the recording rules are evaluated on the metrics of the management cluster only.
+func getHostedClusterAllowlist(list operatorconfig.MetricsAllowlist,
+	hostedCluster hostedClusterInfo) operatorconfig.MetricsAllowlist {
+	selected := func(name string) bool {
+		return len(hostedCluster.metrics) == 0 || contains(hostedCluster.metrics, name)
+	}
+	idMatcher := fmt.Sprintf("%s=\"%s\"", hypershiftIDLabel, hostedCluster.id)
+	hostedList := operatorconfig.MetricsAllowlist{
+		MatchList: []string{},
+		RenameMap: list.RenameMap,
+	}
+	for _, name := range list.NameList {
+		if selected(name) {
+			hostedList.MatchList = append(hostedList.MatchList, fmt.Sprintf("__name__=\"%s\",%s", name, idMatcher))
+		}
+	}
+	for _, match := range list.MatchList {
+		if selected(getNameInMatch(match)) {
+			hostedList.MatchList = append(hostedList.MatchList, match+","+idMatcher)
+		}
+	}
+	return hostedList
+}
+
+// updateHostedClusterCollectors deploys a metrics-collector per hosted cluster, the collectors of the
+// deleted hosted clusters are deleted. Each collector uploads with the client certificate issued by
+// the hub to its hosted cluster, it is deployed once the certificate is issued.
+func updateHostedClusterCollectors(ctx context.Context, c client.Client, params CollectorParams,
+	forceRestart bool) (bool, error) {
+	hostedClusters, err := listHostedClusters(ctx, c, params.obsAddonSpec)
+	if err != nil {
+		return false, err
+	}
+	names := []string{}
+	for i := range hostedClusters {
+		hostedCluster := hostedClusters[i]
+		hostedParams := params
+		hostedParams.isUWL = false
+		hostedParams.remoteWrite = false
+		hostedParams.tenantNamespaces = nil
+		hostedParams.source = nil
+		hostedParams.resources = nil
+		hostedParams.hostedCluster = &hostedCluster
+		hostedParams.allowlist = getHostedClusterAllowlist(params.allowlist, hostedCluster)
+		names = append(names, hostedCluster.name)
+		renewed := false
+		if params.replicaCount > 0 {
+			ready, issued, err := updateHostedClusterCertificate(ctx, c, params.hubClient,
+				params.hubInfo.ClusterName, hostedCluster.name)
+			if err != nil {
+				return false, err
+			}
+			if !ready {
+				log.Info("The client certificate of the hosted cluster is not issued yet", "name", hostedCluster.name)
+				continue
+			}
+			renewed = issued
+		}
+		result, err := updateMetricsCollector(ctx, c, hostedParams, forceRestart || renewed)
+		if err != nil || !result {
+			return result, err
+		}
+	}
+	return true, deleteHostedClusterCollectors(ctx, c, names)
+}
+
+// deleteHostedClusterCollectors deletes the metrics-collectors and the client certificates of the hosted
+// clusters not listed in keep.
+func deleteHostedClusterCollectors(ctx context.Context, c client.Client, keep []string) error {
+	err := deleteLabeledCollectors(ctx, c, hostedClusterLabelKey, keep)
+	if err != nil {
+		return err
+	}
+	return deleteHostedClusterCertificates(ctx, c, keep)
+}
+
+// removeHostedClusterConditions removes the status conditions of the hosted clusters which no longer
+// have a collector.
+func removeHostedClusterConditions(ctx context.Context, c client.Client, obsAddon *oav1beta1.ObservabilityAddon) {
+	hostedClusters, err := listHostedClusters(ctx, c, obsAddon.Spec)
+	if err != nil {
+		return
+	}
+	names := []string{}
+	for _, hostedCluster := range hostedClusters {
+		names = append(names, hostedCluster.name)
+	}
+	removeStaleConditions(ctx, c, obsAddon, operatorconfig.HostedClusterConditionPrefix, names)
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	hyperv1 "github.com/openshift/hypershift/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	oashared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+)
+
+func newHostedCluster(name, id string, annotations map[string]string) *hyperv1.HostedCluster {
+	return &hyperv1.HostedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   hosteClusterNamespace,
+			Annotations: annotations,
+		},
+		Spec: hyperv1.HostedClusterSpec{
+			ClusterID: id,
+		},
+	}
+}
+
+// newCertificate returns a self-signed certificate of the subject in PEM.
+func newCertificate(t *testing.T, subject pkix.Name) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the key: (%v)", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      subject,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("Failed to sign the certificate: (%v)", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// signHostedClusterCSRs issues the pending certificate signing requests of the hosted clusters as the hub does.
+func signHostedClusterCSRs(t *testing.T, hubClient client.Client) []certificatesv1.CertificateSigningRequest {
+	csrs := &certificatesv1.CertificateSigningRequestList{}
+	err := hubClient.List(context.TODO(), csrs)
+	if err != nil {
+		t.Fatalf("Failed to list the certificate signing requests: (%v)", err)
+	}
+	for i := range csrs.Items {
+		csr := &csrs.Items[i]
+		block, _ := pem.Decode(csr.Spec.Request)
+		request, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			t.Fatalf("Failed to parse the certificate request: (%v)", err)
+		}
+		csr.Status.Certificate = newCertificate(t, request.Subject)
+		err = hubClient.Update(context.TODO(), csr)
+		if err != nil {
+			t.Fatalf("Failed to update the certificate signing request: (%v)", err)
+		}
+	}
+	return csrs.Items
+}
+
+func TestGetHostedClusterAllowlist(t *testing.T) {
+	list := operatorconfig.MetricsAllowlist{
+		NameList:          []string{"a", "b"},
+		MatchList:         []string{`__name__="c",job="d"`},
+		RecordingRuleList: []operatorconfig.RecordingRule{{Record: "f", Expr: "g"}},
+	}
+	hostedList := getHostedClusterAllowlist(list, hostedClusterInfo{name: "hosted-a", id: "id-a"})
+	expected := []string{`__name__="a",_id="id-a"`, `__name__="b",_id="id-a"`, `__name__="c",job="d",_id="id-a"`}
+	if !reflect.DeepEqual(hostedList.MatchList, expected) {
+		t.Errorf("match list (%v) is not the expected: (%v)", hostedList.MatchList, expected)
+	}
+	if len(hostedList.NameList) != 0 || len(hostedList.RecordingRuleList) != 0 {
+		t.Errorf("only the matches of the hosted cluster should be listed: (%v)", hostedList)
+	}
+
+	hostedList = getHostedClusterAllowlist(list,
+		hostedClusterInfo{name: "hosted-a", id: "id-a", metrics: []string{"b", "c"}})
+	expected = []string{`__name__="b",_id="id-a"`, `__name__="c",job="d",_id="id-a"`}
+	if !reflect.DeepEqual(hostedList.MatchList, expected) {
+		t.Errorf("match list (%v) is not the expected: (%v)", hostedList.MatchList, expected)
+	}
+}
+
+func TestHostedClusterCollectors(t *testing.T) {
+	ctx := context.TODO()
+	params := CollectorParams{
+		clusterID: testClusterID,
+		obsAddonSpec: oashared.ObservabilityAddonSpec{
+			EnableMetrics:           true,
+			Interval:                60,
+			HostedClusterCollectors: true,
+		},
+		hubInfo: operatorconfig.HubInfo{
+			ClusterName:              "test-cluster",
+			ObservatoriumAPIEndpoint: "http://test-endpoint",
+		},
+		allowlist: operatorconfig.MetricsAllowlist{
+			NameList: []string{"a"},
+		},
+		replicaCount: 1,
+	}
+	// the hosted clusters are requested in the group of the tenant of the managed cluster
+	tenantGroup := operatorconfig.ManagedClusterOU + "-tenant-team-a"
+	c := fake.NewClientBuilder().WithRuntimeObjects(
+		newHostedCluster("hosted-a", "id-a", nil),
+		newHostedCluster("hosted-b", "id-b", nil),
+		newHostedCluster("hosted-c", "", nil),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: mtlsCertName, Namespace: namespace},
+			Data: map[string][]byte{corev1.TLSCertKey: newCertificate(t, pkix.Name{
+				CommonName:         "managed-cluster-observability",
+				OrganizationalUnit: []string{tenantGroup},
+			})},
+		},
+	).Build()
+	params.hubClient = fake.NewClientBuilder().Build()
+
+	_, err := updateHostedClusterCollectors(ctx, c, params, false)
+	if err != nil {
+		t.Fatalf("Failed to request the certificates of the hosted clusters: (%v)", err)
+	}
+	err = c.Get(ctx, types.NamespacedName{Name: getHostedClusterCollectorName("hosted-a"), Namespace: namespace},
+		&appsv1.Deployment{})
+	if err == nil || !errors.IsNotFound(err) {
+		t.Errorf("no collector should be deployed before the certificate of the hosted cluster is issued")
+	}
+	if requeue := getHostedClusterCertificateRequeue(ctx, c); requeue != hostedClusterCertPendingInterval {
+		t.Errorf("requeue (%v) is not the expected: (%v)", requeue, hostedClusterCertPendingInterval)
+	}
+	csrs := signHostedClusterCSRs(t, params.hubClient)
+	if len(csrs) != 2 {
+		t.Fatalf("a certificate should be requested per hosted cluster with a cluster id: (%v)", len(csrs))
+	}
+	for _, csr := range csrs {
+		block, _ := pem.Decode(csr.Spec.Request)
+		request, _ := x509.ParseCertificateRequest(block.Bytes)
+		expected := operatorconfig.GetHostedClusterUser("test-cluster", csr.Labels[hostedClusterLabelKey])
+		if request.Subject.CommonName != expected {
+			t.Errorf("common name (%v) is not the expected: (%v)", request.Subject.CommonName, expected)
+		}
+		if !reflect.DeepEqual(request.Subject.OrganizationalUnit, []string{tenantGroup}) {
+			t.Errorf("group (%v) is not the expected: (%v)", request.Subject.OrganizationalUnit, tenantGroup)
+		}
+		if csr.Labels[addonLabelKey] != addonName || csr.Labels[clusterLabelKey] != "test-cluster" {
+			t.Errorf("the addon manager of the hub should select the request: (%v)", csr.Labels)
+		}
+	}
+
+	_, err = updateHostedClusterCollectors(ctx, c, params, false)
+	if err != nil {
+		t.Fatalf("Failed to create the hosted cluster collectors: (%v)", err)
+	}
+	secret := &corev1.Secret{}
+	err = c.Get(ctx, types.NamespacedName{Name: getHostedClusterCertName("hosted-a"), Namespace: namespace}, secret)
+	if err != nil {
+		t.Fatalf("Failed to get the certificate of the hosted cluster: (%v)", err)
+	}
+	if len(secret.Data[corev1.TLSCertKey]) == 0 || len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+		t.Errorf("the certificate of the hosted cluster should be issued: (%v)", secret.Data)
+	}
+	if requeue := getHostedClusterCertificateRequeue(ctx, c); requeue != hostedClusterCertRenewalInterval {
+		t.Errorf("requeue (%v) is not the expected: (%v)", requeue, hostedClusterCertRenewalInterval)
+	}
+	deployment := &appsv1.Deployment{}
+	err = c.Get(ctx, types.NamespacedName{Name: getHostedClusterCollectorName("hosted-a"), Namespace: namespace},
+		deployment)
+	if err != nil {
+		t.Fatalf("Failed to get the hosted cluster collector: (%v)", err)
+	}
+	if deployment.Spec.Selector.MatchLabels[hostedClusterLabelKey] != "hosted-a" {
+		t.Errorf("the selector of the collector should include the hosted cluster: (%v)", deployment.Spec.Selector)
+	}
+	if deployment.Spec.Selector.MatchLabels[selectorKey] == selectorValue ||
+		deployment.Spec.Template.Labels[selectorKey] == selectorValue {
+		t.Errorf("the platform collector should not select the pods of the hosted cluster: (%v)",
+			deployment.Spec.Template.Labels)
+	}
+	if name := deployment.Spec.Template.Spec.Volumes[0].Secret.SecretName; name != getHostedClusterCertName("hosted-a") {
+		t.Errorf("the collector should upload with the certificate of the hosted cluster: (%v)", name)
+	}
+	command := deployment.Spec.Template.Spec.Containers[0].Command
+	for _, arg := range []string{
+		`--match={__name__="a",_id="id-a"}`,
+		"--status-condition-type=" + operatorconfig.HostedClusterConditionPrefix + "hosted-a",
+	} {
+		if !contains(command, arg) {
+			t.Errorf("the argument %s is missing in (%v)", arg, command)
+		}
+	}
+	err = c.Get(ctx, types.NamespacedName{Name: getHostedClusterCollectorName("hosted-c"), Namespace: namespace},
+		&appsv1.Deployment{})
+	if err == nil || !errors.IsNotFound(err) {
+		t.Errorf("no collector should be deployed for the hosted cluster without cluster id")
+	}
+
+	// the platform collector no longer forwards the metrics of the hosted clusters
+	if command := getCommands(params); !contains(command, `--match={__name__="a",_id=""}`) {
+		t.Errorf("the metrics of the hosted clusters should be excluded: (%v)", command)
+	}
+
+	err = c.Delete(ctx, newHostedCluster("hosted-b", "id-b", nil))
+	if err != nil {
+		t.Fatalf("Failed to delete the hosted cluster: (%v)", err)
+	}
+	_, err = updateHostedClusterCollectors(ctx, c, params, false)
+	if err != nil {
+		t.Fatalf("Failed to update the hosted cluster collectors: (%v)", err)
+	}
+	err = c.Get(ctx, types.NamespacedName{Name: getHostedClusterCollectorName("hosted-b"), Namespace: namespace},
+		&appsv1.Deployment{})
+	if err == nil || !errors.IsNotFound(err) {
+		t.Errorf("the collector of the deleted hosted cluster should be deleted")
+	}
+	err = c.Get(ctx, types.NamespacedName{Name: getHostedClusterCertName("hosted-b"), Namespace: namespace},
+		&corev1.Secret{})
+	if err == nil || !errors.IsNotFound(err) {
+		t.Errorf("the certificate of the deleted hosted cluster should be deleted")
+	}
+
+	params.obsAddonSpec.HostedClusterCollectors = false
+	_, err = updateHostedClusterCollectors(ctx, c, params, false)
+	if err != nil {
+		t.Fatalf("Failed to update the hosted cluster collectors: (%v)", err)
+	}
+	err = c.Get(ctx, types.NamespacedName{Name: getHostedClusterCollectorName("hosted-a"), Namespace: namespace},
+		&appsv1.Deployment{})
+	if err == nil || !errors.IsNotFound(err) {
+		t.Errorf("the hosted cluster collectors should be deleted once disabled")
+	}
+}
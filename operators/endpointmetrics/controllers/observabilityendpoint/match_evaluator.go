@@ -3,17 +3,55 @@
 package observabilityendpoint
 
 import (
+	"strconv"
+	"strings"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 	"github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
 )
 
+const (
+	// selectorOpGt and selectorOpLt compare versions and numbers, the same way as the
+	// node selector operators of kubernetes.
+	selectorOpGt metav1.LabelSelectorOperator = "Gt"
+	selectorOpLt metav1.LabelSelectorOperator = "Lt"
+
+	clusterSetLabel = "cluster.open-cluster-management.io/clusterset"
+)
+
+// clusterInfo contains the facts about the managed cluster used to evaluate the
+// collect rule selectors which are not known by the hub.
+type clusterInfo struct {
+	openshiftVersion   string
+	nodeCount          int
+	hostedControlPlane bool
+}
+
 type evaluateFn func(metav1.LabelSelectorRequirement, ...interface{}) bool
 
 var evaluateFns = map[string]evaluateFn{
-	"clusterType": evaluateClusterType,
+	"clusterType":        evaluateClusterType,
+	"clusterID":          evaluateClusterID,
+	"vendor":             evaluateClusterLabel("vendor"),
+	"cloud":              evaluateClusterLabel("cloud"),
+	"region":             evaluateClusterLabel("region"),
+	"clusterset":         evaluateClusterLabel(clusterSetLabel),
+	"openshiftVersion":   evaluateOpenShiftVersion,
+	"nodeCount":          evaluateNodeCount,
+	"hostedControlPlane": evaluateHostedControlPlane,
 }
 
+// the params of the evaluate functions are, in order: the cluster ID, the cluster type, the addon spec,
+// the hub info, the allowlist, the node selector, the tolerations, the replica count and the cluster info.
+const (
+	clusterIDParam   = 0
+	clusterTypeParam = 1
+	hubInfoParam     = 3
+	clusterInfoParam = 8
+)
+
 func evluateMatchExpression(expr metav1.LabelSelectorRequirement, params ...interface{}) bool {
 	if _, ok := evaluateFns[expr.Key]; !ok {
 		// return false if expr.key not defined
@@ -22,14 +60,169 @@ func evluateMatchExpression(expr metav1.LabelSelectorRequirement, params ...inte
 	return evaluateFns[expr.Key](expr, params...)
 }
 
+// evluateMatchExpressions returns true only if all the expressions of the selector match.
+func evluateMatchExpressions(exprs []metav1.LabelSelectorRequirement, params ...interface{}) bool {
+	for _, expr := range exprs {
+		if !evluateMatchExpression(expr, params...) {
+			return false
+		}
+	}
+	return true
+}
+
 func evaluateClusterType(expr metav1.LabelSelectorRequirement, params ...interface{}) bool {
+	clusterType, _ := getParam(params, clusterTypeParam).(string)
+	return evaluateValue(expr, clusterType, clusterType != "")
+}
+
+func evaluateClusterID(expr metav1.LabelSelectorRequirement, params ...interface{}) bool {
+	clusterID, _ := getParam(params, clusterIDParam).(string)
+	return evaluateValue(expr, clusterID, clusterID != "")
+}
+
+// evaluateClusterLabel evaluates the expression against a label of the managed cluster on the hub.
+func evaluateClusterLabel(label string) evaluateFn {
+	return func(expr metav1.LabelSelectorRequirement, params ...interface{}) bool {
+		hubInfo, ok := getParam(params, hubInfoParam).(operatorconfig.HubInfo)
+		if !ok {
+			return false
+		}
+		value, exists := hubInfo.ClusterLabels[label]
+		return evaluateValue(expr, value, exists)
+	}
+}
+
+// evaluateOpenShiftVersion supports version prefixes with In and NotIn, e.g. 4.12 matches 4.12.3,
+// and version ranges with Gt and Lt.
+func evaluateOpenShiftVersion(expr metav1.LabelSelectorRequirement, params ...interface{}) bool {
+	info, ok := getParam(params, clusterInfoParam).(clusterInfo)
+	if !ok {
+		return false
+	}
+	version := info.openshiftVersion
+	switch expr.Operator {
+	case metav1.LabelSelectorOpIn, metav1.LabelSelectorOpNotIn:
+		matched := false
+		for _, v := range expr.Values {
+			if version != "" && (version == v || strings.HasPrefix(version, v+".")) {
+				matched = true
+				break
+			}
+		}
+		return matched == (expr.Operator == metav1.LabelSelectorOpIn)
+	case selectorOpGt, selectorOpLt:
+		if version == "" || len(expr.Values) != 1 {
+			return false
+		}
+		cmp, ok := compareVersions(version, expr.Values[0])
+		if !ok {
+			return false
+		}
+		if expr.Operator == selectorOpGt {
+			return cmp > 0
+		}
+		return cmp < 0
+	default:
+		return evaluateValue(expr, version, version != "")
+	}
+}
+
+func evaluateNodeCount(expr metav1.LabelSelectorRequirement, params ...interface{}) bool {
+	info, ok := getParam(params, clusterInfoParam).(clusterInfo)
+	if !ok || info.nodeCount == 0 {
+		return false
+	}
+	switch expr.Operator {
+	case selectorOpGt, selectorOpLt:
+		if len(expr.Values) != 1 {
+			return false
+		}
+		n, err := strconv.Atoi(expr.Values[0])
+		if err != nil {
+			return false
+		}
+		if expr.Operator == selectorOpGt {
+			return info.nodeCount > n
+		}
+		return info.nodeCount < n
+	default:
+		return evaluateValue(expr, strconv.Itoa(info.nodeCount), true)
+	}
+}
+
+func evaluateHostedControlPlane(expr metav1.LabelSelectorRequirement, params ...interface{}) bool {
+	info, ok := getParam(params, clusterInfoParam).(clusterInfo)
+	if !ok {
+		return false
+	}
+	return evaluateValue(expr, strconv.FormatBool(info.hostedControlPlane), true)
+}
+
+// evaluateValue evaluates the In, NotIn, Exists and DoesNotExist operators against a value.
+func evaluateValue(expr metav1.LabelSelectorRequirement, value string, exists bool) bool {
 	switch expr.Operator {
 	case metav1.LabelSelectorOpIn:
-		return util.Contains(expr.Values, params[1].(string))
+		return exists && util.Contains(expr.Values, value)
 	case metav1.LabelSelectorOpNotIn:
-		return !util.Contains(expr.Values, params[1].(string))
+		return !exists || !util.Contains(expr.Values, value)
+	case metav1.LabelSelectorOpExists:
+		return exists
+	case metav1.LabelSelectorOpDoesNotExist:
+		return !exists
 	default:
 		// return false for unsupported/invalid operator
 		return false
 	}
 }
+
+func getParam(params []interface{}, i int) interface{} {
+	if i >= len(params) {
+		return nil
+	}
+	return params[i]
+}
+
+// compareVersions compares the numeric dot-separated components of two versions, any pre-release
+// or build suffix is ignored. The second return value is false if a version cannot be parsed.
+func compareVersions(a, b string) (int, bool) {
+	parse := func(v string) ([]int, bool) {
+		v = strings.TrimPrefix(v, "v")
+		if i := strings.IndexAny(v, "-+"); i >= 0 {
+			v = v[:i]
+		}
+		parts := strings.Split(v, ".")
+		nums := make([]int, len(parts))
+		for i, p := range parts {
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return nil, false
+			}
+			nums[i] = n
+		}
+		return nums, true
+	}
+	va, ok := parse(a)
+	if !ok {
+		return 0, false
+	}
+	vb, ok := parse(b)
+	if !ok {
+		return 0, false
+	}
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if x != y {
+			if x > y {
+				return 1, true
+			}
+			return -1, true
+		}
+	}
+	return 0, true
+}
@@ -5,7 +5,11 @@ package observabilityendpoint
 import (
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	oashared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 )
 
 func TestEvluateMatchExpression(t *testing.T) {
@@ -85,3 +89,109 @@ func TestEvluateMatchExpression(t *testing.T) {
 		})
 	}
 }
+
+func TestEvluateMatchExpressions(t *testing.T) {
+	hubInfo := operatorconfig.HubInfo{
+		ClusterName: "test-cluster",
+		ClusterLabels: map[string]string{
+			"vendor":        "OpenShift",
+			"cloud":         "Amazon",
+			"region":        "us-east-1",
+			clusterSetLabel: "prod",
+		},
+	}
+	info := clusterInfo{openshiftVersion: "4.12.3", nodeCount: 6, hostedControlPlane: true}
+	params := []interface{}{"test-id", "", oashared.ObservabilityAddonSpec{}, hubInfo,
+		operatorconfig.MetricsAllowlist{}, map[string]string{}, []corev1.Toleration{}, int32(1), info}
+
+	expr := func(key string, op metav1.LabelSelectorOperator, values ...string) metav1.LabelSelectorRequirement {
+		return metav1.LabelSelectorRequirement{Key: key, Operator: op, Values: values}
+	}
+	caseList := []struct {
+		name           string
+		exprs          []metav1.LabelSelectorRequirement
+		expectedResult bool
+	}{
+		{
+			name: "select by cluster labels",
+			exprs: []metav1.LabelSelectorRequirement{
+				expr("vendor", "In", "OpenShift"),
+				expr("cloud", "In", "Amazon", "Azure"),
+				expr("region", "NotIn", "eu-west-1"),
+			},
+			expectedResult: true,
+		},
+		{
+			name:           "all expressions must match",
+			exprs:          []metav1.LabelSelectorRequirement{expr("vendor", "In", "OpenShift"), expr("cloud", "In", "Azure")},
+			expectedResult: false,
+		},
+		{
+			name:           "select by clusterset",
+			exprs:          []metav1.LabelSelectorRequirement{expr("clusterset", "In", "prod")},
+			expectedResult: true,
+		},
+		{
+			name:           "label exists",
+			exprs:          []metav1.LabelSelectorRequirement{expr("region", "Exists")},
+			expectedResult: true,
+		},
+		{
+			name:           "cluster type does not exist",
+			exprs:          []metav1.LabelSelectorRequirement{expr("clusterType", "DoesNotExist")},
+			expectedResult: true,
+		},
+		{
+			name: "select by openshift version range",
+			exprs: []metav1.LabelSelectorRequirement{
+				expr("openshiftVersion", "Gt", "4.11"),
+				expr("openshiftVersion", "Lt", "4.13"),
+			},
+			expectedResult: true,
+		},
+		{
+			name:           "select by openshift version prefix",
+			exprs:          []metav1.LabelSelectorRequirement{expr("openshiftVersion", "In", "4.12")},
+			expectedResult: true,
+		},
+		{
+			name:           "filter by openshift version prefix",
+			exprs:          []metav1.LabelSelectorRequirement{expr("openshiftVersion", "In", "4.1")},
+			expectedResult: false,
+		},
+		{
+			name:           "select by cluster id",
+			exprs:          []metav1.LabelSelectorRequirement{expr("clusterID", "In", "test-id")},
+			expectedResult: true,
+		},
+		{
+			name:           "select by node count",
+			exprs:          []metav1.LabelSelectorRequirement{expr("nodeCount", "Gt", "3")},
+			expectedResult: true,
+		},
+		{
+			name:           "filter by node count",
+			exprs:          []metav1.LabelSelectorRequirement{expr("nodeCount", "Lt", "3")},
+			expectedResult: false,
+		},
+		{
+			name:           "select hosted control plane",
+			exprs:          []metav1.LabelSelectorRequirement{expr("hostedControlPlane", "In", "true")},
+			expectedResult: true,
+		},
+		{
+			name:           "invalid range value",
+			exprs:          []metav1.LabelSelectorRequirement{expr("nodeCount", "Gt", "three")},
+			expectedResult: false,
+		},
+	}
+
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			r := evluateMatchExpressions(c.exprs, params...)
+			if r != c.expectedResult {
+				t.Fatalf("Wrong result for test %s, expected %v, got %v", c.name, c.expectedResult, r)
+			}
+		})
+	}
+}
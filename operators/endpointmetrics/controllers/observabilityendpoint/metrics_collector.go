@@ -62,6 +62,91 @@ type CollectorParams struct {
 	nodeSelector map[string]string
 	tolerations  []corev1.Toleration
 	replicaCount int32
+	// namespaces with their own allowlist, subject to the namespace series quota
+	tenantNamespaces []string
+	clusterInfo      clusterInfo
+	// the allowlisted metrics are remote written by Prometheus, only collect rules and
+	// recording rules are handled by the collector
+	remoteWrite bool
+	// the url of the existing Prometheus reused on *KS, empty if the bundled one is deployed
+	prometheusURL string
+	// the additional source the metrics are collected from, nil for the platform and uwl collectors
+	source *oashared.MetricsSource
+	// the hosted cluster the metrics are collected for, nil for the collectors of the management cluster
+	hostedCluster *hostedClusterInfo
+	// the resources sized for the collector, the resources of the addon spec are used if nil
+	resources *corev1.ResourceRequirements
+	// the client of the hub, the client certificates of the hosted clusters are requested with it
+	hubClient client.Client
+}
+
+// getCollectorName returns the name of the metrics-collector deployment.
+func getCollectorName(params CollectorParams) string {
+	if params.source != nil {
+		return getMetricsSourceCollectorName(params.source.Name)
+	}
+	if params.hostedCluster != nil {
+		return getHostedClusterCollectorName(params.hostedCluster.name)
+	}
+	if params.isUWL {
+		return uwlMetricsCollectorName
+	}
+	return metricsCollectorName
+}
+
+// getCollectRuleGroups returns the collect rule groups whose selector matches the cluster.
+func getCollectRuleGroups(params CollectorParams) []operatorconfig.CollectRuleGroup {
+	clusterID := params.clusterID
+	if clusterID == "" {
+		clusterID = params.hubInfo.ClusterName
+	}
+	groups := []operatorconfig.CollectRuleGroup{}
+	for _, group := range params.allowlist.CollectRuleGroupList {
+		if group.Selector.MatchExpression == nil {
+			continue
+		}
+		if evluateMatchExpressions(group.Selector.MatchExpression, clusterID, params.clusterType,
+			params.obsAddonSpec, params.hubInfo, params.allowlist, params.nodeSelector, params.tolerations,
+			params.replicaCount, params.clusterInfo) {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// getFederatedMetrics returns the allowlisted names and matches which are always forwarded,
+// the metrics of the matching collect rules are only forwarded while the rules are firing.
+func getFederatedMetrics(params CollectorParams) ([]string, []string) {
+	dynamicMetricList := map[string]bool{}
+	for _, group := range getCollectRuleGroups(params) {
+		for _, rule := range group.CollectRuleList {
+			for _, match := range rule.Metrics.MatchList {
+				if name := getNameInMatch(match); name != "" {
+					dynamicMetricList[name] = false
+				}
+			}
+			for _, name := range rule.Metrics.NameList {
+				dynamicMetricList[name] = false
+			}
+		}
+	}
+
+	names := []string{}
+	for _, name := range params.allowlist.NameList {
+		if _, ok := dynamicMetricList[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	matches := []string{}
+	for _, match := range params.allowlist.MatchList {
+		if name := getNameInMatch(match); name != "" {
+			if _, ok := dynamicMetricList[name]; ok {
+				continue
+			}
+		}
+		matches = append(matches, match)
+	}
+	return names, matches
 }
 
 func getCommands(params CollectorParams) []string {
@@ -94,57 +179,53 @@ func getCommands(params CollectorParams) []string {
 		fmt.Sprintf("--label=\"cluster=%s\"", params.hubInfo.ClusterName),
 		fmt.Sprintf("--label=\"clusterID=%s\"", clusterID),
 	}
-	commands = append(commands, "--from-token-file=/var/run/secrets/kubernetes.io/serviceaccount/token")
-	if !installPrometheus {
-		commands = append(commands, "--from-ca-file="+caFile)
+	if params.source != nil {
+		// the service account token and the cluster CA are never sent to the additional sources
+		commands = append(commands, getMetricsSourceCommands(params.source)...)
+	} else {
+		commands = append(commands, "--from-token-file=/var/run/secrets/kubernetes.io/serviceaccount/token")
+		if !installPrometheus {
+			commands = append(commands, "--from-ca-file="+caFile)
+		}
+	}
+	if params.hostedCluster != nil {
+		commands = append(commands,
+			"--status-condition-type="+getHostedClusterConditionType(params.hostedCluster.name))
 	}
 	if params.clusterType != "" {
 		commands = append(commands, fmt.Sprintf("--label=\"clusterType=%s\"", params.clusterType))
 	}
 
-	dynamicMetricList := map[string]bool{}
-	for _, group := range params.allowlist.CollectRuleGroupList {
-		if group.Selector.MatchExpression != nil {
-			for _, expr := range group.Selector.MatchExpression {
-				if !evluateMatchExpression(expr, clusterID, params.clusterType, params.obsAddonSpec, params.hubInfo,
-					params.allowlist, params.nodeSelector, params.tolerations, params.replicaCount) {
-					continue
-				}
-				for _, rule := range group.CollectRuleList {
-					matchList := []string{}
-					for _, match := range rule.Metrics.MatchList {
-						matchList = append(matchList, `"`+strings.ReplaceAll(match, `"`, `\"`)+`"`)
-						if name := getNameInMatch(match); name != "" {
-							dynamicMetricList[name] = false
-						}
-					}
-					for _, name := range rule.Metrics.NameList {
-						dynamicMetricList[name] = false
-					}
-					matchListStr := "[" + strings.Join(matchList, ",") + "]"
-					nameListStr := `["` + strings.Join(rule.Metrics.NameList, `","`) + `"]`
-					commands = append(
-						commands,
-						fmt.Sprintf("--collectrule={\"name\":\"%s\",\"expr\":\"%s\",\"for\":\"%s\",\"names\":%v,\"matches\":%v}",
-							rule.Collect, rule.Expr, rule.For, nameListStr, matchListStr),
-					)
-				}
+	for _, group := range getCollectRuleGroups(params) {
+		for _, rule := range group.CollectRuleList {
+			matchList := []string{}
+			for _, match := range rule.Metrics.MatchList {
+				matchList = append(matchList, `"`+strings.ReplaceAll(match, `"`, `\"`)+`"`)
 			}
+			matchListStr := "[" + strings.Join(matchList, ",") + "]"
+			nameListStr := `["` + strings.Join(rule.Metrics.NameList, `","`) + `"]`
+			commands = append(
+				commands,
+				fmt.Sprintf("--collectrule={\"name\":\"%s\",\"expr\":\"%s\",\"for\":\"%s\",\"names\":%v,\"matches\":%v}",
+					rule.Collect, rule.Expr, rule.For, nameListStr, matchListStr),
+			)
 		}
 	}
 
-	for _, metrics := range params.allowlist.NameList {
-		if _, ok := dynamicMetricList[metrics]; !ok {
-			commands = append(commands, fmt.Sprintf("--match={__name__=\"%s\"}", metrics))
+	// in remote write mode, Prometheus forwards the allowlisted metrics itself
+	if !params.remoteWrite {
+		names, matches := getFederatedMetrics(params)
+		// the metrics of the hosted clusters are forwarded by their own collectors
+		idMatcher := ""
+		if excludeHostedClusters(params) {
+			idMatcher = fmt.Sprintf(",%s=\"\"", hypershiftIDLabel)
 		}
-	}
-	for _, match := range params.allowlist.MatchList {
-		if name := getNameInMatch(match); name != "" {
-			if _, ok := dynamicMetricList[name]; ok {
-				continue
-			}
+		for _, name := range names {
+			commands = append(commands, fmt.Sprintf("--match={__name__=\"%s\"%s}", name, idMatcher))
+		}
+		for _, match := range matches {
+			commands = append(commands, fmt.Sprintf("--match={%s%s}", match, idMatcher))
 		}
-		commands = append(commands, fmt.Sprintf("--match={%s}", match))
 	}
 
 	renamekeys := make([]string, 0, len(params.allowlist.RenameMap))
@@ -161,6 +242,18 @@ func getCommands(params CollectorParams) []string {
 			fmt.Sprintf("--recordingrule={\"name\":\"%s\",\"query\":\"%s\"}", rule.Record, rule.Expr),
 		)
 	}
+	if !params.remoteWrite {
+		for _, name := range getExemplarMetrics(params.allowlist) {
+			commands = append(commands, fmt.Sprintf("--exemplar-match={__name__=\"%s\"}", name))
+		}
+	}
+	if params.isUWL && params.allowlist.NamespaceSeriesQuota > 0 && len(params.tenantNamespaces) > 0 {
+		commands = append(commands,
+			fmt.Sprintf("--namespace-series-limit=%d", params.allowlist.NamespaceSeriesQuota))
+		for _, ns := range params.tenantNamespaces {
+			commands = append(commands, "--limit-namespace="+ns)
+		}
+	}
 	return commands
 }
 
@@ -170,7 +263,7 @@ func createDeployment(params CollectorParams) *appsv1.Deployment {
 			Name: "mtlscerts",
 			VolumeSource: corev1.VolumeSource{
 				Secret: &corev1.SecretVolumeSource{
-					SecretName: mtlsCertName,
+					SecretName: getClientCertName(params),
 				},
 			},
 		},
@@ -193,7 +286,7 @@ func createDeployment(params CollectorParams) *appsv1.Deployment {
 			MountPath: "/tlscerts/ca",
 		},
 	}
-	if params.clusterID != "" {
+	if params.clusterID != "" && params.source == nil {
 		volumes = append(volumes, corev1.Volume{
 			Name: caVolName,
 			VolumeSource: corev1.VolumeSource{
@@ -210,9 +303,18 @@ func createDeployment(params CollectorParams) *appsv1.Deployment {
 		})
 	}
 
+	if params.source != nil {
+		sourceVolumes, sourceMounts := getMetricsSourceVolumes(params.source)
+		volumes = append(volumes, sourceVolumes...)
+		mounts = append(mounts, sourceMounts...)
+	}
+
 	commands := getCommands(params)
 
 	from := promURL
+	if params.prometheusURL != "" {
+		from = params.prometheusURL
+	}
 	if !installPrometheus {
 		from = ocpPromURL
 		if params.isUWL {
@@ -223,14 +325,32 @@ func createDeployment(params CollectorParams) *appsv1.Deployment {
 	if params.isUWL {
 		fromQuery = uwlQueryURL
 	}
-	name := metricsCollectorName
-	if params.isUWL {
-		name = uwlMetricsCollectorName
+	var labels map[string]string
+	selectorLabels := map[string]string{
+		selectorKey: selectorValue,
+	}
+	podLabels := map[string]string{
+		selectorKey: selectorValue,
+	}
+	if params.source != nil {
+		from = params.source.URL
+		fromQuery = params.source.URL
+		labels = map[string]string{metricsSourceLabelKey: params.source.Name}
+		// the pods of a source are not selected by the deployments of the platform collectors
+		selectorLabels = map[string]string{metricsSourceLabelKey: params.source.Name}
+		podLabels = map[string]string{metricsSourceLabelKey: params.source.Name}
+	}
+	if params.hostedCluster != nil {
+		labels = map[string]string{hostedClusterLabelKey: params.hostedCluster.name}
+		// the pods of a hosted cluster are not selected by the deployments of the platform collectors
+		selectorLabels = map[string]string{hostedClusterLabelKey: params.hostedCluster.name}
+		podLabels = map[string]string{hostedClusterLabelKey: params.hostedCluster.name}
 	}
 	metricsCollectorDep := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
+			Name:      getCollectorName(params),
 			Namespace: namespace,
+			Labels:    labels,
 			Annotations: map[string]string{
 				ownerLabelKey: ownerLabelValue,
 			},
@@ -238,15 +358,11 @@ func createDeployment(params CollectorParams) *appsv1.Deployment {
 		Spec: appsv1.DeploymentSpec{
 			Replicas: int32Ptr(params.replicaCount),
 			Selector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					selectorKey: selectorValue,
-				},
+				MatchLabels: selectorLabels,
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						selectorKey: selectorValue,
-					},
+					Labels: podLabels,
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: serviceAccountName,
@@ -280,43 +396,87 @@ func createDeployment(params CollectorParams) *appsv1.Deployment {
 			},
 		},
 	}
-	if params.obsAddonSpec.Resources != nil {
+	if params.resources != nil {
+		metricsCollectorDep.Spec.Template.Spec.Containers[0].Resources = *params.resources
+	} else if params.obsAddonSpec.Resources != nil {
 		metricsCollectorDep.Spec.Template.Spec.Containers[0].Resources = *params.obsAddonSpec.Resources
 	}
 	return metricsCollectorDep
 }
 
-func updateMetricsCollectors(ctx context.Context, c client.Client, obsAddonSpec oashared.ObservabilityAddonSpec,
-	hubInfo operatorconfig.HubInfo, clusterID string, clusterType string,
-	replicaCount int32, forceRestart bool) (bool, error) {
+func updateMetricsCollectors(ctx context.Context, c client.Client, hubClient client.Client,
+	obsAddonSpec oashared.ObservabilityAddonSpec, hubInfo operatorconfig.HubInfo, clusterID string, clusterType string,
+	replicaCount int32, resources collectorResources, forceRestart bool) (bool, error) {
 
 	list, uwlList, err := getMetricsAllowlist(ctx, c, clusterType)
 	if err != nil {
 		return false, err
 	}
+	prometheusURL := ""
+	if installPrometheus {
+		existingProm, err := findExistingPrometheus(ctx, c)
+		if err != nil {
+			return false, err
+		}
+		if existingProm != nil {
+			prometheusURL = existingProm.url
+		}
+	}
 	endpointDeployment := getEndpointDeployment(ctx, c)
 	params := CollectorParams{
-		isUWL:        false,
-		clusterID:    clusterID,
-		clusterType:  clusterType,
-		obsAddonSpec: obsAddonSpec,
-		hubInfo:      hubInfo,
-		allowlist:    list,
-		replicaCount: replicaCount,
-		nodeSelector: endpointDeployment.Spec.Template.Spec.NodeSelector,
-		tolerations:  endpointDeployment.Spec.Template.Spec.Tolerations,
-	}
-	result, err := updateMetricsCollector(ctx, c, params, forceRestart)
+		isUWL:         false,
+		clusterID:     clusterID,
+		clusterType:   clusterType,
+		obsAddonSpec:  obsAddonSpec,
+		hubInfo:       hubInfo,
+		allowlist:     list,
+		replicaCount:  replicaCount,
+		nodeSelector:  endpointDeployment.Spec.Template.Spec.NodeSelector,
+		tolerations:   endpointDeployment.Spec.Template.Spec.Tolerations,
+		clusterInfo:   getClusterInfo(ctx, c, hubInfo),
+		remoteWrite:   isRemoteWriteMode(obsAddonSpec),
+		prometheusURL: prometheusURL,
+		hubClient:     hubClient,
+	}
+	result, err := updateMetricsSourceCollectors(ctx, c, params, forceRestart)
+	if err != nil || !result {
+		return result, err
+	}
+	result, err = updateHostedClusterCollectors(ctx, c, params, forceRestart)
 	if err != nil || !result {
 		return result, err
 	}
+	if params.remoteWrite && len(getCollectRuleGroups(params)) == 0 && len(list.RecordingRuleList) == 0 {
+		// nothing left for the platform collector, Prometheus remote writes the allowlisted metrics
+		err = deleteMetricsCollector(ctx, c, metricsCollectorName)
+		if err != nil {
+			return false, err
+		}
+	} else {
+		platformParams := params
+		platformParams.resources = resources.platform
+		result, err = updateMetricsCollector(ctx, c, platformParams, forceRestart)
+		if err != nil || !result {
+			return result, err
+		}
+	}
 	isUwl, err := isUWLMonitoringEnabled(ctx, c)
 	if err != nil {
 		return result, err
 	}
-	if isUwl && len(uwlList.NameList) != 0 {
+	tenantList, tenantNamespaces := getTenantAllowlists(ctx, c, uwlList.NamespaceSeriesQuota)
+	for _, match := range tenantList.MatchList {
+		if !contains(uwlList.MatchList, match) {
+			uwlList.MatchList = append(uwlList.MatchList, match)
+		}
+	}
+	if isUwl && (len(uwlList.NameList) != 0 || len(uwlList.MatchList) != 0) {
+		// the user workload metrics are always federated by the collector
 		params.isUWL = true
+		params.remoteWrite = false
 		params.allowlist = uwlList
+		params.tenantNamespaces = tenantNamespaces
+		params.resources = resources.uwl
 		result, err = updateMetricsCollector(ctx, c, params, forceRestart)
 	}
 	return result, err
@@ -324,10 +484,7 @@ func updateMetricsCollectors(ctx context.Context, c client.Client, obsAddonSpec
 
 func updateMetricsCollector(ctx context.Context, c client.Client, params CollectorParams,
 	forceRestart bool) (bool, error) {
-	name := metricsCollectorName
-	if params.isUWL {
-		name = uwlMetricsCollectorName
-	}
+	name := getCollectorName(params)
 	log.Info("updateMetricsCollector", "name", name)
 	deployment := createDeployment(params)
 	found := &appsv1.Deployment{}
@@ -427,6 +584,7 @@ func getMetricsAllowlist(ctx context.Context, c client.Client,
 			if err != nil {
 				log.Error(err, "Failed to parse data in configmap", "namespace", allowlistCM.ObjectMeta.Namespace,
 					"name", allowlistCM.ObjectMeta.Name)
+				continue
 			}
 			if allowlistCM.ObjectMeta.Namespace != namespace {
 				customUwlAllowlist = injectNamespaceLabel(customUwlAllowlist, allowlistCM.ObjectMeta.Namespace)
@@ -456,6 +614,27 @@ func getNameInMatch(match string) string {
 	return ""
 }
 
+// getExemplarMetrics returns the metrics listed for exemplar forwarding which are also
+// allowlisted, exemplars of metrics which are not federated are never forwarded.
+func getExemplarMetrics(allowlist operatorconfig.MetricsAllowlist) []string {
+	allowed := map[string]bool{}
+	for _, name := range allowlist.NameList {
+		allowed[name] = true
+	}
+	for _, match := range allowlist.MatchList {
+		if name := getNameInMatch(match); name != "" {
+			allowed[name] = true
+		}
+	}
+	names := []string{}
+	for _, name := range allowlist.ExemplarList {
+		if allowed[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 func isUWLMonitoringEnabled(ctx context.Context, c client.Client) (bool, error) {
 	sts := &appsv1.StatefulSet{}
 	err := c.Get(ctx, types.NamespacedName{Namespace: uwlNamespace, Name: uwlSts}, sts)
@@ -4,6 +4,8 @@ package observabilityendpoint
 
 import (
 	"context"
+	"reflect"
+	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
@@ -149,3 +151,64 @@ func TestMetricsCollector(t *testing.T) {
 		t.Fatalf("Failed to delete uwl metrics collector deployment: (%v)", err)
 	}
 }
+
+func TestGetExemplarMetrics(t *testing.T) {
+	allowlist := operatorconfig.MetricsAllowlist{
+		NameList:     []string{"a"},
+		MatchList:    []string{`__name__="b",job="test"`},
+		ExemplarList: []string{"a", "b", "c"},
+	}
+	names := getExemplarMetrics(allowlist)
+	if !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Errorf("exemplar metrics (%v) are not the expected: ([a b])", names)
+	}
+
+	params := CollectorParams{
+		clusterID: testClusterID,
+		hubInfo:   operatorconfig.HubInfo{ClusterName: "test-cluster"},
+		allowlist: allowlist,
+	}
+	found := false
+	for _, cmd := range getCommands(params) {
+		if cmd == `--exemplar-match={__name__="c"}` {
+			t.Errorf("exemplars of metric c which is not allowlisted should not be forwarded")
+		}
+		if cmd == `--exemplar-match={__name__="a"}` {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("exemplars of allowlisted metric a should be forwarded")
+	}
+}
+
+func TestGetCommandsRemoteWrite(t *testing.T) {
+	params := CollectorParams{
+		clusterID: testClusterID,
+		hubInfo:   operatorconfig.HubInfo{ClusterName: "test-cluster"},
+		allowlist: operatorconfig.MetricsAllowlist{
+			NameList:          []string{"a"},
+			ExemplarList:      []string{"a"},
+			RecordingRuleList: []operatorconfig.RecordingRule{{Record: "r", Expr: "sum(a)"}},
+		},
+	}
+	for _, remoteWrite := range []bool{false, true} {
+		params.remoteWrite = remoteWrite
+		matched := map[string]bool{}
+		for _, command := range getCommands(params) {
+			for _, flag := range []string{"--match=", "--exemplar-match=", "--recordingrule="} {
+				if strings.HasPrefix(command, flag) {
+					matched[flag] = true
+				}
+			}
+		}
+		expected := map[string]bool{"--recordingrule=": true}
+		if !remoteWrite {
+			expected["--match="] = true
+			expected["--exemplar-match="] = true
+		}
+		if !reflect.DeepEqual(matched, expected) {
+			t.Errorf("commands flags (%v) is not the expected: (%v) with remote write %v", matched, expected, remoteWrite)
+		}
+	}
+}
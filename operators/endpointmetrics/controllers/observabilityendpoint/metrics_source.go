@@ -0,0 +1,185 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/prometheus/promql/parser"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stolostron/multicluster-observability-operator/operators/endpointmetrics/pkg/util"
+	oashared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	oav1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+)
+
+const (
+	// metricsSourceLabelKey labels the metrics-collector deployments of the additional sources
+	metricsSourceLabelKey = "observability.open-cluster-management.io/metrics-source"
+	sourceCAVolName       = "metrics-source-ca"
+	sourceCAMountPath     = "/etc/metrics-source/ca"
+	sourceTokenVolName    = "metrics-source-token"
+	sourceTokenMountPath  = "/etc/metrics-source/token"
+)
+
+func getMetricsSourceCollectorName(name string) string {
+	return "source-" + name + "-" + metricsCollectorName
+}
+
+func getMetricsSourceConditionType(name string) string {
+	return operatorconfig.MetricsSourceConditionPrefix + name
+}
+
+// getMetricsSourceAllowlist converts the match rules of the source into an allowlist,
+// the invalid rules are ignored.
+func getMetricsSourceAllowlist(source oashared.MetricsSource) operatorconfig.MetricsAllowlist {
+	list := operatorconfig.MetricsAllowlist{MatchList: []string{}}
+	for _, match := range source.Matches {
+		match = strings.TrimSpace(match)
+		if _, err := parser.ParseMetricSelector(match); err != nil {
+			log.Error(err, "Invalid match of the metrics source, it is ignored", "source", source.Name, "match", match)
+			continue
+		}
+		match = strings.TrimSuffix(strings.TrimPrefix(match, "{"), "}")
+		if match != "" {
+			list.MatchList = append(list.MatchList, match)
+		}
+	}
+	return list
+}
+
+// getMetricsSourceCommands returns the arguments specific to a metrics source collector.
+func getMetricsSourceCommands(source *oashared.MetricsSource) []string {
+	commands := []string{}
+	if source.SourceType != "" {
+		commands = append(commands, "--source-type="+string(source.SourceType))
+	}
+	if source.TokenSecret != nil {
+		commands = append(commands, "--from-token-file="+sourceTokenMountPath+"/"+source.TokenSecret.Key)
+	}
+	if source.CAConfigMap != nil {
+		commands = append(commands, "--from-ca-file="+sourceCAMountPath+"/"+source.CAConfigMap.Key)
+	}
+	keys := make([]string, 0, len(source.Labels))
+	for k := range source.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		commands = append(commands, fmt.Sprintf("--label=\"%s=%s\"", k, source.Labels[k]))
+	}
+	return append(commands, "--status-condition-type="+getMetricsSourceConditionType(source.Name))
+}
+
+// getMetricsSourceVolumes returns the volumes of the CA bundle and the token of the source.
+func getMetricsSourceVolumes(source *oashared.MetricsSource) ([]corev1.Volume, []corev1.VolumeMount) {
+	volumes := []corev1.Volume{}
+	mounts := []corev1.VolumeMount{}
+	if source.CAConfigMap != nil {
+		volumes = append(volumes, corev1.Volume{
+			Name: sourceCAVolName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: source.CAConfigMap.LocalObjectReference,
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      sourceCAVolName,
+			MountPath: sourceCAMountPath,
+		})
+	}
+	if source.TokenSecret != nil {
+		volumes = append(volumes, corev1.Volume{
+			Name: sourceTokenVolName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: source.TokenSecret.Name,
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      sourceTokenVolName,
+			MountPath: sourceTokenMountPath,
+		})
+	}
+	return volumes, mounts
+}
+
+// updateMetricsSourceCollectors deploys a metrics-collector per additional source of the addon,
+// the collectors of the removed sources are deleted.
+func updateMetricsSourceCollectors(ctx context.Context, c client.Client, params CollectorParams,
+	forceRestart bool) (bool, error) {
+	names := []string{}
+	for i := range params.obsAddonSpec.AdditionalSources {
+		source := params.obsAddonSpec.AdditionalSources[i]
+		sourceParams := params
+		sourceParams.isUWL = false
+		sourceParams.remoteWrite = false
+		sourceParams.tenantNamespaces = nil
+		sourceParams.prometheusURL = ""
+		sourceParams.source = &source
+		sourceParams.allowlist = getMetricsSourceAllowlist(source)
+		result, err := updateMetricsCollector(ctx, c, sourceParams, forceRestart)
+		if err != nil || !result {
+			return result, err
+		}
+		names = append(names, source.Name)
+	}
+	return true, deleteMetricsSourceCollectors(ctx, c, names)
+}
+
+// deleteMetricsSourceCollectors deletes the metrics-collectors of the sources not listed in keep.
+func deleteMetricsSourceCollectors(ctx context.Context, c client.Client, keep []string) error {
+	return deleteLabeledCollectors(ctx, c, metricsSourceLabelKey, keep)
+}
+
+// deleteLabeledCollectors deletes the metrics-collectors having the label key, except those whose
+// label value is listed in keep.
+func deleteLabeledCollectors(ctx context.Context, c client.Client, labelKey string, keep []string) error {
+	deployments := &appsv1.DeploymentList{}
+	err := c.List(ctx, deployments, client.InNamespace(namespace), client.HasLabels{labelKey})
+	if err != nil {
+		log.Error(err, "Failed to list the metrics collectors", "label", labelKey)
+		return err
+	}
+	for _, deployment := range deployments.Items {
+		if contains(keep, deployment.Labels[labelKey]) {
+			continue
+		}
+		err = deleteMetricsCollector(ctx, c, deployment.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeMetricsSourceConditions removes the status conditions of the sources which are no longer listed.
+func removeMetricsSourceConditions(ctx context.Context, c client.Client, obsAddon *oav1beta1.ObservabilityAddon) {
+	names := []string{}
+	for _, source := range obsAddon.Spec.AdditionalSources {
+		names = append(names, source.Name)
+	}
+	removeStaleConditions(ctx, c, obsAddon, operatorconfig.MetricsSourceConditionPrefix, names)
+}
+
+// removeStaleConditions removes the status conditions with the type prefix, except those whose type
+// is followed by a name listed in keep.
+func removeStaleConditions(ctx context.Context, c client.Client, obsAddon *oav1beta1.ObservabilityAddon,
+	prefix string, keep []string) {
+	for _, condition := range obsAddon.Status.Conditions {
+		if !strings.HasPrefix(condition.Type, prefix) {
+			continue
+		}
+		if !contains(keep, strings.TrimPrefix(condition.Type, prefix)) {
+			util.RemoveStatusCondition(ctx, c, obsAddon, condition.Type)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	oashared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+)
+
+func newMetricsSource(name string) oashared.MetricsSource {
+	return oashared.MetricsSource{
+		Name:       name,
+		URL:        "https://" + name + ".istio-system.svc:9090",
+		SourceType: oashared.MetricsSourceRemoteRead,
+		CAConfigMap: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name + "-ca"},
+			Key:                  "ca.crt",
+		},
+		TokenSecret: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name + "-token"},
+			Key:                  "token",
+		},
+		Matches: []string{`{__name__="istio_requests_total"}`, `{__name__=`},
+		Labels:  map[string]string{"source": name},
+	}
+}
+
+func TestGetMetricsSourceAllowlist(t *testing.T) {
+	list := getMetricsSourceAllowlist(newMetricsSource("istio"))
+	expected := []string{`__name__="istio_requests_total"`}
+	if !reflect.DeepEqual(list.MatchList, expected) {
+		t.Errorf("match list (%v) is not the expected: (%v)", list.MatchList, expected)
+	}
+}
+
+func TestMetricsSourceCollectors(t *testing.T) {
+	ctx := context.TODO()
+	params := CollectorParams{
+		clusterID: testClusterID,
+		obsAddonSpec: oashared.ObservabilityAddonSpec{
+			EnableMetrics:     true,
+			Interval:          60,
+			AdditionalSources: []oashared.MetricsSource{newMetricsSource("istio"), newMetricsSource("app")},
+		},
+		hubInfo: operatorconfig.HubInfo{
+			ClusterName:              "test-cluster",
+			ObservatoriumAPIEndpoint: "http://test-endpoint",
+		},
+		replicaCount: 1,
+	}
+	c := fake.NewClientBuilder().Build()
+
+	_, err := updateMetricsSourceCollectors(ctx, c, params, false)
+	if err != nil {
+		t.Fatalf("Failed to create the metrics source collectors: (%v)", err)
+	}
+	deployment := &appsv1.Deployment{}
+	err = c.Get(ctx, types.NamespacedName{Name: getMetricsSourceCollectorName("istio"), Namespace: namespace},
+		deployment)
+	if err != nil {
+		t.Fatalf("Failed to get the metrics source collector: (%v)", err)
+	}
+	// the platform collector does not select the pods of the source
+	selector := deployment.Spec.Selector.MatchLabels
+	if selector[selectorKey] == selectorValue || selector[metricsSourceLabelKey] != "istio" ||
+		deployment.Spec.Template.Labels[selectorKey] == selectorValue {
+		t.Errorf("selector (%v) is not the expected: (%s=istio)", selector, metricsSourceLabelKey)
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if container.Env[0].Value != "https://istio.istio-system.svc:9090" {
+		t.Errorf("the collector should federate from the source, not (%s)", container.Env[0].Value)
+	}
+	for _, arg := range []string{
+		"--source-type=remote-read",
+		"--from-token-file=" + sourceTokenMountPath + "/token",
+		"--from-ca-file=" + sourceCAMountPath + "/ca.crt",
+		`--label="source=istio"`,
+		`--match={__name__="istio_requests_total"}`,
+		"--status-condition-type=" + operatorconfig.MetricsSourceConditionPrefix + "istio",
+	} {
+		if !contains(container.Command, arg) {
+			t.Errorf("the argument %s is missing in (%v)", arg, container.Command)
+		}
+	}
+	if contains(container.Command, "--from-token-file=/var/run/secrets/kubernetes.io/serviceaccount/token") {
+		t.Errorf("the service account token should not be sent to the source")
+	}
+	if len(deployment.Spec.Template.Spec.Volumes) != 4 {
+		t.Errorf("volumes (%v) should include the CA and the token of the source",
+			deployment.Spec.Template.Spec.Volumes)
+	}
+
+	params.obsAddonSpec.AdditionalSources = params.obsAddonSpec.AdditionalSources[:1]
+	_, err = updateMetricsSourceCollectors(ctx, c, params, false)
+	if err != nil {
+		t.Fatalf("Failed to update the metrics source collectors: (%v)", err)
+	}
+	err = c.Get(ctx, types.NamespacedName{Name: getMetricsSourceCollectorName("app"), Namespace: namespace},
+		&appsv1.Deployment{})
+	if err == nil || !errors.IsNotFound(err) {
+		t.Errorf("the collector of the removed source should be deleted")
+	}
+
+	err = deleteMetricsSourceCollectors(ctx, c, nil)
+	if err != nil {
+		t.Fatalf("Failed to delete the metrics source collectors: (%v)", err)
+	}
+	err = c.Get(ctx, types.NamespacedName{Name: getMetricsSourceCollectorName("istio"), Namespace: namespace},
+		&appsv1.Deployment{})
+	if err == nil || !errors.IsNotFound(err) {
+		t.Errorf("the collector of the source should be deleted")
+	}
+}
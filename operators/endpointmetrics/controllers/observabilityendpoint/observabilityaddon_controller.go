@@ -8,6 +8,8 @@ import (
 	"os"
 	"strconv"
 
+	cmomanifests "github.com/openshift/cluster-monitoring-operator/pkg/manifests"
+	hyperv1 "github.com/openshift/hypershift/api/v1alpha1"
 	"gopkg.in/yaml.v2"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -121,22 +123,10 @@ func (r *ObservabilityAddonReconciler) Reconcile(ctx context.Context, req ctrl.R
 	}
 
 	// retrieve the hubInfo
-	hubSecret := &corev1.Secret{}
-	err = r.Client.Get(
-		ctx,
-		types.NamespacedName{Name: operatorconfig.HubInfoSecretName, Namespace: namespace},
-		hubSecret,
-	)
-	if err != nil {
-		return ctrl.Result{}, err
-	}
-	hubInfo := &operatorconfig.HubInfo{}
-	err = yaml.Unmarshal(hubSecret.Data[operatorconfig.HubInfoSecretKey], &hubInfo)
+	hubInfo, err := getHubInfo(ctx, r.Client)
 	if err != nil {
-		log.Error(err, "Failed to unmarshal hub info")
 		return ctrl.Result{}, err
 	}
-	hubInfo.ClusterName = string(hubSecret.Data[operatorconfig.ClusterNameKey])
 
 	clusterType := ""
 	clusterID := ""
@@ -193,44 +183,72 @@ func (r *ObservabilityAddonReconciler) Reconcile(ctx context.Context, req ctrl.R
 			return ctrl.Result{}, err
 		}
 	} else {
-		//Render the prometheus templates
-		renderer := rendererutil.NewRenderer()
-		toDeploy, err := rendering.Render(renderer, r.Client, hubInfo)
+		existingProm, err := findExistingPrometheus(ctx, r.Client)
 		if err != nil {
-			log.Error(err, "Failed to render prometheus templates")
 			return ctrl.Result{}, err
 		}
-		deployer := deploying.NewDeployer(r.Client)
-		for _, res := range toDeploy {
-			if err := controllerutil.SetControllerReference(obsAddon, res, r.Scheme); err != nil {
-				log.Info("Failed to set controller reference", "resource", res.GetName())
-				globalRes = append(globalRes, res)
+		if existingProm != nil {
+			// reuse the existing Prometheus instead of deploying the bundled one
+			log.Info("Existing prometheus found", "url", existingProm.url)
+			if err := deleteBundledPrometheus(ctx, r.Client, hubInfo); err != nil {
+				return ctrl.Result{}, err
 			}
-			if err := deployer.Deploy(res); err != nil {
-				log.Error(err, fmt.Sprintf("Failed to deploy %s %s/%s",
-					res.GetKind(), namespace, res.GetName()))
+		} else {
+			//Render the prometheus templates
+			renderer := rendererutil.NewRenderer()
+			toDeploy, err := rendering.Render(renderer, r.Client, hubInfo, obsAddon.Spec)
+			if err != nil {
+				log.Error(err, "Failed to render prometheus templates")
 				return ctrl.Result{}, err
 			}
+			deployer := deploying.NewDeployer(r.Client)
+			for _, res := range toDeploy {
+				if err := controllerutil.SetControllerReference(obsAddon, res, r.Scheme); err != nil {
+					log.Info("Failed to set controller reference", "resource", res.GetName())
+					globalRes = append(globalRes, res)
+				}
+				if err := deployer.Deploy(res); err != nil {
+					log.Error(err, fmt.Sprintf("Failed to deploy %s %s/%s",
+						res.GetKind(), namespace, res.GetName()))
+					return ctrl.Result{}, err
+				}
+			}
+		}
+	}
+
+	var remoteWrite *cmomanifests.RemoteWriteSpec
+	if obsAddon.Spec.EnableMetrics && isRemoteWriteMode(obsAddon.Spec) {
+		remoteWrite, err = getRemoteWriteSpec(ctx, r.Client, obsAddon.Spec, *hubInfo, clusterID, clusterType)
+		if err != nil {
+			return ctrl.Result{}, err
 		}
 	}
 
 	// create or update the cluster-monitoring-config configmap and relevant resources
-	if err := createOrUpdateClusterMonitoringConfig(ctx, hubInfo, clusterID, r.Client, installPrometheus); err != nil {
+	conflicts, err := createOrUpdateClusterMonitoringConfig(ctx, hubInfo, clusterID, r.Client,
+		installPrometheus, remoteWrite)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
+	reportClusterMonitoringConfigConflicts(ctx, r.Client, obsAddon, conflicts)
+	removeMetricsSourceConditions(ctx, r.Client, obsAddon)
+	removeHostedClusterConditions(ctx, r.Client, obsAddon)
 
 	if obsAddon.Spec.EnableMetrics {
 		forceRestart := false
 		if req.Name == mtlsCertName || req.Name == mtlsCaName || req.Name == caConfigmapName {
 			forceRestart = true
 		}
+		resources := updateResourceRecommendations(ctx, r.Client, obsAddon)
 		created, err := updateMetricsCollectors(
 			ctx,
 			r.Client,
+			r.HubClient,
 			obsAddon.Spec,
 			*hubInfo, clusterID,
 			clusterType,
 			1,
+			resources,
 			forceRestart)
 
 		if err != nil {
@@ -241,7 +259,8 @@ func (r *ObservabilityAddonReconciler) Reconcile(ctx context.Context, req ctrl.R
 			util.ReportStatus(ctx, r.Client, obsAddon, "Deployed")
 		}
 	} else {
-		deleted, err := updateMetricsCollectors(ctx, r.Client, obsAddon.Spec, *hubInfo, clusterID, clusterType, 0, false)
+		deleted, err := updateMetricsCollectors(ctx, r.Client, r.HubClient, obsAddon.Spec, *hubInfo, clusterID,
+			clusterType, 0, collectorResources{}, false)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
@@ -250,6 +269,17 @@ func (r *ObservabilityAddonReconciler) Reconcile(ctx context.Context, req ctrl.R
 		}
 	}
 
+	// the certificate signing requests of the hosted clusters are signed on the hub
+	requeueAfter := getHostedClusterCertificateRequeue(ctx, r.Client)
+	if obsAddon.Spec.EnableMetrics && isResourceAutoSizingEnabled(obsAddon.Spec) &&
+		(requeueAfter == 0 || resourceSizingInterval < requeueAfter) {
+		// the telemetry of the collectors does not trigger a reconcile
+		requeueAfter = resourceSizingInterval
+	}
+	if requeueAfter != 0 {
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+
 	//TODO: UPDATE
 	return ctrl.Result{}, nil
 }
@@ -267,6 +297,14 @@ func (r *ObservabilityAddonReconciler) initFinalization(
 		if err != nil {
 			return false, err
 		}
+		err = deleteMetricsSourceCollectors(ctx, r.Client, nil)
+		if err != nil {
+			return false, err
+		}
+		err = deleteHostedClusterCollectors(ctx, r.Client, nil)
+		if err != nil {
+			return false, err
+		}
 		// revert the change to cluster monitoring stack
 		err = revertClusterMonitoringConfig(ctx, r.Client, installPrometheus)
 		if err != nil {
@@ -327,7 +365,18 @@ func (r *ObservabilityAddonReconciler) SetupWithManager(mgr ctrl.Manager) error
 	if os.Getenv("NAMESPACE") != "" {
 		namespace = os.Getenv("NAMESPACE")
 	}
-	return ctrl.NewControllerManagedBy(mgr).
+	isHypershift := false
+	if os.Getenv("UNIT_TEST") != "true" {
+		crdClient, err := operatorutil.GetOrCreateCRDClient()
+		if err != nil {
+			return err
+		}
+		isHypershift, err = operatorutil.CheckCRDExist(crdClient, "hostedclusters.hypershift.openshift.io")
+		if err != nil {
+			return err
+		}
+	}
+	ctrlBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(
 			&oav1beta1.ObservabilityAddon{},
 			builder.WithPredicates(getPred(obAddonName, namespace, true, true, true)),
@@ -362,11 +411,21 @@ func (r *ObservabilityAddonReconciler) SetupWithManager(mgr ctrl.Manager) error
 			&handler.EnqueueRequestForObject{},
 			builder.WithPredicates(getPred(operatorconfig.AllowlistCustomConfigMapName, "", true, true, true)),
 		).
+		Watches(
+			&source.Kind{Type: &corev1.ConfigMap{}},
+			&handler.EnqueueRequestForObject{},
+			builder.WithPredicates(getNamespaceAllowlistPred()),
+		).
 		Watches(
 			&source.Kind{Type: &corev1.ConfigMap{}},
 			&handler.EnqueueRequestForObject{},
 			builder.WithPredicates(getPred(caConfigmapName, namespace, false, true, true)),
 		).
+		Watches(
+			&source.Kind{Type: &corev1.ConfigMap{}},
+			&handler.EnqueueRequestForObject{},
+			builder.WithPredicates(getPred(existingPrometheusConfigMapName, namespace, true, true, true)),
+		).
 		Watches(
 			&source.Kind{Type: &appsv1.Deployment{}},
 			&handler.EnqueueRequestForObject{},
@@ -381,8 +440,43 @@ func (r *ObservabilityAddonReconciler) SetupWithManager(mgr ctrl.Manager) error
 			&source.Kind{Type: &corev1.ConfigMap{}},
 			&handler.EnqueueRequestForObject{},
 			builder.WithPredicates(getPred(operatorconfig.ImageConfigMap, namespace, true, true, false)),
-		).
-		Complete(r)
+		)
+	if isHypershift {
+		// the service monitors and the collectors of the hosted clusters follow the hosted clusters
+		ctrlBuilder = ctrlBuilder.Watches(
+			&source.Kind{Type: &hyperv1.HostedCluster{}},
+			&handler.EnqueueRequestForObject{},
+			builder.WithPredicates(getHostedClusterPred()),
+		)
+	}
+	return ctrlBuilder.Complete(r)
+}
+
+// getHubInfo reads the hub info and the name and labels of the managed cluster from the hub info secret.
+func getHubInfo(ctx context.Context, c client.Client) (*operatorconfig.HubInfo, error) {
+	hubSecret := &corev1.Secret{}
+	err := c.Get(
+		ctx,
+		types.NamespacedName{Name: operatorconfig.HubInfoSecretName, Namespace: namespace},
+		hubSecret,
+	)
+	if err != nil {
+		return nil, err
+	}
+	hubInfo := &operatorconfig.HubInfo{}
+	err = yaml.Unmarshal(hubSecret.Data[operatorconfig.HubInfoSecretKey], &hubInfo)
+	if err != nil {
+		log.Error(err, "Failed to unmarshal hub info")
+		return nil, err
+	}
+	hubInfo.ClusterName = string(hubSecret.Data[operatorconfig.ClusterNameKey])
+	if clusterLabels, ok := hubSecret.Data[operatorconfig.ClusterLabelsKey]; ok {
+		err = yaml.Unmarshal(clusterLabels, &hubInfo.ClusterLabels)
+		if err != nil {
+			log.Error(err, "Failed to unmarshal the managed cluster labels")
+		}
+	}
+	return hubInfo, nil
 }
 
 func contains(list []string, s string) bool {
@@ -3,21 +3,28 @@
 package observabilityendpoint
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/ghodss/yaml"
 	cmomanifests "github.com/openshift/cluster-monitoring-operator/pkg/manifests"
+	"github.com/stolostron/multicluster-observability-operator/operators/endpointmetrics/pkg/util"
+	oav1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
 	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	operatorutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
 )
 
 const (
@@ -29,112 +36,11 @@ const (
 	clusterMonitoringRevertedName  = "cluster-monitoring-reverted"
 	clusterMonitoringConfigDataKey = "config.yaml"
 	clusterLabelKeyForAlerts       = "cluster"
-)
 
-var (
-	clusterMonitoringConfigReverted = false
-	persistedRevertStateRead        = false
+	clusterMonitoringConfigOwnershipAnnotation = "observability.open-cluster-management.io/owned-fields"
+	clusterMonitoringConfigConflictCondition   = "ClusterMonitoringConfigConflict"
 )
 
-// initializes clusterMonitoringConfigReverted based on the presence of clusterMonitoringRevertedName
-// configmap in openshift-monitoring namespace
-func initPersistedRevertState(ctx context.Context, client client.Client) error {
-	if !persistedRevertStateRead {
-		// check if reverted configmap is present
-		found := &corev1.ConfigMap{}
-		err := client.Get(ctx, types.NamespacedName{Name: clusterMonitoringRevertedName,
-			Namespace: namespace}, found)
-		if err != nil {
-			// treat this as non-fatal error
-			if errors.IsNotFound(err) {
-				// configmap does not exist. Not reverted before
-				clusterMonitoringConfigReverted = false
-				persistedRevertStateRead = true
-			} else {
-				// treat the condition as transient error for retry
-				log.Error(err, "failed to lookup cluster-monitoring-reverted configmap")
-				return err
-			}
-		} else {
-			// marker configmap present
-			persistedRevertStateRead = true
-			clusterMonitoringConfigReverted = true
-		}
-	}
-
-	return nil
-}
-
-func isRevertedAlready(ctx context.Context, client client.Client) (bool, error) {
-	log.Info("in isRevertedAlready")
-	err := initPersistedRevertState(ctx, client)
-	if err != nil {
-		log.Info("isRevertedAlready: error from initPersistedRevertState", "error:", err.Error())
-		return clusterMonitoringConfigReverted, err
-	} else {
-		log.Info("isRevertedAlready", "clusterMonitoringConfigReverted:", clusterMonitoringConfigReverted)
-		return clusterMonitoringConfigReverted, nil
-	}
-}
-
-func setConfigReverted(ctx context.Context, client client.Client) error {
-	err := initPersistedRevertState(ctx, client)
-	if err != nil {
-		return err
-	}
-
-	// if not created already, set persistent state by creating configmap
-	c := &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      clusterMonitoringRevertedName,
-			Namespace: namespace,
-		},
-	}
-	err = client.Create(ctx, c)
-	log.Info("cluster-monitoring-reverted comfigmap created")
-	if err != nil {
-		if errors.IsAlreadyExists(err) {
-			log.Info("persistent state already set. configmap cluster-monitoring-reverted already exists")
-		} else {
-			log.Error(err, "failed to set persistent state. could not cluster-monitoring-reverted configmap")
-			return err
-		}
-	}
-	clusterMonitoringConfigReverted = true
-	return nil
-}
-
-func unsetConfigReverted(ctx context.Context, client client.Client) error {
-	err := initPersistedRevertState(ctx, client)
-	if err != nil {
-		return err
-	}
-
-	// delete any persistent state if present
-	c := &corev1.ConfigMap{}
-	err = client.Get(ctx, types.NamespacedName{Name: clusterMonitoringRevertedName,
-		Namespace: namespace}, c)
-	if err != nil {
-		if errors.IsNotFound(err) {
-			log.Info("persistent state already set. cluster-monitoring-reverted configmap does not exist")
-		} else {
-			log.Error(err, "failed to set persistent state. error looking up cluster-monitoring-reverted configmap")
-			return nil
-		}
-	} else {
-		// delete configmap
-		err = client.Delete(ctx, c)
-		log.Info("cluster-monitoring-reverted configmap deleted")
-		if err != nil {
-			log.Error(err, "failed to delete persistent state. error deleting cluster-monitoring-reverted configmap")
-			return nil
-		}
-	}
-
-	clusterMonitoringConfigReverted = false
-	return nil
-}
-
 // createHubAmRouterCASecret creates the secret that contains CA of the Hub's Alertmanager Route
 func createHubAmRouterCASecret(
 	ctx context.Context,
@@ -320,13 +226,19 @@ func newAdditionalAlertmanagerConfig(hubInfo *operatorconfig.HubInfo) cmomanifes
 
 // createOrUpdateClusterMonitoringConfig creates or updates the configmap
 // cluster-monitoring-config and relevant resources (observability-alertmanager-accessor
-// and hub-alertmanager-router-ca) for the openshift cluster monitoring stack
+// and hub-alertmanager-router-ca) for the openshift cluster monitoring stack.
+// remoteWrite is the remote write of the metrics to hub server, nil if the metrics are
+// forwarded by the metrics-collector.
+// The fields injected by the addon are merged into the configuration of the user, it returns
+// the conflicts with the fields set by the user, which are kept.
+// For *KS, the alerting is configured on the Prometheus resource instead.
 func createOrUpdateClusterMonitoringConfig(
 	ctx context.Context,
 	hubInfo *operatorconfig.HubInfo,
 	clusterID string,
 	client client.Client,
-	installProm bool) error {
+	installProm bool,
+	remoteWrite *cmomanifests.RemoteWriteSpec) ([]string, error) {
 	targetNamespace := promNamespace
 	if installProm {
 		// for *KS, the hub CA and alertmanager access token should be created
@@ -334,343 +246,437 @@ func createOrUpdateClusterMonitoringConfig(
 		targetNamespace = namespace
 	}
 
-	// create or update the cluster-monitoring-config configmap and relevant resources
-	if hubInfo.AlertmanagerEndpoint == "" {
+	// create or update the relevant resources of the cluster-monitoring-config configmap
+	alertForwarding := hubInfo.AlertmanagerEndpoint != ""
+	if alertForwarding {
+		// create the hub-alertmanager-router-ca secret if it doesn't exist or update it if needed
+		if err := createHubAmRouterCASecret(ctx, hubInfo, client, targetNamespace); err != nil {
+			log.Error(err, "failed to create or update the hub-alertmanager-router-ca secret")
+			return nil, err
+		}
+
+		// create the observability-alertmanager-accessor secret if it doesn't exist or update it if needed
+		if err := createHubAmAccessorTokenSecret(ctx, client, targetNamespace); err != nil {
+			log.Error(err, "failed to create or update the observability-alertmanager-accessor secret")
+			return nil, err
+		}
+	} else {
 		log.Info("request to disable alert forwarding")
-		// only revert (once) if not done already and remember state
-		revertedAlready, err := isRevertedAlready(ctx, client)
-		if err != nil {
-			return err
+		if err := deleteHubAmRouterCASecret(ctx, client, targetNamespace); err != nil {
+			log.Error(err, "failed to delete the hub-alertmanager-router-ca secret")
+			return nil, err
 		}
-		if !revertedAlready {
-			if err = revertClusterMonitoringConfig(ctx, client, installProm); err != nil {
-				return err
-			}
-			if err = setConfigReverted(ctx, client); err != nil {
-				return err
-			}
-		} else {
-			log.Info("configuration reverted, nothing to do")
+		if err := deleteHubAmAccessorTokenSecret(ctx, client, targetNamespace); err != nil {
+			log.Error(err, "failed to delete the observability-alertmanager-accessor secret")
+			return nil, err
 		}
-		return nil
 	}
 
-	// create the hub-alertmanager-router-ca secret if it doesn't exist or update it if needed
-	if err := createHubAmRouterCASecret(ctx, hubInfo, client, targetNamespace); err != nil {
-		log.Error(err, "failed to create or update the hub-alertmanager-router-ca secret")
-		return err
+	if installProm {
+		// no configmap cluster-monitoring-config for *KS, the alerting is set on the Prometheus resource
+		return nil, createOrUpdatePrometheusAlerting(ctx, client, hubInfo)
 	}
 
-	// create the observability-alertmanager-accessor secret if it doesn't exist or update it if needed
-	if err := createHubAmAccessorTokenSecret(ctx, client, targetNamespace); err != nil {
-		log.Error(err, "failed to create or update the observability-alertmanager-accessor secret")
-		return err
+	// the prometheus k8s config refers to the client certificate and the hub CA in its own namespace
+	if remoteWrite != nil {
+		if err := createOrUpdateRemoteWriteSecrets(ctx, client); err != nil {
+			return nil, err
+		}
+	} else if err := deleteRemoteWriteSecrets(ctx, client); err != nil {
+		return nil, err
 	}
 
-	if installProm {
-		// no need to create configmap cluster-monitoring-config for *KS
-		return unset(ctx, client)
+	desired := clusterMonitoringConfigFields{remoteWrite: remoteWrite}
+	if alertForwarding {
+		// add cluster label and alertmanager config for alerts from managed cluster
+		desired.externalLabels = map[string]string{operatorconfig.ClusterLabelKeyForAlerts: clusterID}
+		alertmanagerConfig := newAdditionalAlertmanagerConfig(hubInfo)
+		desired.alertmanagerConfig = &alertmanagerConfig
 	}
+	return applyClusterMonitoringConfig(ctx, client, desired)
+}
 
-	// init the prometheus k8s config
-	newExternalLabels := map[string]string{operatorconfig.ClusterLabelKeyForAlerts: clusterID}
-	newAlertmanagerConfigs := []cmomanifests.AdditionalAlertmanagerConfig{newAdditionalAlertmanagerConfig(hubInfo)}
-	newPmK8sConfig := &cmomanifests.PrometheusK8sConfig{
-		// add cluster label for alerts from managed cluster
-		ExternalLabels: newExternalLabels,
-		// add alertmanager configs
-		AlertmanagerConfigs: newAlertmanagerConfigs,
-	}
+// reportClusterMonitoringConfigConflicts reports the conflicts with the cluster-monitoring-config of
+// the user as a condition of the observabilityaddon, the condition is removed once they are solved.
+func reportClusterMonitoringConfigConflicts(ctx context.Context, c client.Client,
+	obsAddon *oav1beta1.ObservabilityAddon, conflicts []string) {
+	if len(conflicts) == 0 {
+		util.RemoveStatusCondition(ctx, c, obsAddon, clusterMonitoringConfigConflictCondition)
+		return
+	}
+	util.SetStatusCondition(ctx, c, obsAddon, oav1beta1.StatusCondition{
+		Type:    clusterMonitoringConfigConflictCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  "FieldsSetByUser",
+		Message: "The fields set by the user are kept: " + strings.Join(conflicts, "; "),
+	})
+}
 
-	// root for CMO configuration
-	newClusterMonitoringConfiguration := cmomanifests.ClusterMonitoringConfiguration{
-		PrometheusK8sConfig: newPmK8sConfig,
+// revertClusterMonitoringConfig reverts the configmap cluster-monitoring-config and relevant resources
+// (observability-alertmanager-accessor and hub-alertmanager-router-ca) for the openshift cluster monitoring stack.
+// Only the fields injected by the addon are removed.
+func revertClusterMonitoringConfig(ctx context.Context, client client.Client, installProm bool) error {
+	log.Info("revertClusterMonitoringConfig called")
+	targetNamespace := promNamespace
+	if installProm {
+		// for *KS, the hub CA and alertmanager access token are not created in namespace:
+		// open-cluster-management-addon-observability
+		targetNamespace = namespace
 	}
 
-	// marshal new CMO configuration to json then to yaml
-	newClusterMonitoringConfigurationJSONBytes, err := json.Marshal(newClusterMonitoringConfiguration)
-	if err != nil {
-		log.Error(err, "failed to marshal the cluster monitoring config")
+	// delete the hub-alertmanager-router-ca secret
+	if err := deleteHubAmRouterCASecret(ctx, client, targetNamespace); err != nil {
+		log.Error(err, "failed to delete the hub-alertmanager-router-ca secret")
 		return err
 	}
-	newClusterMonitoringConfigurationYAMLBytes, err := yaml.JSONToYAML(newClusterMonitoringConfigurationJSONBytes)
-	if err != nil {
-		log.Error(err, "failed to transform JSON to YAML", "JSON", newClusterMonitoringConfigurationJSONBytes)
+
+	// delete the observability-alertmanager-accessor secret
+	if err := deleteHubAmAccessorTokenSecret(ctx, client, targetNamespace); err != nil {
+		log.Error(err, "failed to delete the observability-alertmanager-accessor secret")
 		return err
 	}
 
-	newCusterMonitoringConfigMap := &corev1.ConfigMap{
+	// delete the marker configmap which was used by the previous versions to revert only once
+	marker := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      clusterMonitoringConfigName,
-			Namespace: promNamespace,
+			Name:      clusterMonitoringRevertedName,
+			Namespace: namespace,
 		},
-		Data: map[string]string{clusterMonitoringConfigDataKey: string(newClusterMonitoringConfigurationYAMLBytes)},
+	}
+	if err := client.Delete(ctx, marker); err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "failed to delete configmap", "name", clusterMonitoringRevertedName)
+		return err
+	}
+
+	if installProm {
+		// revert the change to the existing prometheus
+		return revertExistingPrometheus(ctx, client)
 	}
 
-	// try to retrieve the current configmap in the cluster
+	// delete the copies of the client certificate and the hub CA used to remote write
+	if err := deleteRemoteWriteSecrets(ctx, client); err != nil {
+		return err
+	}
+
+	_, err := applyClusterMonitoringConfig(ctx, client, clusterMonitoringConfigFields{})
+	return err
+}
+
+// clusterMonitoringConfigFields are the fields of cluster-monitoring-config injected by the addon.
+type clusterMonitoringConfigFields struct {
+	externalLabels     map[string]string
+	alertmanagerConfig *cmomanifests.AdditionalAlertmanagerConfig
+	remoteWrite        *cmomanifests.RemoteWriteSpec
+}
+
+// clusterMonitoringConfigOwnership records the fields of cluster-monitoring-config injected by the addon
+// in the clusterMonitoringConfigOwnershipAnnotation annotation, so that only them are updated or removed.
+type clusterMonitoringConfigOwnership struct {
+	// ExternalLabels are the external labels set by the addon with their value.
+	ExternalLabels map[string]string `json:"externalLabels,omitempty"`
+	// Alertmanagers are the static configs of the additional alertmanager config of hub server.
+	Alertmanagers []string `json:"alertmanagers,omitempty"`
+	// RemoteWrites are the names of the remote writes to hub server.
+	RemoteWrites []string `json:"remoteWrites,omitempty"`
+}
+
+// applyClusterMonitoringConfig merges the desired fields into the configmap cluster-monitoring-config,
+// the configmap is deleted if nothing is left after the fields owned by the addon are removed.
+func applyClusterMonitoringConfig(ctx context.Context, client client.Client,
+	desired clusterMonitoringConfigFields) ([]string, error) {
 	found := &corev1.ConfigMap{}
-	err = client.Get(ctx, types.NamespacedName{Name: clusterMonitoringConfigName,
+	err := client.Get(ctx, types.NamespacedName{Name: clusterMonitoringConfigName,
 		Namespace: promNamespace}, found)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			log.Info("configmap not found, try to create it", "name", clusterMonitoringConfigName)
-			err = client.Create(ctx, newCusterMonitoringConfigMap)
-			if err != nil {
-				log.Error(err, "failed to create configmap", "name", clusterMonitoringConfigName)
-				return err
-			}
-			log.Info("configmap created", "name", clusterMonitoringConfigName)
-			return unset(ctx, client)
-		} else {
+		if !errors.IsNotFound(err) {
 			log.Error(err, "failed to check configmap", "name", clusterMonitoringConfigName)
-			return err
+			return nil, err
+		}
+		found = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      clusterMonitoringConfigName,
+				Namespace: promNamespace,
+			},
 		}
 	}
 
-	log.Info("configmap already exists, check if it needs update", "name", clusterMonitoringConfigName)
-	foundClusterMonitoringConfigurationYAMLString, ok := found.Data[clusterMonitoringConfigDataKey]
-	if !ok {
-		log.Info(
-			"configmap data doesn't contain key, try to update it",
-			"name",
-			clusterMonitoringConfigName,
-			"key",
-			clusterMonitoringConfigDataKey,
-		)
-		// replace config.yaml in configmap
-		found.Data[clusterMonitoringConfigDataKey] = string(newClusterMonitoringConfigurationYAMLBytes)
-		err = client.Update(ctx, found)
-		if err != nil {
-			log.Error(err, "failed to update configmap", "name", clusterMonitoringConfigName)
-			return err
+	config := map[string]interface{}{}
+	if data, ok := found.Data[clusterMonitoringConfigDataKey]; ok {
+		if config, err = decodeClusterMonitoringConfig(data); err != nil {
+			log.Error(err, "failed to decode the cluster monitoring config", "YAML", data)
+			return nil, err
 		}
-		log.Info("configmap updated", "name", clusterMonitoringConfigName)
-		return unset(ctx, client)
 	}
+	original := runtime.DeepCopyJSON(config)
 
-	log.Info("configmap already exists and key config.yaml exists, check if the value needs update",
-		"name", clusterMonitoringConfigName,
-		"key", clusterMonitoringConfigDataKey)
-	foundClusterMonitoringConfigurationJSONBytes, err := yaml.YAMLToJSON(
-		[]byte(foundClusterMonitoringConfigurationYAMLString),
-	)
+	owned := clusterMonitoringConfigOwnership{}
+	if data, ok := found.Annotations[clusterMonitoringConfigOwnershipAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), &owned); err != nil {
+			// the annotation was edited, the fields are adopted again by the merge
+			log.Error(err, "failed to unmarshal the ownership annotation, ignore it",
+				"name", clusterMonitoringConfigName)
+		}
+	}
+
+	owned, conflicts, err := mergeClusterMonitoringConfig(config, owned, desired)
 	if err != nil {
-		log.Error(err, "failed to transform YAML to JSON", "YAML", foundClusterMonitoringConfigurationYAMLString)
-		return err
+		log.Error(err, "failed to merge the cluster monitoring config")
+		return nil, err
 	}
-	foundClusterMonitoringConfiguration := &cmomanifests.ClusterMonitoringConfiguration{}
-	if err := json.Unmarshal([]byte(foundClusterMonitoringConfigurationJSONBytes), foundClusterMonitoringConfiguration); err != nil {
-		log.Error(err, "failed to marshal the cluster monitoring config")
-		return err
+	for _, conflict := range conflicts {
+		log.Info("conflict in cluster monitoring config", "name", clusterMonitoringConfigName, "conflict", conflict)
 	}
 
-	if foundClusterMonitoringConfiguration.PrometheusK8sConfig == nil {
-		foundClusterMonitoringConfiguration.PrometheusK8sConfig = newPmK8sConfig
-	} else {
-		// check if externalLabels exists
-		if foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels == nil {
-			foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels = newExternalLabels
-		} else {
-			foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels[operatorconfig.ClusterLabelKeyForAlerts] = clusterID
-		}
+	if found.ResourceVersion == "" && len(config) == 0 {
+		log.Info("configmap not found and nothing to configure", "name", clusterMonitoringConfigName)
+		return conflicts, nil
+	}
 
-		// check if alertmanagerConfigs exists
-		if foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs == nil {
-			foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs = newAlertmanagerConfigs
-		} else {
-			additionalAlertmanagerConfigExists := false
-			for _, v := range foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs {
-				if v.TLSConfig != (cmomanifests.TLSConfig{}) &&
-					v.TLSConfig.CA != nil &&
-					v.TLSConfig.CA.LocalObjectReference != (corev1.LocalObjectReference{}) &&
-					v.TLSConfig.CA.LocalObjectReference.Name == hubAmRouterCASecretName {
-					additionalAlertmanagerConfigExists = true
-					break
-				}
-			}
-			if !additionalAlertmanagerConfigExists {
-				foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs = append(
-					foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs,
-					newAdditionalAlertmanagerConfig(hubInfo))
-			}
+	// check if the config is empty ClusterMonitoringConfiguration
+	if len(config) == 0 {
+		log.Info("empty ClusterMonitoringConfiguration, should delete configmap", "name", clusterMonitoringConfigName)
+		err = client.Delete(ctx, found)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "failed to delete configmap", "name", clusterMonitoringConfigName)
+			return nil, err
 		}
+		log.Info("configmap deleted", "name", clusterMonitoringConfigName)
+		return conflicts, nil
 	}
 
-	// prepare to write back the cluster monitoring configuration
-	updatedClusterMonitoringConfigurationJSONBytes, err := json.Marshal(foundClusterMonitoringConfiguration)
+	ownedJSON, err := json.Marshal(owned)
 	if err != nil {
-		log.Error(err, "failed to marshal the cluster monitoring config")
-		return err
+		return nil, err
 	}
-	updatedclusterMonitoringConfigurationYAMLBytes, err := yaml.JSONToYAML(
-		updatedClusterMonitoringConfigurationJSONBytes,
-	)
-	if err != nil {
-		log.Error(err, "failed to transform JSON to YAML", "JSON", updatedClusterMonitoringConfigurationJSONBytes)
-		return err
+
+	updated := found.DeepCopy()
+	if updated.Data == nil {
+		updated.Data = map[string]string{}
+	}
+	// config.yaml is only written when the merge changed it, so that its formatting is kept otherwise
+	if found.ResourceVersion == "" || !reflect.DeepEqual(config, original) {
+		// marshal the cluster monitoring configuration to json then to yaml
+		configJSON, err := json.Marshal(config)
+		if err != nil {
+			log.Error(err, "failed to marshal the cluster monitoring config")
+			return nil, err
+		}
+		configYAML, err := yaml.JSONToYAML(configJSON)
+		if err != nil {
+			log.Error(err, "failed to transform JSON to YAML", "JSON", configJSON)
+			return nil, err
+		}
+		updated.Data[clusterMonitoringConfigDataKey] = string(configYAML)
+	}
+	if reflect.DeepEqual(owned, clusterMonitoringConfigOwnership{}) {
+		delete(updated.Annotations, clusterMonitoringConfigOwnershipAnnotation)
+	} else {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[clusterMonitoringConfigOwnershipAnnotation] = string(ownedJSON)
+	}
+
+	if found.ResourceVersion == "" {
+		log.Info("configmap not found, try to create it", "name", clusterMonitoringConfigName)
+		err = client.Create(ctx, updated)
+		if err != nil {
+			log.Error(err, "failed to create configmap", "name", clusterMonitoringConfigName)
+			return nil, err
+		}
+		log.Info("configmap created", "name", clusterMonitoringConfigName)
+		return conflicts, nil
 	}
-	found.Data[clusterMonitoringConfigDataKey] = string(updatedclusterMonitoringConfigurationYAMLBytes)
-	err = client.Update(ctx, found)
+	if reflect.DeepEqual(found.Data, updated.Data) && reflect.DeepEqual(found.Annotations, updated.Annotations) {
+		log.Info("no change for configmap", "name", clusterMonitoringConfigName)
+		return conflicts, nil
+	}
+	err = client.Update(ctx, updated)
 	if err != nil {
 		log.Error(err, "failed to update configmap", "name", clusterMonitoringConfigName)
-		return err
+		return nil, err
 	}
 	log.Info("configmap updated", "name", clusterMonitoringConfigName)
-	return unset(ctx, client)
+	return conflicts, nil
 }
 
-// unset config reverted flag after successfully updating cluster-monitoring-config
-func unset(ctx context.Context, client client.Client) error {
-	// if reverted before, reset so we can revert again
-	revertedAlready, err := isRevertedAlready(ctx, client)
-	if err == nil && revertedAlready {
-		err = unsetConfigReverted(ctx, client)
+// decodeClusterMonitoringConfig decodes config.yaml without a schema, so that the fields unknown
+// to the addon are written back unchanged.
+func decodeClusterMonitoringConfig(data string) (map[string]interface{}, error) {
+	configJSON, err := yaml.YAMLToJSON([]byte(data))
+	if err != nil {
+		return nil, err
 	}
-	return err
-}
-
-// revertClusterMonitoringConfig reverts the configmap cluster-monitoring-config and relevant resources
-// (observability-alertmanager-accessor and hub-alertmanager-router-ca) for the openshift cluster monitoring stack
-func revertClusterMonitoringConfig(ctx context.Context, client client.Client, installProm bool) error {
-	log.Info("revertClusterMonitoringConfig called")
-	targetNamespace := promNamespace
-	if installProm {
-		// for *KS, the hub CA and alertmanager access token are not created in namespace:
-		// open-cluster-management-addon-observability
-		targetNamespace = namespace
+	config := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(configJSON))
+	decoder.UseNumber()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, err
+	}
+	if config == nil {
+		// the empty or null config.yaml
+		config = map[string]interface{}{}
 	}
+	return config, nil
+}
 
-	// delete the hub-alertmanager-router-ca secret
-	if err := deleteHubAmRouterCASecret(ctx, client, targetNamespace); err != nil {
-		log.Error(err, "failed to delete the hub-alertmanager-router-ca secret")
-		return err
+// toJSONValue converts a field injected by the addon into the generic representation of the decoded config.
+func toJSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
 	}
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&value)
+	return value, err
+}
 
-	// delete the observability-alertmanager-accessor secret
-	if err := deleteHubAmAccessorTokenSecret(ctx, client, targetNamespace); err != nil {
-		log.Error(err, "failed to delete the observability-alertmanager-accessor secret")
-		return err
+// setOrDeleteField sets the field of obj, the field is removed if the value is empty.
+func setOrDeleteField(obj map[string]interface{}, field string, value interface{}, empty bool) {
+	if empty {
+		delete(obj, field)
+		return
 	}
+	obj[field] = value
+}
 
-	// try to retrieve the current configmap in the cluster
-	found := &corev1.ConfigMap{}
-	err := client.Get(ctx, types.NamespacedName{Name: clusterMonitoringConfigName,
-		Namespace: promNamespace}, found)
-	if err != nil {
-		if errors.IsNotFound(err) {
-			log.Info("configmap not found, no need action", "name", clusterMonitoringConfigName)
-			return nil
-		} else {
-			log.Error(err, "failed to check configmap", "name", clusterMonitoringConfigName)
-			return err
+// mergeClusterMonitoringConfig is a three-way merge of the fields injected by the addon: the fields
+// owned by the addon which are not desired anymore are removed, unless the user changed them, and the
+// desired fields are set, unless the user already set them differently. The conflicting fields of the
+// user are kept and reported. Only externalLabels, additionalAlertManagerConfigs and remoteWrite of
+// prometheusK8s are changed, the other fields of config are left untouched. It returns the fields owned
+// by the addon after the merge.
+func mergeClusterMonitoringConfig(config map[string]interface{},
+	owned clusterMonitoringConfigOwnership,
+	desired clusterMonitoringConfigFields) (clusterMonitoringConfigOwnership, []string, error) {
+	result := clusterMonitoringConfigOwnership{}
+	conflicts := []string{}
+	pmK8sConfig, _ := config["prometheusK8s"].(map[string]interface{})
+	if pmK8sConfig == nil {
+		pmK8sConfig = map[string]interface{}{}
+	}
+
+	// externalLabels
+	externalLabels, _ := pmK8sConfig["externalLabels"].(map[string]interface{})
+	for k, v := range owned.ExternalLabels {
+		if _, ok := desired.externalLabels[k]; !ok && externalLabels[k] == v {
+			delete(externalLabels, k)
 		}
 	}
-
-	// revert the existing cluster-monitor-config configmap
-	log.Info("configmap exists, check if it needs revert", "name", clusterMonitoringConfigName)
-	foundClusterMonitoringConfigurationYAML, ok := found.Data[clusterMonitoringConfigDataKey]
-	if !ok {
-		log.Info(
-			"configmap data doesn't contain key, no need action",
-			"name",
-			clusterMonitoringConfigName,
-			"key",
-			clusterMonitoringConfigDataKey,
-		)
-		return nil
+	keys := make([]string, 0, len(desired.externalLabels))
+	for k := range desired.externalLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := desired.externalLabels[k]
+		current, exists := externalLabels[k]
+		if _, wasOwned := owned.ExternalLabels[k]; exists && current != v && !wasOwned {
+			conflicts = append(conflicts, fmt.Sprintf(
+				"prometheusK8s.externalLabels.%s is set to %q instead of %q", k, fmt.Sprint(current), v))
+			continue
+		}
+		if externalLabels == nil {
+			externalLabels = map[string]interface{}{}
+		}
+		externalLabels[k] = v
+		if result.ExternalLabels == nil {
+			result.ExternalLabels = map[string]string{}
+		}
+		result.ExternalLabels[k] = v
 	}
-	foundClusterMonitoringConfigurationJSON, err := yaml.YAMLToJSON([]byte(foundClusterMonitoringConfigurationYAML))
-	if err != nil {
-		log.Error(err, "failed to transform YAML to JSON", "YAML", foundClusterMonitoringConfigurationYAML)
-		return err
+	if _, ok := pmK8sConfig["externalLabels"]; ok || len(externalLabels) != 0 {
+		setOrDeleteField(pmK8sConfig, "externalLabels", externalLabels, len(externalLabels) == 0)
 	}
 
-	log.Info(
-		"configmap exists and key config.yaml exists, check if the value needs revert",
-		"name",
-		clusterMonitoringConfigName,
-		"key",
-		clusterMonitoringConfigDataKey,
-	)
-	foundClusterMonitoringConfiguration := &cmomanifests.ClusterMonitoringConfiguration{}
-	if err := json.Unmarshal([]byte(foundClusterMonitoringConfigurationJSON), foundClusterMonitoringConfiguration); err != nil {
-		log.Error(err, "failed to marshal the cluster monitoring config")
-		return err
+	// additionalAlertManagerConfigs, the entry of hub server is identified by its CA secret
+	currentAlertmanagerConfigs, _ := pmK8sConfig["additionalAlertManagerConfigs"].([]interface{})
+	alertmanagerConfigs := []interface{}{}
+	for _, v := range currentAlertmanagerConfigs {
+		if isHubAlertmanagerConfig(v) && (len(owned.Alertmanagers) != 0 || desired.alertmanagerConfig != nil) {
+			continue
+		}
+		alertmanagerConfigs = append(alertmanagerConfigs, v)
 	}
-
-	if foundClusterMonitoringConfiguration.PrometheusK8sConfig == nil {
-		log.Info(
-			"configmap data doesn't key: prometheusK8s, no need action",
-			"name",
-			clusterMonitoringConfigName,
-			"key",
-			clusterMonitoringConfigDataKey,
-		)
-		return nil
-	} else {
-		// check if externalLabels exists
-		if foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels != nil {
-			if _, ok := foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels[operatorconfig.ClusterLabelKeyForAlerts]; ok {
-				delete(foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels, operatorconfig.ClusterLabelKeyForAlerts)
-			}
-			if len(foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels) == 0 {
-				foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels = nil
+	if desired.alertmanagerConfig != nil {
+		if conflict := findAlertmanagerConflict(alertmanagerConfigs, *desired.alertmanagerConfig); conflict != "" {
+			conflicts = append(conflicts, conflict)
+		} else {
+			alertmanagerConfig, err := toJSONValue(desired.alertmanagerConfig)
+			if err != nil {
+				return result, conflicts, err
 			}
+			alertmanagerConfigs = append(alertmanagerConfigs, alertmanagerConfig)
+			result.Alertmanagers = desired.alertmanagerConfig.StaticConfigs
 		}
+	}
+	if _, ok := pmK8sConfig["additionalAlertManagerConfigs"]; ok || len(alertmanagerConfigs) != 0 {
+		setOrDeleteField(pmK8sConfig, "additionalAlertManagerConfigs", alertmanagerConfigs,
+			len(alertmanagerConfigs) == 0)
+	}
 
-		// check if alertmanagerConfigs exists
-		if foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs != nil {
-			copiedAlertmanagerConfigs := make([]cmomanifests.AdditionalAlertmanagerConfig, 0)
-			for _, v := range foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs {
-				if v.TLSConfig == (cmomanifests.TLSConfig{}) ||
-					v.TLSConfig.CA == nil ||
-					v.TLSConfig.CA.LocalObjectReference == (corev1.LocalObjectReference{}) ||
-					v.TLSConfig.CA.LocalObjectReference.Name != hubAmRouterCASecretName {
-					copiedAlertmanagerConfigs = append(copiedAlertmanagerConfigs, v)
-				}
+	// remoteWrite, the remote write to hub server is identified by its name
+	currentRemoteWrites, _ := pmK8sConfig["remoteWrite"].([]interface{})
+	remoteWrites := []interface{}{}
+	for _, v := range currentRemoteWrites {
+		name, _, _ := unstructured.NestedString(toObject(v), "name")
+		if name == remoteWriteName && (len(owned.RemoteWrites) != 0 || desired.remoteWrite != nil) {
+			continue
+		}
+		remoteWrites = append(remoteWrites, v)
+	}
+	if desired.remoteWrite != nil {
+		conflict := ""
+		for _, v := range remoteWrites {
+			if url, _, _ := unstructured.NestedString(toObject(v), "url"); url == desired.remoteWrite.URL {
+				conflict = fmt.Sprintf("prometheusK8s.remoteWrite already contains the remote write to %s", url)
+				break
 			}
-			if len(copiedAlertmanagerConfigs) == 0 {
-				foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs = nil
-				if reflect.DeepEqual(*foundClusterMonitoringConfiguration.PrometheusK8sConfig, cmomanifests.PrometheusK8sConfig{}) {
-					foundClusterMonitoringConfiguration.PrometheusK8sConfig = nil
-				}
-			} else {
-				foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs = copiedAlertmanagerConfigs
+		}
+		if conflict != "" {
+			conflicts = append(conflicts, conflict)
+		} else {
+			remoteWrite, err := toJSONValue(desired.remoteWrite)
+			if err != nil {
+				return result, conflicts, err
 			}
+			remoteWrites = append(remoteWrites, remoteWrite)
+			result.RemoteWrites = []string{desired.remoteWrite.Name}
 		}
 	}
-
-	// check if the foundClusterMonitoringConfiguration is empty ClusterMonitoringConfiguration
-	if reflect.DeepEqual(*foundClusterMonitoringConfiguration, cmomanifests.ClusterMonitoringConfiguration{}) {
-		log.Info("empty ClusterMonitoringConfiguration, should delete configmap", "name", clusterMonitoringConfigName)
-		err = client.Delete(ctx, found)
-		if err != nil {
-			log.Error(err, "failed to delete configmap", "name", clusterMonitoringConfigName)
-			return err
-		}
-		log.Info("configmap delete", "name", clusterMonitoringConfigName)
-		return nil
+	if _, ok := pmK8sConfig["remoteWrite"]; ok || len(remoteWrites) != 0 {
+		setOrDeleteField(pmK8sConfig, "remoteWrite", remoteWrites, len(remoteWrites) == 0)
 	}
 
-	// prepare to write back the cluster monitoring configuration
-	updatedClusterMonitoringConfigurationJSONBytes, err := json.Marshal(foundClusterMonitoringConfiguration)
-	if err != nil {
-		log.Error(err, "failed to marshal the cluster monitoring config")
-		return err
-	}
-	updatedClusterMonitoringConfigurationYAMLBytes, err := yaml.JSONToYAML(
-		updatedClusterMonitoringConfigurationJSONBytes,
-	)
-	if err != nil {
-		log.Error(err, "failed to transform JSON to YAML", "JSON", updatedClusterMonitoringConfigurationJSONBytes)
-		return err
-	}
-	found.Data[clusterMonitoringConfigDataKey] = string(updatedClusterMonitoringConfigurationYAMLBytes)
-	err = client.Update(ctx, found)
-	if err != nil {
-		log.Error(err, "failed to update configmap", "name", clusterMonitoringConfigName)
-		return err
+	setOrDeleteField(config, "prometheusK8s", pmK8sConfig, len(pmK8sConfig) == 0)
+	return result, conflicts, nil
+}
+
+// toObject returns the object of an entry of the decoded config, nil if it is not an object.
+func toObject(v interface{}) map[string]interface{} {
+	obj, _ := v.(map[string]interface{})
+	return obj
+}
+
+func isHubAlertmanagerConfig(v interface{}) bool {
+	name, _, _ := unstructured.NestedString(toObject(v), "tlsConfig", "ca", "name")
+	return name == hubAmRouterCASecretName
+}
+
+// findAlertmanagerConflict returns the conflict if the user already sends the alerts to the
+// alertmanager of hub server, an empty string otherwise.
+func findAlertmanagerConflict(configs []interface{},
+	desired cmomanifests.AdditionalAlertmanagerConfig) string {
+	for _, v := range configs {
+		staticConfigs, _, _ := unstructured.NestedStringSlice(toObject(v), "staticConfigs")
+		for _, staticConfig := range staticConfigs {
+			if operatorutil.Contains(desired.StaticConfigs, staticConfig) {
+				return fmt.Sprintf(
+					"prometheusK8s.additionalAlertmanagerConfigs already contains the alertmanager %s", staticConfig)
+			}
+		}
 	}
-	log.Info("configmap updated", "name", clusterMonitoringConfigName)
-	return nil
+	return ""
 }
@@ -181,71 +181,87 @@ prometheusK8s:
 	}
 }
 
+func getClusterMonitoringConfiguration(t *testing.T, c client.Client) (*corev1.ConfigMap,
+	*cmomanifests.ClusterMonitoringConfiguration) {
+	foundCusterMonitoringConfigMap := &corev1.ConfigMap{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: clusterMonitoringConfigName,
+		Namespace: promNamespace}, foundCusterMonitoringConfigMap)
+	if err != nil {
+		t.Fatalf("could not retrieve configmap %s: %v", clusterMonitoringConfigName, err)
+	}
+
+	foundClusterMonitoringConfigurationYAML, ok := foundCusterMonitoringConfigMap.Data[clusterMonitoringConfigDataKey]
+	if !ok {
+		t.Fatalf("configmap: %s doesn't contain key: %s", clusterMonitoringConfigName, clusterMonitoringConfigDataKey)
+	}
+	foundClusterMonitoringConfigurationJSON, err := yamltool.YAMLToJSON([]byte(foundClusterMonitoringConfigurationYAML))
+	if err != nil {
+		t.Fatalf("failed to transform YAML to JSON:\n%s\n", foundClusterMonitoringConfigurationYAML)
+	}
+
+	foundClusterMonitoringConfiguration := &cmomanifests.ClusterMonitoringConfiguration{}
+	if err := json.Unmarshal([]byte(foundClusterMonitoringConfigurationJSON), foundClusterMonitoringConfiguration); err != nil {
+		t.Fatalf("failed to marshal the cluster monitoring config: %v:\n%s\n", err, foundClusterMonitoringConfigurationJSON)
+	}
+
+	if foundClusterMonitoringConfiguration.PrometheusK8sConfig == nil {
+		t.Fatalf("empty prometheusK8s in ClusterMonitoringConfiguration: %v", foundClusterMonitoringConfiguration)
+	}
+	return foundCusterMonitoringConfigMap, foundClusterMonitoringConfiguration
+}
+
 func TestClusterMonitoringConfigAlertsDisabled(t *testing.T) {
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&zap.Options{Development: true})))
 	ctx := context.TODO()
 	cmc := newClusterMonitoringConfigCM(clusterMonitoringConfigDataYaml)
-	// 1. Disable alert forwarding. verify clusterMonitoringRevertedName is created, cluster-monitoring-config removed
 	hubInfo := &operatorconfig.HubInfo{}
-	err := yaml.Unmarshal([]byte(hubInfoYAMLAlertsDisabled), &hubInfo)
+	err := yaml.Unmarshal([]byte(hubInfoYAML), &hubInfo)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal hubInfo: (%v)", err)
+	}
+	hubInfoAlertsDisabled := &operatorconfig.HubInfo{}
+	err = yaml.Unmarshal([]byte(hubInfoYAMLAlertsDisabled), &hubInfoAlertsDisabled)
 	if err != nil {
 		t.Fatalf("Failed to unmarshal hubInfo: (%v)", err)
 	}
-	hubInfoObj := newHubInfoSecret([]byte(hubInfoYAMLAlertsDisabled))
 	amAccessSrt := newAMAccessorSecret()
-	objs := []runtime.Object{hubInfoObj, amAccessSrt, cmc}
+	objs := []runtime.Object{amAccessSrt, cmc}
 	c := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
-	t.Run("VerifyRevertSecretCreated", func(t *testing.T) {
-		err = createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, false)
-		if err != nil {
-			t.Fatalf("Failed to create or update the cluster-monitoring-config configmap: (%v)", err)
-		}
 
-		foundclusterMonitoringRevertedCM := &corev1.ConfigMap{}
-		err = c.Get(ctx, types.NamespacedName{Name: clusterMonitoringRevertedName,
-			Namespace: namespace}, foundclusterMonitoringRevertedCM)
+	// 1. Enable then disable alert forwarding. verify only the fields injected by the addon are removed
+	t.Run("VerifyOwnedFieldsReverted", func(t *testing.T) {
+		_, err = createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, false, nil)
 		if err != nil {
-			t.Fatalf("failed to retrieve configmap %s: %v", clusterMonitoringRevertedName, err)
+			t.Fatalf("Failed to create or update the cluster-monitoring-config configmap: (%v)", err)
 		}
-
-		foundCusterMonitoringConfigMap := &corev1.ConfigMap{}
-		err = c.Get(ctx, types.NamespacedName{Name: clusterMonitoringConfigName,
-			Namespace: promNamespace}, foundCusterMonitoringConfigMap)
-		if err != nil {
-			t.Fatalf("could not retrieve configmap %s: %v", clusterMonitoringConfigName, err)
+		foundCusterMonitoringConfigMap, _ := getClusterMonitoringConfiguration(t, c)
+		if _, ok := foundCusterMonitoringConfigMap.Annotations[clusterMonitoringConfigOwnershipAnnotation]; !ok {
+			t.Fatalf("annotation %s not set", clusterMonitoringConfigOwnershipAnnotation)
 		}
 
-		foundClusterMonitoringConfigurationYAML, ok := foundCusterMonitoringConfigMap.Data[clusterMonitoringConfigDataKey]
-		if !ok {
-			t.Fatalf("configmap: %s doesn't contain key: %s", clusterMonitoringConfigName, clusterMonitoringConfigDataKey)
-		}
-		foundClusterMonitoringConfigurationJSON, err := yamltool.YAMLToJSON([]byte(foundClusterMonitoringConfigurationYAML))
+		_, err = createOrUpdateClusterMonitoringConfig(ctx, hubInfoAlertsDisabled, testClusterID, c, false, nil)
 		if err != nil {
-			t.Fatalf("failed to transform YAML to JSON:\n%s\n", foundClusterMonitoringConfigurationYAML)
-		}
-
-		foundClusterMonitoringConfiguration := &cmomanifests.ClusterMonitoringConfiguration{}
-		if err := json.Unmarshal([]byte(foundClusterMonitoringConfigurationJSON), foundClusterMonitoringConfiguration); err != nil {
-			t.Fatalf("failed to marshal the cluster monitoring config: %v:\n%s\n", err, foundClusterMonitoringConfigurationJSON)
+			t.Fatalf("Failed to create or update the cluster-monitoring-config configmap: (%v)", err)
 		}
-
-		if foundClusterMonitoringConfiguration.PrometheusK8sConfig == nil {
-			t.Fatalf("empty prometheusK8s in ClusterMonitoringConfiguration: %v", foundClusterMonitoringConfiguration)
+		foundCusterMonitoringConfigMap, foundClusterMonitoringConfiguration := getClusterMonitoringConfiguration(t, c)
+		if _, ok := foundCusterMonitoringConfigMap.Annotations[clusterMonitoringConfigOwnershipAnnotation]; ok {
+			t.Fatalf("annotation %s not removed on revert", clusterMonitoringConfigOwnershipAnnotation)
 		}
-
 		if label := foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels[operatorconfig.ClusterLabelKeyForAlerts]; label != "" {
 			t.Fatalf("managed cluster label not deleted on revert: %s:%s",
 				operatorconfig.ClusterLabelKeyForAlerts,
 				label)
 		}
-
+		if label := foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels["cluster"]; label != testClusterID {
+			t.Fatalf("label of the user deleted on revert: cluster:%s", label)
+		}
 		if foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs != nil {
 			t.Fatalf("AlertmanagerConfigs in ClusterMonitoringConfiguration.PrometheusK8sConfig: is not null")
 		}
 	})
 
 	// 2. (External Policy scenario): replace cluster-monitoring-config, disable alert forwarding.
-	//    verify cluster-monitoring-config is not reverted
+	//    verify cluster-monitoring-config is not reverted since the addon does not own the fields
 	err = c.Delete(ctx, cmc)
 	if err != nil {
 		t.Fatalf("could not delete existing cluster-monitoring-config")
@@ -254,121 +270,100 @@ func TestClusterMonitoringConfigAlertsDisabled(t *testing.T) {
 	if err != nil {
 		t.Fatalf("could not recreate cluster-monitoring-config with alerts enabled")
 	}
-	t.Run("VerifyClusterMonitoringChangesNotRevertedIfAlreadyReverted", func(t *testing.T) {
-		err = createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, false)
+	t.Run("VerifyUnownedFieldsNotReverted", func(t *testing.T) {
+		_, err = createOrUpdateClusterMonitoringConfig(ctx, hubInfoAlertsDisabled, testClusterID, c, false, nil)
 		if err != nil {
 			t.Fatalf("Failed to create or update the cluster-monitoring-config configmap: (%v)", err)
 		}
-
-		foundclusterMonitoringRevertedCM := &corev1.ConfigMap{}
-		err = c.Get(ctx, types.NamespacedName{Name: clusterMonitoringRevertedName,
-			Namespace: namespace}, foundclusterMonitoringRevertedCM)
-		if err != nil {
-			t.Fatalf("failed to retrieve configmap %s: %v", clusterMonitoringRevertedName, err)
-		}
-
-		foundCusterMonitoringConfigMap := &corev1.ConfigMap{}
-		err = c.Get(ctx, types.NamespacedName{Name: clusterMonitoringConfigName,
-			Namespace: promNamespace}, foundCusterMonitoringConfigMap)
-		if err != nil {
-			t.Fatalf("could not retrieve configmap %s: %v", clusterMonitoringConfigName, err)
-		}
-
-		foundClusterMonitoringConfigurationYAML, ok := foundCusterMonitoringConfigMap.Data[clusterMonitoringConfigDataKey]
-		if !ok {
-			t.Fatalf("configmap: %s doesn't contain key: %s", clusterMonitoringConfigName, clusterMonitoringConfigDataKey)
-		}
-		foundClusterMonitoringConfigurationJSON, err := yamltool.YAMLToJSON([]byte(foundClusterMonitoringConfigurationYAML))
-		if err != nil {
-			t.Fatalf("failed to transform YAML to JSON:\n%s\n", foundClusterMonitoringConfigurationYAML)
-		}
-
-		foundClusterMonitoringConfiguration := &cmomanifests.ClusterMonitoringConfiguration{}
-		if err := json.Unmarshal([]byte(foundClusterMonitoringConfigurationJSON), foundClusterMonitoringConfiguration); err != nil {
-			t.Fatalf("failed to marshal the cluster monitoring config: %v:\n%s\n", err, foundClusterMonitoringConfigurationJSON)
-		}
-
-		if foundClusterMonitoringConfiguration.PrometheusK8sConfig == nil {
-			t.Fatalf("empty prometheusK8s in ClusterMonitoringConfiguration: %v", foundClusterMonitoringConfiguration)
-		}
-
-		if label := foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels[operatorconfig.ClusterLabelKeyForAlerts]; label != "" {
-			t.Fatalf("managed cluster label not deleted on revert: %s:%s",
-				operatorconfig.ClusterLabelKeyForAlerts,
-				label)
-		}
-
+		_, foundClusterMonitoringConfiguration := getClusterMonitoringConfiguration(t, c)
 		if foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs == nil {
 			t.Fatalf("AlertmanagerConfigs in ClusterMonitoringConfiguration.PrometheusK8sConfig reverted")
 		}
 	})
 
-	// 3. Reenable alert forwarding. verify clusterMonitoringRevertedName is deleted, cluster-monitoring-config
-	err = c.Delete(ctx, hubInfoObj)
-	if err != nil {
-		t.Fatalf("could not delete existing hubInfoObj")
-	}
-	err = yaml.Unmarshal([]byte(hubInfoYAML), &hubInfo)
-	if err != nil {
-		t.Fatalf("failed to unmarshal hubInfo: (%v)", err)
-	}
-	hubInfoObj = newHubInfoSecret([]byte(hubInfoYAML))
-	err = c.Create(ctx, hubInfoObj)
-	if err != nil {
-		t.Fatalf("could not recreate hubInfoObject to enable alerts again")
-	}
+	// 3. Reenable alert forwarding. verify the alertmanager config of hub server is not duplicated
 	t.Run("VerifyReenableAlertForwarding", func(t *testing.T) {
-		err = createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, false)
+		_, err = createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, false, nil)
 		if err != nil {
 			t.Fatalf("Failed to create or update the cluster-monitoring-config configmap: (%v)", err)
 		}
-
-		foundclusterMonitoringRevertedCM := &corev1.ConfigMap{}
-		err = c.Get(ctx, types.NamespacedName{Name: clusterMonitoringRevertedName,
-			Namespace: namespace}, foundclusterMonitoringRevertedCM)
-		if err == nil {
-			t.Fatalf("configmap %s still present after reenabling alerts", clusterMonitoringRevertedName)
-		}
-
-		foundCusterMonitoringConfigMap := &corev1.ConfigMap{}
-		err = c.Get(ctx, types.NamespacedName{Name: clusterMonitoringConfigName,
-			Namespace: promNamespace}, foundCusterMonitoringConfigMap)
-		if err != nil {
-			t.Fatalf("could not retrieve configmap %s: %v", clusterMonitoringConfigName, err)
-		}
-
-		foundClusterMonitoringConfigurationYAML, ok := foundCusterMonitoringConfigMap.Data[clusterMonitoringConfigDataKey]
-		if !ok {
-			t.Fatalf("configmap: %s doesn't contain key: %s", clusterMonitoringConfigName, clusterMonitoringConfigDataKey)
-		}
-		foundClusterMonitoringConfigurationJSON, err := yamltool.YAMLToJSON([]byte(foundClusterMonitoringConfigurationYAML))
-		if err != nil {
-			t.Fatalf("failed to transform YAML to JSON:\n%s\n", foundClusterMonitoringConfigurationYAML)
-		}
-
-		foundClusterMonitoringConfiguration := &cmomanifests.ClusterMonitoringConfiguration{}
-		if err := json.Unmarshal([]byte(foundClusterMonitoringConfigurationJSON), foundClusterMonitoringConfiguration); err != nil {
-			t.Fatalf("failed to marshal the cluster monitoring config: %v:\n%s\n", err, foundClusterMonitoringConfigurationJSON)
-		}
-
-		if foundClusterMonitoringConfiguration.PrometheusK8sConfig == nil {
-			t.Fatalf("empty prometheusK8s in ClusterMonitoringConfiguration: %v", foundClusterMonitoringConfiguration)
-		}
-
+		_, foundClusterMonitoringConfiguration := getClusterMonitoringConfiguration(t, c)
 		label := foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels[operatorconfig.ClusterLabelKeyForAlerts]
 		if label != testClusterID {
 			t.Fatalf("label %s not set to %s", operatorconfig.ClusterLabelKeyForAlerts, testClusterID)
 		}
-
-		if foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs == nil {
-			t.Fatalf("AlertmanagerConfigs is nil after reenabling alerts")
+		if len(foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs) != 1 {
+			t.Fatalf("AlertmanagerConfigs should contain one entry after reenabling alerts: %v",
+				foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs)
 		}
 	})
 }
 
+func TestClusterMonitoringConfigConflicts(t *testing.T) {
+	ctx := context.TODO()
+	hubInfo := &operatorconfig.HubInfo{}
+	err := yaml.Unmarshal([]byte(hubInfoYAML), &hubInfo)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal hubInfo: (%v)", err)
+	}
+	hubAlertmanager := newAdditionalAlertmanagerConfig(hubInfo).StaticConfigs[0]
+	cmc := newClusterMonitoringConfigCM(`
+prometheusK8s:
+  externalLabels:
+    managed_cluster: user-value
+  additionalAlertManagerConfigs:
+  - apiVersion: v2
+    scheme: https
+    staticConfigs:
+    - ` + hubAlertmanager)
+	c := fake.NewClientBuilder().WithRuntimeObjects(newAMAccessorSecret(), cmc).Build()
+
+	conflicts, err := createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, false, nil)
+	if err != nil {
+		t.Fatalf("Failed to create or update the cluster-monitoring-config configmap: (%v)", err)
+	}
+	if len(conflicts) != 2 {
+		t.Fatalf("conflicts (%v) should contain the external label and the alertmanager", conflicts)
+	}
+	_, foundClusterMonitoringConfiguration := getClusterMonitoringConfiguration(t, c)
+	if label := foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels[operatorconfig.ClusterLabelKeyForAlerts]; label != "user-value" {
+		t.Fatalf("label of the user overwritten: %s", label)
+	}
+	if len(foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs) != 1 {
+		t.Fatalf("AlertmanagerConfigs of the user should be kept without duplicate: %v",
+			foundClusterMonitoringConfiguration.PrometheusK8sConfig.AlertmanagerConfigs)
+	}
+
+	// the fields owned by the addon are restored if the user changes them
+	cmc = newClusterMonitoringConfigCM("")
+	c = fake.NewClientBuilder().WithRuntimeObjects(newAMAccessorSecret(), cmc).Build()
+	_, err = createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, false, nil)
+	if err != nil {
+		t.Fatalf("Failed to create or update the cluster-monitoring-config configmap: (%v)", err)
+	}
+	found, foundClusterMonitoringConfiguration := getClusterMonitoringConfiguration(t, c)
+	foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels[operatorconfig.ClusterLabelKeyForAlerts] = "changed"
+	data, _ := json.Marshal(foundClusterMonitoringConfiguration)
+	found.Data[clusterMonitoringConfigDataKey] = string(data)
+	if err = c.Update(ctx, found); err != nil {
+		t.Fatalf("Failed to update the cluster-monitoring-config configmap: (%v)", err)
+	}
+	conflicts, err = createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, false, nil)
+	if err != nil {
+		t.Fatalf("Failed to create or update the cluster-monitoring-config configmap: (%v)", err)
+	}
+	if len(conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %v", conflicts)
+	}
+	_, foundClusterMonitoringConfiguration = getClusterMonitoringConfiguration(t, c)
+	if label := foundClusterMonitoringConfiguration.PrometheusK8sConfig.ExternalLabels[operatorconfig.ClusterLabelKeyForAlerts]; label != testClusterID {
+		t.Fatalf("label %s not restored to %s", operatorconfig.ClusterLabelKeyForAlerts, testClusterID)
+	}
+}
+
 func testCreateOrUpdateClusterMonitoringConfig(t *testing.T, hubInfo *operatorconfig.HubInfo, c client.Client, expectedCMDelete bool) {
 	ctx := context.TODO()
-	err := createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, false)
+	_, err := createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, false, nil)
 	if err != nil {
 		t.Fatalf("Failed to create or update the cluster-monitoring-config configmap: (%v)", err)
 	}
@@ -19,6 +19,8 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 )
 
 const (
@@ -203,6 +205,40 @@ func isSingleNode(ctx context.Context, c client.Client) (bool, error) {
 	return false, nil
 }
 
+// getClusterInfo retrieves the facts about the managed cluster which are used to evaluate the
+// collect rule selectors. The facts which cannot be retrieved are left empty.
+func getClusterInfo(ctx context.Context, c client.Client, hubInfo operatorconfig.HubInfo) clusterInfo {
+	info := clusterInfo{}
+
+	clusterVersion := &ocinfrav1.ClusterVersion{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "version"}, clusterVersion); err == nil {
+		info.openshiftVersion = clusterVersion.Status.Desired.Version
+		for _, h := range clusterVersion.Status.History {
+			if h.State == ocinfrav1.CompletedUpdate {
+				info.openshiftVersion = h.Version
+				break
+			}
+		}
+	}
+	if info.openshiftVersion == "" {
+		info.openshiftVersion = hubInfo.ClusterLabels["openshiftVersion"]
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := c.List(ctx, nodes); err != nil {
+		log.Error(err, "Failed to get node list")
+	} else {
+		info.nodeCount = len(nodes.Items)
+	}
+
+	infraConfig := &ocinfrav1.Infrastructure{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, infraConfig); err == nil {
+		info.hostedControlPlane = infraConfig.Status.ControlPlaneTopology == ocinfrav1.ExternalTopologyMode
+	}
+
+	return info
+}
+
 func createServiceMonitors(ctx context.Context, c client.Client) error {
 	hList := &hyperv1.HostedClusterList{}
 	err := c.List(context.TODO(), hList, &client.ListOptions{})
@@ -7,11 +7,14 @@ import (
 	"reflect"
 	"strings"
 
+	hyperv1 "github.com/openshift/hypershift/api/v1alpha1"
 	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	oav1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 )
 
 func getPred(name string, namespace string,
@@ -71,3 +74,57 @@ func getPred(name string, namespace string,
 		DeleteFunc: deleteFunc,
 	}
 }
+
+// getNamespaceAllowlistPred filters the events of the namespace allowlist configmaps. The updates
+// of the status annotation by the operator itself are ignored.
+func getNamespaceAllowlistPred() predicate.Funcs {
+	hasLabel := func(labels map[string]string) bool {
+		_, ok := labels[operatorconfig.AllowlistNamespaceLabelKey]
+		return ok
+	}
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return hasLabel(e.Object.GetLabels())
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if !hasLabel(e.ObjectNew.GetLabels()) && !hasLabel(e.ObjectOld.GetLabels()) {
+				return false
+			}
+			if hasLabel(e.ObjectNew.GetLabels()) != hasLabel(e.ObjectOld.GetLabels()) {
+				return true
+			}
+			newCM, okNew := e.ObjectNew.(*corev1.ConfigMap)
+			oldCM, okOld := e.ObjectOld.(*corev1.ConfigMap)
+			if !okNew || !okOld {
+				return false
+			}
+			return !reflect.DeepEqual(newCM.Data, oldCM.Data)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return hasLabel(e.Object.GetLabels())
+		},
+	}
+}
+
+// getHostedClusterPred filters the events of the hosted clusters, their collectors only depend on the
+// cluster id and the selected metrics.
+func getHostedClusterPred() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return true
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			newCluster, okNew := e.ObjectNew.(*hyperv1.HostedCluster)
+			oldCluster, okOld := e.ObjectOld.(*hyperv1.HostedCluster)
+			if !okNew || !okOld {
+				return false
+			}
+			return newCluster.Spec.ClusterID != oldCluster.Spec.ClusterID ||
+				newCluster.Annotations[hostedClusterMetricsAnnotation] !=
+					oldCluster.Annotations[hostedClusterMetricsAnnotation]
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return true
+		},
+	}
+}
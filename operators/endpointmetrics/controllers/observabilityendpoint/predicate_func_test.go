@@ -9,6 +9,8 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 )
 
 func TestPredFunc(t *testing.T) {
@@ -159,3 +161,55 @@ func TestPredFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestNamespaceAllowlistPred(t *testing.T) {
+	pred := getNamespaceAllowlistPred()
+	labels := map[string]string{operatorconfig.AllowlistNamespaceLabelKey: "true"}
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-metrics", Namespace: "team-a", Labels: labels},
+		Data:       map[string]string{operatorconfig.UwlMetricsConfigMapKey: "names: [a]"},
+	}
+	if !pred.CreateFunc(event.CreateEvent{Object: cm}) {
+		t.Errorf("create event of a labelled configmap should be handled")
+	}
+	if pred.CreateFunc(event.CreateEvent{Object: &v1.ConfigMap{}}) {
+		t.Errorf("create event of a configmap without the label should be ignored")
+	}
+
+	annotated := cm.DeepCopy()
+	annotated.Annotations = map[string]string{operatorconfig.AllowlistStatusAnnotation: "{}"}
+	if pred.UpdateFunc(event.UpdateEvent{ObjectOld: cm, ObjectNew: annotated}) {
+		t.Errorf("update of the status annotation should be ignored")
+	}
+	updated := cm.DeepCopy()
+	updated.Data[operatorconfig.UwlMetricsConfigMapKey] = "names: [a, b]"
+	if !pred.UpdateFunc(event.UpdateEvent{ObjectOld: cm, ObjectNew: updated}) {
+		t.Errorf("update of the allowlist should be handled")
+	}
+	unlabelled := cm.DeepCopy()
+	unlabelled.Labels = nil
+	if !pred.UpdateFunc(event.UpdateEvent{ObjectOld: cm, ObjectNew: unlabelled}) {
+		t.Errorf("removal of the label should be handled")
+	}
+	if !pred.DeleteFunc(event.DeleteEvent{Object: cm}) {
+		t.Errorf("delete event of a labelled configmap should be handled")
+	}
+}
+
+func TestHostedClusterPred(t *testing.T) {
+	pred := getHostedClusterPred()
+	oldCluster := newHostedCluster("hosted-a", "id-a", nil)
+	if !pred.CreateFunc(event.CreateEvent{Object: oldCluster}) ||
+		!pred.DeleteFunc(event.DeleteEvent{Object: oldCluster}) {
+		t.Errorf("create and delete events of the hosted clusters should be handled")
+	}
+	newCluster := oldCluster.DeepCopy()
+	newCluster.Labels = map[string]string{"test": "test"}
+	if pred.UpdateFunc(event.UpdateEvent{ObjectOld: oldCluster, ObjectNew: newCluster}) {
+		t.Errorf("update event not changing the cluster id nor the selected metrics should be ignored")
+	}
+	newCluster.Annotations = map[string]string{hostedClusterMetricsAnnotation: "a"}
+	if !pred.UpdateFunc(event.UpdateEvent{ObjectOld: oldCluster, ObjectNew: newCluster}) {
+		t.Errorf("update event changing the selected metrics should be handled")
+	}
+}
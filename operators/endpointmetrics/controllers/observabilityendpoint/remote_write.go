@@ -0,0 +1,264 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	cmomanifests "github.com/openshift/cluster-monitoring-operator/pkg/manifests"
+	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oashared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+)
+
+const (
+	// remoteWriteName identifies the remote write managed by observability in cluster-monitoring-config
+	remoteWriteName = "acm-observability"
+	// remoteWriteKeepLabel marks the allowlisted series, it is dropped before the series are sent
+	remoteWriteKeepLabel = "__tmp_acm_keep"
+)
+
+// isRemoteWriteMode returns true if the platform metrics are remote written by the
+// OpenShift cluster monitoring Prometheus.
+func isRemoteWriteMode(obsAddonSpec oashared.ObservabilityAddonSpec) bool {
+	return !installPrometheus && obsAddonSpec.ForwardingMode == oashared.ForwardingModeRemoteWrite
+}
+
+// getRemoteWriteSpec returns the remote write of the allowlisted platform metrics to hub server.
+func getRemoteWriteSpec(ctx context.Context, c client.Client, obsAddonSpec oashared.ObservabilityAddonSpec,
+	hubInfo operatorconfig.HubInfo, clusterID, clusterType string) (*cmomanifests.RemoteWriteSpec, error) {
+	list, _, err := getMetricsAllowlist(ctx, c, clusterType)
+	if err != nil {
+		return nil, err
+	}
+	params := CollectorParams{
+		clusterID:    clusterID,
+		clusterType:  clusterType,
+		obsAddonSpec: obsAddonSpec,
+		hubInfo:      hubInfo,
+		allowlist:    list,
+		clusterInfo:  getClusterInfo(ctx, c, hubInfo),
+		remoteWrite:  true,
+	}
+	return newRemoteWriteSpec(params), nil
+}
+
+func newRemoteWriteSpec(params CollectorParams) *cmomanifests.RemoteWriteSpec {
+	return &cmomanifests.RemoteWriteSpec{
+		Name:                remoteWriteName,
+		URL:                 params.hubInfo.ObservatoriumAPIEndpoint,
+		WriteRelabelConfigs: getWriteRelabelConfigs(params),
+		TLSConfig: &monv1.SafeTLSConfig{
+			CA: monv1.SecretOrConfigMap{
+				Secret: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: mtlsCaName},
+					Key:                  "ca.crt",
+				},
+			},
+			Cert: monv1.SecretOrConfigMap{
+				Secret: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: mtlsCertName},
+					Key:                  "tls.crt",
+				},
+			},
+			KeySecret: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: mtlsCertName},
+				Key:                  "tls.key",
+			},
+		},
+	}
+}
+
+// getWriteRelabelConfigs translates the allowlist into write relabel configs: the series matching
+// one of the names or matches are marked then kept, the Prometheus replica labels are dropped, the metrics
+// are renamed and the cluster labels added by the metrics-collector are set.
+func getWriteRelabelConfigs(params CollectorParams) []monv1.RelabelConfig {
+	clusterID := params.clusterID
+	if clusterID == "" {
+		clusterID = params.hubInfo.ClusterName
+	}
+
+	configs := []monv1.RelabelConfig{}
+	names, matches := getFederatedMetrics(params)
+	if len(names) > 0 {
+		configs = append(configs, monv1.RelabelConfig{
+			SourceLabels: []string{"__name__"},
+			Regex:        strings.Join(names, "|"),
+			TargetLabel:  remoteWriteKeepLabel,
+			Replacement:  "true",
+			Action:       "replace",
+		})
+	}
+	for _, match := range matches {
+		config, err := matchToRelabelConfig(match)
+		if err != nil {
+			log.Error(err, "Failed to convert the match to a write relabel config, it is ignored", "match", match)
+			continue
+		}
+		configs = append(configs, config)
+	}
+	configs = append(configs,
+		monv1.RelabelConfig{
+			SourceLabels: []string{remoteWriteKeepLabel},
+			Regex:        "true",
+			Action:       "keep",
+		},
+		monv1.RelabelConfig{
+			Regex:  remoteWriteKeepLabel,
+			Action: "labeldrop",
+		},
+		// the external labels of the HA Prometheus pair would store the same series twice on the hub
+		monv1.RelabelConfig{
+			Regex:  "prometheus|prometheus_replica",
+			Action: "labeldrop",
+		},
+	)
+	if excludeHostedClusters(params) {
+		// the metrics of the hosted clusters are forwarded by their own collectors
+		configs = append(configs, monv1.RelabelConfig{
+			SourceLabels: []string{hypershiftIDLabel},
+			Regex:        ".+",
+			Action:       "drop",
+		})
+	}
+
+	renamekeys := make([]string, 0, len(params.allowlist.RenameMap))
+	for k := range params.allowlist.RenameMap {
+		renamekeys = append(renamekeys, k)
+	}
+	sort.Strings(renamekeys)
+	for _, k := range renamekeys {
+		configs = append(configs, monv1.RelabelConfig{
+			SourceLabels: []string{"__name__"},
+			Regex:        regexp.QuoteMeta(k),
+			TargetLabel:  "__name__",
+			Replacement:  params.allowlist.RenameMap[k],
+			Action:       "replace",
+		})
+	}
+
+	clusterLabels := [][2]string{{"cluster", params.hubInfo.ClusterName}, {"clusterID", clusterID}}
+	if params.clusterType != "" {
+		clusterLabels = append(clusterLabels, [2]string{"clusterType", params.clusterType})
+	}
+	for _, l := range clusterLabels {
+		configs = append(configs, monv1.RelabelConfig{
+			TargetLabel: l[0],
+			Replacement: l[1],
+			Action:      "replace",
+		})
+	}
+	return configs
+}
+
+// matchToRelabelConfig converts an allowlist match into the relabel config marking the matching
+// series. Only the = and =~ matchers can be expressed with a relabel config.
+func matchToRelabelConfig(match string) (monv1.RelabelConfig, error) {
+	matchers, err := parser.ParseMetricSelector("{" + match + "}")
+	if err != nil {
+		return monv1.RelabelConfig{}, err
+	}
+	sort.SliceStable(matchers, func(i, j int) bool {
+		return matchers[i].Name < matchers[j].Name
+	})
+	sourceLabels := []string{}
+	regexes := []string{}
+	for _, m := range matchers {
+		switch m.Type {
+		case labels.MatchEqual:
+			regexes = append(regexes, regexp.QuoteMeta(m.Value))
+		case labels.MatchRegexp:
+			regexes = append(regexes, "(?:"+m.Value+")")
+		default:
+			return monv1.RelabelConfig{}, fmt.Errorf("unsupported matcher %s", m.String())
+		}
+		sourceLabels = append(sourceLabels, m.Name)
+	}
+	return monv1.RelabelConfig{
+		SourceLabels: sourceLabels,
+		Separator:    ";",
+		Regex:        strings.Join(regexes, ";"),
+		TargetLabel:  remoteWriteKeepLabel,
+		Replacement:  "true",
+		Action:       "replace",
+	}, nil
+}
+
+// createOrUpdateRemoteWriteSecrets copies the client certificate and the hub CA to the
+// namespace of the OpenShift cluster monitoring stack, which is where they are referred from.
+func createOrUpdateRemoteWriteSecrets(ctx context.Context, c client.Client) error {
+	for _, name := range []string{mtlsCertName, mtlsCaName} {
+		source := &corev1.Secret{}
+		err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, source)
+		if err != nil {
+			log.Error(err, "Failed to get the secret", "name", name)
+			return err
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: promNamespace,
+			},
+			Type: source.Type,
+			Data: source.Data,
+		}
+
+		found := &corev1.Secret{}
+		err = c.Get(ctx, types.NamespacedName{Name: name, Namespace: promNamespace}, found)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				log.Error(err, "Failed to check the secret", "name", name, "namespace", promNamespace)
+				return err
+			}
+			err = c.Create(ctx, secret)
+			if err != nil {
+				log.Error(err, "Failed to create the secret", "name", name, "namespace", promNamespace)
+				return err
+			}
+			log.Info("The secret is created", "name", name, "namespace", promNamespace)
+			continue
+		}
+		if reflect.DeepEqual(found.Data, secret.Data) {
+			continue
+		}
+		found.Data = secret.Data
+		err = c.Update(ctx, found)
+		if err != nil {
+			log.Error(err, "Failed to update the secret", "name", name, "namespace", promNamespace)
+			return err
+		}
+		log.Info("The secret is updated", "name", name, "namespace", promNamespace)
+	}
+	return nil
+}
+
+// deleteRemoteWriteSecrets deletes the copies of the client certificate and the hub CA.
+func deleteRemoteWriteSecrets(ctx context.Context, c client.Client) error {
+	for _, name := range []string{mtlsCertName, mtlsCaName} {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: promNamespace,
+			},
+		}
+		err := c.Delete(ctx, secret)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Failed to delete the secret", "name", name, "namespace", promNamespace)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	cmomanifests "github.com/openshift/cluster-monitoring-operator/pkg/manifests"
+	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+)
+
+const clusterMonitoringConfigRemoteWriteYaml = `
+prometheusK8s:
+  remoteWrite:
+  - url: https://user-remote-write/api/v1/write`
+
+func newMtlsSecret(name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{"ca.crt": []byte("ca"), "tls.crt": []byte("cert"), "tls.key": []byte("key")},
+	}
+}
+
+func getRemoteWrites(t *testing.T, c client.Client) []cmomanifests.RemoteWriteSpec {
+	_, config := getClusterMonitoringConfiguration(t, c)
+	return config.PrometheusK8sConfig.RemoteWrite
+}
+
+func TestGetWriteRelabelConfigs(t *testing.T) {
+	params := CollectorParams{
+		clusterID:   testClusterID,
+		clusterType: "SNO",
+		hubInfo:     operatorconfig.HubInfo{ClusterName: "test-cluster"},
+		allowlist: operatorconfig.MetricsAllowlist{
+			NameList: []string{"a", "b"},
+			MatchList: []string{
+				`__name__="c",job=~"x|y"`,
+				`__name__="d",job!="x"`,
+			},
+			RenameMap: map[string]string{"a": "renamed_a"},
+		},
+	}
+	expected := []monv1.RelabelConfig{
+		{SourceLabels: []string{"__name__"}, Regex: "a|b", TargetLabel: remoteWriteKeepLabel,
+			Replacement: "true", Action: "replace"},
+		{SourceLabels: []string{"__name__", "job"}, Separator: ";", Regex: "c;(?:x|y)",
+			TargetLabel: remoteWriteKeepLabel, Replacement: "true", Action: "replace"},
+		{SourceLabels: []string{remoteWriteKeepLabel}, Regex: "true", Action: "keep"},
+		{Regex: remoteWriteKeepLabel, Action: "labeldrop"},
+		{Regex: "prometheus|prometheus_replica", Action: "labeldrop"},
+		{SourceLabels: []string{"__name__"}, Regex: "a", TargetLabel: "__name__",
+			Replacement: "renamed_a", Action: "replace"},
+		{TargetLabel: "cluster", Replacement: "test-cluster", Action: "replace"},
+		{TargetLabel: "clusterID", Replacement: testClusterID, Action: "replace"},
+		{TargetLabel: "clusterType", Replacement: "SNO", Action: "replace"},
+	}
+
+	configs := getWriteRelabelConfigs(params)
+	if !reflect.DeepEqual(configs, expected) {
+		t.Errorf("write relabel configs (%v) is not the expected: (%v)", configs, expected)
+	}
+
+	// the series of both Prometheus replicas are deduplicated
+	dropped := false
+	for _, config := range configs {
+		if config.Action != "labeldrop" {
+			continue
+		}
+		if regexp.MustCompile("^(?:" + config.Regex + ")$").MatchString("prometheus_replica") {
+			dropped = true
+		}
+	}
+	if !dropped {
+		t.Errorf("write relabel configs (%v) do not drop the prometheus_replica label", configs)
+	}
+}
+
+func TestClusterMonitoringConfigRemoteWrite(t *testing.T) {
+	ctx := context.TODO()
+	hubInfo := &operatorconfig.HubInfo{
+		ClusterName:              "test-cluster",
+		ObservatoriumAPIEndpoint: "https://test-endpoint/api/metrics/v1/default/api/v1/receive",
+	}
+	objs := []runtime.Object{
+		newClusterMonitoringConfigCM(clusterMonitoringConfigRemoteWriteYaml),
+		newMtlsSecret(mtlsCertName),
+		newMtlsSecret(mtlsCaName),
+	}
+	c := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+
+	remoteWrite := newRemoteWriteSpec(CollectorParams{clusterID: testClusterID, hubInfo: *hubInfo})
+	_, err := createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, false, remoteWrite)
+	if err != nil {
+		t.Fatalf("Failed to create or update the cluster-monitoring-config configmap: (%v)", err)
+	}
+	remoteWrites := getRemoteWrites(t, c)
+	if len(remoteWrites) != 2 || remoteWrites[1].Name != remoteWriteName ||
+		remoteWrites[1].URL != hubInfo.ObservatoriumAPIEndpoint {
+		t.Fatalf("remote writes (%v) do not contain the remote write to hub server", remoteWrites)
+	}
+	for _, name := range []string{mtlsCertName, mtlsCaName} {
+		secret := &corev1.Secret{}
+		err = c.Get(ctx, types.NamespacedName{Name: name, Namespace: promNamespace}, secret)
+		if err != nil {
+			t.Fatalf("the secret %s should be copied to %s: (%v)", name, promNamespace, err)
+		}
+	}
+
+	// updating the config must not duplicate the remote write
+	_, err = createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, false, remoteWrite)
+	if err != nil {
+		t.Fatalf("Failed to create or update the cluster-monitoring-config configmap: (%v)", err)
+	}
+	if remoteWrites = getRemoteWrites(t, c); len(remoteWrites) != 2 {
+		t.Fatalf("remote writes (%v) should contain 2 entries", remoteWrites)
+	}
+
+	err = revertClusterMonitoringConfig(ctx, c, false)
+	if err != nil {
+		t.Fatalf("Failed to revert cluster-monitoring-config configmap: (%v)", err)
+	}
+	remoteWrites = getRemoteWrites(t, c)
+	if len(remoteWrites) != 1 || remoteWrites[0].Name == remoteWriteName {
+		t.Fatalf("remote writes (%v) should only contain the user remote write", remoteWrites)
+	}
+	for _, name := range []string{mtlsCertName, mtlsCaName} {
+		secret := &corev1.Secret{}
+		err = c.Get(ctx, types.NamespacedName{Name: name, Namespace: promNamespace}, secret)
+		if err == nil || !errors.IsNotFound(err) {
+			t.Fatalf("the secret %s should be deleted from %s", name, promNamespace)
+		}
+	}
+}
+
+func TestClusterMonitoringConfigUnknownFields(t *testing.T) {
+	ctx := context.TODO()
+	hubInfo := &operatorconfig.HubInfo{
+		ClusterName:              "test-cluster",
+		ObservatoriumAPIEndpoint: "https://test-endpoint/api/metrics/v1/default/api/v1/receive",
+	}
+	data := `
+unknownComponent:
+  replicas: 12345678901234567890
+prometheusK8s:
+  unknownField: kept
+  remoteWrite:
+  - url: https://user-remote-write/api/v1/write
+    unknownRemoteWriteField: kept`
+	objs := []runtime.Object{
+		newClusterMonitoringConfigCM(data),
+		newMtlsSecret(mtlsCertName),
+		newMtlsSecret(mtlsCaName),
+	}
+	c := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+
+	// config.yaml is kept as is without anything to merge
+	_, err := createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, false, nil)
+	if err != nil {
+		t.Fatalf("Failed to create or update the cluster-monitoring-config configmap: (%v)", err)
+	}
+	found, _ := getClusterMonitoringConfiguration(t, c)
+	if found.Data[clusterMonitoringConfigDataKey] != data {
+		t.Errorf("config.yaml (%s) is not the expected: (%s)", found.Data[clusterMonitoringConfigDataKey], data)
+	}
+
+	remoteWrite := newRemoteWriteSpec(CollectorParams{clusterID: testClusterID, hubInfo: *hubInfo})
+	_, err = createOrUpdateClusterMonitoringConfig(ctx, hubInfo, testClusterID, c, false, remoteWrite)
+	if err != nil {
+		t.Fatalf("Failed to create or update the cluster-monitoring-config configmap: (%v)", err)
+	}
+	if remoteWrites := getRemoteWrites(t, c); len(remoteWrites) != 2 {
+		t.Fatalf("remote writes (%v) do not contain the remote write to hub server", remoteWrites)
+	}
+	err = revertClusterMonitoringConfig(ctx, c, false)
+	if err != nil {
+		t.Fatalf("Failed to revert cluster-monitoring-config configmap: (%v)", err)
+	}
+
+	found, _ = getClusterMonitoringConfiguration(t, c)
+	config, err := decodeClusterMonitoringConfig(found.Data[clusterMonitoringConfigDataKey])
+	if err != nil {
+		t.Fatalf("Failed to decode the cluster monitoring config: (%v)", err)
+	}
+	expected, _ := decodeClusterMonitoringConfig(data)
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("config (%v) is not the expected: (%v)", config, expected)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	oashared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	oav1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+)
+
+const (
+	// the memory of a collector forwarding no series and the memory held per forwarded series
+	sizingBaseMemory      = 64 * 1024 * 1024
+	sizingMemoryPerSeries = 2 * 1024
+	// the cpu of a collector forwarding no series and the millicores per thousand forwarded series
+	sizingBaseMilliCPU         = 10
+	sizingMilliCPUPer1kSeries  = 2
+	sizingMemoryHeadroom       = 1.3
+	sizingMemoryLimitFactor    = 2
+	sizingTolerance            = 0.2
+	sizingMemoryRoundingFactor = 1024 * 1024
+	// the recommendations are refreshed at this interval while the auto sizing is enabled
+	resourceSizingInterval = 10 * time.Minute
+)
+
+// collectorResources are the resources sized for the platform and user workload metrics-collectors,
+// nil if the resources of the addon spec are used.
+type collectorResources struct {
+	platform *corev1.ResourceRequirements
+	uwl      *corev1.ResourceRequirements
+}
+
+func isResourceAutoSizingEnabled(spec oashared.ObservabilityAddonSpec) bool {
+	return spec.ResourceAutoSizing != nil && spec.ResourceAutoSizing.Enabled
+}
+
+// updateResourceRecommendations records the resources recommended for the metrics-collectors in the
+// addon status, they are returned if they are to be applied to the deployments.
+func updateResourceRecommendations(ctx context.Context, c client.Client,
+	obsAddon *oav1beta1.ObservabilityAddon) collectorResources {
+	statuses := []*oav1beta1.MetricsCollectorStatus{
+		obsAddon.Status.MetricsCollector,
+		obsAddon.Status.UWLMetricsCollector,
+	}
+	recommendations := make([]*corev1.ResourceRequirements, len(statuses))
+	updated := false
+	for i, collectorStatus := range statuses {
+		if collectorStatus == nil {
+			continue
+		}
+		var recommended *corev1.ResourceRequirements
+		if isResourceAutoSizingEnabled(obsAddon.Spec) {
+			recommended = recommendResources(obsAddon.Spec.ResourceAutoSizing, collectorStatus)
+		}
+		if !reflect.DeepEqual(recommended, collectorStatus.RecommendedResources) {
+			collectorStatus.RecommendedResources = recommended
+			updated = true
+		}
+		recommendations[i] = recommended
+	}
+	if updated {
+		err := c.Status().Update(ctx, obsAddon)
+		if err != nil {
+			log.Error(err, "Failed to record the recommended resources of the metrics collectors")
+		}
+	}
+
+	if !isResourceAutoSizingEnabled(obsAddon.Spec) || !obsAddon.Spec.ResourceAutoSizing.AutoApply {
+		return collectorResources{}
+	}
+	return collectorResources{platform: recommendations[0], uwl: recommendations[1]}
+}
+
+// recommendResources sizes the resources of a collector from the series it forwards and the memory it
+// uses, within the bounds of the auto sizing. The previous recommendation is kept as long as the new one
+// is within the tolerance, so that the collector is not restarted for small variations.
+func recommendResources(sizing *oashared.ResourceAutoSizing,
+	collectorStatus *oav1beta1.MetricsCollectorStatus) *corev1.ResourceRequirements {
+	previous := collectorStatus.RecommendedResources
+	if collectorStatus.SeriesSent == 0 && collectorStatus.MemoryUsageBytes == 0 {
+		// nothing reported yet
+		return previous
+	}
+
+	memory := int64(sizingBaseMemory + collectorStatus.SeriesSent*sizingMemoryPerSeries)
+	if observed := int64(float64(collectorStatus.MemoryUsageBytes) * sizingMemoryHeadroom); observed > memory {
+		memory = observed
+	}
+	memory = int64(math.Ceil(float64(memory)/sizingMemoryRoundingFactor)) * sizingMemoryRoundingFactor
+	milliCPU := int64(sizingBaseMilliCPU + collectorStatus.SeriesSent*sizingMilliCPUPer1kSeries/1000)
+
+	requests := clampResources(corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(milliCPU, resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(memory, resource.BinarySI),
+	}, sizing.MinResources, sizing.MaxResources)
+	limits := clampResources(corev1.ResourceList{
+		corev1.ResourceMemory: *resource.NewQuantity(requests.Memory().Value()*sizingMemoryLimitFactor,
+			resource.BinarySI),
+	}, requests, sizing.MaxResources)
+
+	if previous != nil && withinTolerance(previous.Requests, requests) &&
+		withinTolerance(previous.Limits, limits) {
+		return previous
+	}
+	return &corev1.ResourceRequirements{Requests: requests, Limits: limits}
+}
+
+// clampResources raises the resources to the minimum and lowers them to the maximum.
+func clampResources(resources, min, max corev1.ResourceList) corev1.ResourceList {
+	for name, quantity := range resources {
+		if q, ok := min[name]; ok && quantity.Cmp(q) < 0 {
+			quantity = q.DeepCopy()
+		}
+		if q, ok := max[name]; ok && quantity.Cmp(q) > 0 {
+			quantity = q.DeepCopy()
+		}
+		resources[name] = quantity
+	}
+	return resources
+}
+
+// withinTolerance returns true if the resources have the same names and their quantities do not differ
+// from the previous ones by more than the tolerance.
+func withinTolerance(previous, resources corev1.ResourceList) bool {
+	if len(previous) != len(resources) {
+		return false
+	}
+	for name, quantity := range resources {
+		q, ok := previous[name]
+		if !ok {
+			return false
+		}
+		previousValue := float64(q.MilliValue())
+		if previousValue == 0 {
+			if quantity.MilliValue() != 0 {
+				return false
+			}
+			continue
+		}
+		if math.Abs(float64(quantity.MilliValue())-previousValue)/previousValue > sizingTolerance {
+			return false
+		}
+	}
+	return true
+}
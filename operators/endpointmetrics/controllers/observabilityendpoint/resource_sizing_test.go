@@ -0,0 +1,143 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	oashared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	oav1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+)
+
+func TestRecommendResources(t *testing.T) {
+	sizing := &oashared.ResourceAutoSizing{
+		Enabled: true,
+		MinResources: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("20m"),
+			corev1.ResourceMemory: resource.MustParse("100Mi"),
+		},
+		MaxResources: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+	caseList := []struct {
+		name           string
+		status         *oav1beta1.MetricsCollectorStatus
+		expectedCPU    string
+		expectedMemory string
+		expectedLimit  string
+	}{
+		{
+			name:           "minimum",
+			status:         &oav1beta1.MetricsCollectorStatus{SeriesSent: 100},
+			expectedCPU:    "20m",
+			expectedMemory: "100Mi",
+			expectedLimit:  "200Mi",
+		},
+		{
+			name:           "sized from the series",
+			status:         &oav1beta1.MetricsCollectorStatus{SeriesSent: 100000, MemoryUsageBytes: 100 * 1024 * 1024},
+			expectedCPU:    "210m",
+			expectedMemory: "260Mi",
+			expectedLimit:  "520Mi",
+		},
+		{
+			name:           "sized from the memory usage",
+			status:         &oav1beta1.MetricsCollectorStatus{SeriesSent: 10000, MemoryUsageBytes: 300 * 1024 * 1024},
+			expectedCPU:    "30m",
+			expectedMemory: "390Mi",
+			expectedLimit:  "780Mi",
+		},
+		{
+			name:           "maximum",
+			status:         &oav1beta1.MetricsCollectorStatus{SeriesSent: 1000000},
+			expectedCPU:    "2010m",
+			expectedMemory: "1Gi",
+			expectedLimit:  "1Gi",
+		},
+	}
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			recommended := recommendResources(sizing, c.status)
+			if recommended.Requests.Cpu().Cmp(resource.MustParse(c.expectedCPU)) != 0 ||
+				recommended.Requests.Memory().Cmp(resource.MustParse(c.expectedMemory)) != 0 ||
+				recommended.Limits.Memory().Cmp(resource.MustParse(c.expectedLimit)) != 0 {
+				t.Errorf("recommended resources (%v) are not the expected: (%s, %s, %s)",
+					recommended, c.expectedCPU, c.expectedMemory, c.expectedLimit)
+			}
+		})
+	}
+
+	status := &oav1beta1.MetricsCollectorStatus{SeriesSent: 100000}
+	status.RecommendedResources = recommendResources(sizing, status)
+	status.SeriesSent = 110000
+	if recommended := recommendResources(sizing, status); recommended != status.RecommendedResources {
+		t.Errorf("the previous recommendation should be kept within the tolerance: (%v)", recommended)
+	}
+	status.SeriesSent = 200000
+	if recommended := recommendResources(sizing, status); recommended == status.RecommendedResources {
+		t.Errorf("the recommendation should be updated beyond the tolerance: (%v)", recommended)
+	}
+}
+
+func TestUpdateResourceRecommendations(t *testing.T) {
+	ctx := context.TODO()
+	obsAddon := &oav1beta1.ObservabilityAddon{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      obAddonName,
+			Namespace: namespace,
+		},
+		Spec: oashared.ObservabilityAddonSpec{
+			EnableMetrics:      true,
+			ResourceAutoSizing: &oashared.ResourceAutoSizing{Enabled: true},
+		},
+		Status: oav1beta1.ObservabilityAddonStatus{
+			MetricsCollector: &oav1beta1.MetricsCollectorStatus{SeriesSent: 100000},
+		},
+	}
+	c := fake.NewClientBuilder().WithRuntimeObjects(obsAddon).Build()
+
+	resources := updateResourceRecommendations(ctx, c, obsAddon)
+	if resources.platform != nil || resources.uwl != nil {
+		t.Errorf("the recommendations should not be applied without auto apply: (%v)", resources)
+	}
+	found := &oav1beta1.ObservabilityAddon{}
+	err := c.Get(ctx, types.NamespacedName{Name: obAddonName, Namespace: namespace}, found)
+	if err != nil {
+		t.Fatalf("Failed to get the observabilityaddon: (%v)", err)
+	}
+	if found.Status.MetricsCollector.RecommendedResources == nil || found.Status.UWLMetricsCollector != nil {
+		t.Errorf("the recommendation should be recorded for the platform collector: (%v)", found.Status)
+	}
+
+	found.Spec.ResourceAutoSizing.AutoApply = true
+	resources = updateResourceRecommendations(ctx, c, found)
+	if resources.platform == nil || resources.uwl != nil {
+		t.Errorf("the recommendation of the platform collector should be applied: (%v)", resources)
+	}
+	params := CollectorParams{
+		clusterID:    testClusterID,
+		obsAddonSpec: found.Spec,
+		resources:    resources.platform,
+		replicaCount: 1,
+	}
+	deployment := createDeployment(params)
+	if deployment.Spec.Template.Spec.Containers[0].Resources.Requests.Memory().Cmp(
+		*resources.platform.Requests.Memory()) != 0 {
+		t.Errorf("the recommended resources should be set on the deployment: (%v)",
+			deployment.Spec.Template.Spec.Containers[0].Resources)
+	}
+
+	found.Spec.ResourceAutoSizing = nil
+	updateResourceRecommendations(ctx, c, found)
+	if found.Status.MetricsCollector.RecommendedResources != nil {
+		t.Errorf("the recommendation should be removed when the auto sizing is disabled")
+	}
+}
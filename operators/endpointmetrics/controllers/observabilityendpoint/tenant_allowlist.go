@@ -0,0 +1,184 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/prometheus/prometheus/promql/parser"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+)
+
+const unsupportedEntryReason = "only names and matches are supported in a namespace allowlist"
+
+var metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// tenantAllowlistStatus is the content of the status annotation of a namespace allowlist configmap.
+type tenantAllowlistStatus struct {
+	Accepted             []string                 `json:"accepted"`
+	Rejected             []rejectedAllowlistEntry `json:"rejected,omitempty"`
+	NamespaceSeriesQuota int                      `json:"namespaceSeriesQuota,omitempty"`
+}
+
+type rejectedAllowlistEntry struct {
+	Entry  string `json:"entry"`
+	Reason string `json:"reason"`
+}
+
+// getTenantAllowlists reads the uwl allowlists which application teams define in their own
+// namespace with a configmap labelled with operatorconfig.AllowlistNamespaceLabelKey.
+// The accepted entries are scoped to the namespace of the configmap and the result of the
+// validation is reported in the status annotation of the configmap. It returns the merged
+// allowlist and the namespaces which have at least one accepted entry.
+func getTenantAllowlists(ctx context.Context, c client.Client,
+	quota int) (operatorconfig.MetricsAllowlist, []string) {
+	merged := operatorconfig.MetricsAllowlist{MatchList: []string{}}
+	namespaces := []string{}
+
+	cmList := &corev1.ConfigMapList{}
+	err := c.List(ctx, cmList, client.HasLabels{operatorconfig.AllowlistNamespaceLabelKey})
+	if err != nil {
+		log.Error(err, "Failed to list namespace allowlist configmaps")
+		return merged, namespaces
+	}
+	sort.Slice(cmList.Items, func(i, j int) bool {
+		if cmList.Items[i].Namespace != cmList.Items[j].Namespace {
+			return cmList.Items[i].Namespace < cmList.Items[j].Namespace
+		}
+		return cmList.Items[i].Name < cmList.Items[j].Name
+	})
+
+	seen := map[string]bool{}
+	for i := range cmList.Items {
+		cm := &cmList.Items[i]
+		// the allowlists of the observability namespace are managed from the hub and the
+		// custom allowlists are already merged with their namespace label injected
+		if cm.Namespace == namespace || cm.Name == operatorconfig.AllowlistCustomConfigMapName {
+			continue
+		}
+
+		allowlist, status := parseTenantAllowlist(cm)
+		status.NamespaceSeriesQuota = quota
+		if len(status.Accepted) > 0 && !seen[cm.Namespace] {
+			seen[cm.Namespace] = true
+			namespaces = append(namespaces, cm.Namespace)
+		}
+		for _, match := range injectNamespaceLabel(allowlist, cm.Namespace).MatchList {
+			if !contains(merged.MatchList, match) {
+				merged.MatchList = append(merged.MatchList, match)
+			}
+		}
+
+		if err := updateTenantAllowlistStatus(ctx, c, cm, status); err != nil {
+			log.Error(err, "Failed to update the status of namespace allowlist configmap",
+				"namespace", cm.Namespace, "name", cm.Name)
+		}
+	}
+
+	return merged, namespaces
+}
+
+// parseTenantAllowlist returns the valid names and matches of a namespace allowlist, along with
+// the status listing the accepted and rejected entries.
+func parseTenantAllowlist(cm *corev1.ConfigMap) (*operatorconfig.MetricsAllowlist, tenantAllowlistStatus) {
+	accepted := &operatorconfig.MetricsAllowlist{NameList: []string{}, MatchList: []string{}}
+	status := tenantAllowlistStatus{Accepted: []string{}}
+	reject := func(entry, reason string) {
+		status.Rejected = append(status.Rejected, rejectedAllowlistEntry{Entry: entry, Reason: reason})
+	}
+
+	allowlist := &operatorconfig.MetricsAllowlist{}
+	data, ok := cm.Data[operatorconfig.UwlMetricsConfigMapKey]
+	if !ok {
+		reject(operatorconfig.UwlMetricsConfigMapKey, "the configmap does not contain the allowlist key")
+		return accepted, status
+	}
+	if err := yaml.Unmarshal([]byte(data), allowlist); err != nil {
+		reject(operatorconfig.UwlMetricsConfigMapKey, fmt.Sprintf("failed to parse the allowlist: %v", err))
+		return accepted, status
+	}
+
+	for _, name := range allowlist.NameList {
+		entry := "names: " + name
+		if !metricNameRegexp.MatchString(name) {
+			reject(entry, "invalid metric name")
+			continue
+		}
+		accepted.NameList = append(accepted.NameList, name)
+		status.Accepted = append(status.Accepted, entry)
+	}
+	for _, match := range allowlist.MatchList {
+		entry := "matches: " + match
+		if reason := validateTenantMatch(match); reason != "" {
+			reject(entry, reason)
+			continue
+		}
+		accepted.MatchList = append(accepted.MatchList, match)
+		status.Accepted = append(status.Accepted, entry)
+	}
+
+	for _, rule := range allowlist.RecordingRuleList {
+		reject("recording_rules: "+rule.Record, unsupportedEntryReason)
+	}
+	for _, rule := range allowlist.RuleList {
+		reject("rules: "+rule.Record, unsupportedEntryReason)
+	}
+	for _, group := range allowlist.CollectRuleGroupList {
+		reject("collect_rules: "+group.Name, unsupportedEntryReason)
+	}
+	renames := make([]string, 0, len(allowlist.RenameMap))
+	for k := range allowlist.RenameMap {
+		renames = append(renames, k)
+	}
+	sort.Strings(renames)
+	for _, k := range renames {
+		reject("renames: "+k, unsupportedEntryReason)
+	}
+	for _, name := range allowlist.ExemplarList {
+		reject("exemplars: "+name, unsupportedEntryReason)
+	}
+	if allowlist.NamespaceSeriesQuota != 0 {
+		reject("namespace_series_quota", "the quota can only be defined on the hub")
+	}
+
+	return accepted, status
+}
+
+// validateTenantMatch returns the reason why the match of a namespace allowlist is invalid,
+// or an empty string if it is valid.
+func validateTenantMatch(match string) string {
+	matchers, err := parser.ParseMetricSelector("{" + match + "}")
+	if err != nil {
+		return fmt.Sprintf("invalid match: %v", err)
+	}
+	for _, m := range matchers {
+		if m.Name == "namespace" {
+			return "the namespace matcher is injected and must not be set"
+		}
+	}
+	return ""
+}
+
+func updateTenantAllowlistStatus(ctx context.Context, c client.Client, cm *corev1.ConfigMap,
+	status tenantAllowlistStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	if cm.Annotations[operatorconfig.AllowlistStatusAnnotation] == string(data) {
+		return nil
+	}
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+	cm.Annotations[operatorconfig.AllowlistStatusAnnotation] = string(data)
+	return c.Update(ctx, cm)
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+package observabilityendpoint
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+)
+
+func getTenantAllowlistCM(ns, data string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "team-metrics",
+			Namespace: ns,
+			Labels:    map[string]string{operatorconfig.AllowlistNamespaceLabelKey: "true"},
+		},
+		Data: map[string]string{operatorconfig.UwlMetricsConfigMapKey: data},
+	}
+}
+
+func TestGetTenantAllowlists(t *testing.T) {
+	ctx := context.TODO()
+	c := fake.NewFakeClient(
+		getTenantAllowlistCM("team-a", `
+names:
+  - app_requests_total
+  - 1invalid
+matches:
+  - __name__="app_latency",job="app"
+  - __name__="app_errors",namespace="team-b"
+recording_rules:
+  - record: app_rate
+    expr: rate(app_requests_total[5m])
+namespace_series_quota: 1000
+`),
+		getTenantAllowlistCM("team-b", `
+names:
+  - 1invalid
+`),
+		// the allowlists in the observability namespace are managed from the hub
+		getTenantAllowlistCM(namespace, `
+names:
+  - ignored
+`),
+	)
+
+	list, namespaces := getTenantAllowlists(ctx, c, 500)
+	expectedMatches := []string{
+		`__name__="app_requests_total",namespace="team-a"`,
+		`__name__="app_latency",job="app",namespace="team-a"`,
+	}
+	if !reflect.DeepEqual(list.MatchList, expectedMatches) {
+		t.Errorf("matches (%v) are not the expected: (%v)", list.MatchList, expectedMatches)
+	}
+	if !reflect.DeepEqual(namespaces, []string{"team-a"}) {
+		t.Errorf("namespaces (%v) are not the expected: ([team-a])", namespaces)
+	}
+
+	cm := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Name: "team-metrics", Namespace: "team-a"}, cm)
+	if err != nil {
+		t.Fatalf("Failed to get the namespace allowlist: (%v)", err)
+	}
+	status := tenantAllowlistStatus{}
+	err = json.Unmarshal([]byte(cm.Annotations[operatorconfig.AllowlistStatusAnnotation]), &status)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal the status annotation: (%v)", err)
+	}
+	if len(status.Accepted) != 2 || len(status.Rejected) != 4 || status.NamespaceSeriesQuota != 500 {
+		t.Errorf("status (%v) is not the expected: (2 accepted, 4 rejected, quota 500)", status)
+	}
+
+	err = c.Get(ctx, types.NamespacedName{Name: "team-metrics", Namespace: namespace}, cm)
+	if err != nil {
+		t.Fatalf("Failed to get the namespace allowlist: (%v)", err)
+	}
+	if _, ok := cm.Annotations[operatorconfig.AllowlistStatusAnnotation]; ok {
+		t.Errorf("the allowlist in the observability namespace should not be reported")
+	}
+
+	params := CollectorParams{
+		isUWL:            true,
+		clusterID:        testClusterID,
+		hubInfo:          operatorconfig.HubInfo{ClusterName: "test-cluster"},
+		allowlist:        operatorconfig.MetricsAllowlist{MatchList: list.MatchList, NamespaceSeriesQuota: 500},
+		tenantNamespaces: namespaces,
+	}
+	commands := getCommands(params)
+	if !contains(commands, "--namespace-series-limit=500") || !contains(commands, "--limit-namespace=team-a") {
+		t.Errorf("commands (%v) should limit the series of namespace team-a", commands)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -61,11 +62,14 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var diagnosticsInterval time.Duration
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8383", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&diagnosticsInterval, "diagnostics-interval", 5*time.Minute,
+		"The interval of the self-diagnostics reported as conditions of the observabilityaddon, 0 disables them.")
 	opts := zap.Options{
 		// enable development mode for more human-readable output, extra stack traces and logging information, etc
 		// disable this in final release
@@ -76,23 +80,7 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	namespaceSelector := fmt.Sprintf("metadata.namespace==%s", os.Getenv("WATCH_NAMESPACE"))
-	gvkLabelMap := map[schema.GroupVersionKind][]filteredcache.Selector{
-		v1.SchemeGroupVersion.WithKind("Secret"): []filteredcache.Selector{
-			{FieldSelector: namespaceSelector},
-		},
-		v1.SchemeGroupVersion.WithKind("ConfigMap"): []filteredcache.Selector{
-			{FieldSelector: namespaceSelector},
-			{FieldSelector: fmt.Sprintf("metadata.name==%s,metadata.namespace!=%s",
-				operatorconfig.AllowlistCustomConfigMapName, "open-cluster-management-observability")},
-		},
-		appsv1.SchemeGroupVersion.WithKind("Deployment"): []filteredcache.Selector{
-			{FieldSelector: namespaceSelector},
-		},
-		oav1beta1.GroupVersion.WithKind("ObservabilityAddon"): []filteredcache.Selector{
-			{FieldSelector: namespaceSelector},
-		},
-	}
+	gvkLabelMap := getGVKLabelMap(os.Getenv("WATCH_NAMESPACE"))
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
@@ -132,6 +120,14 @@ func main() {
 	}
 	// +kubebuilder:scaffold:builder
 
+	if err := mgr.Add(&obsepctl.Diagnostics{
+		Client:   mgr.GetClient(),
+		Interval: diagnosticsInterval,
+	}); err != nil {
+		setupLog.Error(err, "unable to set up the diagnostics")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("health", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -150,3 +146,29 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// getGVKLabelMap returns the selectors of the objects cached by the manager, the configmaps outside of the
+// watched namespace are limited to the custom allowlists and to the tenant allowlists.
+func getGVKLabelMap(watchNamespace string) map[schema.GroupVersionKind][]filteredcache.Selector {
+	namespaceSelector := fmt.Sprintf("metadata.namespace==%s", watchNamespace)
+	return map[schema.GroupVersionKind][]filteredcache.Selector{
+		v1.SchemeGroupVersion.WithKind("Secret"): []filteredcache.Selector{
+			{FieldSelector: namespaceSelector},
+		},
+		v1.SchemeGroupVersion.WithKind("ConfigMap"): []filteredcache.Selector{
+			{FieldSelector: namespaceSelector},
+			{FieldSelector: fmt.Sprintf("metadata.name==%s,metadata.namespace!=%s",
+				operatorconfig.AllowlistCustomConfigMapName, "open-cluster-management-observability")},
+			{LabelSelector: operatorconfig.AllowlistNamespaceLabelKey},
+		},
+		appsv1.SchemeGroupVersion.WithKind("Deployment"): []filteredcache.Selector{
+			{FieldSelector: namespaceSelector},
+		},
+		v1.SchemeGroupVersion.WithKind("Pod"): []filteredcache.Selector{
+			{FieldSelector: namespaceSelector},
+		},
+		oav1beta1.GroupVersion.WithKind("ObservabilityAddon"): []filteredcache.Selector{
+			{FieldSelector: namespaceSelector},
+		},
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/IBM/controller-filtered-cache/filteredcache"
+	v1 "k8s.io/api/core/v1"
+
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+)
+
+func TestGetGVKLabelMap(t *testing.T) {
+	selectors := getGVKLabelMap("open-cluster-management-addon-observability")[v1.SchemeGroupVersion.WithKind("ConfigMap")]
+
+	// the tenant allowlists of the application namespaces are cached
+	expected := filteredcache.Selector{LabelSelector: operatorconfig.AllowlistNamespaceLabelKey}
+	found := false
+	for _, selector := range selectors {
+		if selector == expected {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("configmap selectors (%v) do not include the expected: (%v)", selectors, expected)
+	}
+
+	expected = filteredcache.Selector{FieldSelector: "metadata.namespace==open-cluster-management-addon-observability"}
+	if len(selectors) == 0 || selectors[0] != expected {
+		t.Errorf("configmap selectors (%v) do not include the expected: (%v)", selectors, expected)
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package rendering
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	oashared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
+)
+
+const (
+	kubeStateMetricsPrefix = "kube_"
+	nodeExporterPrefix     = "node_"
+)
+
+// nodeExporterCollectors maps the prefixes of the node-exporter metrics to the collector exposing them.
+var nodeExporterCollectors = map[string]string{
+	"node_cpu_":          "cpu",
+	"node_disk_":         "diskstats",
+	"node_filesystem_":   "filesystem",
+	"node_load":          "loadavg",
+	"node_md_":           "mdadm",
+	"node_memory_":       "meminfo",
+	"node_network_":      "netdev",
+	"node_nf_conntrack_": "conntrack",
+	"node_textfile_":     "textfile",
+	"node_timex_":        "timex",
+	"node_uname_":        "uname",
+	"node_vmstat_":       "vmstat",
+}
+
+// metricNameMatcher tells whether a metric name is referenced by the allowlist: listed in the names,
+// selected by a match or used in the expression of a recording rule or a collect rule.
+type metricNameMatcher struct {
+	names    map[string]bool
+	matchers []*labels.Matcher
+	// set if a reference could not be resolved, every metric is then considered as referenced
+	matchAll bool
+}
+
+func newMetricNameMatcher(list operatorconfig.MetricsAllowlist) *metricNameMatcher {
+	m := &metricNameMatcher{names: map[string]bool{}}
+	for _, name := range list.NameList {
+		m.names[name] = true
+	}
+	for _, match := range list.MatchList {
+		m.addSelector("{" + match + "}")
+	}
+	for _, rule := range list.RecordingRuleList {
+		m.addSelector(rule.Expr)
+	}
+	for _, rule := range list.RuleList {
+		m.addSelector(rule.Expr)
+	}
+	for _, group := range list.CollectRuleGroupList {
+		for _, rule := range group.CollectRuleList {
+			m.addSelector(rule.Expr)
+			for _, name := range rule.Metrics.NameList {
+				m.names[name] = true
+			}
+			for _, match := range rule.Metrics.MatchList {
+				m.addSelector("{" + match + "}")
+			}
+		}
+	}
+	return m
+}
+
+// addPrometheusRules records the metric names used in the expressions of the rendered PrometheusRules,
+// the exporters must keep the series the rules are evaluated on.
+func (m *metricNameMatcher) addPrometheusRules(resources []*unstructured.Unstructured) {
+	for _, res := range resources {
+		if res.GetKind() != "PrometheusRule" {
+			continue
+		}
+		groups, _, _ := unstructured.NestedSlice(res.Object, "spec", "groups")
+		for _, group := range groups {
+			g, ok := group.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			rules, _, _ := unstructured.NestedSlice(g, "rules")
+			for _, rule := range rules {
+				r, ok := rule.(map[string]interface{})
+				if !ok || r["expr"] == nil {
+					continue
+				}
+				m.addSelector(fmt.Sprint(r["expr"]))
+			}
+		}
+	}
+}
+
+// addSelector records the metric names selected by the vector selectors of the expression.
+func (m *metricNameMatcher) addSelector(expr string) {
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		log.Error(err, "Failed to parse the allowlist expression, no metric is disabled", "expr", expr)
+		m.matchAll = true
+		return
+	}
+	parser.Inspect(parsed, func(node parser.Node, _ []parser.Node) error {
+		vs, ok := node.(*parser.VectorSelector)
+		if !ok {
+			return nil
+		}
+		for _, lm := range vs.LabelMatchers {
+			if lm.Name != labels.MetricName {
+				continue
+			}
+			if lm.Type == labels.MatchEqual {
+				m.names[lm.Value] = true
+			} else {
+				m.matchers = append(m.matchers, lm)
+			}
+		}
+		return nil
+	})
+}
+
+func (m *metricNameMatcher) matches(name string) bool {
+	if m.matchAll || m.names[name] {
+		return true
+	}
+	for _, lm := range m.matchers {
+		if lm.Matches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// kubeStateMetricsAllowlist returns the --metric-allowlist of kube-state-metrics, the patterns of the
+// referenced kube-state-metrics metrics. False is returned if the metrics cannot be restricted.
+func (m *metricNameMatcher) kubeStateMetricsAllowlist() (string, bool) {
+	if m.matchAll {
+		return "", false
+	}
+	patterns := []string{}
+	for name := range m.names {
+		if strings.HasPrefix(name, kubeStateMetricsPrefix) {
+			patterns = append(patterns, "^"+regexp.QuoteMeta(name)+"$")
+		}
+	}
+	for _, lm := range m.matchers {
+		// the negative matchers select almost every metric, and the patterns are comma separated
+		if lm.Type != labels.MatchRegexp || strings.Contains(lm.Value, ",") {
+			return "", false
+		}
+		patterns = append(patterns, "^(?:"+lm.Value+")$")
+	}
+	if len(patterns) == 0 {
+		return "", false
+	}
+	sort.Strings(patterns)
+	return strings.Join(patterns, ","), true
+}
+
+// nodeExporterCollectors returns the node-exporter collectors exposing the referenced node-exporter metrics.
+func (m *metricNameMatcher) nodeExporterCollectors() []string {
+	collectors := []string{}
+	for name := range m.names {
+		if !strings.HasPrefix(name, nodeExporterPrefix) {
+			continue
+		}
+		for prefix, collector := range nodeExporterCollectors {
+			if strings.HasPrefix(name, prefix) && !util.Contains(collectors, collector) {
+				collectors = append(collectors, collector)
+			}
+		}
+	}
+	sort.Strings(collectors)
+	return collectors
+}
+
+// getAllowlist reads the default metrics allowlist, an empty allowlist is returned if it does not exist.
+func getAllowlist(c runtimeclient.Client) (operatorconfig.MetricsAllowlist, error) {
+	list := operatorconfig.MetricsAllowlist{}
+	cm := &corev1.ConfigMap{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: operatorconfig.AllowlistConfigMapName,
+		Namespace: namespace}, cm)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("The metrics allowlist does not exist yet")
+			return list, nil
+		}
+		return list, err
+	}
+	err = yaml.Unmarshal([]byte(cm.Data[metricsConfigMapKey]), &list)
+	if err != nil {
+		log.Error(err, "Failed to unmarshal the metrics allowlist")
+		return list, err
+	}
+	return list, nil
+}
+
+// getDisabledMetrics returns the regex of the expensive metrics dropped at scrape time, those referenced
+// by the allowlist are kept.
+func getDisabledMetrics(matcher *metricNameMatcher) string {
+	metricsList := []string{}
+	for _, m := range disabledMetrics {
+		if !matcher.matches(m) {
+			metricsList = append(metricsList, m)
+		}
+	}
+	return strings.Join(metricsList, "|")
+}
+
+// getNodeExporterArgs enables only the selected node-exporter collectors, and those exposing the metrics
+// referenced by the allowlist or the rules.
+func getNodeExporterArgs(args []string, exporters *oashared.BundledExporters, matcher *metricNameMatcher) []string {
+	if exporters == nil || len(exporters.NodeExporterCollectors) == 0 {
+		return args
+	}
+	updated := []string{}
+	for _, arg := range args {
+		// the collectors are disabled by default
+		if !strings.HasPrefix(arg, "--no-collector.") {
+			updated = append(updated, arg)
+		}
+	}
+	updated = append(updated, "--collector.disable-defaults")
+	for _, collector := range exporters.NodeExporterCollectors {
+		updated = append(updated, "--collector."+collector)
+	}
+	for _, collector := range matcher.nodeExporterCollectors() {
+		if !util.Contains(exporters.NodeExporterCollectors, collector) {
+			log.Info("The node-exporter collector is enabled for the referenced metrics", "collector", collector)
+			updated = append(updated, "--collector."+collector)
+		}
+	}
+	return updated
+}
+
+// getKubeStateMetricsArgs restricts the resources and the metrics of kube-state-metrics.
+func getKubeStateMetricsArgs(args []string, exporters *oashared.BundledExporters,
+	matcher *metricNameMatcher) []string {
+	if exporters == nil {
+		return args
+	}
+	if len(exporters.KubeStateMetricsResources) > 0 {
+		args = append(args, "--resources="+strings.Join(exporters.KubeStateMetricsResources, ","))
+	}
+	if exporters.AllowlistedKubeStateMetrics {
+		if allowlist, ok := matcher.kubeStateMetricsAllowlist(); ok {
+			args = append(args, "--metric-allowlist="+allowlist)
+		} else {
+			log.Info("The kube-state-metrics metrics cannot be restricted to the allowlist")
+		}
+	}
+	return args
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package rendering
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	oashared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+)
+
+func TestGetDisabledMetrics(t *testing.T) {
+	caseList := []struct {
+		name      string
+		allowlist operatorconfig.MetricsAllowlist
+		enabled   []string
+	}{
+		{
+			name:      "listed in the names",
+			allowlist: operatorconfig.MetricsAllowlist{NameList: []string{"apiserver_watch_events_sizes_bucket"}},
+			enabled:   []string{"apiserver_watch_events_sizes_bucket"},
+		},
+		{
+			name: "name is a prefix of the disabled metric",
+			allowlist: operatorconfig.MetricsAllowlist{
+				NameList: []string{"apiserver_watch_events_sizes", "etcd_request_duration_seconds"},
+			},
+		},
+		{
+			name: "selected by a match",
+			allowlist: operatorconfig.MetricsAllowlist{
+				MatchList: []string{`__name__=~"rest_client_.*",job="apiserver"`},
+			},
+			enabled: []string{"rest_client_request_duration_seconds_bucket"},
+		},
+		{
+			name: "used by a recording rule",
+			allowlist: operatorconfig.MetricsAllowlist{
+				RecordingRuleList: []operatorconfig.RecordingRule{{
+					Record: "apiserver_response_sizes:p99",
+					Expr:   "histogram_quantile(0.99, sum(rate(apiserver_response_sizes_bucket[5m])) by (le))",
+				}},
+			},
+			enabled: []string{"apiserver_response_sizes_bucket"},
+		},
+	}
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			disabled := strings.Split(getDisabledMetrics(newMetricNameMatcher(c.allowlist)), "|")
+			if len(disabled) != len(disabledMetrics)-len(c.enabled) {
+				t.Errorf("disabled metrics (%v) should not include (%v)", disabled, c.enabled)
+			}
+			for _, name := range c.enabled {
+				for _, d := range disabled {
+					if d == name {
+						t.Errorf("metric %s is referenced by the allowlist, it should not be disabled", name)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGetNodeExporterArgs(t *testing.T) {
+	args := []string{"--web.listen-address=127.0.0.1:9100", "--no-collector.wifi", "--no-collector.hwmon"}
+	matcher := newMetricNameMatcher(operatorconfig.MetricsAllowlist{
+		NameList: []string{"node_cpu_seconds_total", "node_memory_MemTotal_bytes", "up"},
+	})
+	if updated := getNodeExporterArgs(args, nil, matcher); !reflect.DeepEqual(updated, args) {
+		t.Errorf("args (%v) should not be changed without the bundled exporters", updated)
+	}
+	updated := getNodeExporterArgs(args, &oashared.BundledExporters{NodeExporterCollectors: []string{"cpu", "hwmon"}},
+		matcher)
+	expected := []string{"--web.listen-address=127.0.0.1:9100", "--collector.disable-defaults",
+		"--collector.cpu", "--collector.hwmon", "--collector.meminfo"}
+	if !reflect.DeepEqual(updated, expected) {
+		t.Errorf("args (%v) are not the expected: (%v)", updated, expected)
+	}
+}
+
+func TestGetKubeStateMetricsArgs(t *testing.T) {
+	exporters := &oashared.BundledExporters{
+		KubeStateMetricsResources:   []string{"pods", "nodes"},
+		AllowlistedKubeStateMetrics: true,
+	}
+	matcher := newMetricNameMatcher(operatorconfig.MetricsAllowlist{
+		NameList:  []string{"kube_pod_info", "up"},
+		MatchList: []string{`__name__=~"kube_node_status_.+"`},
+	})
+	updated := getKubeStateMetricsArgs([]string{"--port=8081"}, exporters, matcher)
+	expected := []string{"--port=8081", "--resources=pods,nodes",
+		"--metric-allowlist=^(?:kube_node_status_.+)$,^kube_pod_info$"}
+	if !reflect.DeepEqual(updated, expected) {
+		t.Errorf("args (%v) are not the expected: (%v)", updated, expected)
+	}
+
+	matcher = newMetricNameMatcher(operatorconfig.MetricsAllowlist{
+		NameList:  []string{"kube_pod_info"},
+		MatchList: []string{`__name__!="up"`},
+	})
+	updated = getKubeStateMetricsArgs([]string{"--port=8081"}, exporters, matcher)
+	expected = []string{"--port=8081", "--resources=pods,nodes"}
+	if !reflect.DeepEqual(updated, expected) {
+		t.Errorf("the metrics should not be restricted with a negative matcher: (%v)", updated)
+	}
+}
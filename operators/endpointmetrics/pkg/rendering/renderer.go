@@ -4,7 +4,6 @@
 package rendering
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -14,11 +13,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/stolostron/multicluster-observability-operator/operators/endpointmetrics/pkg/rendering/templates"
+	oashared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
 	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 	rendererutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/rendering"
 	templatesutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/rendering/templates"
@@ -53,6 +52,7 @@ func Render(
 	r *rendererutil.Renderer,
 	c runtimeclient.Client,
 	hubInfo *operatorconfig.HubInfo,
+	obsAddonSpec oashared.ObservabilityAddonSpec,
 ) ([]*unstructured.Unstructured, error) {
 
 	genericTemplates, err := templates.GetTemplates(templatesutil.GetTemplateRenderer())
@@ -63,6 +63,13 @@ func Render(
 	if err != nil {
 		return nil, err
 	}
+	allowlist, err := getAllowlist(c)
+	if err != nil {
+		return nil, err
+	}
+	matcher := newMetricNameMatcher(allowlist)
+	// the default rules are evaluated on the series of the exporters too
+	matcher.addPrometheusRules(resources)
 	for idx := range resources {
 		if resources[idx].GetKind() == "Deployment" && resources[idx].GetName() == "kube-state-metrics" {
 			obj := util.GetK8sObj(resources[idx].GetKind())
@@ -73,6 +80,8 @@ func Render(
 			dep := obj.(*v1.Deployment)
 			spec := &dep.Spec.Template.Spec
 			spec.Containers[0].Image = Images[operatorconfig.KubeStateMetricsKey]
+			spec.Containers[0].Args = getKubeStateMetricsArgs(spec.Containers[0].Args,
+				obsAddonSpec.BundledExporters, matcher)
 			spec.Containers[1].Image = Images[operatorconfig.KubeRbacProxyKey]
 			spec.Containers[2].Image = Images[operatorconfig.KubeRbacProxyKey]
 			spec.ImagePullSecrets = []corev1.LocalObjectReference{
@@ -155,6 +164,8 @@ func Render(
 			ds := obj.(*v1.DaemonSet)
 			spec := &ds.Spec.Template.Spec
 			spec.Containers[0].Image = Images[operatorconfig.NodeExporterKey]
+			spec.Containers[0].Args = getNodeExporterArgs(spec.Containers[0].Args,
+				obsAddonSpec.BundledExporters, matcher)
 			spec.Containers[1].Image = Images[operatorconfig.KubeRbacProxyKey]
 			spec.ImagePullSecrets = []corev1.LocalObjectReference{
 				{Name: os.Getenv(operatorconfig.PullSecret)},
@@ -166,7 +177,7 @@ func Render(
 			}
 			resources[idx].Object = unstructuredObj
 		}
-		if resources[idx].GetKind() == "Secret" && resources[idx].GetName() == "prometheus-scrape-targets " {
+		if resources[idx].GetKind() == "Secret" && resources[idx].GetName() == "prometheus-scrape-targets" {
 			obj := util.GetK8sObj(resources[idx].GetKind())
 			err := runtime.DefaultUnstructuredConverter.FromUnstructured(resources[idx].Object, obj)
 			if err != nil {
@@ -183,10 +194,7 @@ func Render(
 			}
 
 			// replace the disabled metrics
-			disabledMetricsSt, err := getDisabledMetrics(c)
-			if err != nil {
-				return nil, err
-			}
+			disabledMetricsSt := getDisabledMetrics(matcher)
 			if disabledMetricsSt != "" {
 				s.StringData["scrape-targets.yaml"] = strings.ReplaceAll(promConfig, "_DISABLED_METRICS_", disabledMetricsSt)
 			}
@@ -228,18 +236,11 @@ func Render(
 	return resources, nil
 }
 
-func getDisabledMetrics(c runtimeclient.Client) (string, error) {
-	cm := &corev1.ConfigMap{}
-	err := c.Get(context.TODO(), types.NamespacedName{Name: operatorconfig.AllowlistConfigMapName,
-		Namespace: namespace}, cm)
+// RenderPrometheusRules renders the default prometheus rules for *KS clusters.
+func RenderPrometheusRules(r *rendererutil.Renderer) ([]*unstructured.Unstructured, error) {
+	ruleTemplates, err := templates.GetPrometheusRuleTemplates(templatesutil.GetTemplateRenderer())
 	if err != nil {
-		return "", err
-	}
-	metricsList := []string{}
-	for _, m := range disabledMetrics {
-		if !strings.Contains(cm.Data[metricsConfigMapKey], m) {
-			metricsList = append(metricsList, m)
-		}
+		return nil, err
 	}
-	return strings.Join(metricsList, "|"), nil
+	return r.RenderTemplates(ruleTemplates, namespace, map[string]string{})
 }
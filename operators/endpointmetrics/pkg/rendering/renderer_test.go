@@ -6,6 +6,8 @@ package rendering
 import (
 	"os"
 	"path"
+	"regexp"
+	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
@@ -14,9 +16,11 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	oashared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
 	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 	rendererutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/rendering"
 	templatesutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/rendering/templates"
+	"github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
 )
 
 func getAllowlistCM() *corev1.ConfigMap {
@@ -52,12 +56,133 @@ func TestRender(t *testing.T) {
 
 	c := fake.NewFakeClient([]runtime.Object{getAllowlistCM()}...)
 
-	objs, err := Render(renderer, c, hubInfo)
+	objs, err := Render(renderer, c, hubInfo, oashared.ObservabilityAddonSpec{})
 	if err != nil {
 		t.Fatalf("failed to render endpoint templates: %v", err)
 	}
 
 	printObjs(t, objs)
+	found := false
+	for _, obj := range objs {
+		if obj.GetKind() != "Secret" || obj.GetName() != "prometheus-scrape-targets" {
+			continue
+		}
+		found = true
+		targets, _, _ := unstructured.NestedString(obj.Object, "stringData", "scrape-targets.yaml")
+		if strings.Contains(targets, "_DISABLED_METRICS_") ||
+			!strings.Contains(targets, "etcd_request_duration_seconds_bucket") {
+			t.Errorf("the disabled metrics are not set in the scrape targets")
+		}
+		if strings.Contains(targets, "apiserver_watch_events_sizes_bucket") {
+			t.Errorf("the allowlisted metric should not be disabled")
+		}
+	}
+	if !found {
+		t.Errorf("the scrape targets are not rendered")
+	}
+}
+
+func TestRenderPrometheusRules(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working dir %v", err)
+	}
+	templatesPath := path.Join(path.Dir(path.Dir(wd)), "manifests")
+	os.Setenv(templatesutil.TemplatesPathEnvVar, templatesPath)
+	defer os.Unsetenv(templatesutil.TemplatesPathEnvVar)
+
+	objs, err := RenderPrometheusRules(rendererutil.NewRenderer())
+	if err != nil {
+		t.Fatalf("failed to render prometheus rules: %v", err)
+	}
+	found := false
+	for _, obj := range objs {
+		if obj.GetKind() != "PrometheusRule" {
+			t.Errorf("the kind of %s (%s) is not PrometheusRule", obj.GetName(), obj.GetKind())
+		}
+		if obj.GetName() == "watchdog-rules" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("the watchdog rules are not rendered")
+	}
+}
+
+func TestRenderExportersKeepRuleMetrics(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working dir %v", err)
+	}
+	templatesPath := path.Join(path.Dir(path.Dir(wd)), "manifests")
+	os.Setenv(templatesutil.TemplatesPathEnvVar, templatesPath)
+	defer os.Unsetenv(templatesutil.TemplatesPathEnvVar)
+
+	rules, err := RenderPrometheusRules(rendererutil.NewRenderer())
+	if err != nil {
+		t.Fatalf("failed to render prometheus rules: %v", err)
+	}
+	referenced := newMetricNameMatcher(operatorconfig.MetricsAllowlist{})
+	referenced.addPrometheusRules(rules)
+	if referenced.matchAll {
+		t.Fatalf("the expressions of the rules should be parsed")
+	}
+
+	c := fake.NewFakeClient([]runtime.Object{getAllowlistCM()}...)
+	objs, err := Render(rendererutil.NewRenderer(), c, &operatorconfig.HubInfo{ClusterName: "foo"},
+		oashared.ObservabilityAddonSpec{BundledExporters: &oashared.BundledExporters{
+			NodeExporterCollectors:      []string{"cpu"},
+			AllowlistedKubeStateMetrics: true,
+		}})
+	if err != nil {
+		t.Fatalf("failed to render endpoint templates: %v", err)
+	}
+	args := map[string][]string{}
+	for _, obj := range objs {
+		if (obj.GetKind() == "Deployment" && obj.GetName() == "kube-state-metrics") ||
+			(obj.GetKind() == "DaemonSet" && obj.GetName() == "node-exporter") {
+			containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+			args[obj.GetName()], _, _ = unstructured.NestedStringSlice(containers[0].(map[string]interface{}), "args")
+		}
+	}
+	ksmAllowlist := []*regexp.Regexp{}
+	for _, arg := range args["kube-state-metrics"] {
+		if strings.HasPrefix(arg, "--metric-allowlist=") {
+			for _, pattern := range strings.Split(strings.TrimPrefix(arg, "--metric-allowlist="), ",") {
+				ksmAllowlist = append(ksmAllowlist, regexp.MustCompile(pattern))
+			}
+		}
+	}
+	if len(ksmAllowlist) == 0 {
+		t.Fatalf("the kube-state-metrics metrics should be restricted: (%v)", args["kube-state-metrics"])
+	}
+
+	for name := range referenced.names {
+		if strings.Contains(name, ":") {
+			// recorded by the rules
+			continue
+		}
+		switch {
+		case strings.HasPrefix(name, kubeStateMetricsPrefix):
+			kept := false
+			for _, pattern := range ksmAllowlist {
+				kept = kept || pattern.MatchString(name)
+			}
+			if !kept {
+				t.Errorf("metric %s is used by the rules, it should be kept by kube-state-metrics", name)
+			}
+		case strings.HasPrefix(name, nodeExporterPrefix):
+			kept := false
+			for prefix, collector := range nodeExporterCollectors {
+				if strings.HasPrefix(name, prefix) {
+					kept = util.Contains(args["node-exporter"], "--collector."+collector)
+				}
+			}
+			if !kept {
+				t.Errorf("metric %s is used by the rules, its node-exporter collector should be enabled", name)
+			}
+		}
+	}
 }
 
 func printObjs(t *testing.T, objs []*unstructured.Unstructured) {
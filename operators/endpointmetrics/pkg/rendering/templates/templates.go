@@ -32,3 +32,12 @@ func GetTemplates(r *templates.TemplateRenderer) ([]*resource.Resource, error) {
 
 	return resourceList, nil
 }
+
+// GetPrometheusRuleTemplates reads the default prometheus rules for *KS clusters
+func GetPrometheusRuleTemplates(r *templates.TemplateRenderer) ([]*resource.Resource, error) {
+	resourceList := []*resource.Resource{}
+	if err := r.AddTemplateFromPath(r.GetTemplatesPath()+"/prometheus/prometheusrules", &resourceList); err != nil {
+		return resourceList, err
+	}
+	return resourceList, nil
+}
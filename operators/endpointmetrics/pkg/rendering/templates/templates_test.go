@@ -26,3 +26,24 @@ func TestGetCoreTemplates(t *testing.T) {
 		t.Fatalf("failed to render core template %v", err)
 	}
 }
+
+func TestGetPrometheusRuleTemplates(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working dir %v", err)
+	}
+	templatesPath := path.Join(path.Dir(path.Dir(path.Dir(wd))), "manifests")
+
+	rules, err := GetPrometheusRuleTemplates(templatesutil.NewTemplateRenderer(templatesPath))
+	if err != nil {
+		t.Fatalf("failed to render prometheus rule templates %v", err)
+	}
+	if len(rules) == 0 {
+		t.Fatalf("no prometheus rule is rendered")
+	}
+	for _, rule := range rules {
+		if rule.GetKind() != "PrometheusRule" {
+			t.Errorf("the kind of %s (%s) is not PrometheusRule", rule.GetName(), rule.GetKind())
+		}
+	}
+}
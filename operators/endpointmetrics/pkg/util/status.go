@@ -32,8 +32,10 @@ var (
 	}
 )
 
+// ReportStatus replaces the status condition of the observabilityaddon, the conditions reported
+// with SetStatusCondition are kept.
 func ReportStatus(ctx context.Context, client client.Client, i *oav1beta1.ObservabilityAddon, t string) {
-	i.Status.Conditions = []oav1beta1.StatusCondition{
+	statusConditions := []oav1beta1.StatusCondition{
 		{
 			Type:               conditions[t]["type"],
 			Status:             metav1.ConditionTrue,
@@ -42,8 +44,89 @@ func ReportStatus(ctx context.Context, client client.Client, i *oav1beta1.Observ
 			Message:            conditions[t]["message"],
 		},
 	}
+	for _, c := range i.Status.Conditions {
+		if !isStatusConditionType(c.Type) {
+			statusConditions = append(statusConditions, c)
+		}
+	}
+	i.Status.Conditions = statusConditions
+	err := client.Status().Update(ctx, i)
+	if err != nil {
+		log.Error(err, "Failed to update status for observabilityaddon")
+	}
+}
+
+// SetStatusCondition adds or updates a condition of the observabilityaddon, next to the
+// status condition. The transition time is kept if the status of the condition is unchanged.
+func SetStatusCondition(ctx context.Context, client client.Client, i *oav1beta1.ObservabilityAddon,
+	condition oav1beta1.StatusCondition) {
+	SetStatusConditions(ctx, client, i, condition)
+}
+
+// SetStatusConditions adds or updates several conditions like SetStatusCondition, the status is
+// only updated once.
+func SetStatusConditions(ctx context.Context, client client.Client, i *oav1beta1.ObservabilityAddon,
+	conditions ...oav1beta1.StatusCondition) {
+	updated := false
+	for _, condition := range conditions {
+		if mergeStatusCondition(i, condition) {
+			updated = true
+		}
+	}
+	if !updated {
+		return
+	}
+	err := client.Status().Update(ctx, i)
+	if err != nil {
+		log.Error(err, "Failed to update status for observabilityaddon")
+	}
+}
+
+func mergeStatusCondition(i *oav1beta1.ObservabilityAddon, condition oav1beta1.StatusCondition) bool {
+	statusConditions := []oav1beta1.StatusCondition{}
+	for _, c := range i.Status.Conditions {
+		if c.Type != condition.Type {
+			statusConditions = append(statusConditions, c)
+			continue
+		}
+		if c.Status == condition.Status && c.Reason == condition.Reason && c.Message == condition.Message {
+			return false
+		}
+		if c.Status == condition.Status {
+			condition.LastTransitionTime = c.LastTransitionTime
+		}
+	}
+	if condition.LastTransitionTime.IsZero() {
+		condition.LastTransitionTime = metav1.NewTime(time.Now())
+	}
+	i.Status.Conditions = append(statusConditions, condition)
+	return true
+}
+
+// RemoveStatusCondition removes a condition added with SetStatusCondition.
+func RemoveStatusCondition(ctx context.Context, client client.Client, i *oav1beta1.ObservabilityAddon,
+	conditionType string) {
+	statusConditions := []oav1beta1.StatusCondition{}
+	for _, c := range i.Status.Conditions {
+		if c.Type != conditionType {
+			statusConditions = append(statusConditions, c)
+		}
+	}
+	if len(statusConditions) == len(i.Status.Conditions) {
+		return
+	}
+	i.Status.Conditions = statusConditions
 	err := client.Status().Update(ctx, i)
 	if err != nil {
 		log.Error(err, "Failed to update status for observabilityaddon")
 	}
 }
+
+func isStatusConditionType(conditionType string) bool {
+	for _, c := range conditions {
+		if c["type"] == conditionType {
+			return true
+		}
+	}
+	return false
+}
@@ -68,3 +68,35 @@ func TestReportStatus(t *testing.T) {
 	}
 
 }
+
+func TestSetStatusCondition(t *testing.T) {
+	oa := newObservabilityAddon(name, testNamespace)
+	s := scheme.Scheme
+	if err := oav1beta1.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add oav1beta1 scheme: (%v)", err)
+	}
+	c := fake.NewFakeClient(oa)
+
+	ReportStatus(context.TODO(), c, oa, "Deployed")
+	SetStatusCondition(context.TODO(), c, oa, oav1beta1.StatusCondition{
+		Type:    "Conflict",
+		Status:  metav1.ConditionTrue,
+		Reason:  "Conflict",
+		Message: "conflict",
+	})
+	if len(oa.Status.Conditions) != 2 || oa.Status.Conditions[1].Type != "Conflict" {
+		t.Fatalf("Error: condition not added: %+v", oa.Status.Conditions)
+	}
+
+	// the status condition is replaced, the other conditions are kept
+	ReportStatus(context.TODO(), c, oa, "Disabled")
+	if len(oa.Status.Conditions) != 2 || oa.Status.Conditions[0].Type != "Disabled" ||
+		oa.Status.Conditions[1].Type != "Conflict" {
+		t.Fatalf("Error: conditions not updated: %+v", oa.Status.Conditions)
+	}
+
+	RemoveStatusCondition(context.TODO(), c, oa, "Conflict")
+	if len(oa.Status.Conditions) != 1 || oa.Status.Conditions[0].Type != "Disabled" {
+		t.Fatalf("Error: condition not removed: %+v", oa.Status.Conditions)
+	}
+}
@@ -29,8 +29,142 @@ type ObservabilityAddonSpec struct {
 	// Resource requirement for metrics-collector
 	// +optional
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// ForwardingMode defines how the platform metrics are forwarded to hub server.
+	// With RemoteWrite, the OpenShift cluster monitoring Prometheus writes the allowlisted metrics
+	// to hub server and the metrics-collector only runs for the collect rules and recording rules.
+	// It is ignored on the clusters without the OpenShift cluster monitoring stack.
+	// +optional
+	// +kubebuilder:default:=Collector
+	ForwardingMode ForwardingMode `json:"forwardingMode,omitempty"`
+
+	// AdditionalSources are the Prometheus instances the metrics are collected from in addition
+	// to the platform and user workload Prometheus, a metrics-collector is deployed per source.
+	// +optional
+	AdditionalSources []MetricsSource `json:"additionalSources,omitempty"`
+
+	// ResourceAutoSizing adjusts the resources of the platform and user workload metrics-collectors
+	// to the series they forward and the memory they use. It overrides Resources when applied.
+	// +optional
+	ResourceAutoSizing *ResourceAutoSizing `json:"resourceAutoSizing,omitempty"`
+
+	// HostedClusterCollectors deploys a metrics-collector per HyperShift hosted cluster on the
+	// management clusters, the metrics of the hosted clusters are no longer forwarded by the
+	// platform metrics-collector. Each collector authenticates to the hub with a client certificate
+	// issued by the hub to its hosted cluster. It is ignored on the clusters without HyperShift.
+	// +optional
+	HostedClusterCollectors bool `json:"hostedClusterCollectors,omitempty"`
+
+	// BundledExporters configures the node-exporter and the kube-state-metrics deployed with the
+	// Prometheus bundled on the *KS clusters. It is ignored on the OpenShift clusters.
+	// +optional
+	BundledExporters *BundledExporters `json:"bundledExporters,omitempty"`
+}
+
+// ResourceAutoSizing configures the sizing of the metrics-collector resources from the telemetry
+// reported by the collectors.
+type ResourceAutoSizing struct {
+	// Enabled turns on the resource recommendations of the metrics-collectors.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// AutoApply sets the recommended resources on the metrics-collector deployments, otherwise
+	// the recommendations are only recorded in the status of the observability addon.
+	// +optional
+	AutoApply bool `json:"autoApply,omitempty"`
+
+	// MinResources are the lowest resources recommended.
+	// +optional
+	MinResources corev1.ResourceList `json:"minResources,omitempty"`
+
+	// MaxResources are the highest resources recommended.
+	// +optional
+	MaxResources corev1.ResourceList `json:"maxResources,omitempty"`
+}
+
+// BundledExporters selects what the node-exporter and the kube-state-metrics of the bundled Prometheus
+// collect, so that the series which are never forwarded are not scraped.
+type BundledExporters struct {
+	// NodeExporterCollectors are the node-exporter collectors enabled, e.g. cpu or meminfo. The collectors
+	// exposing the metrics used by the allowlist or the default Prometheus rules are enabled as well.
+	// The default collectors of node-exporter are enabled if empty.
+	// +optional
+	NodeExporterCollectors []string `json:"nodeExporterCollectors,omitempty"`
+
+	// KubeStateMetricsResources are the resources kube-state-metrics collects, e.g. pods or nodes.
+	// All the resources are collected if empty.
+	// +optional
+	KubeStateMetricsResources []string `json:"kubeStateMetricsResources,omitempty"`
+
+	// AllowlistedKubeStateMetrics restricts the metrics exposed by kube-state-metrics to those referenced
+	// by the metrics allowlist or the default Prometheus rules.
+	// +optional
+	AllowlistedKubeStateMetrics bool `json:"allowlistedKubeStateMetrics,omitempty"`
+}
+
+// MetricsSource is a Prometheus instance of the managed cluster the metrics are collected from.
+// The CA configmap and the token secret are read from the namespace of the observability addon.
+type MetricsSource struct {
+	// Name identifies the source, it names the metrics-collector of the source and its status condition.
+	// +required
+	// +kubebuilder:validation:MaxLength=40
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// URL of the Prometheus server to collect from. The default path of the source type is used
+	// when the URL has no path.
+	// +required
+	URL string `json:"url"`
+
+	// SourceType is the API the metrics are retrieved through, the query source runs one instant
+	// query per match rule.
+	// +optional
+	// +kubebuilder:default=federate
+	SourceType MetricsSourceType `json:"sourceType,omitempty"`
+
+	// CAConfigMap selects the CA bundle used to verify the certificate of the source.
+	// +optional
+	CAConfigMap *corev1.ConfigMapKeySelector `json:"caConfigMap,omitempty"`
+
+	// TokenSecret selects the bearer token used to authenticate to the source.
+	// +optional
+	TokenSecret *corev1.SecretKeySelector `json:"tokenSecret,omitempty"`
+
+	// Matches are the match rules of the metrics collected from the source,
+	// e.g. `{__name__="istio_requests_total"}`.
+	// +required
+	// +kubebuilder:validation:MinItems=1
+	Matches []string `json:"matches"`
+
+	// Labels are added to the metrics collected from the source.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
+// MetricsSourceType is the API the metrics are retrieved through from a metrics source.
+// +kubebuilder:validation:Enum=federate;remote-read;query
+type MetricsSourceType string
+
+const (
+	// MetricsSourceFederate retrieves the metrics from the Prometheus /federate endpoint.
+	MetricsSourceFederate MetricsSourceType = "federate"
+	// MetricsSourceRemoteRead retrieves the metrics through the Prometheus remote read API.
+	MetricsSourceRemoteRead MetricsSourceType = "remote-read"
+	// MetricsSourceQuery retrieves the metrics through the Prometheus query API.
+	MetricsSourceQuery MetricsSourceType = "query"
+)
+
+// ForwardingMode is the way the metrics are forwarded from a managed cluster to hub server.
+// +kubebuilder:validation:Enum=Collector;RemoteWrite
+type ForwardingMode string
+
+const (
+	// ForwardingModeCollector federates the metrics with the metrics-collector.
+	ForwardingModeCollector ForwardingMode = "Collector"
+	// ForwardingModeRemoteWrite configures the remote write of the in-cluster Prometheus.
+	ForwardingModeRemoteWrite ForwardingMode = "RemoteWrite"
+)
+
 type PreConfiguredStorage struct {
 	// The key of the secret to select from. Must be a valid secret key.
 	// Refer to https://thanos.io/tip/thanos/storage.md/#configuring-access-to-object-storage for a valid content of key.
@@ -25,6 +25,31 @@ import (
 	"k8s.io/api/core/v1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundledExporters) DeepCopyInto(out *BundledExporters) {
+	*out = *in
+	if in.NodeExporterCollectors != nil {
+		in, out := &in.NodeExporterCollectors, &out.NodeExporterCollectors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.KubeStateMetricsResources != nil {
+		in, out := &in.KubeStateMetricsResources, &out.KubeStateMetricsResources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundledExporters.
+func (in *BundledExporters) DeepCopy() *BundledExporters {
+	if in == nil {
+		return nil
+	}
+	out := new(BundledExporters)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
@@ -41,6 +66,43 @@ func (in *Condition) DeepCopy() *Condition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsSource) DeepCopyInto(out *MetricsSource) {
+	*out = *in
+	if in.CAConfigMap != nil {
+		in, out := &in.CAConfigMap, &out.CAConfigMap
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TokenSecret != nil {
+		in, out := &in.TokenSecret, &out.TokenSecret
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Matches != nil {
+		in, out := &in.Matches, &out.Matches
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsSource.
+func (in *MetricsSource) DeepCopy() *MetricsSource {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObservabilityAddonSpec) DeepCopyInto(out *ObservabilityAddonSpec) {
 	*out = *in
@@ -49,6 +111,23 @@ func (in *ObservabilityAddonSpec) DeepCopyInto(out *ObservabilityAddonSpec) {
 		*out = new(v1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AdditionalSources != nil {
+		in, out := &in.AdditionalSources, &out.AdditionalSources
+		*out = make([]MetricsSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ResourceAutoSizing != nil {
+		in, out := &in.ResourceAutoSizing, &out.ResourceAutoSizing
+		*out = new(ResourceAutoSizing)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BundledExporters != nil {
+		in, out := &in.BundledExporters, &out.BundledExporters
+		*out = new(BundledExporters)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityAddonSpec.
@@ -75,3 +154,32 @@ func (in *PreConfiguredStorage) DeepCopy() *PreConfiguredStorage {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceAutoSizing) DeepCopyInto(out *ResourceAutoSizing) {
+	*out = *in
+	if in.MinResources != nil {
+		in, out := &in.MinResources, &out.MinResources
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.MaxResources != nil {
+		in, out := &in.MaxResources, &out.MaxResources
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceAutoSizing.
+func (in *ResourceAutoSizing) DeepCopy() *ResourceAutoSizing {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceAutoSizing)
+	in.DeepCopyInto(out)
+	return out
+}
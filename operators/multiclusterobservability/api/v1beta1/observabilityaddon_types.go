@@ -4,6 +4,7 @@
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	observabilityshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
@@ -21,12 +22,44 @@ type StatusCondition struct {
 	Message            string                 `json:"message"`
 }
 
+// MetricsCollectorStatus contains the telemetry reported by a metrics collector
+type MetricsCollectorStatus struct {
+	// LastSuccessfulPushTime is the last time metrics were pushed to the hub successfully.
+	// +optional
+	LastSuccessfulPushTime *metav1.Time `json:"lastSuccessfulPushTime,omitempty"`
+	// SeriesSent is the number of series sent to the hub in the last interval.
+	// +optional
+	SeriesSent int64 `json:"seriesSent,omitempty"`
+	// BytesSent is the number of bytes sent to the hub in the last interval.
+	// +optional
+	BytesSent int64 `json:"bytesSent,omitempty"`
+	// ConsecutiveFailures is the number of consecutive intervals the metrics failed to be forwarded.
+	// +optional
+	ConsecutiveFailures int64 `json:"consecutiveFailures,omitempty"`
+	// CollectRuleMetrics lists the extra metrics currently collected because of fired collect rules.
+	// +optional
+	CollectRuleMetrics []string `json:"collectRuleMetrics,omitempty"`
+	// MemoryUsageBytes is the memory used by the metrics collector process.
+	// +optional
+	MemoryUsageBytes int64 `json:"memoryUsageBytes,omitempty"`
+	// RecommendedResources are the resources recommended for the metrics collector when the
+	// resource auto sizing is enabled.
+	// +optional
+	RecommendedResources *corev1.ResourceRequirements `json:"recommendedResources,omitempty"`
+}
+
 // ObservabilityAddonStatus defines the observed state of ObservabilityAddon
 type ObservabilityAddonStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
 	Conditions []StatusCondition `json:"conditions"`
+	// MetricsCollector contains the telemetry reported by the platform metrics collector.
+	// +optional
+	MetricsCollector *MetricsCollectorStatus `json:"metricsCollector,omitempty"`
+	// UWLMetricsCollector contains the telemetry reported by the user workload metrics collector.
+	// +optional
+	UWLMetricsCollector *MetricsCollectorStatus `json:"uwlMetricsCollector,omitempty"`
 }
 
 // +kubebuilder:object:root=true
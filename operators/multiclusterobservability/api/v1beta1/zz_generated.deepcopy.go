@@ -27,6 +27,35 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsCollectorStatus) DeepCopyInto(out *MetricsCollectorStatus) {
+	*out = *in
+	if in.LastSuccessfulPushTime != nil {
+		in, out := &in.LastSuccessfulPushTime, &out.LastSuccessfulPushTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CollectRuleMetrics != nil {
+		in, out := &in.CollectRuleMetrics, &out.CollectRuleMetrics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RecommendedResources != nil {
+		in, out := &in.RecommendedResources, &out.RecommendedResources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsCollectorStatus.
+func (in *MetricsCollectorStatus) DeepCopy() *MetricsCollectorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsCollectorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MultiClusterObservability) DeepCopyInto(out *MultiClusterObservability) {
 	*out = *in
@@ -216,6 +245,16 @@ func (in *ObservabilityAddonStatus) DeepCopyInto(out *ObservabilityAddonStatus)
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.MetricsCollector != nil {
+		in, out := &in.MetricsCollector, &out.MetricsCollector
+		*out = new(MetricsCollectorStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UWLMetricsCollector != nil {
+		in, out := &in.UWLMetricsCollector, &out.UWLMetricsCollector
+		*out = new(MetricsCollectorStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityAddonStatus.
@@ -38,6 +38,25 @@ type MultiClusterObservabilitySpec struct {
 	// clusters which have observability add-on enabled.
 	// +required
 	ObservabilityAddonSpec *observabilityshared.ObservabilityAddonSpec `json:"observabilityAddonSpec"`
+	// The tenants of the metrics in addition to the default tenant. The managed clusters write their metrics into
+	// the first tenant they are mapped to, into the default tenant if they are not mapped to any tenant.
+	// +optional
+	Tenants []TenantSpec `json:"tenants,omitempty"`
+}
+
+// TenantSpec is a tenant of the metrics and the managed clusters mapped to it.
+type TenantSpec struct {
+	// Name of the tenant, the name default is reserved for the default tenant.
+	// +required
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// The ManagedClusterSets whose managed clusters are mapped to the tenant.
+	// +optional
+	ClusterSets []string `json:"clusterSets,omitempty"`
+	// The label selector of the managed clusters mapped to the tenant.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
 }
 
 type AdvancedConfig struct {
@@ -65,6 +84,9 @@ type AdvancedConfig struct {
 	// spec for thanos-query-frontend
 	// +optional
 	QueryFrontend *CommonSpec `json:"queryFrontend,omitempty"`
+	// The query splitting and the results cache of thanos-query-frontend
+	// +optional
+	QueryFrontendConfig *QueryFrontendConfig `json:"queryFrontendConfig,omitempty"`
 	// spec for thanos-query
 	// +optional
 	Query *QuerySpec `json:"query,omitempty"`
@@ -89,6 +111,44 @@ type CommonSpec struct {
 	// Replicas for this component.
 	// +optional
 	Replicas *int32 `json:"replicas,omitempty"`
+	// Autoscaling scales the replicas of this component with a HorizontalPodAutoscaler, Replicas is ignored
+	// when it is set. It is supported by observatoriumAPI, query, queryFrontend, rbacQueryProxy and grafana.
+	// +optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+	// Affinity of the pods of this component, it is merged into the default pod anti-affinity. The scheduling
+	// of the pods is supported by all the components except observatoriumAPI and the memcached.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// TopologySpreadConstraints of the pods of this component.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// PriorityClassName of the pods of this component.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+}
+
+// AutoscalingSpec is the spec of the HorizontalPodAutoscaler of a component.
+// The average CPU utilization target is 80% if no target is set.
+type AutoscalingSpec struct {
+	// The lower limit of the replicas, 1 by default.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// The upper limit of the replicas.
+	// +required
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+	// The target average CPU utilization, in percent of the CPU requests.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// The target average memory utilization, in percent of the memory requests.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
 }
 
 // Thanos Query Spec
@@ -96,19 +156,118 @@ type QuerySpec struct {
 	// Annotations is an unstructured key value map stored with a service account
 	// +optional
 	ServiceAccountAnnotations map[string]string `json:"serviceAccountAnnotations,omitempty"`
+	// The maximum duration of the processing of a query, e.g. 2m.
+	// +optional
+	Timeout string `json:"timeout,omitempty"`
+	// The maximum number of queries processed concurrently.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxConcurrent *int32 `json:"maxConcurrent,omitempty"`
+	// The strategy of the queries when some stores fail: warn returns the partial response with a warning,
+	// abort fails the query.
+	// +optional
+	// +kubebuilder:validation:Enum=warn;abort
+	PartialResponseStrategy string `json:"partialResponseStrategy,omitempty"`
+	// The maximum lookback duration for retrieving metrics during expression evaluations.
+	// Twice the interval of the metrics collection by default if it is more than 5m.
+	// +optional
+	LookbackDelta string `json:"lookbackDelta,omitempty"`
+	// The step of the range queries without step, it selects the resolution of the auto-downsampling.
+	// +optional
+	DefaultStep string `json:"defaultStep,omitempty"`
 
 	CommonSpec `json:",inline"`
 }
 
+const (
+	// PartialResponseStrategyWarn returns the partial response of the queries with a warning
+	PartialResponseStrategyWarn = "warn"
+	// PartialResponseStrategyAbort fails the queries with a partial response
+	PartialResponseStrategyAbort = "abort"
+)
+
+// QueryFrontendConfig is the query splitting and the results cache of thanos-query-frontend.
+type QueryFrontendConfig struct {
+	// The time to live of the cached query results, e.g. 24h.
+	// +optional
+	ResultsCacheTTL string `json:"resultsCacheTTL,omitempty"`
+	// The interval the range queries are split by, the split queries are processed and cached separately.
+	// +optional
+	SplitInterval string `json:"splitInterval,omitempty"`
+}
+
 // Thanos Receive Spec
 type ReceiveSpec struct {
 	// Annotations is an unstructured key value map stored with a service account
 	// +optional
 	ServiceAccountAnnotations map[string]string `json:"serviceAccountAnnotations,omitempty"`
+	// The number of copies of every time series, at most the replicas of the receivers.
+	// 3 by default, or the replicas of the receivers if they are less than 3.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ReplicationFactor *int32 `json:"replicationFactor,omitempty"`
+	// The hashrings of the tenants, each hashring is served by its own receivers.
+	// The tenants not in any hashring are served by the default hashring.
+	// +optional
+	Hashrings []HashringSpec `json:"hashrings,omitempty"`
+	// The limits of the writes of the tenants into the receivers.
+	// +optional
+	Limits *ReceiveLimitsSpec `json:"limits,omitempty"`
 
 	CommonSpec `json:",inline"`
 }
 
+// HashringSpec is a hashring of the receivers and the tenants it serves.
+type HashringSpec struct {
+	// Name of the hashring, the name default is reserved for the default hashring.
+	// +required
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// The names of the tenants served by the hashring.
+	// +required
+	// +kubebuilder:validation:MinItems=1
+	Tenants []string `json:"tenants"`
+}
+
+// ReceiveLimitsSpec is the limits of the writes into the receivers.
+type ReceiveLimitsSpec struct {
+	// The default limits of the tenants.
+	ReceiveLimits `json:",inline"`
+	// The limits of the tenants which override the default limits.
+	// +optional
+	Tenants []TenantReceiveLimits `json:"tenants,omitempty"`
+}
+
+// ReceiveLimits is the limits of the writes of a tenant, 0 or unset means no limit.
+type ReceiveLimits struct {
+	// The maximum number of active series in the head of a receiver.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	HeadSeriesLimit *int64 `json:"headSeriesLimit,omitempty"`
+	// The maximum size in bytes of the body of a remote write request.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RequestSizeBytesLimit *int64 `json:"requestSizeBytesLimit,omitempty"`
+	// The maximum number of series in a remote write request.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RequestSeriesLimit *int64 `json:"requestSeriesLimit,omitempty"`
+	// The maximum number of samples in a remote write request.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RequestSamplesLimit *int64 `json:"requestSamplesLimit,omitempty"`
+}
+
+// TenantReceiveLimits is the limits of the writes of a tenant.
+type TenantReceiveLimits struct {
+	// Name of the tenant.
+	// +required
+	Name string `json:"name"`
+
+	ReceiveLimits `json:",inline"`
+}
+
 // Thanos Store Spec
 type StoreSpec struct {
 	// Annotations is an unstructured key value map stored with a service account
@@ -139,6 +298,18 @@ type CompactSpec struct {
 	// Annotations is an unstructured key value map stored with a service account
 	// +optional
 	ServiceAccountAnnotations map[string]string `json:"serviceAccountAnnotations,omitempty"`
+	// Affinity of the pods of the compact, it is merged into the default pod anti-affinity.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// TopologySpreadConstraints of the pods of the compact.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// PriorityClassName of the pods of the compact.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
 }
 
 // CacheConfig is the spec of memcached.
@@ -218,6 +389,45 @@ type StorageConfig struct {
 	// +optional
 	// +kubebuilder:default:="10Gi"
 	StoreStorageSize string `json:"storeStorageSize,omitempty"`
+	// The amount of storage of the ReadWriteMany volume mounted in the thanos receive, store, rule and compact
+	// stateful sets at the directory of the filesystem object storage. It is only used with the filesystem type.
+	// +optional
+	// +kubebuilder:default:="100Gi"
+	FilesystemStorageSize string `json:"filesystemStorageSize,omitempty"`
+	// Mode of the credentials of the metric object storage. With the static mode the keys are read from the
+	// object storage secret. With the workloadIdentity mode the thanos components use the short-lived credentials
+	// of the cloud identity set in WorkloadIdentity, the object storage secret must not contain any key.
+	// +optional
+	// +kubebuilder:validation:Enum=static;workloadIdentity
+	CredentialsMode string `json:"credentialsMode,omitempty"`
+	// The cloud identity bound to the service accounts of the thanos components, required by the
+	// workloadIdentity credentials mode.
+	// +optional
+	WorkloadIdentity *WorkloadIdentityConfig `json:"workloadIdentity,omitempty"`
+}
+
+const (
+	// CredentialsModeStatic reads the keys of the metric object storage from its secret
+	CredentialsModeStatic = "static"
+	// CredentialsModeWorkloadIdentity uses the cloud identity bound to the service accounts of the thanos components
+	CredentialsModeWorkloadIdentity = "workloadIdentity"
+)
+
+// WorkloadIdentityConfig is the cloud identity used by the thanos components to access the metric object storage,
+// only the identity of the type of the object storage is set.
+type WorkloadIdentityConfig struct {
+	// ARN of the AWS IAM role assumed with the service account token (IRSA) for the s3 object storage.
+	// +optional
+	AWSRoleARN string `json:"awsRoleARN,omitempty"`
+	// Email of the Google service account impersonated with GKE workload identity for the gcs object storage.
+	// +optional
+	GCPServiceAccount string `json:"gcpServiceAccount,omitempty"`
+	// Client ID of the Azure managed identity federated with the service account for the azure object storage.
+	// +optional
+	AzureClientID string `json:"azureClientID,omitempty"`
+	// ID of the Azure tenant of the managed identity, the identity is checked by the operator when it is set.
+	// +optional
+	AzureTenantID string `json:"azureTenantID,omitempty"`
 }
 
 // MultiClusterObservabilityStatus defines the observed state of MultiClusterObservability
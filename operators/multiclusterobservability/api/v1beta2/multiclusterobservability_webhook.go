@@ -5,16 +5,26 @@ package v1beta2
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	observabilityshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
 )
 
 // +kubebuilder:docs-gen:collapse=Go imports
@@ -24,12 +34,51 @@ var multiclusterobservabilitylog = logf.Log.WithName("multiclusterobservability-
 
 var kubeClient kubernetes.Interface
 
+// The object storage and the remote write configurations are validated by the operator packages which
+// import this package, the validations are set by the operator before the webhook is set up.
+var (
+	// ObjStorageConfValidator validates the object storage configuration of MetricObjectStorage with the
+	// credentials mode of the storage config.
+	ObjStorageConfValidator func(data []byte, storageConfig *StorageConfig) (bool, error)
+	// WriteStorageValidator validates the remote write endpoint configuration of WriteStorage.
+	WriteStorageValidator func(data []byte) error
+	// Defaulter materializes the defaults of the operator into the spec, no mutating webhook is
+	// registered if it is not set.
+	Defaulter admission.CustomDefaulter
+	// DefaultNamespace returns the namespace of the operands where the storage secrets are read from,
+	// defaultStorageSecretNamespace is used if it is not set.
+	DefaultNamespace func() string
+)
+
+const (
+	// the namespace of the storage secrets if DefaultNamespace is not set
+	defaultStorageSecretNamespace = "open-cluster-management-observability"
+	// the tenant of the managed clusters which are not mapped to any tenant
+	defaultTenantName = "default"
+	// the hashring of the tenants which are not in any hashring
+	defaultHashringName = "default"
+	// the replicas of the receivers if they are not set
+	defaultReceiveReplicas = 3
+
+	// the annotations supported by the MultiClusterObservability CR
+	annotationMCOPause                    = "mco-pause"
+	annotationMCOWithoutResourcesRequests = "mco-thanos-without-resources-requests"
+	annotationCertDuration                = "mco-cert-duration"
+	annotationDisableMCOAlerting          = "mco-disable-alerting"
+
+	minAddonInterval = 15
+	maxAddonInterval = 3600
+)
+
 func (mco *MultiClusterObservability) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(mco).
+		WithDefaulter(Defaulter).
 		Complete()
 }
 
+// +kubebuilder:webhook:path=/mutate-observability-open-cluster-management-io-v1beta2-multiclusterobservability,mutating=true,failurePolicy=fail,sideEffects=None,groups=observability.open-cluster-management.io,resources=multiclusterobservabilities,verbs=create;update,versions=v1beta2,name=mmulticlusterobservability.observability.open-cluster-management.io,admissionReviewVersions={v1,v1beta1}
+
 // +kubebuilder:webhook:path=/validate-observability-open-cluster-management-io-v1beta2-multiclusterobservability,mutating=false,failurePolicy=fail,sideEffects=None,groups=observability.open-cluster-management.io,resources=multiclusterobservabilities,verbs=create;update,versions=v1beta2,name=vmulticlusterobservability.observability.open-cluster-management.io,admissionReviewVersions={v1,v1beta1}
 
 var _ webhook.Validator = &MultiClusterObservability{}
@@ -56,13 +105,23 @@ func (mco *MultiClusterObservability) ValidateDelete() error {
 
 // validateMultiClusterObservability validates  the name and the spec of the MultiClusterObservability CR.
 func (mco *MultiClusterObservability) validateMultiClusterObservability(old runtime.Object) error {
-	var allErrs field.ErrorList
-	if err := mco.validateMultiClusterObservabilityName(); err != nil {
-		allErrs = append(allErrs, err)
+	// the finalizers are removed from the deleted CR regardless of its spec
+	if mco.DeletionTimestamp != nil {
+		return nil
 	}
-	if err := mco.validateMultiClusterObservabilitySpec(); err != nil {
-		allErrs = append(allErrs, err)
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, mco.validateMultiClusterObservabilityName()...)
+	allErrs = append(allErrs, mco.validateMultiClusterObservabilityAnnotations()...)
+	secretCheck := storageSecretOptional
+	if oldMCO, ok := old.(*MultiClusterObservability); ok {
+		secretCheck = storageSecretRequired
+		// the secrets are not revalidated when the storage config is unchanged, so that the other updates,
+		// e.g. the finalizers or the annotations, are not rejected because of a secret changed meanwhile
+		if apiequality.Semantic.DeepEqual(oldMCO.Spec.StorageConfig, mco.Spec.StorageConfig) {
+			secretCheck = storageSecretWarn
+		}
 	}
+	allErrs = append(allErrs, mco.validateMultiClusterObservabilitySpec(secretCheck)...)
 
 	// validate the MultiClusterObservability CR update
 	if old != nil {
@@ -83,18 +142,518 @@ func (mco *MultiClusterObservability) validateMultiClusterObservability(old runt
 // Validating the length of a string field can be done declaratively by the validation schema.
 // But the `ObjectMeta.Name` field is defined in a shared package under the apimachinery repo,
 // so we can't declaratively validate it using the validation schema.
-func (mco *MultiClusterObservability) validateMultiClusterObservabilityName() *field.Error {
-	return nil
+func (mco *MultiClusterObservability) validateMultiClusterObservabilityName() field.ErrorList {
+	var errs field.ErrorList
+	// the name is set as a label value of the operands
+	for _, msg := range validation.IsValidLabelValue(mco.Name) {
+		errs = append(errs, field.Invalid(field.NewPath("metadata").Child("name"), mco.Name, msg))
+	}
+	return errs
+}
+
+// validateMultiClusterObservabilityAnnotations validates the values of the annotations supported by the
+// MultiClusterObservability CR.
+func (mco *MultiClusterObservability) validateMultiClusterObservabilityAnnotations() field.ErrorList {
+	var errs field.ErrorList
+	annotationsPath := field.NewPath("metadata").Child("annotations")
+	for _, key := range []string{annotationMCOPause, annotationMCOWithoutResourcesRequests,
+		annotationDisableMCOAlerting} {
+		// the operator only enables the annotations set to the exact value true
+		if value, ok := mco.Annotations[key]; ok && value != "true" && value != "false" {
+			errs = append(errs, field.NotSupported(annotationsPath.Key(key), value, []string{"true", "false"}))
+		}
+	}
+	if value, ok := mco.Annotations[annotationCertDuration]; ok {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, field.Invalid(annotationsPath.Key(annotationCertDuration), value, err.Error()))
+		} else if d <= 0 {
+			errs = append(errs, field.Invalid(annotationsPath.Key(annotationCertDuration), value,
+				"must be a positive duration"))
+		}
+	}
+	return errs
 }
 
 // validateMultiClusterObservabilitySpec validates the spec of the MultiClusterObservability CR.
 // notice that some fields are declaratively validated by OpenAPI schema with `// +kubebuilder:validation` in the type
-// definition.
-func (mco *MultiClusterObservability) validateMultiClusterObservabilitySpec() *field.Error {
+// definition. The storage secrets are validated as told by secretCheck.
+func (mco *MultiClusterObservability) validateMultiClusterObservabilitySpec(
+	secretCheck storageSecretCheck) field.ErrorList {
 	// The field helpers from the kubernetes API machinery help us return nicely structured validation errors.
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+	if mco.Spec.AdvancedConfig != nil {
+		advancedPath := specPath.Child("advanced")
+		errs = append(errs, validateRetentionConfig(mco.Spec.AdvancedConfig.RetentionConfig,
+			advancedPath.Child("retentionConfig"))...)
+		if rule := mco.Spec.AdvancedConfig.Rule; rule != nil && rule.EvalInterval != "" {
+			errs = append(errs, validateDuration(rule.EvalInterval, advancedPath.Child("rule").Child("evalInterval"),
+				false)...)
+		}
+		errs = append(errs, validateReceiveSpec(mco.Spec.AdvancedConfig.Receive, mco.Spec.Tenants,
+			advancedPath.Child("receive"))...)
+		errs = append(errs, validateQuerySpec(mco.Spec.AdvancedConfig.Query, advancedPath.Child("query"))...)
+		errs = append(errs, validateAutoscaling(mco.Spec.AdvancedConfig, advancedPath)...)
+		errs = append(errs, validateScheduling(mco.Spec.AdvancedConfig, advancedPath)...)
+		if queryFrontend := mco.Spec.AdvancedConfig.QueryFrontendConfig; queryFrontend != nil {
+			queryFrontendPath := advancedPath.Child("queryFrontendConfig")
+			if queryFrontend.ResultsCacheTTL != "" {
+				errs = append(errs, validateDuration(queryFrontend.ResultsCacheTTL,
+					queryFrontendPath.Child("resultsCacheTTL"), false)...)
+			}
+			if queryFrontend.SplitInterval != "" {
+				errs = append(errs, validateDuration(queryFrontend.SplitInterval,
+					queryFrontendPath.Child("splitInterval"), false)...)
+			}
+		}
+	}
+	errs = append(errs, validateStorageConfig(mco.Spec.StorageConfig, specPath.Child("storageConfig"),
+		secretCheck)...)
+	errs = append(errs, validateTenants(mco.Spec.Tenants, specPath.Child("tenants"))...)
+	if addonSpec := mco.Spec.ObservabilityAddonSpec; addonSpec != nil && addonSpec.Interval != 0 &&
+		(addonSpec.Interval < minAddonInterval || addonSpec.Interval > maxAddonInterval) {
+		errs = append(errs, field.Invalid(specPath.Child("observabilityAddonSpec").Child("interval"),
+			addonSpec.Interval, fmt.Sprintf("must be between %d and %d seconds", minAddonInterval, maxAddonInterval)))
+	}
+	return errs
+}
+
+// validateTenants validates that the tenants are unique and map managed clusters, a cluster set is mapped to
+// one tenant at most.
+func validateTenants(tenants []TenantSpec, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	names := map[string]bool{}
+	clusterSets := map[string]bool{}
+	for i, tenant := range tenants {
+		tenantPath := fldPath.Index(i)
+		if tenant.Name == defaultTenantName {
+			errs = append(errs, field.Invalid(tenantPath.Child("name"), tenant.Name,
+				"reserved for the default tenant"))
+		} else if names[tenant.Name] {
+			errs = append(errs, field.Duplicate(tenantPath.Child("name"), tenant.Name))
+		}
+		names[tenant.Name] = true
+		if len(tenant.ClusterSets) == 0 && tenant.ClusterSelector == nil {
+			errs = append(errs, field.Required(tenantPath,
+				"at least one of clusterSets and clusterSelector is required"))
+		}
+		for j, clusterSet := range tenant.ClusterSets {
+			if clusterSets[clusterSet] {
+				errs = append(errs, field.Duplicate(tenantPath.Child("clusterSets").Index(j), clusterSet))
+			}
+			clusterSets[clusterSet] = true
+		}
+		if tenant.ClusterSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(tenant.ClusterSelector); err != nil {
+				errs = append(errs, field.Invalid(tenantPath.Child("clusterSelector"), tenant.ClusterSelector,
+					err.Error()))
+			}
+		}
+	}
+	return errs
+}
+
+// validateReceiveSpec validates that the replication factor is at most the replicas of the receivers, and that
+// the hashrings and the limits refer to the tenants, a tenant is in one hashring at most.
+func validateReceiveSpec(receive *ReceiveSpec, tenants []TenantSpec, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if receive == nil {
+		return errs
+	}
+	replicas := int32(defaultReceiveReplicas)
+	if receive.Replicas != nil {
+		replicas = *receive.Replicas
+	}
+	if receive.ReplicationFactor != nil && *receive.ReplicationFactor > replicas {
+		errs = append(errs, field.Invalid(fldPath.Child("replicationFactor"), *receive.ReplicationFactor,
+			fmt.Sprintf("must be less than or equal to the replicas of the receivers (%d)", replicas)))
+	}
+
+	tenantNames := map[string]bool{defaultTenantName: true}
+	for _, tenant := range tenants {
+		tenantNames[tenant.Name] = true
+	}
+	hashrings := map[string]bool{}
+	hashringTenants := map[string]bool{}
+	for i, hashring := range receive.Hashrings {
+		hashringPath := fldPath.Child("hashrings").Index(i)
+		if hashring.Name == defaultHashringName {
+			errs = append(errs, field.Invalid(hashringPath.Child("name"), hashring.Name,
+				"reserved for the default hashring"))
+		} else if hashrings[hashring.Name] {
+			errs = append(errs, field.Duplicate(hashringPath.Child("name"), hashring.Name))
+		}
+		hashrings[hashring.Name] = true
+		for j, tenant := range hashring.Tenants {
+			if !tenantNames[tenant] {
+				errs = append(errs, field.NotFound(hashringPath.Child("tenants").Index(j), tenant))
+			} else if hashringTenants[tenant] {
+				errs = append(errs, field.Duplicate(hashringPath.Child("tenants").Index(j), tenant))
+			}
+			hashringTenants[tenant] = true
+		}
+	}
+
+	if receive.Limits != nil {
+		limitTenants := map[string]bool{}
+		for i, tenant := range receive.Limits.Tenants {
+			namePath := fldPath.Child("limits").Child("tenants").Index(i).Child("name")
+			if !tenantNames[tenant.Name] {
+				errs = append(errs, field.NotFound(namePath, tenant.Name))
+			} else if limitTenants[tenant.Name] {
+				errs = append(errs, field.Duplicate(namePath, tenant.Name))
+			}
+			limitTenants[tenant.Name] = true
+		}
+	}
+	return errs
+}
+
+// validateQuerySpec validates the durations and the limits of the queries.
+func validateQuerySpec(query *QuerySpec, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if query == nil {
+		return errs
+	}
+	for _, d := range []struct {
+		name  string
+		value string
+	}{
+		{"timeout", query.Timeout},
+		{"lookbackDelta", query.LookbackDelta},
+		{"defaultStep", query.DefaultStep},
+	} {
+		if d.value != "" {
+			errs = append(errs, validateDuration(d.value, fldPath.Child(d.name), false)...)
+		}
+	}
+	if query.MaxConcurrent != nil && *query.MaxConcurrent < 1 {
+		errs = append(errs, field.Invalid(fldPath.Child("maxConcurrent"), *query.MaxConcurrent,
+			"must be greater than or equal to 1"))
+	}
+	if query.PartialResponseStrategy != "" && query.PartialResponseStrategy != PartialResponseStrategyWarn &&
+		query.PartialResponseStrategy != PartialResponseStrategyAbort {
+		errs = append(errs, field.NotSupported(fldPath.Child("partialResponseStrategy"),
+			query.PartialResponseStrategy, []string{PartialResponseStrategyWarn, PartialResponseStrategyAbort}))
+	}
+	return errs
+}
+
+// componentSpec is the common spec of a component of the advanced configuration, with its field name.
+type componentSpec struct {
+	name string
+	spec *CommonSpec
+}
+
+// getComponentSpecs returns the common specs of the components which are set in the advanced configuration.
+func getComponentSpecs(advanced *AdvancedConfig) []componentSpec {
+	specs := []componentSpec{
+		{"observatoriumAPI", advanced.ObservatoriumAPI},
+		{"rbacQueryProxy", advanced.RBACQueryProxy},
+		{"grafana", advanced.Grafana},
+		{"alertmanager", advanced.Alertmanager},
+	}
+	if advanced.Query != nil {
+		specs = append(specs, componentSpec{"query", &advanced.Query.CommonSpec})
+	}
+	if advanced.QueryFrontend != nil {
+		specs = append(specs, componentSpec{"queryFrontend", advanced.QueryFrontend})
+	}
+	if advanced.Receive != nil {
+		specs = append(specs, componentSpec{"receive", &advanced.Receive.CommonSpec})
+	}
+	if advanced.Rule != nil {
+		specs = append(specs, componentSpec{"rule", &advanced.Rule.CommonSpec})
+	}
+	if advanced.Store != nil {
+		specs = append(specs, componentSpec{"store", &advanced.Store.CommonSpec})
+	}
+	if advanced.StoreMemcached != nil {
+		specs = append(specs, componentSpec{"storeMemcached", &advanced.StoreMemcached.CommonSpec})
+	}
+	if advanced.QueryFrontendMemcached != nil {
+		specs = append(specs, componentSpec{"queryFrontendMemcached", &advanced.QueryFrontendMemcached.CommonSpec})
+	}
+
+	setSpecs := []componentSpec{}
+	for _, spec := range specs {
+		if spec.spec != nil {
+			setSpecs = append(setSpecs, spec)
+		}
+	}
+	return setSpecs
+}
+
+// validateAutoscaling validates that only the stateless components are autoscaled, with a replicas range.
+func validateAutoscaling(advanced *AdvancedConfig, fldPath *field.Path) field.ErrorList {
+	// the stateful components and the caches are not autoscaled
+	supported := map[string]bool{
+		"observatoriumAPI": true,
+		"rbacQueryProxy":   true,
+		"grafana":          true,
+		"query":            true,
+		"queryFrontend":    true,
+	}
+
+	var errs field.ErrorList
+	for _, component := range getComponentSpecs(advanced) {
+		autoscaling := component.spec.Autoscaling
+		if autoscaling == nil {
+			continue
+		}
+		autoscalingPath := fldPath.Child(component.name).Child("autoscaling")
+		if !supported[component.name] {
+			errs = append(errs, field.Forbidden(autoscalingPath,
+				"autoscaling is only supported by observatoriumAPI, query, queryFrontend, rbacQueryProxy and grafana"))
+			continue
+		}
+		if autoscaling.MaxReplicas < 1 {
+			errs = append(errs, field.Invalid(autoscalingPath.Child("maxReplicas"), autoscaling.MaxReplicas,
+				"must be greater than or equal to 1"))
+		}
+		if autoscaling.MinReplicas != nil && *autoscaling.MinReplicas > autoscaling.MaxReplicas {
+			errs = append(errs, field.Invalid(autoscalingPath.Child("minReplicas"), *autoscaling.MinReplicas,
+				"must be less than or equal to maxReplicas"))
+		}
+	}
+	return errs
+}
+
+// validateScheduling validates the scheduling of the components, the pods of observatoriumAPI and the memcached
+// are not scheduled per component.
+func validateScheduling(advanced *AdvancedConfig, fldPath *field.Path) field.ErrorList {
+	unsupported := map[string]bool{
+		"observatoriumAPI":       true,
+		"storeMemcached":         true,
+		"queryFrontendMemcached": true,
+	}
+
+	var errs field.ErrorList
+	for _, component := range getComponentSpecs(advanced) {
+		componentPath := fldPath.Child(component.name)
+		if unsupported[component.name] {
+			msg := "scheduling is not supported by observatoriumAPI, storeMemcached and queryFrontendMemcached"
+			if component.spec.Affinity != nil {
+				errs = append(errs, field.Forbidden(componentPath.Child("affinity"), msg))
+			}
+			if len(component.spec.TopologySpreadConstraints) != 0 {
+				errs = append(errs, field.Forbidden(componentPath.Child("topologySpreadConstraints"), msg))
+			}
+			if component.spec.PriorityClassName != "" {
+				errs = append(errs, field.Forbidden(componentPath.Child("priorityClassName"), msg))
+			}
+			continue
+		}
+		errs = append(errs, validateTopologySpreadConstraints(component.spec.TopologySpreadConstraints,
+			componentPath.Child("topologySpreadConstraints"))...)
+	}
+	if advanced.Compact != nil {
+		errs = append(errs, validateTopologySpreadConstraints(advanced.Compact.TopologySpreadConstraints,
+			fldPath.Child("compact").Child("topologySpreadConstraints"))...)
+	}
+	return errs
+}
+
+// validateTopologySpreadConstraints validates the skew and the topology key of the constraints.
+func validateTopologySpreadConstraints(constraints []corev1.TopologySpreadConstraint,
+	fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, constraint := range constraints {
+		if constraint.MaxSkew < 1 {
+			errs = append(errs, field.Invalid(fldPath.Index(i).Child("maxSkew"), constraint.MaxSkew,
+				"must be greater than or equal to 1"))
+		}
+		if constraint.TopologyKey == "" {
+			errs = append(errs, field.Required(fldPath.Index(i).Child("topologyKey"), ""))
+		}
+	}
+	return errs
+}
+
+// validateDuration validates a Prometheus duration, e.g. 30s or 365d.
+func validateDuration(value string, fldPath *field.Path, allowZero bool) field.ErrorList {
+	d, err := model.ParseDuration(value)
+	if err != nil {
+		return field.ErrorList{field.Invalid(fldPath, value, err.Error())}
+	}
+	if d == 0 && !allowZero {
+		return field.ErrorList{field.Invalid(fldPath, value, "must be a positive duration")}
+	}
 	return nil
 }
 
+// validateRetentionConfig validates the retention and the block durations, a zero retention keeps the
+// samples forever.
+func validateRetentionConfig(retention *RetentionConfig, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if retention == nil {
+		return errs
+	}
+	for _, d := range []struct {
+		name      string
+		value     string
+		allowZero bool
+	}{
+		{"retentionResolutionRaw", retention.RetentionResolutionRaw, true},
+		{"retentionResolution5m", retention.RetentionResolution5m, true},
+		{"retentionResolution1h", retention.RetentionResolution1h, true},
+		{"retentionInLocal", retention.RetentionInLocal, false},
+		{"deleteDelay", retention.DeleteDelay, true},
+		{"blockDuration", retention.BlockDuration, false},
+	} {
+		if d.value != "" {
+			errs = append(errs, validateDuration(d.value, fldPath.Child(d.name), d.allowZero)...)
+		}
+	}
+	return errs
+}
+
+// storageSecretCheck tells how the storage secrets are validated.
+type storageSecretCheck int
+
+const (
+	// the secrets must exist and hold a valid configuration
+	storageSecretRequired storageSecretCheck = iota
+	// the missing secrets are only logged, as they can be applied after the CR
+	storageSecretOptional
+	// the invalid secrets are only logged, as the storage config is unchanged
+	storageSecretWarn
+)
+
+// validateStorageConfig validates the storage sizes and the storage secrets as told by secretCheck.
+func validateStorageConfig(storageConfig *StorageConfig, fldPath *field.Path,
+	secretCheck storageSecretCheck) field.ErrorList {
+	var errs field.ErrorList
+	if storageConfig == nil {
+		return append(errs, field.Required(fldPath, ""))
+	}
+	for _, size := range []struct {
+		name  string
+		value string
+	}{
+		{"alertmanagerStorageSize", storageConfig.AlertmanagerStorageSize},
+		{"ruleStorageSize", storageConfig.RuleStorageSize},
+		{"compactStorageSize", storageConfig.CompactStorageSize},
+		{"receiveStorageSize", storageConfig.ReceiveStorageSize},
+		{"storeStorageSize", storageConfig.StoreStorageSize},
+		{"filesystemStorageSize", storageConfig.FilesystemStorageSize},
+	} {
+		if size.value == "" {
+			continue
+		}
+		q, err := resource.ParseQuantity(size.value)
+		if err != nil {
+			errs = append(errs, field.Invalid(fldPath.Child(size.name), size.value, err.Error()))
+		} else if q.Sign() <= 0 {
+			errs = append(errs, field.Invalid(fldPath.Child(size.name), size.value, "must be greater than zero"))
+		}
+	}
+
+	workloadIdentityPath := fldPath.Child("workloadIdentity")
+	workloadIdentityMissing := false
+	if storageConfig.CredentialsMode == CredentialsModeWorkloadIdentity {
+		if storageConfig.WorkloadIdentity == nil {
+			workloadIdentityMissing = true
+			errs = append(errs, field.Required(workloadIdentityPath,
+				"required by the workloadIdentity credentials mode"))
+		}
+	} else if storageConfig.WorkloadIdentity != nil {
+		errs = append(errs, field.Forbidden(workloadIdentityPath,
+			"only allowed with the workloadIdentity credentials mode"))
+	}
+
+	objStoragePath := fldPath.Child("metricObjectStorage")
+	if storageConfig.MetricObjectStorage == nil {
+		errs = append(errs, field.Required(objStoragePath, ""))
+	} else {
+		errs = append(errs, validateStorageSecret(storageConfig.MetricObjectStorage, objStoragePath,
+			secretCheck, func(data []byte) error {
+				if ObjStorageConfValidator == nil || workloadIdentityMissing {
+					return nil
+				}
+				_, err := ObjStorageConfValidator(data, storageConfig)
+				return err
+			})...)
+	}
+	for i, writeStorage := range storageConfig.WriteStorage {
+		writeStoragePath := fldPath.Child("writeStorage").Index(i)
+		if writeStorage == nil {
+			errs = append(errs, field.Required(writeStoragePath, ""))
+			continue
+		}
+		errs = append(errs, validateStorageSecret(writeStorage, writeStoragePath, secretCheck,
+			func(data []byte) error {
+				if WriteStorageValidator == nil {
+					return nil
+				}
+				return WriteStorageValidator(data)
+			})...)
+	}
+	return errs
+}
+
+// validateStorageSecret checks that the secret key of the storage exists and holds a valid configuration,
+// the errors of the secret are only logged as told by secretCheck.
+func validateStorageSecret(storage *observabilityshared.PreConfiguredStorage, fldPath *field.Path,
+	secretCheck storageSecretCheck, validate func(data []byte) error) field.ErrorList {
+	var errs field.ErrorList
+	if storage.Name == "" {
+		errs = append(errs, field.Required(fldPath.Child("name"), ""))
+	}
+	if storage.Key == "" {
+		errs = append(errs, field.Required(fldPath.Child("key"), ""))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	errs = getStorageSecretErrors(storage, fldPath, secretCheck != storageSecretRequired, validate)
+	if secretCheck == storageSecretWarn {
+		for _, err := range errs {
+			multiclusterobservabilitylog.Info("Warning: the storage secret is invalid", "error", err.Error())
+		}
+		return nil
+	}
+	return errs
+}
+
+// getStorageSecretErrors returns the errors of the secret of the storage, the missing secret is only logged
+// if allowMissing is true.
+func getStorageSecretErrors(storage *observabilityshared.PreConfiguredStorage, fldPath *field.Path,
+	allowMissing bool, validate func(data []byte) error) field.ErrorList {
+	var errs field.ErrorList
+
+	kubeClient, err := createOrGetKubeClient()
+	if err != nil {
+		return append(errs, field.InternalError(fldPath, err))
+	}
+	namespace := defaultStorageSecretNamespace
+	if DefaultNamespace != nil {
+		namespace = DefaultNamespace()
+	}
+	secret, err := kubeClient.CoreV1().Secrets(namespace).Get(context.TODO(), storage.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) && allowMissing {
+			multiclusterobservabilitylog.Info("Warning: the storage secret does not exist yet",
+				"field", fldPath.String(), "namespace", namespace, "name", storage.Name)
+			return errs
+		}
+		if apierrors.IsNotFound(err) {
+			return append(errs, field.NotFound(fldPath.Child("name"), storage.Name))
+		}
+		return append(errs, field.InternalError(fldPath.Child("name"), err))
+	}
+	data, ok := secret.Data[storage.Key]
+	if !ok {
+		return append(errs, field.NotFound(fldPath.Child("key"), storage.Key))
+	}
+	if err := validate(data); err != nil {
+		errs = append(errs, field.Invalid(fldPath.Child("key"), storage.Key,
+			fmt.Sprintf("invalid configuration in secret %s: %v", storage.Name, err)))
+	}
+	return errs
+}
+
 // validateUpdateMultiClusterObservabilitySpec validates the update of the MultiClusterObservability CR.
 func (mco *MultiClusterObservability) validateUpdateMultiClusterObservabilitySpec(old runtime.Object) field.ErrorList {
 	return mco.validateUpdateMultiClusterObservabilityStorageSize(old)
@@ -107,6 +666,9 @@ func (mco *MultiClusterObservability) validateUpdateMultiClusterObservabilitySto
 ) field.ErrorList {
 	var errs field.ErrorList
 	oldMCO := old.(*MultiClusterObservability)
+	if apiequality.Semantic.DeepEqual(oldMCO.Spec.StorageConfig, mco.Spec.StorageConfig) {
+		return nil
+	}
 	kubeClient, err := createOrGetKubeClient()
 	if err != nil {
 		return append(errs, field.InternalError(nil, err))
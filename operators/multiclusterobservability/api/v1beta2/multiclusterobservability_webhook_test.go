@@ -0,0 +1,355 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta2
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	observabilityshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+)
+
+func newTestMCO() *MultiClusterObservability {
+	return &MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability"},
+		Spec: MultiClusterObservabilitySpec{
+			StorageConfig: &StorageConfig{
+				MetricObjectStorage: &observabilityshared.PreConfiguredStorage{
+					Name: "thanos-object-storage",
+					Key:  "thanos.yaml",
+				},
+				WriteStorage: []*observabilityshared.PreConfiguredStorage{{
+					Name: "victoriametrics",
+					Key:  "ep.yaml",
+				}},
+				ReceiveStorageSize: "100Gi",
+			},
+			ObservabilityAddonSpec: &observabilityshared.ObservabilityAddonSpec{
+				EnableMetrics: true,
+				Interval:      300,
+			},
+		},
+	}
+}
+
+func getInvalidFields(err error) []string {
+	fields := []string{}
+	statusErr := &apierrors.StatusError{}
+	if !errors.As(err, &statusErr) || statusErr.ErrStatus.Details == nil {
+		return fields
+	}
+	for _, cause := range statusErr.ErrStatus.Details.Causes {
+		fields = append(fields, cause.Field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func TestValidateMultiClusterObservability(t *testing.T) {
+	namespace := "custom-observability"
+	kubeClient = fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "thanos-object-storage", Namespace: namespace},
+			Data:       map[string][]byte{"thanos.yaml": []byte("type: s3")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "victoriametrics", Namespace: namespace},
+			Data:       map[string][]byte{"ep.yaml": []byte("url: http://victoriametrics:8428/api/v1/write")},
+		},
+		&storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "gp2",
+				Annotations: map[string]string{"storageclass.kubernetes.io/is-default-class": "true"},
+			},
+		},
+	)
+	defer func() {
+		kubeClient = nil
+		ObjStorageConfValidator = nil
+		WriteStorageValidator = nil
+		DefaultNamespace = nil
+	}()
+	DefaultNamespace = func() string {
+		return namespace
+	}
+	ObjStorageConfValidator = func(data []byte, storageConfig *StorageConfig) (bool, error) {
+		if string(data) != "type: s3" {
+			return false, errors.New("invalid object storage type config")
+		}
+		return true, nil
+	}
+	WriteStorageValidator = func(data []byte) error {
+		return nil
+	}
+
+	caseList := []struct {
+		name     string
+		update   func(mco *MultiClusterObservability)
+		expected []string
+	}{
+		{
+			name:     "valid spec",
+			update:   func(mco *MultiClusterObservability) {},
+			expected: []string{},
+		},
+		{
+			name: "invalid durations",
+			update: func(mco *MultiClusterObservability) {
+				mco.Spec.AdvancedConfig = &AdvancedConfig{
+					RetentionConfig: &RetentionConfig{
+						RetentionResolutionRaw: "0d",
+						RetentionResolution5m:  "5 days",
+						BlockDuration:          "0s",
+					},
+					Rule: &RuleSpec{EvalInterval: "30"},
+				}
+			},
+			expected: []string{
+				"spec.advanced.retentionConfig.blockDuration",
+				"spec.advanced.retentionConfig.retentionResolution5m",
+				"spec.advanced.rule.evalInterval",
+			},
+		},
+		{
+			name: "invalid storage size and interval",
+			update: func(mco *MultiClusterObservability) {
+				mco.Spec.StorageConfig.CompactStorageSize = "100GB"
+				mco.Spec.StorageConfig.StoreStorageSize = "0"
+				mco.Spec.ObservabilityAddonSpec.Interval = 5
+			},
+			expected: []string{
+				"spec.observabilityAddonSpec.interval",
+				"spec.storageConfig.compactStorageSize",
+				"spec.storageConfig.storeStorageSize",
+			},
+		},
+		{
+			name: "invalid tenants",
+			update: func(mco *MultiClusterObservability) {
+				mco.Spec.Tenants = []TenantSpec{
+					{Name: "default", ClusterSets: []string{"default"}},
+					{Name: "team-a", ClusterSets: []string{"team-a"}},
+					{Name: "team-a", ClusterSets: []string{"team-a"}},
+					{Name: "team-b"},
+					{Name: "team-c", ClusterSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: "Equal"}},
+					}},
+				}
+			},
+			expected: []string{
+				"spec.tenants[0].name",
+				"spec.tenants[2].clusterSets[0]",
+				"spec.tenants[2].name",
+				"spec.tenants[3]",
+				"spec.tenants[4].clusterSelector",
+			},
+		},
+		{
+			name: "invalid receive spec",
+			update: func(mco *MultiClusterObservability) {
+				replicas, replicationFactor := int32(2), int32(3)
+				mco.Spec.Tenants = []TenantSpec{{Name: "team-a", ClusterSets: []string{"team-a"}}}
+				mco.Spec.AdvancedConfig = &AdvancedConfig{
+					Receive: &ReceiveSpec{
+						ReplicationFactor: &replicationFactor,
+						Hashrings: []HashringSpec{
+							{Name: "default", Tenants: []string{"default"}},
+							{Name: "team-a", Tenants: []string{"team-a", "team-b"}},
+							{Name: "team-a", Tenants: []string{"team-a"}},
+						},
+						Limits: &ReceiveLimitsSpec{
+							Tenants: []TenantReceiveLimits{{Name: "team-a"}, {Name: "team-a"}, {Name: "team-c"}},
+						},
+						CommonSpec: CommonSpec{Replicas: &replicas},
+					},
+				}
+			},
+			expected: []string{
+				"spec.advanced.receive.hashrings[0].name",
+				"spec.advanced.receive.hashrings[1].tenants[1]",
+				"spec.advanced.receive.hashrings[2].name",
+				"spec.advanced.receive.hashrings[2].tenants[0]",
+				"spec.advanced.receive.limits.tenants[1].name",
+				"spec.advanced.receive.limits.tenants[2].name",
+				"spec.advanced.receive.replicationFactor",
+			},
+		},
+		{
+			name: "invalid query settings",
+			update: func(mco *MultiClusterObservability) {
+				maxConcurrent := int32(0)
+				mco.Spec.AdvancedConfig = &AdvancedConfig{
+					Query: &QuerySpec{
+						Timeout:                 "2x",
+						LookbackDelta:           "0s",
+						DefaultStep:             "1m",
+						MaxConcurrent:           &maxConcurrent,
+						PartialResponseStrategy: "ignore",
+					},
+					QueryFrontendConfig: &QueryFrontendConfig{
+						ResultsCacheTTL: "24h",
+						SplitInterval:   "-1h",
+					},
+				}
+			},
+			expected: []string{
+				"spec.advanced.query.lookbackDelta",
+				"spec.advanced.query.maxConcurrent",
+				"spec.advanced.query.partialResponseStrategy",
+				"spec.advanced.query.timeout",
+				"spec.advanced.queryFrontendConfig.splitInterval",
+			},
+		},
+		{
+			name: "invalid autoscaling",
+			update: func(mco *MultiClusterObservability) {
+				minReplicas := int32(5)
+				mco.Spec.AdvancedConfig = &AdvancedConfig{
+					Query: &QuerySpec{
+						CommonSpec: CommonSpec{
+							Autoscaling: &AutoscalingSpec{MinReplicas: &minReplicas, MaxReplicas: 3},
+						},
+					},
+					Grafana: &CommonSpec{Autoscaling: &AutoscalingSpec{MaxReplicas: 0}},
+					RBACQueryProxy: &CommonSpec{
+						Autoscaling: &AutoscalingSpec{MinReplicas: &minReplicas, MaxReplicas: 10},
+					},
+					Receive: &ReceiveSpec{
+						CommonSpec: CommonSpec{Autoscaling: &AutoscalingSpec{MaxReplicas: 3}},
+					},
+				}
+			},
+			expected: []string{
+				"spec.advanced.grafana.autoscaling.maxReplicas",
+				"spec.advanced.query.autoscaling.minReplicas",
+				"spec.advanced.receive.autoscaling",
+			},
+		},
+		{
+			name: "invalid scheduling",
+			update: func(mco *MultiClusterObservability) {
+				mco.Spec.AdvancedConfig = &AdvancedConfig{
+					Alertmanager: &CommonSpec{
+						PriorityClassName: "system-cluster-critical",
+						TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+							{MaxSkew: 0, TopologyKey: "topology.kubernetes.io/zone"},
+							{MaxSkew: 1},
+						},
+					},
+					Grafana: &CommonSpec{
+						TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+							{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone"},
+						},
+					},
+					Receive: &ReceiveSpec{
+						CommonSpec: CommonSpec{
+							TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+								{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone"},
+							},
+						},
+					},
+					Compact: &CompactSpec{
+						TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{MaxSkew: 1}},
+					},
+					ObservatoriumAPI: &CommonSpec{PriorityClassName: "system-cluster-critical"},
+				}
+			},
+			expected: []string{
+				"spec.advanced.alertmanager.topologySpreadConstraints[0].maxSkew",
+				"spec.advanced.alertmanager.topologySpreadConstraints[1].topologyKey",
+				"spec.advanced.compact.topologySpreadConstraints[0].topologyKey",
+				"spec.advanced.observatoriumAPI.priorityClassName",
+			},
+		},
+		{
+			name: "workload identity without identity",
+			update: func(mco *MultiClusterObservability) {
+				mco.Spec.StorageConfig.CredentialsMode = CredentialsModeWorkloadIdentity
+			},
+			expected: []string{"spec.storageConfig.workloadIdentity"},
+		},
+		{
+			name: "identity with static credentials",
+			update: func(mco *MultiClusterObservability) {
+				mco.Spec.StorageConfig.WorkloadIdentity = &WorkloadIdentityConfig{AWSRoleARN: "arn"}
+			},
+			expected: []string{"spec.storageConfig.workloadIdentity"},
+		},
+		{
+			// the missing secret may be created after the CR
+			name: "invalid storage secrets",
+			update: func(mco *MultiClusterObservability) {
+				mco.Spec.StorageConfig.MetricObjectStorage.Key = "missing.yaml"
+				mco.Spec.StorageConfig.WriteStorage = append(mco.Spec.StorageConfig.WriteStorage,
+					&observabilityshared.PreConfiguredStorage{Name: "missing", Key: "ep.yaml"})
+			},
+			expected: []string{
+				"spec.storageConfig.metricObjectStorage.key",
+			},
+		},
+		{
+			name: "invalid annotations",
+			update: func(mco *MultiClusterObservability) {
+				mco.Annotations = map[string]string{
+					annotationMCOPause:           "yes",
+					annotationCertDuration:       "1y",
+					annotationDisableMCOAlerting: "TRUE",
+				}
+			},
+			expected: []string{
+				"metadata.annotations[mco-cert-duration]",
+				"metadata.annotations[mco-disable-alerting]",
+				"metadata.annotations[mco-pause]",
+			},
+		},
+	}
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			mco := newTestMCO()
+			c.update(mco)
+			fields := getInvalidFields(mco.ValidateCreate())
+			if !reflect.DeepEqual(fields, c.expected) {
+				t.Errorf("invalid fields (%v) are not the expected: (%v)", fields, c.expected)
+			}
+		})
+	}
+
+	// the missing secret is rejected once the CR exists
+	mco := newTestMCO()
+	mco.Spec.StorageConfig.WriteStorage[0].Name = "missing"
+	errs := mco.validateMultiClusterObservabilitySpec(storageSecretRequired)
+	if len(errs) != 1 || errs[0].Field != "spec.storageConfig.writeStorage[0].name" {
+		t.Errorf("errors (%v) are not the expected: (spec.storageConfig.writeStorage[0].name)", errs)
+	}
+
+	// the secrets are only revalidated on update if the storage config changed
+	old := mco.DeepCopy()
+	mco.Finalizers = []string{"observability.open-cluster-management.io/res-cleanup"}
+	mco.Annotations = map[string]string{annotationMCOPause: "true"}
+	if fields := getInvalidFields(mco.validateMultiClusterObservability(old)); len(fields) != 0 {
+		t.Errorf("the update should not be rejected for the unchanged storage config: (%v)", fields)
+	}
+	mco.Spec.StorageConfig.WriteStorage[0].Key = "other.yaml"
+	fields := getInvalidFields(mco.validateMultiClusterObservability(old))
+	if expected := []string{"spec.storageConfig.writeStorage[0].name"}; !reflect.DeepEqual(fields, expected) {
+		t.Errorf("invalid fields (%v) are not the expected: (%v)", fields, expected)
+	}
+
+	ObjStorageConfValidator = func(data []byte, storageConfig *StorageConfig) (bool, error) {
+		return false, errors.New("invalid object storage type config")
+	}
+	fields = getInvalidFields(newTestMCO().ValidateCreate())
+	expected := []string{"spec.storageConfig.metricObjectStorage.key"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("invalid fields (%v) are not the expected: (%v)", fields, expected)
+	}
+}
@@ -24,6 +24,7 @@ package v1beta2
 import (
 	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -70,6 +71,11 @@ func (in *AdvancedConfig) DeepCopyInto(out *AdvancedConfig) {
 		*out = new(CommonSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.QueryFrontendConfig != nil {
+		in, out := &in.QueryFrontendConfig, &out.QueryFrontendConfig
+		*out = new(QueryFrontendConfig)
+		**out = **in
+	}
 	if in.Query != nil {
 		in, out := &in.Query, &out.Query
 		*out = new(QuerySpec)
@@ -107,6 +113,36 @@ func (in *AdvancedConfig) DeepCopy() *AdvancedConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalingSpec) DeepCopyInto(out *AutoscalingSpec) {
+	*out = *in
+	if in.MinReplicas != nil {
+		in, out := &in.MinReplicas, &out.MinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetCPUUtilizationPercentage != nil {
+		in, out := &in.TargetCPUUtilizationPercentage, &out.TargetCPUUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetMemoryUtilizationPercentage != nil {
+		in, out := &in.TargetMemoryUtilizationPercentage, &out.TargetMemoryUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalingSpec.
+func (in *AutoscalingSpec) DeepCopy() *AutoscalingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CacheConfig) DeepCopyInto(out *CacheConfig) {
 	*out = *in
@@ -146,6 +182,23 @@ func (in *CommonSpec) DeepCopyInto(out *CommonSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Autoscaling != nil {
+		in, out := &in.Autoscaling, &out.Autoscaling
+		*out = new(AutoscalingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommonSpec.
@@ -173,6 +226,18 @@ func (in *CompactSpec) DeepCopyInto(out *CompactSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompactSpec.
@@ -185,6 +250,26 @@ func (in *CompactSpec) DeepCopy() *CompactSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HashringSpec) DeepCopyInto(out *HashringSpec) {
+	*out = *in
+	if in.Tenants != nil {
+		in, out := &in.Tenants, &out.Tenants
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HashringSpec.
+func (in *HashringSpec) DeepCopy() *HashringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HashringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MultiClusterObservability) DeepCopyInto(out *MultiClusterObservability) {
 	*out = *in
@@ -276,6 +361,13 @@ func (in *MultiClusterObservabilitySpec) DeepCopyInto(out *MultiClusterObservabi
 		*out = new(shared.ObservabilityAddonSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Tenants != nil {
+		in, out := &in.Tenants, &out.Tenants
+		*out = make([]TenantSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiClusterObservabilitySpec.
@@ -310,6 +402,21 @@ func (in *MultiClusterObservabilityStatus) DeepCopy() *MultiClusterObservability
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryFrontendConfig) DeepCopyInto(out *QueryFrontendConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryFrontendConfig.
+func (in *QueryFrontendConfig) DeepCopy() *QueryFrontendConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryFrontendConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *QuerySpec) DeepCopyInto(out *QuerySpec) {
 	*out = *in
@@ -320,6 +427,11 @@ func (in *QuerySpec) DeepCopyInto(out *QuerySpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.MaxConcurrent != nil {
+		in, out := &in.MaxConcurrent, &out.MaxConcurrent
+		*out = new(int32)
+		**out = **in
+	}
 	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
 }
 
@@ -333,6 +445,64 @@ func (in *QuerySpec) DeepCopy() *QuerySpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReceiveLimits) DeepCopyInto(out *ReceiveLimits) {
+	*out = *in
+	if in.HeadSeriesLimit != nil {
+		in, out := &in.HeadSeriesLimit, &out.HeadSeriesLimit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RequestSizeBytesLimit != nil {
+		in, out := &in.RequestSizeBytesLimit, &out.RequestSizeBytesLimit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RequestSeriesLimit != nil {
+		in, out := &in.RequestSeriesLimit, &out.RequestSeriesLimit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RequestSamplesLimit != nil {
+		in, out := &in.RequestSamplesLimit, &out.RequestSamplesLimit
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReceiveLimits.
+func (in *ReceiveLimits) DeepCopy() *ReceiveLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(ReceiveLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReceiveLimitsSpec) DeepCopyInto(out *ReceiveLimitsSpec) {
+	*out = *in
+	in.ReceiveLimits.DeepCopyInto(&out.ReceiveLimits)
+	if in.Tenants != nil {
+		in, out := &in.Tenants, &out.Tenants
+		*out = make([]TenantReceiveLimits, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReceiveLimitsSpec.
+func (in *ReceiveLimitsSpec) DeepCopy() *ReceiveLimitsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReceiveLimitsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReceiveSpec) DeepCopyInto(out *ReceiveSpec) {
 	*out = *in
@@ -343,6 +513,23 @@ func (in *ReceiveSpec) DeepCopyInto(out *ReceiveSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.ReplicationFactor != nil {
+		in, out := &in.ReplicationFactor, &out.ReplicationFactor
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Hashrings != nil {
+		in, out := &in.Hashrings, &out.Hashrings
+		*out = make([]HashringSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(ReceiveLimitsSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
 }
 
@@ -413,6 +600,11 @@ func (in *StorageConfig) DeepCopyInto(out *StorageConfig) {
 			}
 		}
 	}
+	if in.WorkloadIdentity != nil {
+		in, out := &in.WorkloadIdentity, &out.WorkloadIdentity
+		*out = new(WorkloadIdentityConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageConfig.
@@ -447,3 +639,59 @@ func (in *StoreSpec) DeepCopy() *StoreSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantReceiveLimits) DeepCopyInto(out *TenantReceiveLimits) {
+	*out = *in
+	in.ReceiveLimits.DeepCopyInto(&out.ReceiveLimits)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantReceiveLimits.
+func (in *TenantReceiveLimits) DeepCopy() *TenantReceiveLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantReceiveLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantSpec) DeepCopyInto(out *TenantSpec) {
+	*out = *in
+	if in.ClusterSets != nil {
+		in, out := &in.ClusterSets, &out.ClusterSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantSpec.
+func (in *TenantSpec) DeepCopy() *TenantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentityConfig) DeepCopyInto(out *WorkloadIdentityConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentityConfig.
+func (in *WorkloadIdentityConfig) DeepCopy() *WorkloadIdentityConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentityConfig)
+	in.DeepCopyInto(out)
+	return out
+}
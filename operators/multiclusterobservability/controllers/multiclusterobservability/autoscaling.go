@@ -0,0 +1,235 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package multiclusterobservability
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	mcoshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+)
+
+const (
+	autoscalingConditionType    = "Autoscaling"
+	defaultTargetCPUUtilization = int32(80)
+)
+
+// getAutoscaledDeploymentName returns the name of the deployment of the component, which is also the name of its
+// HorizontalPodAutoscaler.
+func getAutoscaledDeploymentName(component string) string {
+	switch component {
+	case mcoconfig.ThanosQuery, mcoconfig.ThanosQueryFrontend:
+		return mcoconfig.GetOperandName(mcoconfig.Observatorium) + "-" + component
+	default:
+		return mcoconfig.GetOperandName(component)
+	}
+}
+
+func newHorizontalPodAutoscaler(name string,
+	autoscaling *mcov1beta2.AutoscalingSpec) *autoscalingv2.HorizontalPodAutoscaler {
+	minReplicas := mcoconfig.Replicas1
+	if autoscaling.MinReplicas != nil {
+		minReplicas = *autoscaling.MinReplicas
+	}
+	metrics := []autoscalingv2.MetricSpec{}
+	targetCPU := autoscaling.TargetCPUUtilizationPercentage
+	if targetCPU == nil && autoscaling.TargetMemoryUtilizationPercentage == nil {
+		defaultTarget := defaultTargetCPUUtilization
+		targetCPU = &defaultTarget
+	}
+	if targetCPU != nil {
+		metrics = append(metrics, newUtilizationMetric(corev1.ResourceCPU, *targetCPU))
+	}
+	if autoscaling.TargetMemoryUtilizationPercentage != nil {
+		metrics = append(metrics,
+			newUtilizationMetric(corev1.ResourceMemory, *autoscaling.TargetMemoryUtilizationPercentage))
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: mcoconfig.GetDefaultNamespace(),
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       name,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: autoscaling.MaxReplicas,
+			Metrics:     metrics,
+		},
+	}
+}
+
+func newUtilizationMetric(resource corev1.ResourceName, utilization int32) autoscalingv2.MetricSpec {
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name: resource,
+			Target: autoscalingv2.MetricTarget{
+				Type:               autoscalingv2.UtilizationMetricType,
+				AverageUtilization: &utilization,
+			},
+		},
+	}
+}
+
+// GenerateHorizontalPodAutoscalers creates or updates the HorizontalPodAutoscalers of the autoscaled components,
+// the HorizontalPodAutoscaler of a component is deleted when its autoscaling is unset.
+func GenerateHorizontalPodAutoscalers(
+	cl client.Client, scheme *runtime.Scheme,
+	mco *mcov1beta2.MultiClusterObservability) (*ctrl.Result, error) {
+	for _, component := range mcoconfig.AutoscaledComponents {
+		name := getAutoscaledDeploymentName(component)
+		found := &autoscalingv2.HorizontalPodAutoscaler{}
+		err := cl.Get(context.TODO(), types.NamespacedName{
+			Name:      name,
+			Namespace: mcoconfig.GetDefaultNamespace(),
+		}, found)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return &ctrl.Result{}, err
+		}
+		exists := err == nil
+
+		autoscaling := mcoconfig.GetAutoscaling(component, mco.Spec.AdvancedConfig)
+		if autoscaling == nil {
+			if exists {
+				log.Info("Deleting the HorizontalPodAutoscaler", "name", name)
+				if err = cl.Delete(context.TODO(), found); err != nil && !k8serrors.IsNotFound(err) {
+					return &ctrl.Result{}, err
+				}
+			}
+			continue
+		}
+
+		hpa := newHorizontalPodAutoscaler(name, autoscaling)
+		if err = controllerutil.SetControllerReference(mco, hpa, scheme); err != nil {
+			return &ctrl.Result{}, err
+		}
+		if !exists {
+			log.Info("Creating the HorizontalPodAutoscaler", "name", name)
+			if err = cl.Create(context.TODO(), hpa); err != nil {
+				return &ctrl.Result{}, err
+			}
+			continue
+		}
+		// the behavior is defaulted by the apiserver, so only the fields set by the operator are compared
+		if !apiequality.Semantic.DeepDerivative(hpa.Spec, found.Spec) {
+			log.Info("Updating the HorizontalPodAutoscaler", "name", name)
+			found.Spec.ScaleTargetRef = hpa.Spec.ScaleTargetRef
+			found.Spec.MinReplicas = hpa.Spec.MinReplicas
+			found.Spec.MaxReplicas = hpa.Spec.MaxReplicas
+			found.Spec.Metrics = hpa.Spec.Metrics
+			if err = cl.Update(context.TODO(), found); err != nil {
+				return &ctrl.Result{}, err
+			}
+		}
+	}
+	return nil, nil
+}
+
+// getAutoscaledReplicas returns the replicas of the component for the Observatorium CR. The replicas of an
+// autoscaled component follow its HorizontalPodAutoscaler, so that the observatorium operator does not scale the
+// deployment back to the fixed replicas.
+func getAutoscaledReplicas(cl client.Client, mco *mcov1beta2.MultiClusterObservability,
+	component string, replicas *int32) *int32 {
+	autoscaling := mcoconfig.GetAutoscaling(component, mco.Spec.AdvancedConfig)
+	if autoscaling == nil {
+		return replicas
+	}
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	err := cl.Get(context.TODO(), types.NamespacedName{
+		Name:      getAutoscaledDeploymentName(component),
+		Namespace: mcoconfig.GetDefaultNamespace(),
+	}, hpa)
+	if err == nil && hpa.Status.DesiredReplicas > 0 {
+		desired := hpa.Status.DesiredReplicas
+		return &desired
+	}
+
+	// the HorizontalPodAutoscaler has not scaled yet, keep the replicas within its limits
+	autoscaled := mcoconfig.Replicas1
+	if replicas != nil {
+		autoscaled = *replicas
+	}
+	if autoscaling.MinReplicas != nil && autoscaled < *autoscaling.MinReplicas {
+		autoscaled = *autoscaling.MinReplicas
+	}
+	if autoscaled > autoscaling.MaxReplicas {
+		autoscaled = autoscaling.MaxReplicas
+	}
+	return &autoscaled
+}
+
+// checkAutoscalingStatus summarizes the status of the HorizontalPodAutoscalers, it returns nil if no component is
+// autoscaled.
+func checkAutoscalingStatus(c client.Client, mco *mcov1beta2.MultiClusterObservability) *mcoshared.Condition {
+	scaling := []string{}
+	for _, component := range mcoconfig.AutoscaledComponents {
+		if mcoconfig.GetAutoscaling(component, mco.Spec.AdvancedConfig) == nil {
+			continue
+		}
+		name := getAutoscaledDeploymentName(component)
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+		err := c.Get(context.TODO(), types.NamespacedName{
+			Name:      name,
+			Namespace: mcoconfig.GetDefaultNamespace(),
+		}, hpa)
+		if err != nil {
+			log.Error(err, "Failed to get the HorizontalPodAutoscaler", "name", name)
+			msg := fmt.Sprintf("Failed to get the HorizontalPodAutoscaler %s", name)
+			return newAutoscalingCondition(metav1.ConditionUnknown, "HorizontalPodAutoscalerNotFound", msg)
+		}
+		for _, condition := range hpa.Status.Conditions {
+			if (condition.Type == autoscalingv2.AbleToScale || condition.Type == autoscalingv2.ScalingActive) &&
+				condition.Status == corev1.ConditionFalse {
+				msg := fmt.Sprintf("HorizontalPodAutoscaler %s cannot scale", name)
+				return newAutoscalingCondition(metav1.ConditionFalse, condition.Reason, msg)
+			}
+		}
+		scaling = append(scaling, component)
+	}
+	if len(scaling) == 0 {
+		return nil
+	}
+	msg := "Autoscaled components: " + strings.Join(scaling, ", ")
+	return newAutoscalingCondition(metav1.ConditionTrue, "ScalingActive", msg)
+}
+
+func updateAutoscalingStatus(
+	conditions *[]mcoshared.Condition,
+	c client.Client,
+	mco *mcov1beta2.MultiClusterObservability) {
+	autoscalingStatus := checkAutoscalingStatus(c, mco)
+	if autoscalingStatus != nil {
+		setStatusCondition(conditions, *autoscalingStatus)
+	} else {
+		removeStatusCondition(conditions, autoscalingConditionType)
+	}
+}
+
+func newAutoscalingCondition(status metav1.ConditionStatus, reason, msg string) *mcoshared.Condition {
+	return &mcoshared.Condition{
+		Type:    autoscalingConditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: msg,
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package multiclusterobservability
+
+import (
+	"context"
+	"testing"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mcoshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+)
+
+func newAutoscaledMCO(minReplicas *int32, maxReplicas int32) *mcov1beta2.MultiClusterObservability {
+	return &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability"},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			AdvancedConfig: &mcov1beta2.AdvancedConfig{
+				Query: &mcov1beta2.QuerySpec{
+					CommonSpec: mcov1beta2.CommonSpec{
+						Autoscaling: &mcov1beta2.AutoscalingSpec{
+							MinReplicas: minReplicas,
+							MaxReplicas: maxReplicas,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestNewHorizontalPodAutoscaler(t *testing.T) {
+	hpa := newHorizontalPodAutoscaler("observability-thanos-query", &mcov1beta2.AutoscalingSpec{MaxReplicas: 5})
+	if *hpa.Spec.MinReplicas != 1 || hpa.Spec.MaxReplicas != 5 {
+		t.Errorf("replicas (%v/%v) are not the expected: (1/5)", *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+	}
+	if hpa.Spec.ScaleTargetRef.Kind != "Deployment" || hpa.Spec.ScaleTargetRef.Name != "observability-thanos-query" {
+		t.Errorf("scale target (%v) is not the expected: (observability-thanos-query)", hpa.Spec.ScaleTargetRef)
+	}
+	if len(hpa.Spec.Metrics) != 1 || hpa.Spec.Metrics[0].Resource.Name != corev1.ResourceCPU ||
+		*hpa.Spec.Metrics[0].Resource.Target.AverageUtilization != defaultTargetCPUUtilization {
+		t.Errorf("metrics (%v) are not the expected: (cpu %v)", hpa.Spec.Metrics, defaultTargetCPUUtilization)
+	}
+
+	targetMemory := int32(70)
+	hpa = newHorizontalPodAutoscaler("observability-thanos-query", &mcov1beta2.AutoscalingSpec{
+		MaxReplicas:                       5,
+		TargetMemoryUtilizationPercentage: &targetMemory,
+	})
+	if len(hpa.Spec.Metrics) != 1 || hpa.Spec.Metrics[0].Resource.Name != corev1.ResourceMemory ||
+		*hpa.Spec.Metrics[0].Resource.Target.AverageUtilization != targetMemory {
+		t.Errorf("metrics (%v) are not the expected: (memory %v)", hpa.Spec.Metrics, targetMemory)
+	}
+}
+
+func TestGenerateHorizontalPodAutoscalers(t *testing.T) {
+	s := runtime.NewScheme()
+	autoscalingv2.AddToScheme(s)
+	mcov1beta2.SchemeBuilder.AddToScheme(s)
+
+	minReplicas := int32(2)
+	mco := newAutoscaledMCO(&minReplicas, 10)
+	c := fake.NewClientBuilder().WithScheme(s).Build()
+	namespacedName := types.NamespacedName{
+		Name:      getAutoscaledDeploymentName(mcoconfig.ThanosQuery),
+		Namespace: mcoconfig.GetDefaultNamespace(),
+	}
+
+	if _, err := GenerateHorizontalPodAutoscalers(c, s, mco); err != nil {
+		t.Fatalf("Failed to generate the HorizontalPodAutoscalers: (%v)", err)
+	}
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	if err := c.Get(context.TODO(), namespacedName, hpa); err != nil {
+		t.Fatalf("Failed to get the HorizontalPodAutoscaler: (%v)", err)
+	}
+	if *hpa.Spec.MinReplicas != 2 || hpa.Spec.MaxReplicas != 10 {
+		t.Errorf("replicas (%v/%v) are not the expected: (2/10)", *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+	}
+	if len(hpa.OwnerReferences) != 1 || hpa.OwnerReferences[0].Name != mco.Name {
+		t.Errorf("owner references (%v) are not the expected: (%v)", hpa.OwnerReferences, mco.Name)
+	}
+
+	// the HorizontalPodAutoscaler is updated with the autoscaling
+	mco.Spec.AdvancedConfig.Query.Autoscaling.MaxReplicas = 20
+	if _, err := GenerateHorizontalPodAutoscalers(c, s, mco); err != nil {
+		t.Fatalf("Failed to update the HorizontalPodAutoscalers: (%v)", err)
+	}
+	if err := c.Get(context.TODO(), namespacedName, hpa); err != nil {
+		t.Fatalf("Failed to get the HorizontalPodAutoscaler: (%v)", err)
+	}
+	if hpa.Spec.MaxReplicas != 20 {
+		t.Errorf("max replicas (%v) are not the expected: (20)", hpa.Spec.MaxReplicas)
+	}
+
+	// the HorizontalPodAutoscaler is deleted with the autoscaling
+	mco.Spec.AdvancedConfig.Query.Autoscaling = nil
+	if _, err := GenerateHorizontalPodAutoscalers(c, s, mco); err != nil {
+		t.Fatalf("Failed to delete the HorizontalPodAutoscalers: (%v)", err)
+	}
+	if err := c.Get(context.TODO(), namespacedName, hpa); !k8serrors.IsNotFound(err) {
+		t.Errorf("the HorizontalPodAutoscaler should be deleted: (%v)", err)
+	}
+}
+
+func TestGetAutoscaledReplicas(t *testing.T) {
+	s := runtime.NewScheme()
+	autoscalingv2.AddToScheme(s)
+
+	minReplicas := int32(3)
+	mco := newAutoscaledMCO(&minReplicas, 10)
+	replicas := int32(2)
+	c := fake.NewClientBuilder().WithScheme(s).Build()
+
+	// the replicas are kept within the limits before the HorizontalPodAutoscaler scales
+	autoscaled := getAutoscaledReplicas(c, mco, mcoconfig.ThanosQuery, &replicas)
+	if *autoscaled != 3 {
+		t.Errorf("replicas (%v) are not the expected: (3)", *autoscaled)
+	}
+	// the replicas of the components which are not autoscaled are not changed
+	autoscaled = getAutoscaledReplicas(c, mco, mcoconfig.ThanosQueryFrontend, &replicas)
+	if autoscaled != &replicas {
+		t.Errorf("replicas (%v) are not the expected: (%v)", *autoscaled, replicas)
+	}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getAutoscaledDeploymentName(mcoconfig.ThanosQuery),
+			Namespace: mcoconfig.GetDefaultNamespace(),
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: 4, DesiredReplicas: 6},
+	}
+	c = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(hpa).Build()
+	autoscaled = getAutoscaledReplicas(c, mco, mcoconfig.ThanosQuery, &replicas)
+	if *autoscaled != 6 {
+		t.Errorf("replicas (%v) are not the expected: (6)", *autoscaled)
+	}
+}
+
+func TestUpdateAutoscalingStatus(t *testing.T) {
+	s := runtime.NewScheme()
+	autoscalingv2.AddToScheme(s)
+
+	mco := newAutoscaledMCO(nil, 10)
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getAutoscaledDeploymentName(mcoconfig.ThanosQuery),
+			Namespace: mcoconfig.GetDefaultNamespace(),
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: 4, DesiredReplicas: 6},
+	}
+	conditions := []mcoshared.Condition{}
+
+	c := fake.NewClientBuilder().WithScheme(s).Build()
+	updateAutoscalingStatus(&conditions, c, mco)
+	if len(conditions) != 1 || conditions[0].Status != metav1.ConditionUnknown {
+		t.Errorf("conditions (%v) are not the expected: (%v)", conditions, metav1.ConditionUnknown)
+	}
+
+	c = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(hpa).Build()
+	updateAutoscalingStatus(&conditions, c, mco)
+	if len(conditions) != 1 || conditions[0].Status != metav1.ConditionTrue ||
+		conditions[0].Message != "Autoscaled components: thanos-query" {
+		t.Errorf("conditions (%v) are not the expected: (%v)", conditions, metav1.ConditionTrue)
+	}
+
+	hpa.Status.Conditions = []autoscalingv2.HorizontalPodAutoscalerCondition{
+		{
+			Type:    autoscalingv2.ScalingActive,
+			Status:  corev1.ConditionFalse,
+			Reason:  "FailedGetResourceMetric",
+			Message: "missing request for cpu",
+		},
+	}
+	c = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(hpa).Build()
+	updateAutoscalingStatus(&conditions, c, mco)
+	if len(conditions) != 1 || conditions[0].Status != metav1.ConditionFalse ||
+		conditions[0].Reason != "FailedGetResourceMetric" ||
+		conditions[0].Message != "HorizontalPodAutoscaler "+hpa.Name+" cannot scale" {
+		t.Errorf("conditions (%v) are not the expected: (%v)", conditions, metav1.ConditionFalse)
+	}
+
+	// the condition is removed without autoscaled components
+	mco.Spec.AdvancedConfig.Query.Autoscaling = nil
+	updateAutoscalingStatus(&conditions, c, mco)
+	if len(conditions) != 0 {
+		t.Errorf("conditions (%v) are not the expected: ([])", conditions)
+	}
+}
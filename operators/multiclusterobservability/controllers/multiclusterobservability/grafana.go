@@ -80,7 +80,7 @@ func GenerateGrafanaDataSource(
 		DynamicTimeInterval = 30
 	}
 
-	grafanaDatasources, err := yaml.Marshal(GrafanaDatasources{
+	datasources := GrafanaDatasources{
 		APIVersion: 1,
 		Datasources: []*GrafanaDatasource{
 			{
@@ -114,7 +114,27 @@ func GenerateGrafanaDataSource(
 				},
 			},
 		},
-	})
+	}
+	// the metrics of the other tenants are queried through the tenant path of the rbac-query-proxy
+	for _, tenant := range mco.Spec.Tenants {
+		datasources.Datasources = append(datasources.Datasources, &GrafanaDatasource{
+			Name:      "Observatorium-" + tenant.Name,
+			Type:      "prometheus",
+			Access:    "proxy",
+			IsDefault: false,
+			URL: fmt.Sprintf(
+				"http://%s.%s.svc.cluster.local:8080/tenants/%s",
+				config.ProxyServiceName,
+				config.GetDefaultNamespace(),
+				tenant.Name,
+			),
+			JSONData: &JsonData{
+				QueryTimeout: "300s",
+				TimeInterval: fmt.Sprintf("%ds", mco.Spec.ObservabilityAddonSpec.Interval),
+			},
+		})
+	}
+	grafanaDatasources, err := yaml.Marshal(datasources)
 	if err != nil {
 		return &ctrl.Result{}, err
 	}
@@ -13,7 +13,9 @@ import (
 	"github.com/go-logr/logr"
 	routev1 "github.com/openshift/api/route/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	storev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -294,6 +296,30 @@ func (r *MultiClusterObservabilityReconciler) Reconcile(ctx context.Context, req
 		return *result, err
 	}
 
+	// render the receive limits with the tenant ids of the Observatorium CR
+	result, err = GenerateReceiveLimitsConfigMap(r.Client, r.Scheme, instance)
+	if result != nil {
+		return *result, err
+	}
+
+	// patch the thanos workloads with the configuration the Observatorium CR cannot carry
+	result, err = GenerateThanosWorkloadPatches(r.Client, r.Scheme, instance)
+	if result != nil {
+		return *result, err
+	}
+
+	// create the HorizontalPodAutoscalers of the autoscaled components
+	result, err = GenerateHorizontalPodAutoscalers(r.Client, r.Scheme, instance)
+	if result != nil {
+		return *result, err
+	}
+
+	// create the PodDisruptionBudgets of the stateful components and of the caches
+	result, err = GeneratePodDisruptionBudgets(r.Client, r.Scheme, instance)
+	if result != nil {
+		return *result, err
+	}
+
 	// generate grafana datasource to point to observatorium api gateway
 	result, err = GenerateGrafanaDataSource(r.Client, r.Scheme, instance)
 	if result != nil {
@@ -512,6 +538,17 @@ func (r *MultiClusterObservabilityReconciler) SetupWithManager(mgr ctrl.Manager)
 		Owns(&corev1.Service{}).
 		// Watch for changes to secondary Observatorium CR and requeue the owner MultiClusterObservability
 		Owns(&observatoriumv1alpha1.Observatorium{}).
+		// Watch for changes to secondary resource HorizontalPodAutoscaler and requeue the owner MultiClusterObservability
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
+		// Watch for changes to secondary resource PodDisruptionBudget and requeue the owner MultiClusterObservability
+		Owns(&policyv1.PodDisruptionBudget{}).
+		// Watch the thanos workloads rendered by the observatorium operator, which reverts their patches
+		Watches(&source.Kind{Type: &appsv1.StatefulSet{}}, &handler.EnqueueRequestForOwner{
+			OwnerType: &observatoriumv1alpha1.Observatorium{}, IsController: true,
+		}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &appsv1.Deployment{}}, &handler.EnqueueRequestForOwner{
+			OwnerType: &observatoriumv1alpha1.Observatorium{}, IsController: true,
+		}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		// Watch the configmap for thanos-ruler-custom-rules update
 		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestForObject{}, builder.WithPredicates(cmPred)).
 
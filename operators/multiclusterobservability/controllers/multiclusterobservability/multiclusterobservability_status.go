@@ -35,8 +35,13 @@ func StartStatusUpdate(c client.Client, instance *mcov1beta2.MultiClusterObserva
 			updateStatusIsRunnning = true
 			// defer close(stopStatusUpdate)
 			// defer close(requeueStatusUpdate)
+			// the object storage is probed periodically
+			probeTicker := time.NewTicker(objStorageProbeInterval)
+			defer probeTicker.Stop()
 			for {
 				select {
+				case <-probeTicker.C:
+					updateStatus(c)
 				case <-stopStatusUpdate:
 					updateStatusIsRunnning = false
 					close(stopCheckReady)
@@ -91,6 +96,8 @@ func updateStatus(c client.Client) {
 	updateInstallStatus(&newStatus.Conditions)
 	updateReadyStatus(&newStatus.Conditions, c, instance)
 	updateAddonSpecStatus(&newStatus.Conditions, instance)
+	updateObjStorageReadyStatus(&newStatus.Conditions, c, instance)
+	updateAutoscalingStatus(&newStatus.Conditions, c, instance)
 	fillupStatus(&newStatus.Conditions)
 	instance.Status.Conditions = newStatus.Conditions
 	if !reflect.DeepEqual(newStatus.Conditions, oldStatus.Conditions) {
@@ -290,10 +297,22 @@ func getExpectedStatefulSetNames() []string {
 	}
 }
 
+// getHashringStatefulSetNames returns the names of the receivers of the hashrings other than the default one.
+func getHashringStatefulSetNames(mco *mcov1beta2.MultiClusterObservability) []string {
+	names := []string{}
+	if mco.Spec.AdvancedConfig == nil || mco.Spec.AdvancedConfig.Receive == nil {
+		return names
+	}
+	for _, hashring := range mco.Spec.AdvancedConfig.Receive.Hashrings {
+		names = append(names, config.GetOperandNamePrefix()+"thanos-receive-"+hashring.Name)
+	}
+	return names
+}
+
 func checkStatefulSetStatus(
 	c client.Client,
 	mco *mcov1beta2.MultiClusterObservability) *mcoshared.Condition {
-	expectedStatefulSetNames := getExpectedStatefulSetNames()
+	expectedStatefulSetNames := append(getExpectedStatefulSetNames(), getHashringStatefulSetNames(mco)...)
 	for _, name := range expectedStatefulSetNames {
 		found := &appsv1.StatefulSet{}
 		namespacedName := types.NamespacedName{
@@ -336,7 +355,7 @@ func checkObjStorageStatus(
 		return newFailedCondition("ObjectStorageConfInvalid", msg)
 	}
 
-	ok, err = config.CheckObjStorageConf(data)
+	ok, err = config.CheckMetricObjStorageConf(data, mco.Spec.StorageConfig)
 	if !ok {
 		return newFailedCondition("ObjectStorageConfInvalid", err.Error())
 	}
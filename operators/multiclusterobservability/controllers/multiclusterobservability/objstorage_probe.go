@@ -0,0 +1,250 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package multiclusterobservability
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mcoshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+)
+
+const (
+	objStorageReadyConditionType = "ObjectStorageReady"
+	// objStorageProbeInterval is the period of the object storage probe when its configuration does not change
+	objStorageProbeInterval = 5 * time.Minute
+)
+
+var (
+	objStorageProber = &objStorageProbe{}
+	// probeObjStorage probes the object storage, it is replaced in the unit tests
+	probeObjStorage = config.ProbeObjStorage
+	// probeWorkloadIdentityObjStorage probes the object storage with the workload identity, it is replaced in the
+	// unit tests
+	probeWorkloadIdentityObjStorage = config.ProbeWorkloadIdentityObjStorage
+	// requestServiceAccountToken requests a token of the service account, it is replaced in the unit tests
+	requestServiceAccountToken = createServiceAccountToken
+	kubeClient                 kubernetes.Interface
+)
+
+// objStorageProbe keeps the result of the last probe of the object storage, the probe runs in the
+// background when the configuration changes or when the result is older than objStorageProbeInterval.
+type objStorageProbe struct {
+	mu sync.Mutex
+	// hash of the configuration probed
+	hash    string
+	time    time.Time
+	err     error
+	done    bool
+	running bool
+}
+
+// getObjStorageConfHash returns the hash of the object storage configuration, of its TLS files and of the
+// workload identity, if any.
+func getObjStorageConfHash(data []byte, tlsFiles map[string][]byte, identity ...string) string {
+	h := sha256.New()
+	h.Write(data)
+	for _, value := range identity {
+		h.Write([]byte(value))
+	}
+	names := []string{}
+	for name := range tlsFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write(tlsFiles[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// result returns the result of the probe of the configuration with the hash, false if the configuration is not
+// probed yet. A probe is started if needed.
+func (p *objStorageProbe) result(hash string, probe func(ctx context.Context) error) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.running && (hash != p.hash || time.Since(p.time) > objStorageProbeInterval) {
+		if hash != p.hash {
+			p.done = false
+		}
+		p.hash = hash
+		p.running = true
+		go p.run(hash, probe)
+	}
+	return p.done, p.err
+}
+
+func (p *objStorageProbe) run(hash string, probe func(ctx context.Context) error) {
+	err := probe(context.Background())
+	if err != nil && !errors.Is(err, config.ErrObjStorageProbeNotSupported) {
+		log.Error(err, "The object storage probe failed")
+	}
+	p.mu.Lock()
+	p.running = false
+	if p.hash == hash {
+		p.err = err
+		p.time = time.Now()
+		p.done = true
+	}
+	p.mu.Unlock()
+
+	// report the result, the status is updated periodically anyway if the status update is busy
+	select {
+	case requeueStatusUpdate <- struct{}{}:
+	default:
+	}
+}
+
+// getTLSFiles returns the content of the TLS secret of the object storage.
+func getTLSFiles(c client.Client, objStorageConf *mcoshared.PreConfiguredStorage) (map[string][]byte, error) {
+	if objStorageConf.TLSSecretName == "" {
+		return nil, nil
+	}
+	secret := &corev1.Secret{}
+	err := c.Get(context.TODO(), types.NamespacedName{
+		Name:      objStorageConf.TLSSecretName,
+		Namespace: config.GetDefaultNamespace(),
+	}, secret)
+	if err != nil {
+		return nil, err
+	}
+	return secret.Data, nil
+}
+
+// checkObjStorageReady returns the ObjectStorageReady condition, with the result of the probe of the
+// object storage.
+func checkObjStorageReady(c client.Client, mco *mcov1beta2.MultiClusterObservability) *mcoshared.Condition {
+	if status := checkObjStorageStatus(c, mco); status != nil {
+		return newObjStorageReadyCondition(metav1.ConditionFalse, status.Reason, status.Message)
+	}
+	objStorageConf := mco.Spec.StorageConfig.MetricObjectStorage
+	secret := &corev1.Secret{}
+	err := c.Get(context.TODO(), types.NamespacedName{
+		Name:      objStorageConf.Name,
+		Namespace: config.GetDefaultNamespace(),
+	}, secret)
+	if err != nil {
+		return newObjStorageReadyCondition(metav1.ConditionFalse, "ObjectStorageSecretNotFound", err.Error())
+	}
+	tlsFiles, err := getTLSFiles(c, objStorageConf)
+	if err != nil {
+		return newObjStorageReadyCondition(metav1.ConditionFalse, "ObjectStorageTLSSecretNotFound", err.Error())
+	}
+
+	data := secret.Data[objStorageConf.Key]
+	hash := getObjStorageConfHash(data, tlsFiles)
+	probe := func(ctx context.Context) error {
+		return probeObjStorage(ctx, data, tlsFiles)
+	}
+	if config.IsWorkloadIdentityMode(mco.Spec.StorageConfig) {
+		identity := mco.Spec.StorageConfig.WorkloadIdentity
+		serviceAccount, err := getThanosServiceAccountName(c)
+		if err != nil {
+			return newObjStorageReadyCondition(metav1.ConditionUnknown, "WorkloadIdentityPending", err.Error())
+		}
+		hash = getObjStorageConfHash(data, tlsFiles, serviceAccount, identity.AWSRoleARN,
+			identity.GCPServiceAccount, identity.AzureClientID, identity.AzureTenantID)
+		probe = func(ctx context.Context) error {
+			audience := config.GetWorkloadIdentityTokenAudience(data)
+			if audience == "" {
+				return config.ErrObjStorageProbeNotSupported
+			}
+			token, err := requestServiceAccountToken(ctx, config.GetDefaultNamespace(), serviceAccount, audience)
+			if err != nil {
+				return fmt.Errorf("%w: failed to request a token of the service account %s: %v",
+					config.ErrWorkloadIdentityNotUsable, serviceAccount, err)
+			}
+			return probeWorkloadIdentityObjStorage(ctx, data, tlsFiles, identity, token)
+		}
+	}
+
+	done, err := objStorageProber.result(hash, probe)
+	if !done {
+		return newObjStorageReadyCondition(metav1.ConditionUnknown, "ObjectStorageProbeInProgress",
+			"The object storage is being probed")
+	}
+	if errors.Is(err, config.ErrObjStorageProbeNotSupported) {
+		return newObjStorageReadyCondition(metav1.ConditionUnknown, "ObjectStorageProbeNotSupported", err.Error())
+	}
+	if errors.Is(err, config.ErrWorkloadIdentityNotUsable) {
+		return newObjStorageReadyCondition(metav1.ConditionFalse, "WorkloadIdentityNotUsable", err.Error())
+	}
+	if err != nil {
+		return newObjStorageReadyCondition(metav1.ConditionFalse, "ObjectStorageProbeFailed", err.Error())
+	}
+	return newObjStorageReadyCondition(metav1.ConditionTrue, "ObjectStorageProbeSucceeded",
+		"The objects of the object storage can be listed, uploaded, read and deleted")
+}
+
+// getThanosServiceAccountName returns the name of the service account of the thanos compact, the object storage
+// is probed with its workload identity.
+func getThanosServiceAccountName(c client.Client) (string, error) {
+	sts := &appsv1.StatefulSet{}
+	name := config.GetOperandNamePrefix() + config.ThanosCompact
+	err := c.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: config.GetDefaultNamespace()}, sts)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the statefulset %s: %w", name, err)
+	}
+	if sts.Spec.Template.Spec.ServiceAccountName == "" {
+		return "", fmt.Errorf("no service account in the statefulset %s", name)
+	}
+	return sts.Spec.Template.Spec.ServiceAccountName, nil
+}
+
+// createServiceAccountToken requests a short-lived token of the service account with the audience.
+func createServiceAccountToken(ctx context.Context, namespace, name, audience string) ([]byte, error) {
+	if kubeClient == nil {
+		var err error
+		kubeClient, err = kubernetes.NewForConfig(ctrl.GetConfigOrDie())
+		if err != nil {
+			return nil, err
+		}
+	}
+	expiration := int64(time.Hour.Seconds())
+	tokenRequest, err := kubeClient.CoreV1().ServiceAccounts(namespace).CreateToken(ctx, name,
+		&authv1.TokenRequest{
+			Spec: authv1.TokenRequestSpec{
+				Audiences:         []string{audience},
+				ExpirationSeconds: &expiration,
+			},
+		}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(tokenRequest.Status.Token), nil
+}
+
+func updateObjStorageReadyStatus(
+	conditions *[]mcoshared.Condition,
+	c client.Client,
+	mco *mcov1beta2.MultiClusterObservability) {
+	setStatusCondition(conditions, *checkObjStorageReady(c, mco))
+}
+
+func newObjStorageReadyCondition(status metav1.ConditionStatus, reason, msg string) *mcoshared.Condition {
+	return &mcoshared.Condition{
+		Type:    objStorageReadyConditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: msg,
+	}
+}
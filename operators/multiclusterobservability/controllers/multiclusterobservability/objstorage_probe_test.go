@@ -0,0 +1,185 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package multiclusterobservability
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mcoshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+)
+
+func TestCheckObjStorageReady(t *testing.T) {
+	objStorageProber = &objStorageProbe{}
+	var probeMu sync.Mutex
+	var probeErr error
+	probes := 0
+	probeObjStorage = func(ctx context.Context, data []byte, tlsFiles map[string][]byte) error {
+		probeMu.Lock()
+		defer probeMu.Unlock()
+		probes++
+		return probeErr
+	}
+	defer func() {
+		objStorageProber = &objStorageProbe{}
+		probeObjStorage = mcoconfig.ProbeObjStorage
+	}()
+
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability"},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			StorageConfig: &mcov1beta2.StorageConfig{
+				MetricObjectStorage: &mcoshared.PreConfiguredStorage{Key: "test", Name: "test"},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().Build()
+	condition := checkObjStorageReady(c, mco)
+	if condition.Status != metav1.ConditionFalse || condition.Reason != "ObjectStorageSecretNotFound" {
+		t.Errorf("condition (%v) should report the missing secret", condition)
+	}
+
+	c = fake.NewClientBuilder().WithRuntimeObjects(
+		createSecret("test", "test", mcoconfig.GetDefaultNamespace())).Build()
+	getCondition := func(status metav1.ConditionStatus) *mcoshared.Condition {
+		var condition *mcoshared.Condition
+		_ = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			condition = checkObjStorageReady(c, mco)
+			return condition.Status == status, nil
+		})
+		return condition
+	}
+	condition = getCondition(metav1.ConditionTrue)
+	if condition.Status != metav1.ConditionTrue || condition.Reason != "ObjectStorageProbeSucceeded" {
+		t.Errorf("condition (%v) should report the succeeded probe", condition)
+	}
+	probeMu.Lock()
+	if probes != 1 {
+		t.Errorf("the object storage should be probed once, probed %d times", probes)
+	}
+	probeMu.Unlock()
+
+	mco.Spec.StorageConfig.MetricObjectStorage.TLSSecretName = "tls"
+	condition = checkObjStorageReady(c, mco)
+	if condition.Reason != "ObjectStorageTLSSecretNotFound" {
+		t.Errorf("condition (%v) should report the missing TLS secret", condition)
+	}
+	mco.Spec.StorageConfig.MetricObjectStorage.TLSSecretName = ""
+
+	// the object storage is probed again periodically
+	probeMu.Lock()
+	probeErr = errors.New("NoSuchBucket")
+	probeMu.Unlock()
+	objStorageProber.mu.Lock()
+	objStorageProber.time = time.Now().Add(-objStorageProbeInterval)
+	objStorageProber.mu.Unlock()
+	condition = getCondition(metav1.ConditionFalse)
+	if condition.Reason != "ObjectStorageProbeFailed" || condition.Message != "NoSuchBucket" {
+		t.Errorf("condition (%v) should report the failed probe", condition)
+	}
+
+	probeMu.Lock()
+	probeErr = mcoconfig.ErrObjStorageProbeNotSupported
+	probeMu.Unlock()
+	objStorageProber.mu.Lock()
+	objStorageProber.time = time.Now().Add(-objStorageProbeInterval)
+	objStorageProber.mu.Unlock()
+	condition = getCondition(metav1.ConditionUnknown)
+	if condition.Reason != "ObjectStorageProbeNotSupported" {
+		t.Errorf("condition (%v) should report the unsupported probe", condition)
+	}
+}
+
+func TestCheckObjStorageReadyWorkloadIdentity(t *testing.T) {
+	objStorageProber = &objStorageProbe{}
+	var probeMu sync.Mutex
+	var probeErr error
+	var probedToken []byte
+	requestServiceAccountToken = func(ctx context.Context, namespace, name, audience string) ([]byte, error) {
+		if name != "thanos-compact" || audience != mcoconfig.AWSTokenAudience {
+			return nil, errors.New("unexpected token request")
+		}
+		return []byte("token"), nil
+	}
+	probeWorkloadIdentityObjStorage = func(ctx context.Context, data []byte, tlsFiles map[string][]byte,
+		identity *mcov1beta2.WorkloadIdentityConfig, token []byte) error {
+		probeMu.Lock()
+		defer probeMu.Unlock()
+		probedToken = token
+		return probeErr
+	}
+	defer func() {
+		objStorageProber = &objStorageProbe{}
+		requestServiceAccountToken = createServiceAccountToken
+		probeWorkloadIdentityObjStorage = mcoconfig.ProbeWorkloadIdentityObjStorage
+	}()
+
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability"},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			StorageConfig: &mcov1beta2.StorageConfig{
+				MetricObjectStorage: &mcoshared.PreConfiguredStorage{Key: "thanos.yaml", Name: "thanos-object-storage"},
+				CredentialsMode:     mcov1beta2.CredentialsModeWorkloadIdentity,
+				WorkloadIdentity:    &mcov1beta2.WorkloadIdentityConfig{AWSRoleARN: "arn:aws:iam::123:role/thanos"},
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "thanos-object-storage", Namespace: mcoconfig.GetDefaultNamespace()},
+		Data: map[string][]byte{
+			"thanos.yaml": []byte("type: s3\nconfig:\n  bucket: bucket\n  endpoint: s3.us-east-1.amazonaws.com\n"),
+		},
+	}
+	c := fake.NewClientBuilder().WithRuntimeObjects(secret).Build()
+	condition := checkObjStorageReady(c, mco)
+	if condition.Status != metav1.ConditionUnknown || condition.Reason != "WorkloadIdentityPending" {
+		t.Errorf("condition (%v) should report the missing thanos compact", condition)
+	}
+
+	compact := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mcoconfig.GetOperandNamePrefix() + mcoconfig.ThanosCompact,
+			Namespace: mcoconfig.GetDefaultNamespace(),
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{ServiceAccountName: "thanos-compact"}},
+		},
+	}
+	c = fake.NewClientBuilder().WithRuntimeObjects(secret, compact).Build()
+	getCondition := func(status metav1.ConditionStatus) *mcoshared.Condition {
+		var condition *mcoshared.Condition
+		_ = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			condition = checkObjStorageReady(c, mco)
+			return condition.Status == status, nil
+		})
+		return condition
+	}
+	condition = getCondition(metav1.ConditionTrue)
+	if condition.Status != metav1.ConditionTrue || condition.Reason != "ObjectStorageProbeSucceeded" {
+		t.Errorf("condition (%v) should report the succeeded probe", condition)
+	}
+	probeMu.Lock()
+	if string(probedToken) != "token" {
+		t.Errorf("token (%s) is not the expected: (token)", probedToken)
+	}
+	probeErr = mcoconfig.ErrWorkloadIdentityNotUsable
+	probeMu.Unlock()
+
+	mco.Spec.StorageConfig.WorkloadIdentity.AWSRoleARN = "arn:aws:iam::123:role/other"
+	condition = getCondition(metav1.ConditionFalse)
+	if condition.Reason != "WorkloadIdentityNotUsable" {
+		t.Errorf("condition (%v) should report the unusable identity", condition)
+	}
+}
@@ -190,7 +190,7 @@ func updateTenantID(
 	oldTenant obsv1alpha1.APITenant,
 	idx int) {
 
-	if oldTenant.Name == newTenant.Name && newTenant.ID == oldTenant.ID {
+	if oldTenant.Name != newTenant.Name || newTenant.ID == oldTenant.ID {
 		return
 	}
 
@@ -198,7 +198,7 @@ func updateTenantID(
 	for j, hashring := range newSpec.Hashrings {
 		if util.Contains(hashring.Tenants, newTenant.ID) {
 			newSpec.Hashrings[j].Tenants = util.Remove(newSpec.Hashrings[j].Tenants, newTenant.ID)
-			newSpec.Hashrings[j].Tenants = append(newSpec.Hashrings[0].Tenants, oldTenant.ID)
+			newSpec.Hashrings[j].Tenants = append(newSpec.Hashrings[j].Tenants, oldTenant.ID)
 		}
 	}
 }
@@ -265,13 +265,15 @@ func newDefaultObservatoriumSpec(cl client.Client, mco *mcov1beta2.MultiClusterO
 	}
 	obs.API = obsApi
 	obs.Thanos = newThanosSpec(mco, scSelected)
+	obs.API.Replicas = getAutoscaledReplicas(cl, mco, mcoconfig.ObservatoriumAPI, obs.API.Replicas)
+	obs.Thanos.Query.Replicas = getAutoscaledReplicas(cl, mco, mcoconfig.ThanosQuery, obs.Thanos.Query.Replicas)
+	obs.Thanos.QueryFrontend.Replicas = getAutoscaledReplicas(cl, mco, mcoconfig.ThanosQueryFrontend,
+		obs.Thanos.QueryFrontend.Replicas)
 	if util.ProxyEnvVarsAreSet() {
 		obs.EnvVars = newEnvVars()
 	}
 
-	obs.Hashrings = []*obsv1alpha1.Hashring{
-		{Hashring: "default", Tenants: []string{mcoconfig.GetTenantUID()}},
-	}
+	obs.Hashrings = newHashrings(mco, obs.API.Tenants)
 
 	obs.ObjectStorageConfig.Thanos = &obsv1alpha1.ThanosObjectStorageConfigSpec{}
 	if mco.Spec.StorageConfig != nil && mco.Spec.StorageConfig.MetricObjectStorage != nil {
@@ -284,6 +286,37 @@ func newDefaultObservatoriumSpec(cl client.Client, mco *mcov1beta2.MultiClusterO
 	return obs, nil
 }
 
+// newHashrings returns the hashrings of the receive spec with the ids of their tenants, followed by the default
+// hashring of the other tenants. A hashring without tenants serves all the tenants, so the default hashring is the
+// last one.
+func newHashrings(mco *mcov1beta2.MultiClusterObservability,
+	tenants []obsv1alpha1.APITenant) []*obsv1alpha1.Hashring {
+	tenantIDs := map[string]string{}
+	for _, tenant := range tenants {
+		tenantIDs[tenant.Name] = tenant.ID
+	}
+	hashrings := []*obsv1alpha1.Hashring{}
+	if mco.Spec.AdvancedConfig != nil && mco.Spec.AdvancedConfig.Receive != nil {
+		for _, hashring := range mco.Spec.AdvancedConfig.Receive.Hashrings {
+			ids := []string{}
+			for _, name := range hashring.Tenants {
+				if id, ok := tenantIDs[name]; ok {
+					ids = append(ids, id)
+					delete(tenantIDs, name)
+				}
+			}
+			hashrings = append(hashrings, &obsv1alpha1.Hashring{Hashring: hashring.Name, Tenants: ids})
+		}
+	}
+	defaultIDs := []string{}
+	for _, tenant := range tenants {
+		if id, ok := tenantIDs[tenant.Name]; ok {
+			defaultIDs = append(defaultIDs, id)
+		}
+	}
+	return append(hashrings, &obsv1alpha1.Hashring{Hashring: "default", Tenants: defaultIDs})
+}
+
 // return proxy variables
 // OLM set these environment variables as a unit
 func newEnvVars() map[string]string {
@@ -294,8 +327,18 @@ func newEnvVars() map[string]string {
 	}
 }
 
-func newAPIRBAC() obsv1alpha1.APIRBAC {
-	return obsv1alpha1.APIRBAC{
+// getWriteRoleName returns the name of the write role of the tenant, the default tenant keeps the original name.
+func getWriteRoleName(tenant string) string {
+	if tenant == mcoconfig.GetDefaultTenantName() {
+		return writeOnlyRoleName
+	}
+	return writeOnlyRoleName + "-" + tenant
+}
+
+// newAPIRBAC returns the read role of all the tenants bound to grafana, and a write role per tenant bound to the
+// group of the managed clusters mapped to the tenant, the organization unit of their client certificates.
+func newAPIRBAC(mco *mcov1beta2.MultiClusterObservability) obsv1alpha1.APIRBAC {
+	rbac := obsv1alpha1.APIRBAC{
 		Roles: []obsv1alpha1.RBACRole{
 			{
 				Name: readOnlyRoleName,
@@ -305,21 +348,7 @@ func newAPIRBAC() obsv1alpha1.APIRBAC {
 				Permissions: []obsv1alpha1.Permission{
 					obsv1alpha1.Read,
 				},
-				Tenants: []string{
-					mcoconfig.GetDefaultTenantName(),
-				},
-			},
-			{
-				Name: writeOnlyRoleName,
-				Resources: []string{
-					"metrics",
-				},
-				Permissions: []obsv1alpha1.Permission{
-					obsv1alpha1.Write,
-				},
-				Tenants: []string{
-					mcoconfig.GetDefaultTenantName(),
-				},
+				Tenants: mcoconfig.GetTenantNames(mco),
 			},
 		},
 		RoleBindings: []obsv1alpha1.RBACRoleBinding{
@@ -335,33 +364,51 @@ func newAPIRBAC() obsv1alpha1.APIRBAC {
 					},
 				},
 			},
-			{
-				Name: writeOnlyRoleName,
-				Roles: []string{
-					writeOnlyRoleName,
-				},
-				Subjects: []obsv1alpha1.Subject{
-					{
-						Name: config.ManagedClusterOU,
-						Kind: obsv1alpha1.Group,
-					},
+		},
+	}
+	for _, tenant := range mcoconfig.GetTenantNames(mco) {
+		name := getWriteRoleName(tenant)
+		rbac.Roles = append(rbac.Roles, obsv1alpha1.RBACRole{
+			Name: name,
+			Resources: []string{
+				"metrics",
+			},
+			Permissions: []obsv1alpha1.Permission{
+				obsv1alpha1.Write,
+			},
+			Tenants: []string{tenant},
+		})
+		rbac.RoleBindings = append(rbac.RoleBindings, obsv1alpha1.RBACRoleBinding{
+			Name: name,
+			Roles: []string{
+				name,
+			},
+			Subjects: []obsv1alpha1.Subject{
+				{
+					Name: mcoconfig.GetTenantClusterGroup(tenant),
+					Kind: obsv1alpha1.Group,
 				},
 			},
-		},
+		})
 	}
+	return rbac
 }
 
-func newAPITenants() []obsv1alpha1.APITenant {
-	return []obsv1alpha1.APITenant{
-		{
-			Name: mcoconfig.GetDefaultTenantName(),
-			ID:   mcoconfig.GetTenantUID(),
+// newAPITenants returns the default tenant and the tenants of the MultiClusterObservability CR, the managed
+// clusters of all the tenants are authenticated with the same client CA.
+func newAPITenants(mco *mcov1beta2.MultiClusterObservability) []obsv1alpha1.APITenant {
+	tenants := []obsv1alpha1.APITenant{}
+	for _, name := range mcoconfig.GetTenantNames(mco) {
+		tenants = append(tenants, obsv1alpha1.APITenant{
+			Name: name,
+			ID:   mcoconfig.GetTenantUIDByName(name),
 			MTLS: &obsv1alpha1.TenantMTLS{
 				SecretName: config.ClientCACerts,
 				CAKey:      "tls.crt",
 			},
-		},
+		})
 	}
+	return tenants
 }
 
 func newAPITLS() obsv1alpha1.TLS {
@@ -424,8 +471,8 @@ func applyEndpointsSecret(c client.Client, eps []mcoutil.RemoteWriteEndpointWith
 
 func newAPISpec(c client.Client, mco *mcov1beta2.MultiClusterObservability) (obsv1alpha1.APISpec, error) {
 	apiSpec := obsv1alpha1.APISpec{}
-	apiSpec.RBAC = newAPIRBAC()
-	apiSpec.Tenants = newAPITenants()
+	apiSpec.RBAC = newAPIRBAC(mco)
+	apiSpec.Tenants = newAPITenants(mco)
 	apiSpec.TLS = newAPITLS()
 	apiSpec.Replicas = mcoconfig.GetReplicas(mcoconfig.ObservatoriumAPI, mco.Spec.AdvancedConfig)
 	if !mcoconfig.WithoutResourcesRequests(mco.GetAnnotations()) {
@@ -507,6 +554,20 @@ func newAPISpec(c client.Client, mco *mcov1beta2.MultiClusterObservability) (obs
 	return apiSpec, nil
 }
 
+// getReceiveReplicationFactor returns the replication factor of the receive spec if it is at most the replicas of
+// the receivers, otherwise 3 or the replicas if they are less than 3.
+func getReceiveReplicationFactor(mco *mcov1beta2.MultiClusterObservability) *int32 {
+	replicas := mcoconfig.GetReplicas(mcoconfig.ThanosReceive, mco.Spec.AdvancedConfig)
+	if mco.Spec.AdvancedConfig != nil && mco.Spec.AdvancedConfig.Receive != nil &&
+		mco.Spec.AdvancedConfig.Receive.ReplicationFactor != nil &&
+		*mco.Spec.AdvancedConfig.Receive.ReplicationFactor <= *replicas {
+		return mco.Spec.AdvancedConfig.Receive.ReplicationFactor
+	} else if *replicas < 3 {
+		return replicas
+	}
+	return &config.Replicas3
+}
+
 func newReceiversSpec(
 	mco *mcov1beta2.MultiClusterObservability,
 	scSelected string) obsv1alpha1.ReceiversSpec {
@@ -519,11 +580,7 @@ func newReceiversSpec(
 	}
 
 	receSpec.Replicas = mcoconfig.GetReplicas(mcoconfig.ThanosReceive, mco.Spec.AdvancedConfig)
-	if *receSpec.Replicas < 3 {
-		receSpec.ReplicationFactor = receSpec.Replicas
-	} else {
-		receSpec.ReplicationFactor = &config.Replicas3
-	}
+	receSpec.ReplicationFactor = getReceiveReplicationFactor(mco)
 
 	receSpec.ServiceMonitor = true
 	if !mcoconfig.WithoutResourcesRequests(mco.GetAnnotations()) {
@@ -537,6 +594,7 @@ func newReceiversSpec(
 		mco.Spec.AdvancedConfig.Receive.ServiceAccountAnnotations != nil {
 		receSpec.ServiceAccountAnnotations = mco.Spec.AdvancedConfig.Receive.ServiceAccountAnnotations
 	}
+	receSpec.ServiceAccountAnnotations = withWorkloadIdentityAnnotations(mco, receSpec.ServiceAccountAnnotations)
 	return receSpec
 }
 
@@ -632,6 +690,7 @@ func newRuleSpec(mco *mcov1beta2.MultiClusterObservability, scSelected string) o
 		mco.Spec.AdvancedConfig.Rule.ServiceAccountAnnotations != nil {
 		ruleSpec.ServiceAccountAnnotations = mco.Spec.AdvancedConfig.Rule.ServiceAccountAnnotations
 	}
+	ruleSpec.ServiceAccountAnnotations = withWorkloadIdentityAnnotations(mco, ruleSpec.ServiceAccountAnnotations)
 
 	return ruleSpec
 }
@@ -654,6 +713,7 @@ func newStoreSpec(mco *mcov1beta2.MultiClusterObservability, scSelected string)
 		mco.Spec.AdvancedConfig.Store.ServiceAccountAnnotations != nil {
 		storeSpec.ServiceAccountAnnotations = mco.Spec.AdvancedConfig.Store.ServiceAccountAnnotations
 	}
+	storeSpec.ServiceAccountAnnotations = withWorkloadIdentityAnnotations(mco, storeSpec.ServiceAccountAnnotations)
 
 	return storeSpec
 }
@@ -750,7 +810,10 @@ func newQuerySpec(mco *mcov1beta2.MultiClusterObservability) obsv1alpha1.QuerySp
 	querySpec.ServiceMonitor = true
 	// only set lookback-delta when the scrape interval * 2 is larger than 5 minute,
 	// otherwise default value(5m) will be used.
-	if mco.Spec.ObservabilityAddonSpec.Interval*2 > 300 {
+	if mco.Spec.AdvancedConfig != nil && mco.Spec.AdvancedConfig.Query != nil &&
+		mco.Spec.AdvancedConfig.Query.LookbackDelta != "" {
+		querySpec.LookbackDelta = mco.Spec.AdvancedConfig.Query.LookbackDelta
+	} else if mco.Spec.ObservabilityAddonSpec.Interval*2 > 300 {
 		querySpec.LookbackDelta = fmt.Sprintf("%ds", mco.Spec.ObservabilityAddonSpec.Interval*2)
 	}
 	if !mcoconfig.WithoutResourcesRequests(mco.GetAnnotations()) {
@@ -760,6 +823,7 @@ func newQuerySpec(mco *mcov1beta2.MultiClusterObservability) obsv1alpha1.QuerySp
 		mco.Spec.AdvancedConfig.Query.ServiceAccountAnnotations != nil {
 		querySpec.ServiceAccountAnnotations = mco.Spec.AdvancedConfig.Query.ServiceAccountAnnotations
 	}
+	querySpec.ServiceAccountAnnotations = withWorkloadIdentityAnnotations(mco, querySpec.ServiceAccountAnnotations)
 	return querySpec
 }
 
@@ -833,6 +897,7 @@ func newCompactSpec(mco *mcov1beta2.MultiClusterObservability, scSelected string
 		mco.Spec.AdvancedConfig.Compact.ServiceAccountAnnotations != nil {
 		compactSpec.ServiceAccountAnnotations = mco.Spec.AdvancedConfig.Compact.ServiceAccountAnnotations
 	}
+	compactSpec.ServiceAccountAnnotations = withWorkloadIdentityAnnotations(mco, compactSpec.ServiceAccountAnnotations)
 
 	compactSpec.VolumeClaimTemplate = newVolumeClaimTemplate(
 		mco.Spec.StorageConfig.CompactStorageSize,
@@ -912,3 +977,17 @@ func addBackupLabel(c client.Client, name string, backupS *v1.Secret) error {
 	}
 	return nil
 }
+
+// withWorkloadIdentityAnnotations adds the annotations binding the service account of a thanos component to the
+// cloud identity of the workloadIdentity credentials mode, the annotations set in the advanced config are kept.
+func withWorkloadIdentityAnnotations(mco *mcov1beta2.MultiClusterObservability,
+	annotations map[string]string) map[string]string {
+	identityAnnotations := mcoconfig.GetWorkloadIdentityAnnotations(mco.Spec.StorageConfig)
+	if len(identityAnnotations) == 0 {
+		return annotations
+	}
+	for key, value := range annotations {
+		identityAnnotations[key] = value
+	}
+	return identityAnnotations
+}
@@ -6,6 +6,7 @@ package multiclusterobservability
 import (
 	"bytes"
 	"context"
+	"reflect"
 	"testing"
 
 	"gopkg.in/yaml.v2"
@@ -320,3 +321,137 @@ config:
 		}
 	}
 }
+
+func TestWithWorkloadIdentityAnnotations(t *testing.T) {
+	mco := &mcov1beta2.MultiClusterObservability{
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			StorageConfig: &mcov1beta2.StorageConfig{
+				StoreStorageSize: "1Gi",
+				WorkloadIdentity: &mcov1beta2.WorkloadIdentityConfig{AWSRoleARN: "arn:aws:iam::123:role/thanos"},
+			},
+		},
+	}
+	annotations := map[string]string{"key": "value"}
+	if output := withWorkloadIdentityAnnotations(mco, annotations); !reflect.DeepEqual(output, annotations) {
+		t.Errorf("annotations (%v) are not the expected with the static credentials: (%v)", output, annotations)
+	}
+
+	mco.Spec.StorageConfig.CredentialsMode = mcov1beta2.CredentialsModeWorkloadIdentity
+	expected := map[string]string{
+		"key":                          "value",
+		mcoconfig.AWSRoleARNAnnotation: "arn:aws:iam::123:role/thanos",
+	}
+	if output := withWorkloadIdentityAnnotations(mco, annotations); !reflect.DeepEqual(output, expected) {
+		t.Errorf("annotations (%v) are not the expected: (%v)", output, expected)
+	}
+	storeSpec := newStoreSpec(mco, storageClassName)
+	if storeSpec.ServiceAccountAnnotations[mcoconfig.AWSRoleARNAnnotation] == "" {
+		t.Errorf("the service account of the store should be bound to the role: (%v)",
+			storeSpec.ServiceAccountAnnotations)
+	}
+}
+
+func TestNewAPITenants(t *testing.T) {
+	mco := &mcov1beta2.MultiClusterObservability{
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			Tenants: []mcov1beta2.TenantSpec{
+				{Name: "team-a", ClusterSets: []string{"set-a"}},
+			},
+		},
+	}
+	tenants := newAPITenants(mco)
+	if len(tenants) != 2 || tenants[0].Name != mcoconfig.GetDefaultTenantName() || tenants[1].Name != "team-a" {
+		t.Fatalf("tenants (%v) are not the default and team-a tenants", tenants)
+	}
+	if tenants[1].ID == "" || tenants[1].ID == tenants[0].ID || tenants[1].MTLS == nil {
+		t.Errorf("tenant (%v) should have its own id and the mtls of the managed clusters", tenants[1])
+	}
+	rbac := newAPIRBAC(mco)
+	if !reflect.DeepEqual(rbac.Roles[0].Tenants, []string{mcoconfig.GetDefaultTenantName(), "team-a"}) {
+		t.Errorf("tenants of the role (%v) are not the expected", rbac.Roles[0].Tenants)
+	}
+	// the write role of each tenant is bound to the group of its managed clusters only
+	for i, tenant := range []string{mcoconfig.GetDefaultTenantName(), "team-a"} {
+		role, binding := rbac.Roles[i+1], rbac.RoleBindings[i+1]
+		if !reflect.DeepEqual(role.Tenants, []string{tenant}) {
+			t.Errorf("tenants of the write role (%v) are not the expected: (%v)", role.Tenants, []string{tenant})
+		}
+		if group := mcoconfig.GetTenantClusterGroup(tenant); binding.Subjects[0].Name != group ||
+			binding.Roles[0] != role.Name {
+			t.Errorf("binding (%v) is not the expected: (%v)", binding, group)
+		}
+	}
+
+	// the ids of the existing tenants are kept in the tenants and in the hashrings
+	oldSpec := observatoriumv1alpha1.ObservatoriumSpec{
+		API: observatoriumv1alpha1.APISpec{Tenants: []observatoriumv1alpha1.APITenant{
+			{Name: mcoconfig.GetDefaultTenantName(), ID: tenants[0].ID},
+			{Name: "team-a", ID: "old-id"},
+		}},
+	}
+	newSpec := observatoriumv1alpha1.ObservatoriumSpec{
+		API: observatoriumv1alpha1.APISpec{Tenants: tenants},
+		Hashrings: []*observatoriumv1alpha1.Hashring{
+			{Hashring: "default", Tenants: []string{tenants[0].ID, tenants[1].ID}},
+		},
+	}
+	for i, newTenant := range newSpec.API.Tenants {
+		for _, oldTenant := range oldSpec.API.Tenants {
+			updateTenantID(&newSpec, newTenant, oldTenant, i)
+		}
+	}
+	if newSpec.API.Tenants[1].ID != "old-id" {
+		t.Errorf("id (%v) is not the expected: (%v)", newSpec.API.Tenants[1].ID, "old-id")
+	}
+	expected := []string{tenants[0].ID, "old-id"}
+	if !reflect.DeepEqual(newSpec.Hashrings[0].Tenants, expected) {
+		t.Errorf("hashring tenants (%v) are not the expected: (%v)", newSpec.Hashrings[0].Tenants, expected)
+	}
+}
+
+func TestNewHashrings(t *testing.T) {
+	mco := &mcov1beta2.MultiClusterObservability{
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			AdvancedConfig: &mcov1beta2.AdvancedConfig{
+				Receive: &mcov1beta2.ReceiveSpec{
+					Hashrings: []mcov1beta2.HashringSpec{
+						{Name: "team-a", Tenants: []string{"team-a"}},
+					},
+				},
+			},
+		},
+	}
+	tenants := []observatoriumv1alpha1.APITenant{
+		{Name: mcoconfig.GetDefaultTenantName(), ID: "default-id"},
+		{Name: "team-a", ID: "team-a-id"},
+		{Name: "team-b", ID: "team-b-id"},
+	}
+	expected := []*observatoriumv1alpha1.Hashring{
+		{Hashring: "team-a", Tenants: []string{"team-a-id"}},
+		{Hashring: "default", Tenants: []string{"default-id", "team-b-id"}},
+	}
+	if hashrings := newHashrings(mco, tenants); !reflect.DeepEqual(hashrings, expected) {
+		t.Errorf("hashrings (%v) are not the expected: (%v)", hashrings, expected)
+	}
+}
+
+func TestNewReceiversSpecReplicationFactor(t *testing.T) {
+	replicas, replicationFactor := int32(5), int32(2)
+	mco := &mcov1beta2.MultiClusterObservability{
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			StorageConfig: &mcov1beta2.StorageConfig{ReceiveStorageSize: "1Gi"},
+			AdvancedConfig: &mcov1beta2.AdvancedConfig{
+				Receive: &mcov1beta2.ReceiveSpec{
+					CommonSpec: mcov1beta2.CommonSpec{Replicas: &replicas},
+				},
+			},
+		},
+	}
+	if receSpec := newReceiversSpec(mco, storageClassName); *receSpec.ReplicationFactor != 3 {
+		t.Errorf("replication factor (%v) is not the expected: (%v)", *receSpec.ReplicationFactor, 3)
+	}
+	mco.Spec.AdvancedConfig.Receive.ReplicationFactor = &replicationFactor
+	if receSpec := newReceiversSpec(mco, storageClassName); *receSpec.ReplicationFactor != replicationFactor {
+		t.Errorf("replication factor (%v) is not the expected: (%v)", *receSpec.ReplicationFactor, replicationFactor)
+	}
+}
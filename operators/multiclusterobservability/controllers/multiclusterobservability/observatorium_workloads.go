@@ -0,0 +1,557 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package multiclusterobservability
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
+)
+
+const (
+	// filesystemVolumeName is the volume of the thanos components holding the filesystem object storage
+	filesystemVolumeName = "thanos-filesystem"
+	// receiveLimitsVolumeName is the volume of thanos receive holding the receive limits configmap
+	receiveLimitsVolumeName = "thanos-receive-limits"
+	receiveLimitsMountPath  = "/etc/thanos/receive-limits"
+	// receiveLimitsHashAnnotation rolls the receivers out when the limits change
+	receiveLimitsHashAnnotation = "observability.open-cluster-management.io/receive-limits-hash"
+	// responseCacheConfigFlag is the flag of the results cache configuration of thanos query frontend
+	responseCacheConfigFlag = "--query-range.response-cache-config"
+	// thanosArgsAnnotation holds the arguments of the thanos container rendered by the observatorium operator, the
+	// flags removed from the MultiClusterObservability are restored from it
+	thanosArgsAnnotation = "observability.open-cluster-management.io/thanos-args"
+	// thanosSchedulingAnnotation holds the scheduling of the pods rendered by the observatorium operator, the
+	// scheduling removed from the MultiClusterObservability is restored from it
+	thanosSchedulingAnnotation = "observability.open-cluster-management.io/thanos-scheduling"
+)
+
+// thanosWorkloads are the app.kubernetes.io/name labels of the thanos workloads rendered by the observatorium
+// operator, keyed by their components. The thanos container of a workload is named after its label.
+var thanosWorkloads = map[string]string{
+	mcoconfig.ThanosReceive:       "thanos-receive",
+	mcoconfig.ThanosStoreShard:    "thanos-store",
+	mcoconfig.ThanosRule:          "thanos-rule",
+	mcoconfig.ThanosCompact:       "thanos-compact",
+	mcoconfig.ThanosQuery:         "thanos-query",
+	mcoconfig.ThanosQueryFrontend: "thanos-query-frontend",
+}
+
+// filesystemComponents are the thanos components reading or writing the blocks of the object storage.
+var filesystemComponents = []string{
+	mcoconfig.ThanosReceive,
+	mcoconfig.ThanosStoreShard,
+	mcoconfig.ThanosRule,
+	mcoconfig.ThanosCompact,
+}
+
+// workloadIdentityComponents are the thanos components whose service accounts are bound to the cloud identity of
+// the workloadIdentity credentials mode.
+var workloadIdentityComponents = []string{
+	mcoconfig.ThanosReceive,
+	mcoconfig.ThanosStoreShard,
+	mcoconfig.ThanosRule,
+	mcoconfig.ThanosCompact,
+	mcoconfig.ThanosQuery,
+}
+
+// thanosWorkloadPatch is the configuration of the MultiClusterObservability which the Observatorium CR cannot
+// carry, it is set on the pod templates of the thanos workloads rendered by the observatorium operator.
+type thanosWorkloadPatch struct {
+	// filesystemDirectory is the directory of the filesystem object storage, empty with the other types
+	filesystemDirectory string
+	// workloadIdentityLabels are the pod labels required by the cloud identity of the workloadIdentity mode
+	workloadIdentityLabels map[string]string
+	// receiveLimitsHash is the hash of the receive limits configmap, empty without limits
+	receiveLimitsHash string
+	// resultsCacheTTL is the time to live of the results cached by thanos query frontend, empty by default
+	resultsCacheTTL string
+	// args are the arguments set on the thanos containers, keyed by component
+	args map[string][]string
+	// scheduling is the scheduling of the pods of the thanos workloads, keyed by component
+	scheduling map[string]*mcoconfig.SchedulingSpec
+}
+
+func getFilesystemPVCName() string {
+	return mcoconfig.GetOperandName(mcoconfig.Observatorium) + "-" + filesystemVolumeName
+}
+
+// getFilesystemDirectory returns the directory of the metric object storage if it is of the filesystem type.
+func getFilesystemDirectory(cl client.Client, mco *mcov1beta2.MultiClusterObservability) (string, error) {
+	if mco.Spec.StorageConfig == nil || mco.Spec.StorageConfig.MetricObjectStorage == nil {
+		return "", nil
+	}
+	objStorageConf := mco.Spec.StorageConfig.MetricObjectStorage
+	secret := &corev1.Secret{}
+	err := cl.Get(context.TODO(), types.NamespacedName{
+		Name:      objStorageConf.Name,
+		Namespace: mcoconfig.GetDefaultNamespace(),
+	}, secret)
+	if err != nil {
+		// the missing secret is reported by checkObjStorageStatus
+		if k8serrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return mcoconfig.GetFilesystemDirectory(secret.Data[objStorageConf.Key]), nil
+}
+
+// getReceiveLimitsHash returns the hash of the receive limits configmap, empty if there is none.
+func getReceiveLimitsHash(cl client.Client) (string, error) {
+	cm := &corev1.ConfigMap{}
+	err := cl.Get(context.TODO(), types.NamespacedName{
+		Name:      receiveLimitsConfigMapName,
+		Namespace: mcoconfig.GetDefaultNamespace(),
+	}, cm)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(cm.Data[receiveLimitsKey]))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// newQueryFlags returns the flags of thanos query set by the query spec, the lookback delta is set in the
+// Observatorium CR.
+func newQueryFlags(query *mcov1beta2.QuerySpec) []string {
+	var flags []string
+	if query.Timeout != "" {
+		flags = append(flags, "--query.timeout="+query.Timeout)
+	}
+	if query.MaxConcurrent != nil {
+		flags = append(flags, "--query.max-concurrent="+strconv.Itoa(int(*query.MaxConcurrent)))
+	}
+	switch query.PartialResponseStrategy {
+	case mcov1beta2.PartialResponseStrategyWarn:
+		flags = append(flags, "--query.partial-response")
+	case mcov1beta2.PartialResponseStrategyAbort:
+		flags = append(flags, "--no-query.partial-response")
+	}
+	if query.DefaultStep != "" {
+		flags = append(flags, "--query.default-step="+query.DefaultStep)
+	}
+	return flags
+}
+
+func newThanosWorkloadPatch(cl client.Client, mco *mcov1beta2.MultiClusterObservability) (*thanosWorkloadPatch, error) {
+	directory, err := getFilesystemDirectory(cl, mco)
+	if err != nil {
+		return nil, err
+	}
+	receiveLimitsHash, err := getReceiveLimitsHash(cl)
+	if err != nil {
+		return nil, err
+	}
+	patch := &thanosWorkloadPatch{
+		filesystemDirectory:    directory,
+		workloadIdentityLabels: mcoconfig.GetWorkloadIdentityPodLabels(mco.Spec.StorageConfig),
+		receiveLimitsHash:      receiveLimitsHash,
+		args:                   map[string][]string{},
+		scheduling:             map[string]*mcoconfig.SchedulingSpec{},
+	}
+	for component := range thanosWorkloads {
+		if scheduling := mcoconfig.GetSchedulingSpec(component, mco.Spec.AdvancedConfig); scheduling != nil {
+			patch.scheduling[component] = scheduling
+		}
+	}
+	if receiveLimitsHash != "" {
+		patch.args[mcoconfig.ThanosReceive] = []string{
+			"--receive.limits-config-file=" + receiveLimitsMountPath + "/" + receiveLimitsKey,
+		}
+	}
+	if advanced := mco.Spec.AdvancedConfig; advanced != nil && advanced.Query != nil {
+		patch.args[mcoconfig.ThanosQuery] = newQueryFlags(advanced.Query)
+	}
+	if advanced := mco.Spec.AdvancedConfig; advanced != nil && advanced.QueryFrontendConfig != nil {
+		if advanced.QueryFrontendConfig.SplitInterval != "" {
+			patch.args[mcoconfig.ThanosQueryFrontend] = []string{
+				"--query-range.split-interval=" + advanced.QueryFrontendConfig.SplitInterval,
+			}
+		}
+		patch.resultsCacheTTL = advanced.QueryFrontendConfig.ResultsCacheTTL
+	}
+	return patch, nil
+}
+
+// getFlagName returns the name of the flag of the argument, the negated boolean flags are named after their flag.
+func getFlagName(arg string) string {
+	return strings.Replace(strings.SplitN(arg, "=", 2)[0], "--no-", "--", 1)
+}
+
+// getRenderedArgs returns the arguments of the container rendered by the observatorium operator.
+func getRenderedArgs(template *corev1.PodTemplateSpec, container *corev1.Container) []string {
+	if saved, ok := template.Annotations[thanosArgsAnnotation]; ok {
+		var args []string
+		if err := json.Unmarshal([]byte(saved), &args); err == nil {
+			return args
+		}
+	}
+	return container.Args
+}
+
+// setArgs sets the flags on the rendered arguments of the container, a flag replaces the argument of the same
+// name. The rendered arguments are kept in an annotation to restore the flags later removed.
+func setArgs(template *corev1.PodTemplateSpec, container *corev1.Container, rendered []string, flags []string) {
+	args := append([]string(nil), rendered...)
+	for _, flag := range flags {
+		replaced := false
+		for i := range args {
+			if getFlagName(args[i]) == getFlagName(flag) {
+				args[i] = flag
+				replaced = true
+			}
+		}
+		if !replaced {
+			args = append(args, flag)
+		}
+	}
+	container.Args = args
+
+	delete(template.Annotations, thanosArgsAnnotation)
+	if len(flags) != 0 {
+		saved, _ := json.Marshal(rendered)
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		template.Annotations[thanosArgsAnnotation] = string(saved)
+	}
+}
+
+// getRenderedScheduling returns the scheduling of the pods rendered by the observatorium operator.
+func getRenderedScheduling(template *corev1.PodTemplateSpec) *mcoconfig.SchedulingSpec {
+	if saved, ok := template.Annotations[thanosSchedulingAnnotation]; ok {
+		scheduling := &mcoconfig.SchedulingSpec{}
+		if err := json.Unmarshal([]byte(saved), scheduling); err == nil {
+			return scheduling
+		}
+	}
+	return &mcoconfig.SchedulingSpec{
+		Affinity:                  template.Spec.Affinity,
+		TopologySpreadConstraints: template.Spec.TopologySpreadConstraints,
+		PriorityClassName:         template.Spec.PriorityClassName,
+	}
+}
+
+// setScheduling sets the scheduling on the rendered scheduling of the pods, the affinity is merged into the
+// rendered pod anti-affinity. The rendered scheduling is kept in an annotation to restore it later.
+func setScheduling(template *corev1.PodTemplateSpec, rendered *mcoconfig.SchedulingSpec,
+	scheduling *mcoconfig.SchedulingSpec) {
+	template.Spec.Affinity = rendered.Affinity
+	template.Spec.TopologySpreadConstraints = rendered.TopologySpreadConstraints
+	template.Spec.PriorityClassName = rendered.PriorityClassName
+
+	delete(template.Annotations, thanosSchedulingAnnotation)
+	if scheduling == nil {
+		return
+	}
+	if scheduling.Affinity != nil {
+		affinity := &corev1.Affinity{}
+		if rendered.Affinity != nil {
+			affinity = rendered.Affinity.DeepCopy()
+		}
+		if scheduling.Affinity.NodeAffinity != nil {
+			affinity.NodeAffinity = scheduling.Affinity.NodeAffinity.DeepCopy()
+		}
+		if scheduling.Affinity.PodAffinity != nil {
+			affinity.PodAffinity = scheduling.Affinity.PodAffinity.DeepCopy()
+		}
+		if scheduling.Affinity.PodAntiAffinity != nil {
+			affinity.PodAntiAffinity = scheduling.Affinity.PodAntiAffinity.DeepCopy()
+		}
+		template.Spec.Affinity = affinity
+	}
+	if len(scheduling.TopologySpreadConstraints) != 0 {
+		template.Spec.TopologySpreadConstraints = scheduling.TopologySpreadConstraints
+	}
+	if scheduling.PriorityClassName != "" {
+		template.Spec.PriorityClassName = scheduling.PriorityClassName
+	}
+	saved, _ := json.Marshal(rendered)
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[thanosSchedulingAnnotation] = string(saved)
+}
+
+// setResponseCacheTTL returns the response cache configuration argument of thanos query frontend with the time to
+// live of the cached results, the expiration of the memcached and redis caches or the validity of the in-memory
+// cache. The argument is returned unchanged if its configuration cannot be parsed.
+func setResponseCacheTTL(arg string, ttl string) string {
+	parts := strings.SplitN(arg, "=", 2)
+	if len(parts) != 2 {
+		return arg
+	}
+	var config yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(parts[1]), &config); err != nil {
+		log.Error(err, "Failed to parse the response cache configuration of thanos query frontend")
+		return arg
+	}
+	key := "expiration"
+	for _, item := range config {
+		if item.Key == "type" && strings.EqualFold(fmt.Sprint(item.Value), "IN-MEMORY") {
+			key = "validity"
+		}
+	}
+	for i, item := range config {
+		backend, ok := item.Value.(yaml.MapSlice)
+		if item.Key != "config" || !ok {
+			continue
+		}
+		found := false
+		for j := range backend {
+			if backend[j].Key == key {
+				backend[j].Value = ttl
+				found = true
+			}
+		}
+		if !found {
+			backend = append(backend, yaml.MapItem{Key: key, Value: ttl})
+		}
+		config[i].Value = backend
+		data, err := yaml.Marshal(config)
+		if err != nil {
+			log.Error(err, "Failed to render the response cache configuration of thanos query frontend")
+			return arg
+		}
+		return parts[0] + "=" + string(data)
+	}
+	return arg
+}
+
+// apply sets the patch on the pod template of the workload of the component. What the patch sets is removed
+// first, so that the template does not keep the settings removed from the MultiClusterObservability.
+func (p *thanosWorkloadPatch) apply(component string, template *corev1.PodTemplateSpec) {
+	containerName := thanosWorkloads[component]
+	var container *corev1.Container
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == containerName {
+			container = &template.Spec.Containers[i]
+		}
+	}
+	if container == nil {
+		return
+	}
+
+	var volumes []corev1.Volume
+	for _, volume := range template.Spec.Volumes {
+		if volume.Name != filesystemVolumeName && volume.Name != receiveLimitsVolumeName {
+			volumes = append(volumes, volume)
+		}
+	}
+	var mounts []corev1.VolumeMount
+	for _, mount := range container.VolumeMounts {
+		if mount.Name != filesystemVolumeName && mount.Name != receiveLimitsVolumeName {
+			mounts = append(mounts, mount)
+		}
+	}
+	if p.filesystemDirectory != "" && util.Contains(filesystemComponents, component) {
+		volumes = append(volumes, corev1.Volume{
+			Name: filesystemVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: getFilesystemPVCName()},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: filesystemVolumeName, MountPath: p.filesystemDirectory})
+	}
+	delete(template.Annotations, receiveLimitsHashAnnotation)
+	if p.receiveLimitsHash != "" && component == mcoconfig.ThanosReceive {
+		volumes = append(volumes, corev1.Volume{
+			Name: receiveLimitsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: receiveLimitsConfigMapName},
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      receiveLimitsVolumeName,
+			MountPath: receiveLimitsMountPath,
+			ReadOnly:  true,
+		})
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		template.Annotations[receiveLimitsHashAnnotation] = p.receiveLimitsHash
+	}
+	template.Spec.Volumes = volumes
+	container.VolumeMounts = mounts
+	rendered := getRenderedArgs(template, container)
+	flags := append([]string(nil), p.args[component]...)
+	if component == mcoconfig.ThanosQueryFrontend && p.resultsCacheTTL != "" {
+		for _, arg := range rendered {
+			if getFlagName(arg) == responseCacheConfigFlag {
+				flags = append(flags, setResponseCacheTTL(arg, p.resultsCacheTTL))
+			}
+		}
+	}
+	setArgs(template, container, rendered, flags)
+	setScheduling(template, getRenderedScheduling(template), p.scheduling[component])
+
+	delete(template.Labels, mcoconfig.AzureWorkloadIdentityUseLabel)
+	if len(p.workloadIdentityLabels) != 0 && util.Contains(workloadIdentityComponents, component) {
+		if template.Labels == nil {
+			template.Labels = map[string]string{}
+		}
+		for key, value := range p.workloadIdentityLabels {
+			template.Labels[key] = value
+		}
+	}
+}
+
+// generateFilesystemPVC creates the ReadWriteMany PersistentVolumeClaim shared by the thanos components as the
+// filesystem object storage, and deletes it with the other types. The claim is only expanded, it cannot shrink.
+func generateFilesystemPVC(cl client.Client, scheme *runtime.Scheme, mco *mcov1beta2.MultiClusterObservability,
+	directory string) error {
+	found := &corev1.PersistentVolumeClaim{}
+	err := cl.Get(context.TODO(), types.NamespacedName{
+		Name:      getFilesystemPVCName(),
+		Namespace: mcoconfig.GetDefaultNamespace(),
+	}, found)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+	if directory == "" {
+		if exists {
+			log.Info("Deleting the filesystem object storage volume", "name", found.Name)
+			if err = cl.Delete(context.TODO(), found); err != nil && !k8serrors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	size := mco.Spec.StorageConfig.FilesystemStorageSize
+	if size == "" {
+		size = mcoconfig.DefaultFilesystemStorageSize
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if current := found.Spec.Resources.Requests[corev1.ResourceStorage]; quantity.Cmp(current) > 0 {
+			log.Info("Expanding the filesystem object storage volume", "name", found.Name, "size", size)
+			found.Spec.Resources.Requests[corev1.ResourceStorage] = quantity
+			return cl.Update(context.TODO(), found)
+		}
+		return nil
+	}
+
+	storageClass, err := getStorageClass(mco, cl)
+	if err != nil {
+		return err
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getFilesystemPVCName(),
+			Namespace: mcoconfig.GetDefaultNamespace(),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			StorageClassName: &storageClass,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: quantity},
+			},
+		},
+	}
+	if err = controllerutil.SetControllerReference(mco, pvc, scheme); err != nil {
+		return err
+	}
+	log.Info("Creating the filesystem object storage volume", "name", pvc.Name)
+	return cl.Create(context.TODO(), pvc)
+}
+
+// GenerateThanosWorkloadPatches sets the configuration the Observatorium CR cannot carry on the thanos workloads
+// rendered by the observatorium operator. The observatorium operator reverts the workloads when it renders the
+// Observatorium CR again, the workloads are watched to patch them again.
+func GenerateThanosWorkloadPatches(
+	cl client.Client, scheme *runtime.Scheme,
+	mco *mcov1beta2.MultiClusterObservability) (*ctrl.Result, error) {
+	patch, err := newThanosWorkloadPatch(cl, mco)
+	if err != nil {
+		return &ctrl.Result{}, err
+	}
+	if err = generateFilesystemPVC(cl, scheme, mco, patch.filesystemDirectory); err != nil {
+		log.Error(err, "Failed to generate the filesystem object storage volume")
+		return &ctrl.Result{}, err
+	}
+
+	components := map[string]string{}
+	for component, name := range thanosWorkloads {
+		components[name] = component
+	}
+	selector := client.MatchingLabels{"app.kubernetes.io/instance": mcoconfig.GetOperandName(mcoconfig.Observatorium)}
+	namespace := client.InNamespace(mcoconfig.GetDefaultNamespace())
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err = cl.List(context.TODO(), statefulSets, namespace, selector); err != nil {
+		return &ctrl.Result{}, err
+	}
+	for i := range statefulSets.Items {
+		sts := &statefulSets.Items[i]
+		component, ok := components[sts.Labels["app.kubernetes.io/name"]]
+		if !ok {
+			continue
+		}
+		template := sts.Spec.Template.DeepCopy()
+		patch.apply(component, template)
+		if apiequality.Semantic.DeepEqual(*template, sts.Spec.Template) {
+			continue
+		}
+		log.Info("Patching the thanos statefulset", "name", sts.Name)
+		sts.Spec.Template = *template
+		if err = cl.Update(context.TODO(), sts); err != nil {
+			return &ctrl.Result{}, err
+		}
+	}
+
+	deployments := &appsv1.DeploymentList{}
+	if err = cl.List(context.TODO(), deployments, namespace, selector); err != nil {
+		return &ctrl.Result{}, err
+	}
+	for i := range deployments.Items {
+		dep := &deployments.Items[i]
+		component, ok := components[dep.Labels["app.kubernetes.io/name"]]
+		if !ok {
+			continue
+		}
+		template := dep.Spec.Template.DeepCopy()
+		patch.apply(component, template)
+		if apiequality.Semantic.DeepEqual(*template, dep.Spec.Template) {
+			continue
+		}
+		log.Info("Patching the thanos deployment", "name", dep.Name)
+		dep.Spec.Template = *template
+		if err = cl.Update(context.TODO(), dep); err != nil {
+			return &ctrl.Result{}, err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,536 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package multiclusterobservability
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storev1 "k8s.io/api/storage/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mcoshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+)
+
+func newThanosWorkloadLabels(name string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/instance": mcoconfig.GetOperandName(mcoconfig.Observatorium),
+		"app.kubernetes.io/name":     name,
+	}
+}
+
+func newThanosStatefulSet(name string, label string) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: mcoconfig.GetDefaultNamespace(),
+			Labels:    newThanosWorkloadLabels(label),
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: newThanosWorkloadLabels(label)},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:         label,
+							VolumeMounts: []corev1.VolumeMount{{Name: "data", MountPath: "/var/thanos/receive"}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newThanosDeployment(name string, label string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: mcoconfig.GetDefaultNamespace(),
+			Labels:    newThanosWorkloadLabels(label),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: newThanosWorkloadLabels(label)},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: label}}},
+			},
+		},
+	}
+}
+
+func getThanosStatefulSet(t *testing.T, c client.Client, name string) *appsv1.StatefulSet {
+	sts := &appsv1.StatefulSet{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: mcoconfig.GetDefaultNamespace()}, sts)
+	if err != nil {
+		t.Fatalf("Failed to get the statefulset %s: (%v)", name, err)
+	}
+	return sts
+}
+
+func getThanosDeployment(t *testing.T, c client.Client, name string) *appsv1.Deployment {
+	dep := &appsv1.Deployment{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: mcoconfig.GetDefaultNamespace()}, dep)
+	if err != nil {
+		t.Fatalf("Failed to get the deployment %s: (%v)", name, err)
+	}
+	return dep
+}
+
+func TestGenerateThanosWorkloadPatchesFilesystem(t *testing.T) {
+	s := runtime.NewScheme()
+	corev1.AddToScheme(s)
+	appsv1.AddToScheme(s)
+	storev1.AddToScheme(s)
+	mcov1beta2.SchemeBuilder.AddToScheme(s)
+
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability"},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			StorageConfig: &mcov1beta2.StorageConfig{
+				MetricObjectStorage: &mcoshared.PreConfiguredStorage{Key: "thanos.yaml", Name: "thanos-object-storage"},
+				StorageClass:        "nfs",
+			},
+		},
+	}
+	objStorageSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "thanos-object-storage", Namespace: mcoconfig.GetDefaultNamespace()},
+		Data: map[string][]byte{
+			"thanos.yaml": []byte("type: filesystem\nconfig:\n  directory: /var/thanos/bucket\n"),
+		},
+	}
+	storageClass := &storev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "nfs"}}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objStorageSecret, storageClass).Build()
+	mcoconfig.SetOperandNames(c)
+	instance := mcoconfig.GetOperandName(mcoconfig.Observatorium)
+	receiveName := instance + "-thanos-receive-default"
+	queryName := instance + "-thanos-query"
+	err := c.Create(context.TODO(), newThanosStatefulSet(receiveName, "thanos-receive"))
+	if err == nil {
+		err = c.Create(context.TODO(), newThanosDeployment(queryName, "thanos-query"))
+	}
+	if err != nil {
+		t.Fatalf("Failed to create the thanos workloads: (%v)", err)
+	}
+
+	if _, err := GenerateThanosWorkloadPatches(c, s, mco); err != nil {
+		t.Fatalf("Failed to patch the thanos workloads: (%v)", err)
+	}
+	pvc := &corev1.PersistentVolumeClaim{}
+	err = c.Get(context.TODO(), types.NamespacedName{
+		Name:      getFilesystemPVCName(),
+		Namespace: mcoconfig.GetDefaultNamespace(),
+	}, pvc)
+	if err != nil {
+		t.Fatalf("Failed to get the filesystem volume: (%v)", err)
+	}
+	if len(pvc.Spec.AccessModes) != 1 || pvc.Spec.AccessModes[0] != corev1.ReadWriteMany ||
+		*pvc.Spec.StorageClassName != "nfs" {
+		t.Errorf("filesystem volume (%v) is not the expected: (ReadWriteMany nfs)", pvc.Spec)
+	}
+	if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() !=
+		mcoconfig.DefaultFilesystemStorageSize {
+		t.Errorf("filesystem volume size (%v) is not the expected: (%v)", size.String(),
+			mcoconfig.DefaultFilesystemStorageSize)
+	}
+
+	receive := getThanosStatefulSet(t, c, receiveName)
+	volumes := receive.Spec.Template.Spec.Volumes
+	if len(volumes) != 1 || volumes[0].PersistentVolumeClaim == nil ||
+		volumes[0].PersistentVolumeClaim.ClaimName != getFilesystemPVCName() {
+		t.Errorf("receive volumes (%v) are not the expected: (%v)", volumes, getFilesystemPVCName())
+	}
+	mounts := receive.Spec.Template.Spec.Containers[0].VolumeMounts
+	if len(mounts) != 2 || mounts[1].Name != filesystemVolumeName || mounts[1].MountPath != "/var/thanos/bucket" {
+		t.Errorf("receive volume mounts (%v) are not the expected: (/var/thanos/bucket)", mounts)
+	}
+	query := getThanosDeployment(t, c, queryName)
+	if len(query.Spec.Template.Spec.Volumes) != 0 {
+		t.Errorf("query volumes (%v) are not the expected: ([])", query.Spec.Template.Spec.Volumes)
+	}
+
+	// the patch is stable
+	version := receive.ResourceVersion
+	if _, err := GenerateThanosWorkloadPatches(c, s, mco); err != nil {
+		t.Fatalf("Failed to patch the thanos workloads: (%v)", err)
+	}
+	if receive = getThanosStatefulSet(t, c, receiveName); receive.ResourceVersion != version {
+		t.Errorf("receive resource version (%v) is not the expected: (%v)", receive.ResourceVersion, version)
+	}
+
+	// the volume is removed with the other object storage types
+	objStorageSecret.Data["thanos.yaml"] = []byte("type: s3\nconfig:\n  bucket: bucket\n  endpoint: endpoint\n")
+	if err = c.Update(context.TODO(), objStorageSecret); err != nil {
+		t.Fatalf("Failed to update the object storage secret: (%v)", err)
+	}
+	if _, err := GenerateThanosWorkloadPatches(c, s, mco); err != nil {
+		t.Fatalf("Failed to patch the thanos workloads: (%v)", err)
+	}
+	receive = getThanosStatefulSet(t, c, receiveName)
+	if len(receive.Spec.Template.Spec.Volumes) != 0 || len(receive.Spec.Template.Spec.Containers[0].VolumeMounts) != 1 {
+		t.Errorf("receive pod spec (%v) is not the expected: (no filesystem volume)", receive.Spec.Template.Spec)
+	}
+	err = c.Get(context.TODO(), types.NamespacedName{
+		Name:      getFilesystemPVCName(),
+		Namespace: mcoconfig.GetDefaultNamespace(),
+	}, pvc)
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("filesystem volume is not deleted: (%v)", err)
+	}
+}
+
+func TestGenerateThanosWorkloadPatchesWorkloadIdentity(t *testing.T) {
+	s := runtime.NewScheme()
+	corev1.AddToScheme(s)
+	appsv1.AddToScheme(s)
+	storev1.AddToScheme(s)
+	mcov1beta2.SchemeBuilder.AddToScheme(s)
+
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability"},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			StorageConfig: &mcov1beta2.StorageConfig{
+				MetricObjectStorage: &mcoshared.PreConfiguredStorage{Key: "thanos.yaml", Name: "thanos-object-storage"},
+				CredentialsMode:     mcov1beta2.CredentialsModeWorkloadIdentity,
+				WorkloadIdentity:    &mcov1beta2.WorkloadIdentityConfig{AzureClientID: "client"},
+			},
+		},
+	}
+	objStorageSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "thanos-object-storage", Namespace: mcoconfig.GetDefaultNamespace()},
+		Data: map[string][]byte{
+			"thanos.yaml": []byte("type: azure\nconfig:\n  storage_account: account\n  container: container\n"),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objStorageSecret).Build()
+	mcoconfig.SetOperandNames(c)
+	instance := mcoconfig.GetOperandName(mcoconfig.Observatorium)
+	storeName := instance + "-thanos-store-shard-0"
+	queryFrontendName := instance + "-thanos-query-frontend"
+	err := c.Create(context.TODO(), newThanosStatefulSet(storeName, "thanos-store"))
+	if err == nil {
+		err = c.Create(context.TODO(), newThanosDeployment(queryFrontendName, "thanos-query-frontend"))
+	}
+	if err != nil {
+		t.Fatalf("Failed to create the thanos workloads: (%v)", err)
+	}
+
+	// the azure workload identity webhook only injects the credentials in the labeled pods
+	if _, err := GenerateThanosWorkloadPatches(c, s, mco); err != nil {
+		t.Fatalf("Failed to patch the thanos workloads: (%v)", err)
+	}
+	store := getThanosStatefulSet(t, c, storeName)
+	if value := store.Spec.Template.Labels[mcoconfig.AzureWorkloadIdentityUseLabel]; value != "true" {
+		t.Errorf("store pod label %s (%v) is not the expected: (true)", mcoconfig.AzureWorkloadIdentityUseLabel, value)
+	}
+	queryFrontend := getThanosDeployment(t, c, queryFrontendName)
+	if _, ok := queryFrontend.Spec.Template.Labels[mcoconfig.AzureWorkloadIdentityUseLabel]; ok {
+		t.Errorf("query-frontend pod labels (%v) should not select the workload identity",
+			queryFrontend.Spec.Template.Labels)
+	}
+
+	// the label is removed with the static credentials mode
+	mco.Spec.StorageConfig.CredentialsMode = mcov1beta2.CredentialsModeStatic
+	if _, err := GenerateThanosWorkloadPatches(c, s, mco); err != nil {
+		t.Fatalf("Failed to patch the thanos workloads: (%v)", err)
+	}
+	store = getThanosStatefulSet(t, c, storeName)
+	if _, ok := store.Spec.Template.Labels[mcoconfig.AzureWorkloadIdentityUseLabel]; ok {
+		t.Errorf("store pod labels (%v) should not select the workload identity", store.Spec.Template.Labels)
+	}
+	if store.Spec.Template.Labels["app.kubernetes.io/name"] != "thanos-store" {
+		t.Errorf("store pod labels (%v) are not the expected: (thanos-store)", store.Spec.Template.Labels)
+	}
+}
+
+func TestGenerateThanosWorkloadPatchesReceiveLimits(t *testing.T) {
+	s := runtime.NewScheme()
+	corev1.AddToScheme(s)
+	appsv1.AddToScheme(s)
+	mcov1beta2.SchemeBuilder.AddToScheme(s)
+
+	mco := &mcov1beta2.MultiClusterObservability{ObjectMeta: metav1.ObjectMeta{Name: "observability"}}
+	limits := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: receiveLimitsConfigMapName, Namespace: mcoconfig.GetDefaultNamespace()},
+		Data:       map[string]string{receiveLimitsKey: "write:\n  default:\n    head_series_limit: 1000000\n"},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(limits).Build()
+	mcoconfig.SetOperandNames(c)
+	receiveName := mcoconfig.GetOperandName(mcoconfig.Observatorium) + "-thanos-receive-default"
+	receive := newThanosStatefulSet(receiveName, "thanos-receive")
+	args := []string{"receive", "--log.level=info"}
+	receive.Spec.Template.Spec.Containers[0].Args = args
+	if err := c.Create(context.TODO(), receive); err != nil {
+		t.Fatalf("Failed to create the thanos workloads: (%v)", err)
+	}
+
+	// the receivers load the limits from the mounted configmap
+	if _, err := GenerateThanosWorkloadPatches(c, s, mco); err != nil {
+		t.Fatalf("Failed to patch the thanos workloads: (%v)", err)
+	}
+	receive = getThanosStatefulSet(t, c, receiveName)
+	template := receive.Spec.Template
+	container := template.Spec.Containers[0]
+	expected := append(args, "--receive.limits-config-file="+receiveLimitsMountPath+"/"+receiveLimitsKey)
+	if !reflect.DeepEqual(container.Args, expected) {
+		t.Errorf("receive args (%v) are not the expected: (%v)", container.Args, expected)
+	}
+	if len(template.Spec.Volumes) != 1 || template.Spec.Volumes[0].ConfigMap == nil ||
+		template.Spec.Volumes[0].ConfigMap.Name != receiveLimitsConfigMapName {
+		t.Errorf("receive volumes (%v) should include the limits configmap", template.Spec.Volumes)
+	}
+	if len(container.VolumeMounts) != 2 || container.VolumeMounts[1].MountPath != receiveLimitsMountPath {
+		t.Errorf("receive volume mounts (%v) should include the limits", container.VolumeMounts)
+	}
+	hash := template.Annotations[receiveLimitsHashAnnotation]
+	if hash == "" {
+		t.Errorf("receive annotations (%v) should include the hash of the limits", template.Annotations)
+	}
+
+	// the receivers roll out with the new limits
+	limits.Data[receiveLimitsKey] = "write:\n  default:\n    head_series_limit: 2000000\n"
+	if err := c.Update(context.TODO(), limits); err != nil {
+		t.Fatalf("Failed to update the receive limits: (%v)", err)
+	}
+	if _, err := GenerateThanosWorkloadPatches(c, s, mco); err != nil {
+		t.Fatalf("Failed to patch the thanos workloads: (%v)", err)
+	}
+	receive = getThanosStatefulSet(t, c, receiveName)
+	if receive.Spec.Template.Annotations[receiveLimitsHashAnnotation] == hash {
+		t.Errorf("receive annotation %s should change with the limits", receiveLimitsHashAnnotation)
+	}
+	if !reflect.DeepEqual(receive.Spec.Template.Spec.Containers[0].Args, expected) {
+		t.Errorf("receive args (%v) are not the expected: (%v)", receive.Spec.Template.Spec.Containers[0].Args, expected)
+	}
+
+	// the rendered args are restored without limits
+	if err := c.Delete(context.TODO(), limits); err != nil {
+		t.Fatalf("Failed to delete the receive limits: (%v)", err)
+	}
+	if _, err := GenerateThanosWorkloadPatches(c, s, mco); err != nil {
+		t.Fatalf("Failed to patch the thanos workloads: (%v)", err)
+	}
+	receive = getThanosStatefulSet(t, c, receiveName)
+	template = receive.Spec.Template
+	if !reflect.DeepEqual(template.Spec.Containers[0].Args, args) {
+		t.Errorf("receive args (%v) are not the expected: (%v)", template.Spec.Containers[0].Args, args)
+	}
+	if len(template.Spec.Volumes) != 0 || len(template.Spec.Containers[0].VolumeMounts) != 1 {
+		t.Errorf("receive volumes (%v) should not include the limits", template.Spec.Volumes)
+	}
+	if len(template.Annotations) != 0 {
+		t.Errorf("receive annotations (%v) should not include the patch", template.Annotations)
+	}
+}
+
+func TestGenerateThanosWorkloadPatchesQuery(t *testing.T) {
+	s := runtime.NewScheme()
+	corev1.AddToScheme(s)
+	appsv1.AddToScheme(s)
+	mcov1beta2.SchemeBuilder.AddToScheme(s)
+
+	maxConcurrent := int32(40)
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability"},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			AdvancedConfig: &mcov1beta2.AdvancedConfig{
+				Query: &mcov1beta2.QuerySpec{
+					Timeout:                 "2m",
+					MaxConcurrent:           &maxConcurrent,
+					PartialResponseStrategy: mcov1beta2.PartialResponseStrategyAbort,
+					DefaultStep:             "1m",
+				},
+				QueryFrontendConfig: &mcov1beta2.QueryFrontendConfig{
+					ResultsCacheTTL: "12h",
+					SplitInterval:   "6h",
+				},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).Build()
+	mcoconfig.SetOperandNames(c)
+	instance := mcoconfig.GetOperandName(mcoconfig.Observatorium)
+	queryName := instance + "-thanos-query"
+	queryFrontendName := instance + "-thanos-query-frontend"
+	queryArgs := []string{"query", "--query.timeout=5m", "--query.partial-response"}
+	queryFrontendArgs := []string{
+		"query-frontend",
+		"--query-range.split-interval=24h",
+		"--query-range.response-cache-config=type: MEMCACHED\nconfig:\n  addresses:\n  - memcached:11211\n",
+	}
+	query := newThanosDeployment(queryName, "thanos-query")
+	query.Spec.Template.Spec.Containers[0].Args = queryArgs
+	queryFrontend := newThanosDeployment(queryFrontendName, "thanos-query-frontend")
+	queryFrontend.Spec.Template.Spec.Containers[0].Args = queryFrontendArgs
+	err := c.Create(context.TODO(), query)
+	if err == nil {
+		err = c.Create(context.TODO(), queryFrontend)
+	}
+	if err != nil {
+		t.Fatalf("Failed to create the thanos workloads: (%v)", err)
+	}
+
+	// the flags replace the rendered arguments of the same name
+	if _, err := GenerateThanosWorkloadPatches(c, s, mco); err != nil {
+		t.Fatalf("Failed to patch the thanos workloads: (%v)", err)
+	}
+	expected := []string{
+		"query",
+		"--query.timeout=2m",
+		"--no-query.partial-response",
+		"--query.max-concurrent=40",
+		"--query.default-step=1m",
+	}
+	query = getThanosDeployment(t, c, queryName)
+	if args := query.Spec.Template.Spec.Containers[0].Args; !reflect.DeepEqual(args, expected) {
+		t.Errorf("query args (%v) are not the expected: (%v)", args, expected)
+	}
+	expected = []string{
+		"query-frontend",
+		"--query-range.split-interval=6h",
+		"--query-range.response-cache-config=type: MEMCACHED\nconfig:\n  addresses:\n  - memcached:11211\n" +
+			"  expiration: 12h\n",
+	}
+	queryFrontend = getThanosDeployment(t, c, queryFrontendName)
+	if args := queryFrontend.Spec.Template.Spec.Containers[0].Args; !reflect.DeepEqual(args, expected) {
+		t.Errorf("query-frontend args (%v) are not the expected: (%v)", args, expected)
+	}
+
+	// the rendered arguments are restored without the settings
+	mco.Spec.AdvancedConfig = nil
+	if _, err := GenerateThanosWorkloadPatches(c, s, mco); err != nil {
+		t.Fatalf("Failed to patch the thanos workloads: (%v)", err)
+	}
+	query = getThanosDeployment(t, c, queryName)
+	if args := query.Spec.Template.Spec.Containers[0].Args; !reflect.DeepEqual(args, queryArgs) {
+		t.Errorf("query args (%v) are not the expected: (%v)", args, queryArgs)
+	}
+	queryFrontend = getThanosDeployment(t, c, queryFrontendName)
+	if args := queryFrontend.Spec.Template.Spec.Containers[0].Args; !reflect.DeepEqual(args, queryFrontendArgs) {
+		t.Errorf("query-frontend args (%v) are not the expected: (%v)", args, queryFrontendArgs)
+	}
+	if len(queryFrontend.Spec.Template.Annotations) != 0 {
+		t.Errorf("query-frontend annotations (%v) should not include the patch", queryFrontend.Spec.Template.Annotations)
+	}
+}
+
+func TestGenerateThanosWorkloadPatchesScheduling(t *testing.T) {
+	s := runtime.NewScheme()
+	corev1.AddToScheme(s)
+	appsv1.AddToScheme(s)
+	mcov1beta2.SchemeBuilder.AddToScheme(s)
+
+	nodeAffinity := &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: "node-role.kubernetes.io/infra", Operator: corev1.NodeSelectorOpExists},
+					},
+				},
+			},
+		},
+	}
+	constraints := []corev1.TopologySpreadConstraint{
+		{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule},
+	}
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability"},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			AdvancedConfig: &mcov1beta2.AdvancedConfig{
+				Receive: &mcov1beta2.ReceiveSpec{
+					CommonSpec: mcov1beta2.CommonSpec{
+						Affinity:                  &corev1.Affinity{NodeAffinity: nodeAffinity},
+						TopologySpreadConstraints: constraints,
+						PriorityClassName:         "system-cluster-critical",
+					},
+				},
+				Compact: &mcov1beta2.CompactSpec{PriorityClassName: "system-cluster-critical"},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).Build()
+	mcoconfig.SetOperandNames(c)
+	instance := mcoconfig.GetOperandName(mcoconfig.Observatorium)
+	receiveName := instance + "-thanos-receive-default"
+	compactName := instance + "-thanos-compact"
+	queryName := instance + "-thanos-query"
+	antiAffinity := &corev1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+			{
+				Weight: 30,
+				PodAffinityTerm: corev1.PodAffinityTerm{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: newThanosWorkloadLabels("thanos-receive")},
+					TopologyKey:   "kubernetes.io/hostname",
+				},
+			},
+		},
+	}
+	receive := newThanosStatefulSet(receiveName, "thanos-receive")
+	receive.Spec.Template.Spec.Affinity = &corev1.Affinity{PodAntiAffinity: antiAffinity}
+	err := c.Create(context.TODO(), receive)
+	if err == nil {
+		err = c.Create(context.TODO(), newThanosStatefulSet(compactName, "thanos-compact"))
+	}
+	if err == nil {
+		err = c.Create(context.TODO(), newThanosDeployment(queryName, "thanos-query"))
+	}
+	if err != nil {
+		t.Fatalf("Failed to create the thanos workloads: (%v)", err)
+	}
+
+	// the affinity is merged into the rendered pod anti-affinity
+	if _, err := GenerateThanosWorkloadPatches(c, s, mco); err != nil {
+		t.Fatalf("Failed to patch the thanos workloads: (%v)", err)
+	}
+	expected := &corev1.Affinity{NodeAffinity: nodeAffinity, PodAntiAffinity: antiAffinity}
+	podSpec := getThanosStatefulSet(t, c, receiveName).Spec.Template.Spec
+	if !reflect.DeepEqual(podSpec.Affinity, expected) {
+		t.Errorf("receive affinity (%v) is not the expected: (%v)", podSpec.Affinity, expected)
+	}
+	if !reflect.DeepEqual(podSpec.TopologySpreadConstraints, constraints) {
+		t.Errorf("receive topology spread constraints (%v) are not the expected: (%v)",
+			podSpec.TopologySpreadConstraints, constraints)
+	}
+	if podSpec.PriorityClassName != "system-cluster-critical" {
+		t.Errorf("receive priority class (%v) is not the expected: (system-cluster-critical)",
+			podSpec.PriorityClassName)
+	}
+	podSpec = getThanosStatefulSet(t, c, compactName).Spec.Template.Spec
+	if podSpec.PriorityClassName != "system-cluster-critical" {
+		t.Errorf("compact priority class (%v) is not the expected: (system-cluster-critical)",
+			podSpec.PriorityClassName)
+	}
+	query := getThanosDeployment(t, c, queryName)
+	if query.Spec.Template.Spec.PriorityClassName != "" || len(query.Spec.Template.Annotations) != 0 {
+		t.Errorf("query pod template (%v) should not include the patch", query.Spec.Template)
+	}
+
+	// the rendered scheduling is restored without the settings
+	mco.Spec.AdvancedConfig = nil
+	if _, err := GenerateThanosWorkloadPatches(c, s, mco); err != nil {
+		t.Fatalf("Failed to patch the thanos workloads: (%v)", err)
+	}
+	receive = getThanosStatefulSet(t, c, receiveName)
+	expected = &corev1.Affinity{PodAntiAffinity: antiAffinity}
+	if podSpec := receive.Spec.Template.Spec; !reflect.DeepEqual(podSpec.Affinity, expected) ||
+		len(podSpec.TopologySpreadConstraints) != 0 || podSpec.PriorityClassName != "" {
+		t.Errorf("receive pod spec (%v) should not include the patch", podSpec)
+	}
+	if len(receive.Spec.Template.Annotations) != 0 {
+		t.Errorf("receive annotations (%v) should not include the patch", receive.Spec.Template.Annotations)
+	}
+	if podSpec := getThanosStatefulSet(t, c, compactName).Spec.Template.Spec; podSpec.PriorityClassName != "" {
+		t.Errorf("compact priority class (%v) should not be set", podSpec.PriorityClassName)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package multiclusterobservability
+
+import (
+	"context"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+)
+
+// applyOperandConfigMap creates or updates the configmap of the configuration of the operands which the
+// Observatorium CR cannot carry, the configmap is deleted if data is nil.
+func applyOperandConfigMap(cl client.Client, scheme *runtime.Scheme, mco *mcov1beta2.MultiClusterObservability,
+	name string, data map[string]string) error {
+	found := &corev1.ConfigMap{}
+	err := cl.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: mcoconfig.GetDefaultNamespace()}, found)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	if data == nil {
+		if exists {
+			log.Info("Deleting the operand configmap", "name", name)
+			if err = cl.Delete(context.TODO(), found); err != nil && !k8serrors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: mcoconfig.GetDefaultNamespace(),
+		},
+		Data: data,
+	}
+	if err = controllerutil.SetControllerReference(mco, cm, scheme); err != nil {
+		return err
+	}
+	if !exists {
+		log.Info("Creating the operand configmap", "name", name)
+		return cl.Create(context.TODO(), cm)
+	}
+	if !reflect.DeepEqual(found.Data, data) {
+		log.Info("Updating the operand configmap", "name", name)
+		cm.ResourceVersion = found.ResourceVersion
+		return cl.Update(context.TODO(), cm)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package multiclusterobservability
+
+import (
+	"context"
+
+	policyv1 "k8s.io/api/policy/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+)
+
+// getMaxUnavailable returns how many pods of the component can be disrupted at a time. The remote writes succeed
+// when a quorum of the replication factor of the receivers stores them, so that the receivers tolerate
+// (replicationFactor-1)/2 disrupted pods. The other components tolerate one disrupted pod, but not their last one.
+func getMaxUnavailable(component string, mco *mcov1beta2.MultiClusterObservability) int32 {
+	replicas := *mcoconfig.GetReplicas(component, mco.Spec.AdvancedConfig)
+	maxUnavailable := int32(1)
+	if component == mcoconfig.ThanosReceive {
+		maxUnavailable = (*getReceiveReplicationFactor(mco) - 1) / 2
+	}
+	if maxUnavailable > replicas-1 {
+		maxUnavailable = replicas - 1
+	}
+	return maxUnavailable
+}
+
+// newPodDisruptionBudgets returns the PodDisruptionBudgets of the stateful components and of the caches, keyed by
+// their names. The PodDisruptionBudget is nil when the component cannot lose any pod, it would block the drains.
+func newPodDisruptionBudgets(mco *mcov1beta2.MultiClusterObservability) map[string]*policyv1.PodDisruptionBudget {
+	instance := mcoconfig.GetOperandName(mcoconfig.Observatorium)
+	observatoriumSelector := func(name string, component string) map[string]string {
+		selector := map[string]string{
+			"app.kubernetes.io/instance": instance,
+			"app.kubernetes.io/name":     name,
+		}
+		if component != "" {
+			selector["app.kubernetes.io/component"] = component
+		}
+		return selector
+	}
+
+	budgets := []struct {
+		name      string
+		component string
+		selector  map[string]string
+	}{
+		{
+			instance + "-" + mcoconfig.ThanosReceive,
+			mcoconfig.ThanosReceive,
+			observatoriumSelector("thanos-receive", ""),
+		},
+		{
+			instance + "-" + mcoconfig.ThanosStoreShard,
+			mcoconfig.ThanosStoreShard,
+			observatoriumSelector("thanos-store", ""),
+		},
+		{
+			instance + "-" + mcoconfig.ThanosRule,
+			mcoconfig.ThanosRule,
+			observatoriumSelector("thanos-rule", ""),
+		},
+		{
+			instance + "-" + mcoconfig.ThanosStoreMemcached,
+			mcoconfig.ThanosStoreMemcached,
+			observatoriumSelector("memcached", "store-cache"),
+		},
+		{
+			instance + "-" + mcoconfig.ThanosQueryFrontendMemcached,
+			mcoconfig.ThanosQueryFrontendMemcached,
+			observatoriumSelector("memcached", "query-frontend-cache"),
+		},
+		{
+			mcoconfig.GetOperandName(mcoconfig.Alertmanager),
+			mcoconfig.Alertmanager,
+			map[string]string{"app": "multicluster-observability-alertmanager"},
+		},
+	}
+
+	pdbs := map[string]*policyv1.PodDisruptionBudget{}
+	for _, budget := range budgets {
+		maxUnavailableReplicas := getMaxUnavailable(budget.component, mco)
+		if maxUnavailableReplicas < 1 {
+			pdbs[budget.name] = nil
+			continue
+		}
+		maxUnavailable := intstr.FromInt(int(maxUnavailableReplicas))
+		pdbs[budget.name] = &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      budget.name,
+				Namespace: mcoconfig.GetDefaultNamespace(),
+			},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MaxUnavailable: &maxUnavailable,
+				Selector:       &metav1.LabelSelector{MatchLabels: budget.selector},
+			},
+		}
+	}
+	return pdbs
+}
+
+// GeneratePodDisruptionBudgets creates or updates the PodDisruptionBudgets of the stateful components and of the
+// caches, so that draining the nodes does not evict more of their replicas than they tolerate. The
+// PodDisruptionBudgets of the components which cannot lose any pod are deleted.
+func GeneratePodDisruptionBudgets(
+	cl client.Client, scheme *runtime.Scheme,
+	mco *mcov1beta2.MultiClusterObservability) (*ctrl.Result, error) {
+	for name, pdb := range newPodDisruptionBudgets(mco) {
+		if pdb != nil {
+			if err := controllerutil.SetControllerReference(mco, pdb, scheme); err != nil {
+				return &ctrl.Result{}, err
+			}
+		}
+		found := &policyv1.PodDisruptionBudget{}
+		err := cl.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: mcoconfig.GetDefaultNamespace()},
+			found)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return &ctrl.Result{}, err
+		}
+		if pdb == nil {
+			if err == nil {
+				log.Info("Deleting the PodDisruptionBudget, the component cannot lose any pod", "name", name)
+				if err = cl.Delete(context.TODO(), found); err != nil && !k8serrors.IsNotFound(err) {
+					return &ctrl.Result{}, err
+				}
+			}
+			continue
+		}
+		if k8serrors.IsNotFound(err) {
+			log.Info("Creating the PodDisruptionBudget", "name", pdb.Name)
+			if err = cl.Create(context.TODO(), pdb); err != nil {
+				return &ctrl.Result{}, err
+			}
+			continue
+		}
+		if !apiequality.Semantic.DeepDerivative(pdb.Spec, found.Spec) {
+			log.Info("Updating the PodDisruptionBudget", "name", pdb.Name)
+			found.Spec.MinAvailable = nil
+			found.Spec.MaxUnavailable = pdb.Spec.MaxUnavailable
+			found.Spec.Selector = pdb.Spec.Selector
+			if err = cl.Update(context.TODO(), found); err != nil {
+				return &ctrl.Result{}, err
+			}
+		}
+	}
+	return nil, nil
+}
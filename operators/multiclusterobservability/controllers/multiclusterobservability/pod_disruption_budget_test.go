@@ -0,0 +1,137 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package multiclusterobservability
+
+import (
+	"context"
+	"testing"
+
+	observatoriumv1alpha1 "github.com/stolostron/observatorium-operator/api/v1alpha1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+)
+
+func TestGeneratePodDisruptionBudgets(t *testing.T) {
+	s := runtime.NewScheme()
+	policyv1.AddToScheme(s)
+	mcov1beta2.SchemeBuilder.AddToScheme(s)
+	observatoriumv1alpha1.AddToScheme(s)
+
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability"},
+	}
+	minAvailable := intstr.FromInt(2)
+	receivePDBName := mcoconfig.GetDefaultCRName() + "-" + mcoconfig.ThanosReceive
+	receivePDB := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      receivePDBName,
+			Namespace: mcoconfig.GetDefaultNamespace(),
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{MinAvailable: &minAvailable},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(receivePDB).Build()
+	mcoconfig.SetOperandNames(c)
+
+	if _, err := GeneratePodDisruptionBudgets(c, s, mco); err != nil {
+		t.Fatalf("Failed to generate the PodDisruptionBudgets: (%v)", err)
+	}
+	pdbs := &policyv1.PodDisruptionBudgetList{}
+	if err := c.List(context.TODO(), pdbs); err != nil {
+		t.Fatalf("Failed to list the PodDisruptionBudgets: (%v)", err)
+	}
+	if len(pdbs.Items) != 6 {
+		t.Errorf("PodDisruptionBudgets (%v) are not the expected: (6)", len(pdbs.Items))
+	}
+
+	// the modified PodDisruptionBudget is reverted
+	pdb := &policyv1.PodDisruptionBudget{}
+	err := c.Get(context.TODO(), types.NamespacedName{
+		Name:      receivePDBName,
+		Namespace: mcoconfig.GetDefaultNamespace(),
+	}, pdb)
+	if err != nil {
+		t.Fatalf("Failed to get the PodDisruptionBudget: (%v)", err)
+	}
+	if pdb.Spec.MinAvailable != nil || pdb.Spec.MaxUnavailable == nil || pdb.Spec.MaxUnavailable.IntValue() != 1 {
+		t.Errorf("spec (%v) is not the expected: (maxUnavailable 1)", pdb.Spec)
+	}
+	if pdb.Spec.Selector == nil || pdb.Spec.Selector.MatchLabels["app.kubernetes.io/name"] != "thanos-receive" {
+		t.Errorf("selector (%v) is not the expected: (thanos-receive)", pdb.Spec.Selector)
+	}
+
+	// the receivers tolerate less disrupted pods than the quorum of the replication factor, and the single
+	// replica of alertmanager cannot be disrupted
+	receiveReplicas, replicationFactor, alertmanagerReplicas := int32(6), int32(5), int32(1)
+	mco.Spec.AdvancedConfig = &mcov1beta2.AdvancedConfig{
+		Receive: &mcov1beta2.ReceiveSpec{
+			ReplicationFactor: &replicationFactor,
+			CommonSpec:        mcov1beta2.CommonSpec{Replicas: &receiveReplicas},
+		},
+		Alertmanager: &mcov1beta2.CommonSpec{Replicas: &alertmanagerReplicas},
+	}
+	if _, err := GeneratePodDisruptionBudgets(c, s, mco); err != nil {
+		t.Fatalf("Failed to generate the PodDisruptionBudgets: (%v)", err)
+	}
+	err = c.Get(context.TODO(), types.NamespacedName{
+		Name:      receivePDBName,
+		Namespace: mcoconfig.GetDefaultNamespace(),
+	}, pdb)
+	if err != nil {
+		t.Fatalf("Failed to get the PodDisruptionBudget: (%v)", err)
+	}
+	if pdb.Spec.MaxUnavailable == nil || pdb.Spec.MaxUnavailable.IntValue() != 2 {
+		t.Errorf("spec (%v) is not the expected: (maxUnavailable 2)", pdb.Spec)
+	}
+	err = c.Get(context.TODO(), types.NamespacedName{
+		Name:      mcoconfig.GetOperandName(mcoconfig.Alertmanager),
+		Namespace: mcoconfig.GetDefaultNamespace(),
+	}, pdb)
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("PodDisruptionBudget of alertmanager (%v) is not deleted", pdb.Name)
+	}
+}
+
+func TestGetMaxUnavailable(t *testing.T) {
+	caseList := []struct {
+		name              string
+		component         string
+		replicas          int32
+		replicationFactor *int32
+		expected          int32
+	}{
+		{"default receivers", mcoconfig.ThanosReceive, 3, nil, 1},
+		{"single receiver", mcoconfig.ThanosReceive, 1, nil, 0},
+		{"two receivers", mcoconfig.ThanosReceive, 2, nil, 0},
+		{"receivers without replication", mcoconfig.ThanosReceive, 3, &mcoconfig.Replicas1, 0},
+		{"single store", mcoconfig.ThanosStoreShard, 1, nil, 0},
+		{"stores", mcoconfig.ThanosStoreShard, 3, nil, 1},
+	}
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			replicas := c.replicas
+			mco := &mcov1beta2.MultiClusterObservability{
+				Spec: mcov1beta2.MultiClusterObservabilitySpec{
+					AdvancedConfig: &mcov1beta2.AdvancedConfig{
+						Receive: &mcov1beta2.ReceiveSpec{
+							ReplicationFactor: c.replicationFactor,
+							CommonSpec:        mcov1beta2.CommonSpec{Replicas: &replicas},
+						},
+						Store: &mcov1beta2.StoreSpec{CommonSpec: mcov1beta2.CommonSpec{Replicas: &replicas}},
+					},
+				},
+			}
+			if maxUnavailable := getMaxUnavailable(c.component, mco); maxUnavailable != c.expected {
+				t.Errorf("maxUnavailable (%v) is not the expected: (%v)", maxUnavailable, c.expected)
+			}
+		})
+	}
+}
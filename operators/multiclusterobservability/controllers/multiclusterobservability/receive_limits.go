@@ -0,0 +1,118 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package multiclusterobservability
+
+import (
+	"context"
+
+	obsv1alpha1 "github.com/stolostron/observatorium-operator/api/v1alpha1"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+)
+
+const (
+	receiveLimitsConfigMapName = "observability-thanos-receive-limits"
+	receiveLimitsKey           = "limits.yaml"
+)
+
+// receiveLimitsConfig is the limits configuration file of thanos receive.
+type receiveLimitsConfig struct {
+	Write receiveWriteLimitsConfig `yaml:"write"`
+}
+
+type receiveWriteLimitsConfig struct {
+	Default receiveTenantLimitsConfig `yaml:"default"`
+	// the limits of the tenants, keyed by tenant id
+	Tenants map[string]receiveTenantLimitsConfig `yaml:"tenants,omitempty"`
+}
+
+type receiveTenantLimitsConfig struct {
+	Request         *receiveRequestLimitsConfig `yaml:"request,omitempty"`
+	HeadSeriesLimit *int64                      `yaml:"head_series_limit,omitempty"`
+}
+
+type receiveRequestLimitsConfig struct {
+	SizeBytesLimit *int64 `yaml:"size_bytes_limit,omitempty"`
+	SeriesLimit    *int64 `yaml:"series_limit,omitempty"`
+	SamplesLimit   *int64 `yaml:"samples_limit,omitempty"`
+}
+
+func newReceiveTenantLimitsConfig(limits mcov1beta2.ReceiveLimits) receiveTenantLimitsConfig {
+	config := receiveTenantLimitsConfig{HeadSeriesLimit: limits.HeadSeriesLimit}
+	if limits.RequestSizeBytesLimit != nil || limits.RequestSeriesLimit != nil || limits.RequestSamplesLimit != nil {
+		config.Request = &receiveRequestLimitsConfig{
+			SizeBytesLimit: limits.RequestSizeBytesLimit,
+			SeriesLimit:    limits.RequestSeriesLimit,
+			SamplesLimit:   limits.RequestSamplesLimit,
+		}
+	}
+	return config
+}
+
+// newReceiveLimitsConfig returns the limits configuration of thanos receive, the tenants are identified by the ids
+// of the observatorium API tenants.
+func newReceiveLimitsConfig(limits *mcov1beta2.ReceiveLimitsSpec, tenants []obsv1alpha1.APITenant) ([]byte, error) {
+	tenantIDs := map[string]string{}
+	for _, tenant := range tenants {
+		tenantIDs[tenant.Name] = tenant.ID
+	}
+	config := receiveLimitsConfig{
+		Write: receiveWriteLimitsConfig{
+			Default: newReceiveTenantLimitsConfig(limits.ReceiveLimits),
+		},
+	}
+	for _, tenant := range limits.Tenants {
+		id, ok := tenantIDs[tenant.Name]
+		if !ok {
+			log.Info("The tenant of the receive limits is not found", "tenant", tenant.Name)
+			continue
+		}
+		if config.Write.Tenants == nil {
+			config.Write.Tenants = map[string]receiveTenantLimitsConfig{}
+		}
+		config.Write.Tenants[id] = newReceiveTenantLimitsConfig(tenant.ReceiveLimits)
+	}
+	return yaml.Marshal(config)
+}
+
+// GenerateReceiveLimitsConfigMap renders the receive limits of the MultiClusterObservability CR into the limits
+// configuration of thanos receive, with the tenant ids of the Observatorium CR. The configmap is deleted when no
+// limits are set.
+func GenerateReceiveLimitsConfigMap(
+	cl client.Client, scheme *runtime.Scheme,
+	mco *mcov1beta2.MultiClusterObservability) (*ctrl.Result, error) {
+
+	if mco.Spec.AdvancedConfig == nil || mco.Spec.AdvancedConfig.Receive == nil ||
+		mco.Spec.AdvancedConfig.Receive.Limits == nil {
+		if err := applyOperandConfigMap(cl, scheme, mco, receiveLimitsConfigMapName, nil); err != nil {
+			return &ctrl.Result{}, err
+		}
+		return nil, nil
+	}
+
+	obs := &obsv1alpha1.Observatorium{}
+	err := cl.Get(context.TODO(), types.NamespacedName{
+		Name:      mcoconfig.GetOperandName(mcoconfig.Observatorium),
+		Namespace: mcoconfig.GetDefaultNamespace(),
+	}, obs)
+	if err != nil {
+		return &ctrl.Result{}, err
+	}
+	limitsYaml, err := newReceiveLimitsConfig(mco.Spec.AdvancedConfig.Receive.Limits, obs.Spec.API.Tenants)
+	if err != nil {
+		return &ctrl.Result{}, err
+	}
+	err = applyOperandConfigMap(cl, scheme, mco, receiveLimitsConfigMapName,
+		map[string]string{receiveLimitsKey: string(limitsYaml)})
+	if err != nil {
+		return &ctrl.Result{}, err
+	}
+	return nil, nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package multiclusterobservability
+
+import (
+	"context"
+	"testing"
+
+	obsv1alpha1 "github.com/stolostron/observatorium-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+)
+
+func TestNewReceiveLimitsConfig(t *testing.T) {
+	headSeriesLimit, seriesLimit, tenantSeriesLimit := int64(1000000), int64(5000), int64(10000)
+	limits := &mcov1beta2.ReceiveLimitsSpec{
+		ReceiveLimits: mcov1beta2.ReceiveLimits{
+			HeadSeriesLimit:    &headSeriesLimit,
+			RequestSeriesLimit: &seriesLimit,
+		},
+		Tenants: []mcov1beta2.TenantReceiveLimits{
+			{Name: "team-a", ReceiveLimits: mcov1beta2.ReceiveLimits{RequestSeriesLimit: &tenantSeriesLimit}},
+			{Name: "team-b", ReceiveLimits: mcov1beta2.ReceiveLimits{RequestSeriesLimit: &tenantSeriesLimit}},
+		},
+	}
+	tenants := []obsv1alpha1.APITenant{
+		{Name: "default", ID: "default-id"},
+		{Name: "team-a", ID: "team-a-id"},
+	}
+	output, err := newReceiveLimitsConfig(limits, tenants)
+	if err != nil {
+		t.Fatalf("Failed to render the receive limits: (%v)", err)
+	}
+	expected := `write:
+  default:
+    request:
+      series_limit: 5000
+    head_series_limit: 1000000
+  tenants:
+    team-a-id:
+      request:
+        series_limit: 10000
+`
+	if string(output) != expected {
+		t.Errorf("limits (%v) are not the expected: (%v)", string(output), expected)
+	}
+}
+
+func TestGenerateReceiveLimitsConfigMap(t *testing.T) {
+	s := runtime.NewScheme()
+	corev1.AddToScheme(s)
+	mcov1beta2.SchemeBuilder.AddToScheme(s)
+	obsv1alpha1.AddToScheme(s)
+
+	headSeriesLimit := int64(1000000)
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability"},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			AdvancedConfig: &mcov1beta2.AdvancedConfig{
+				Receive: &mcov1beta2.ReceiveSpec{
+					Limits: &mcov1beta2.ReceiveLimitsSpec{
+						ReceiveLimits: mcov1beta2.ReceiveLimits{HeadSeriesLimit: &headSeriesLimit},
+					},
+				},
+			},
+		},
+	}
+	obs := &obsv1alpha1.Observatorium{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mcoconfig.GetOperandName(mcoconfig.Observatorium),
+			Namespace: mcoconfig.GetDefaultNamespace(),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(obs).Build()
+	namespacedName := types.NamespacedName{
+		Name:      receiveLimitsConfigMapName,
+		Namespace: mcoconfig.GetDefaultNamespace(),
+	}
+
+	if _, err := GenerateReceiveLimitsConfigMap(c, s, mco); err != nil {
+		t.Fatalf("Failed to generate the receive limits configmap: (%v)", err)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(context.TODO(), namespacedName, cm); err != nil {
+		t.Fatalf("Failed to get the receive limits configmap: (%v)", err)
+	}
+	expected := "write:\n  default:\n    head_series_limit: 1000000\n"
+	if cm.Data[receiveLimitsKey] != expected {
+		t.Errorf("limits (%v) are not the expected: (%v)", cm.Data[receiveLimitsKey], expected)
+	}
+
+	// the configmap is deleted with the limits
+	mco.Spec.AdvancedConfig.Receive.Limits = nil
+	if _, err := GenerateReceiveLimitsConfigMap(c, s, mco); err != nil {
+		t.Fatalf("Failed to delete the receive limits configmap: (%v)", err)
+	}
+	if err := c.Get(context.TODO(), namespacedName, cm); !k8serrors.IsNotFound(err) {
+		t.Errorf("the receive limits configmap should be deleted: (%v)", err)
+	}
+}
@@ -93,3 +93,25 @@ func generateHubInfoSecret(client client.Client, obsNamespace string,
 		Data: configYamlMap,
 	}, nil
 }
+
+// setHubInfoTenant sets the path of the observatorium API endpoint of the hub info secret to the remote write API
+// of the tenant, the metrics of the managed cluster are written into its tenant.
+func setHubInfoTenant(hubInfoSecret *corev1.Secret, tenant string) error {
+	hubInfo := &operatorconfig.HubInfo{}
+	err := yaml.Unmarshal(hubInfoSecret.Data[operatorconfig.HubInfoSecretKey], hubInfo)
+	if err != nil {
+		return err
+	}
+	obsApiURL, err := url.Parse(hubInfo.ObservatoriumAPIEndpoint)
+	if err != nil {
+		return err
+	}
+	obsApiURL.Path = operatorconfig.GetObservatoriumAPIRemoteWritePath(tenant)
+	hubInfo.ObservatoriumAPIEndpoint = obsApiURL.String()
+	configYaml, err := yaml.Marshal(hubInfo)
+	if err != nil {
+		return err
+	}
+	hubInfoSecret.Data[operatorconfig.HubInfoSecretKey] = configYaml
+	return nil
+}
@@ -158,3 +158,31 @@ func TestNewBYOSecret(t *testing.T) {
 		t.Fatalf("Wrong content in hub info secret: \ngot: "+hub.ObservatoriumAPIEndpoint+" "+hub.AlertmanagerEndpoint+" "+hub.AlertmanagerRouterCA, clusterName+" "+"https://test-host"+" "+"test-host"+" "+routerBYOCA)
 	}
 }
+
+func TestSetHubInfoTenant(t *testing.T) {
+	initSchema(t)
+
+	objs := []runtime.Object{newTestObsApiRoute(), newTestAlertmanagerRoute(), newTestIngressController(), newTestRouteCASecret()}
+	c := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+
+	hubInfo, err := generateHubInfoSecret(c, mcoNamespace, namespace, true)
+	if err != nil {
+		t.Fatalf("Failed to initial the hub info secret: (%v)", err)
+	}
+	// the hub info secret is shared by the managed clusters, the tenant is replaced for each of them
+	for _, tenant := range []string{"team-a", config.GetDefaultTenantName()} {
+		err = setHubInfoTenant(hubInfo, tenant)
+		if err != nil {
+			t.Fatalf("Failed to set the tenant of the hub info secret: (%v)", err)
+		}
+		hub := &operatorconfig.HubInfo{}
+		err = yaml.Unmarshal(hubInfo.Data[operatorconfig.HubInfoSecretKey], &hub)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal data in hub info secret (%v)", err)
+		}
+		expected := "https://" + routeHost + "/api/metrics/v1/" + tenant + "/api/v1/receive"
+		if hub.ObservatoriumAPIEndpoint != expected {
+			t.Errorf("endpoint (%v) is not the expected: (%v)", hub.ObservatoriumAPIEndpoint, expected)
+		}
+	}
+}
@@ -361,6 +361,17 @@ func createManifestWorks(c client.Client, restMapper meta.RESTMapper,
 
 	// inject the hub info secret
 	hubInfo.Data[operatorconfig.ClusterNameKey] = []byte(clusterName)
+	// the managed cluster labels are used to evaluate the collect rule selectors,
+	// managedClusterListMutex is already locked by the caller
+	clusterLabels, err := yaml.Marshal(managedClusterLabelList[clusterName])
+	if err != nil {
+		return err
+	}
+	hubInfo.Data[operatorconfig.ClusterLabelsKey] = clusterLabels
+	tenant := config.GetClusterTenant(mco.Spec.Tenants, managedClusterLabelList[clusterName])
+	if err = setHubInfoTenant(hubInfo, tenant); err != nil {
+		return err
+	}
 	manifests = injectIntoWork(manifests, hubInfo)
 
 	work.Spec.Workload.Manifests = manifests
@@ -584,9 +595,14 @@ func getObservabilityAddon(c client.Client, namespace string,
 			Namespace: spokeNameSpace,
 		},
 		Spec: mcoshared.ObservabilityAddonSpec{
-			EnableMetrics: mco.Spec.ObservabilityAddonSpec.EnableMetrics,
-			Interval:      mco.Spec.ObservabilityAddonSpec.Interval,
-			Resources:     config.GetOBAResources(mco.Spec.ObservabilityAddonSpec),
+			EnableMetrics:           mco.Spec.ObservabilityAddonSpec.EnableMetrics,
+			Interval:                mco.Spec.ObservabilityAddonSpec.Interval,
+			Resources:               config.GetOBAResources(mco.Spec.ObservabilityAddonSpec),
+			ForwardingMode:          mco.Spec.ObservabilityAddonSpec.ForwardingMode,
+			AdditionalSources:       mco.Spec.ObservabilityAddonSpec.AdditionalSources,
+			ResourceAutoSizing:      mco.Spec.ObservabilityAddonSpec.ResourceAutoSizing,
+			HostedClusterCollectors: mco.Spec.ObservabilityAddonSpec.HostedClusterCollectors,
+			BundledExporters:        mco.Spec.ObservabilityAddonSpec.BundledExporters,
 		},
 	}, nil
 }
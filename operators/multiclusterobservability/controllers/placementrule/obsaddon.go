@@ -50,6 +50,9 @@ func deleteObsAddon(c client.Client, namespace string) error {
 		}
 	})
 
+	deleteCollectorMetrics(namespace, platformCollector)
+	deleteCollectorMetrics(namespace, uwlCollector)
+
 	log.Info("observabilityaddon is deleted", "namespace", namespace)
 	return nil
 }
@@ -62,6 +62,7 @@ var (
 	defaultAddonDeploymentConfig  = &addonv1alpha1.AddOnDeploymentConfig{}
 	isplacementControllerRunnning = false
 	managedClusterList            = map[string]string{}
+	managedClusterLabelList       = map[string]map[string]string{}
 	managedClusterListMutex       = &sync.RWMutex{}
 )
 
@@ -340,6 +341,10 @@ func createAllRelatedRes(
 
 	failedCreateManagedClusterRes := false
 	managedClusterListMutex.RLock()
+	if err = updateTenantClustersConfigMap(c, mco); err != nil {
+		failedCreateManagedClusterRes = true
+		log.Error(err, "Failed to update the tenant clusters configmap")
+	}
 	for managedCluster, openshiftVersion := range managedClusterList {
 		currentClusters = commonutil.Remove(currentClusters, managedCluster)
 		if isReconcileRequired(request, managedCluster) {
@@ -417,6 +422,10 @@ func deleteGlobalResource(c client.Client) error {
 		return err
 	}
 	isCRoleCreated = false
+	err = deleteTenantClustersConfigMap(c)
+	if err != nil {
+		return err
+	}
 	//delete ClusterManagementAddon
 	err = util.DeleteClusterManagementAddon(c)
 	if err != nil {
@@ -514,6 +523,7 @@ func updateManagedClusterList(obj client.Object) {
 	} else {
 		managedClusterList[obj.GetName()] = nonOCP
 	}
+	managedClusterLabelList[obj.GetName()] = obj.GetLabels()
 }
 
 // Do not reconcile objects if this instance of mch has the
@@ -34,6 +34,7 @@ func getClusterPreds() predicate.Funcs {
 			log.Info("managedcluster is in terminating state", "managedCluster", e.ObjectNew.GetName())
 			managedClusterListMutex.Lock()
 			delete(managedClusterList, e.ObjectNew.GetName())
+			delete(managedClusterLabelList, e.ObjectNew.GetName())
 			managedClusterListMutex.Unlock()
 			managedClusterImageRegistryMutex.Lock()
 			delete(managedClusterImageRegistry, e.ObjectNew.GetName())
@@ -55,6 +56,7 @@ func getClusterPreds() predicate.Funcs {
 
 		managedClusterListMutex.Lock()
 		delete(managedClusterList, e.Object.GetName())
+		delete(managedClusterLabelList, e.Object.GetName())
 		managedClusterListMutex.Unlock()
 		managedClusterImageRegistryMutex.Lock()
 		delete(managedClusterImageRegistry, e.Object.GetName())
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"reflect"
 
+	certificatesv1 "k8s.io/api/certificates/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -52,6 +53,19 @@ func createClusterRole(c client.Client) error {
 					mcov1beta2.GroupVersion.Group,
 				},
 			},
+			// the addon agent requests the client certificates of the hosted clusters
+			{
+				Resources: []string{
+					"certificatesigningrequests",
+				},
+				Verbs: []string{
+					"create",
+					"get",
+				},
+				APIGroups: []string{
+					certificatesv1.GroupName,
+				},
+			},
 		},
 	}
 
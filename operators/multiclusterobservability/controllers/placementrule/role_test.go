@@ -70,7 +70,7 @@ func TestCreateClusterRole(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to update mcoClusterRole: (%v)", err)
 	}
-	if len(found.Rules) != 1 {
+	if len(found.Rules) != 2 {
 		t.Fatalf("role is no updated correctly")
 	}
 }
@@ -6,14 +6,20 @@ package placementrule
 import (
 	"context"
 	"reflect"
+	"strings"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	mcov1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
 	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/util"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 )
 
@@ -25,9 +31,52 @@ var (
 		"Disabled":     "Degraded",
 		"Degraded":     "Degraded",
 		"NotSupported": "Degraded",
+		// reported next to the status condition by the endpoint operator
+		"ClusterMonitoringConfigConflict": "ClusterMonitoringConfigConflict",
 	}
 )
 
+const (
+	metricsCollectorConditionType    = "MetricsCollector"
+	uwlMetricsCollectorConditionType = "UWLMetricsCollector"
+	collectorLabel                   = "collector"
+	platformCollector                = "platform"
+	uwlCollector                     = "uwl"
+)
+
+var (
+	addonLastPushTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "acm_observability_addon_last_successful_push_timestamp_seconds",
+		Help: "The last time the metrics collector of a managed cluster pushed metrics successfully",
+	}, []string{"cluster", collectorLabel})
+	addonSeriesSent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "acm_observability_addon_series_sent",
+		Help: "The number of series sent by the metrics collector of a managed cluster in the last interval",
+	}, []string{"cluster", collectorLabel})
+	addonBytesSent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "acm_observability_addon_bytes_sent",
+		Help: "The number of bytes sent by the metrics collector of a managed cluster in the last interval",
+	}, []string{"cluster", collectorLabel})
+	addonConsecutiveFailures = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "acm_observability_addon_consecutive_failures",
+		Help: "The number of consecutive intervals the metrics collector of a managed cluster failed to forward metrics",
+	}, []string{"cluster", collectorLabel})
+	addonCollectRuleMetrics = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "acm_observability_addon_collect_rule_metrics",
+		Help: "The number of extra metrics collected from a managed cluster because of fired collect rules",
+	}, []string{"cluster", collectorLabel})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		addonLastPushTimestamp,
+		addonSeriesSent,
+		addonBytesSent,
+		addonConsecutiveFailures,
+		addonCollectRuleMetrics,
+	)
+}
+
 func updateAddonStatus(c client.Client, addonList mcov1beta1.ObservabilityAddonList) error {
 	for _, addon := range addonList.Items {
 		if addon.Status.Conditions == nil || len(addon.Status.Conditions) == 0 {
@@ -35,8 +84,19 @@ func updateAddonStatus(c client.Client, addonList mcov1beta1.ObservabilityAddonL
 		}
 		conditions := []metav1.Condition{}
 		for _, c := range addon.Status.Conditions {
+			conditionType := statusMap[c.Type]
+			if strings.HasPrefix(c.Type, operatorconfig.MetricsSourceConditionPrefix) ||
+				strings.HasPrefix(c.Type, operatorconfig.DiagnosticConditionPrefix) ||
+				strings.HasPrefix(c.Type, operatorconfig.HostedClusterConditionPrefix) {
+				// the conditions of the additional metrics sources, the diagnostics and the hosted cluster
+				// collectors are reported as they are
+				conditionType = c.Type
+			}
+			if conditionType == "" {
+				continue
+			}
 			condition := metav1.Condition{
-				Type:               statusMap[c.Type],
+				Type:               conditionType,
 				Status:             c.Status,
 				LastTransitionTime: c.LastTransitionTime,
 				Reason:             c.Reason,
@@ -44,6 +104,8 @@ func updateAddonStatus(c client.Client, addonList mcov1beta1.ObservabilityAddonL
 			}
 			conditions = append(conditions, condition)
 		}
+		updateCollectorMetrics(addon.ObjectMeta.Namespace, platformCollector, addon.Status.MetricsCollector)
+		updateCollectorMetrics(addon.ObjectMeta.Namespace, uwlCollector, addon.Status.UWLMetricsCollector)
 		managedclusteraddon := &addonv1alpha1.ManagedClusterAddOn{}
 		err := c.Get(context.TODO(), types.NamespacedName{
 			Name:      util.ManagedClusterAddonName,
@@ -57,6 +119,10 @@ func updateAddonStatus(c client.Client, addonList mcov1beta1.ObservabilityAddonL
 			log.Error(err, "Failed to get managedclusteraddon", "namespace", addon.ObjectMeta.Namespace)
 			return err
 		}
+		conditions = appendCollectorCondition(conditions, managedclusteraddon.Status.Conditions,
+			metricsCollectorConditionType, addon.Status.MetricsCollector)
+		conditions = appendCollectorCondition(conditions, managedclusteraddon.Status.Conditions,
+			uwlMetricsCollectorConditionType, addon.Status.UWLMetricsCollector)
 		if !reflect.DeepEqual(conditions, managedclusteraddon.Status.Conditions) {
 			managedclusteraddon.Status.Conditions = conditions
 			err = c.Status().Update(context.TODO(), managedclusteraddon)
@@ -74,3 +140,60 @@ func updateAddonStatus(c client.Client, addonList mcov1beta1.ObservabilityAddonL
 	}
 	return nil
 }
+
+// appendCollectorCondition summarizes the health reported by a metrics collector as a
+// condition of the managedclusteraddon. The transition time of the existing condition is kept
+// as long as the health of the collector does not change.
+func appendCollectorCondition(conditions, existing []metav1.Condition, conditionType string,
+	collectorStatus *mcov1beta1.MetricsCollectorStatus) []metav1.Condition {
+	if collectorStatus == nil {
+		return conditions
+	}
+
+	// the message is kept stable, the counters are exposed by the status of the observabilityaddon and
+	// by the hub metrics, so that the condition is only updated when the health of the collector changes
+	condition := metav1.Condition{
+		Type:    conditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  "MetricsForwarded",
+		Message: "Metrics are forwarded to the hub",
+	}
+	if collectorStatus.ConsecutiveFailures > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ForwardFailed"
+		condition.Message = "Metrics failed to be forwarded to the hub"
+	}
+
+	if found := meta.FindStatusCondition(existing, conditionType); found != nil && found.Status == condition.Status {
+		condition.LastTransitionTime = found.LastTransitionTime
+	} else if collectorStatus.LastSuccessfulPushTime != nil && condition.Status == metav1.ConditionTrue {
+		condition.LastTransitionTime = *collectorStatus.LastSuccessfulPushTime
+	} else {
+		condition.LastTransitionTime = metav1.NewTime(time.Now())
+	}
+	return append(conditions, condition)
+}
+
+// updateCollectorMetrics exposes the telemetry reported by a metrics collector as hub metrics.
+func updateCollectorMetrics(cluster, collector string, collectorStatus *mcov1beta1.MetricsCollectorStatus) {
+	if collectorStatus == nil {
+		deleteCollectorMetrics(cluster, collector)
+		return
+	}
+	if collectorStatus.LastSuccessfulPushTime != nil {
+		addonLastPushTimestamp.WithLabelValues(cluster, collector).Set(
+			float64(collectorStatus.LastSuccessfulPushTime.Unix()))
+	}
+	addonSeriesSent.WithLabelValues(cluster, collector).Set(float64(collectorStatus.SeriesSent))
+	addonBytesSent.WithLabelValues(cluster, collector).Set(float64(collectorStatus.BytesSent))
+	addonConsecutiveFailures.WithLabelValues(cluster, collector).Set(float64(collectorStatus.ConsecutiveFailures))
+	addonCollectRuleMetrics.WithLabelValues(cluster, collector).Set(float64(len(collectorStatus.CollectRuleMetrics)))
+}
+
+func deleteCollectorMetrics(cluster, collector string) {
+	addonLastPushTimestamp.DeleteLabelValues(cluster, collector)
+	addonSeriesSent.DeleteLabelValues(cluster, collector)
+	addonBytesSent.DeleteLabelValues(cluster, collector)
+	addonConsecutiveFailures.DeleteLabelValues(cluster, collector)
+	addonCollectRuleMetrics.DeleteLabelValues(cluster, collector)
+}
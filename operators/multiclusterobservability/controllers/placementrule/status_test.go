@@ -8,8 +8,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	mcov1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
 	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/util"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -65,4 +68,170 @@ func TestUpdateAddonStatus(t *testing.T) {
 	if maddon.Status.Conditions == nil || len(maddon.Status.Conditions) != 1 {
 		t.Fatalf("Status not updated correctly in managedclusteraddon: (%v)", maddon)
 	}
+
+	// the condition of an additional metrics source is reported as it is
+	sourceConditionType := operatorconfig.MetricsSourceConditionPrefix + "istio"
+	addonList.Items[0].Status.Conditions = append(addonList.Items[0].Status.Conditions, mcov1beta1.StatusCondition{
+		Type:               sourceConditionType,
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Reason:             "Degraded",
+		Message:            "Failed to retrieve metrics",
+	})
+	err = updateAddonStatus(c, *addonList)
+	if err != nil {
+		t.Fatalf("Failed to update status for managedclusteraddon: (%v)", err)
+	}
+	err = c.Get(context.TODO(), types.NamespacedName{
+		Name:      util.ManagedClusterAddonName,
+		Namespace: namespace,
+	}, maddon)
+	if err != nil {
+		t.Fatalf("Failed to get managedclusteraddon: (%v)", err)
+	}
+	if len(maddon.Status.Conditions) != 2 || maddon.Status.Conditions[1].Type != sourceConditionType {
+		t.Fatalf("The metrics source condition is not reported in managedclusteraddon: (%v)", maddon)
+	}
+
+	// the diagnostics are reported as they are
+	diagnosticConditionType := operatorconfig.DiagnosticConditionPrefix + "ClockSkew"
+	addonList.Items[0].Status.Conditions = append(addonList.Items[0].Status.Conditions, mcov1beta1.StatusCondition{
+		Type:               diagnosticConditionType,
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Reason:             "ClockSkewed",
+		Message:            "the clock differs from the hub by 2m0s",
+	})
+	err = updateAddonStatus(c, *addonList)
+	if err != nil {
+		t.Fatalf("Failed to update status for managedclusteraddon: (%v)", err)
+	}
+	err = c.Get(context.TODO(), types.NamespacedName{
+		Name:      util.ManagedClusterAddonName,
+		Namespace: namespace,
+	}, maddon)
+	if err != nil {
+		t.Fatalf("Failed to get managedclusteraddon: (%v)", err)
+	}
+	if len(maddon.Status.Conditions) != 3 || maddon.Status.Conditions[2].Type != diagnosticConditionType {
+		t.Fatalf("The diagnostic condition is not reported in managedclusteraddon: (%v)", maddon)
+	}
+
+	// the conditions of the hosted cluster collectors are reported as they are
+	hostedClusterConditionType := operatorconfig.HostedClusterConditionPrefix + "hosted-a"
+	addonList.Items[0].Status.Conditions = append(addonList.Items[0].Status.Conditions, mcov1beta1.StatusCondition{
+		Type:               hostedClusterConditionType,
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Reason:             "ForwardSuccessful",
+		Message:            "Metrics forwarded successfully",
+	})
+	err = updateAddonStatus(c, *addonList)
+	if err != nil {
+		t.Fatalf("Failed to update status for managedclusteraddon: (%v)", err)
+	}
+	err = c.Get(context.TODO(), types.NamespacedName{
+		Name:      util.ManagedClusterAddonName,
+		Namespace: namespace,
+	}, maddon)
+	if err != nil {
+		t.Fatalf("Failed to get managedclusteraddon: (%v)", err)
+	}
+	if len(maddon.Status.Conditions) != 4 || maddon.Status.Conditions[3].Type != hostedClusterConditionType {
+		t.Fatalf("The hosted cluster condition is not reported in managedclusteraddon: (%v)", maddon)
+	}
+}
+
+func TestUpdateAddonStatusWithTelemetry(t *testing.T) {
+	maddon := &addonv1alpha1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      util.ManagedClusterAddonName,
+			Namespace: namespace,
+		},
+		Status: addonv1alpha1.ManagedClusterAddOnStatus{},
+	}
+	objs := []runtime.Object{maddon}
+	c := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+
+	lastPush := metav1.NewTime(time.Now())
+	addonList := &mcov1beta1.ObservabilityAddonList{
+		Items: []mcov1beta1.ObservabilityAddon{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      obsAddonName,
+					Namespace: namespace,
+				},
+				Status: mcov1beta1.ObservabilityAddonStatus{
+					Conditions: []mcov1beta1.StatusCondition{
+						{
+							Type:               "Available",
+							Status:             metav1.ConditionTrue,
+							LastTransitionTime: metav1.NewTime(time.Now()),
+							Reason:             "Available",
+							Message:            "Cluster metrics sent successfully",
+						},
+					},
+					MetricsCollector: &mcov1beta1.MetricsCollectorStatus{
+						LastSuccessfulPushTime: &lastPush,
+						SeriesSent:             100,
+						BytesSent:              2048,
+					},
+					UWLMetricsCollector: &mcov1beta1.MetricsCollectorStatus{
+						ConsecutiveFailures: 3,
+					},
+				},
+			},
+		},
+	}
+
+	err := updateAddonStatus(c, *addonList)
+	if err != nil {
+		t.Fatalf("Failed to update status for managedclusteraddon: (%v)", err)
+	}
+
+	err = c.Get(context.TODO(), types.NamespacedName{
+		Name:      util.ManagedClusterAddonName,
+		Namespace: namespace,
+	}, maddon)
+	if err != nil {
+		t.Fatalf("Failed to get managedclusteraddon: (%v)", err)
+	}
+	if len(maddon.Status.Conditions) != 3 {
+		t.Fatalf("Status not updated correctly in managedclusteraddon: (%v)", maddon)
+	}
+	if c := meta.FindStatusCondition(maddon.Status.Conditions, metricsCollectorConditionType); c == nil ||
+		c.Status != metav1.ConditionTrue {
+		t.Errorf("Condition %s not set correctly: (%v)", metricsCollectorConditionType, c)
+	}
+	if c := meta.FindStatusCondition(maddon.Status.Conditions, uwlMetricsCollectorConditionType); c == nil ||
+		c.Status != metav1.ConditionFalse {
+		t.Errorf("Condition %s not set correctly: (%v)", uwlMetricsCollectorConditionType, c)
+	}
+
+	if v := testutil.ToFloat64(addonSeriesSent.WithLabelValues(namespace, platformCollector)); v != 100 {
+		t.Errorf("series sent metric (%v) is not the expected: (100)", v)
+	}
+	if v := testutil.ToFloat64(addonConsecutiveFailures.WithLabelValues(namespace, uwlCollector)); v != 3 {
+		t.Errorf("consecutive failures metric (%v) is not the expected: (3)", v)
+	}
+}
+
+func TestAppendCollectorConditionIsStable(t *testing.T) {
+	pushTime := metav1.NewTime(time.Now().Add(-time.Minute))
+	collectorStatus := &mcov1beta1.MetricsCollectorStatus{
+		LastSuccessfulPushTime: &pushTime,
+		SeriesSent:             100,
+		BytesSent:              2048,
+	}
+	conditions := appendCollectorCondition(nil, nil, metricsCollectorConditionType, collectorStatus)
+
+	// the counters change on every push, the condition does not
+	newPushTime := metav1.NewTime(time.Now())
+	collectorStatus.LastSuccessfulPushTime = &newPushTime
+	collectorStatus.SeriesSent = 200
+	collectorStatus.BytesSent = 4096
+	updated := appendCollectorCondition(nil, conditions, metricsCollectorConditionType, collectorStatus)
+	if len(updated) != 1 || updated[0] != conditions[0] {
+		t.Errorf("condition (%v) is not the expected: (%v)", updated, conditions)
+	}
 }
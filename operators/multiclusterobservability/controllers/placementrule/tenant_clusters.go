@@ -0,0 +1,77 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package placementrule
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+)
+
+// updateTenantClustersConfigMap writes the managed clusters mapped to each tenant other than the default tenant, the
+// rbac-query-proxy checks a user can access one of them before routing the queries of the tenant.
+// managedClusterListMutex is already locked by the caller.
+func updateTenantClustersConfigMap(c client.Client, mco *mcov1beta2.MultiClusterObservability) error {
+	tenantClusters := map[string][]string{}
+	for cluster, labels := range managedClusterLabelList {
+		tenant := config.GetClusterTenant(mco.Spec.Tenants, labels)
+		if tenant != config.GetDefaultTenantName() {
+			tenantClusters[tenant] = append(tenantClusters[tenant], cluster)
+		}
+	}
+	for _, clusters := range tenantClusters {
+		sort.Strings(clusters)
+	}
+	data, err := yaml.Marshal(tenantClusters)
+	if err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.TenantClustersConfigMapName,
+			Namespace: config.GetDefaultNamespace(),
+		},
+		Data: map[string]string{config.TenantClustersConfigMapKey: string(data)},
+	}
+	found := &corev1.ConfigMap{}
+	err = c.Get(context.TODO(), types.NamespacedName{Name: cm.Name, Namespace: cm.Namespace}, found)
+	if err != nil && k8serrors.IsNotFound(err) {
+		log.Info("Creating the tenant clusters configmap", "name", cm.Name)
+		return c.Create(context.TODO(), cm)
+	} else if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(found.Data, cm.Data) {
+		return nil
+	}
+	log.Info("Updating the tenant clusters configmap", "name", cm.Name)
+	found.Data = cm.Data
+	return c.Update(context.TODO(), found)
+}
+
+func deleteTenantClustersConfigMap(c client.Client) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.TenantClustersConfigMapName,
+			Namespace: config.GetDefaultNamespace(),
+		},
+	}
+	err := c.Delete(context.TODO(), cm)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		log.Error(err, "Failed to delete the tenant clusters configmap", "name", cm.Name)
+		return err
+	}
+	return nil
+}
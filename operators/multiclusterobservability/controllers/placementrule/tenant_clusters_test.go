@@ -0,0 +1,80 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package placementrule
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+)
+
+func TestTenantClustersConfigMap(t *testing.T) {
+	mco := newTestMCO()
+	mco.Spec.Tenants = []mcov1beta2.TenantSpec{
+		{
+			Name:        "team-a",
+			ClusterSets: []string{"set-a"},
+		},
+	}
+	managedClusterListMutex.Lock()
+	managedClusterLabelList = map[string]map[string]string{
+		"cluster-b": {config.ClusterSetLabel: "set-a"},
+		"cluster-a": {config.ClusterSetLabel: "set-a"},
+		"cluster-c": {},
+	}
+	managedClusterListMutex.Unlock()
+	c := fake.NewClientBuilder().Build()
+
+	if err := updateTenantClustersConfigMap(c, mco); err != nil {
+		t.Fatalf("Failed to update the tenant clusters configmap: (%v)", err)
+	}
+	cm := &corev1.ConfigMap{}
+	err := c.Get(context.TODO(), types.NamespacedName{
+		Name:      config.TenantClustersConfigMapName,
+		Namespace: config.GetDefaultNamespace(),
+	}, cm)
+	if err != nil {
+		t.Fatalf("Failed to get the tenant clusters configmap: (%v)", err)
+	}
+	expected := "team-a:\n- cluster-a\n- cluster-b\n"
+	if data := cm.Data[config.TenantClustersConfigMapKey]; data != expected {
+		t.Errorf("(%v) is not the expected: (%v)", data, expected)
+	}
+
+	mco.Spec.Tenants = nil
+	if err = updateTenantClustersConfigMap(c, mco); err != nil {
+		t.Fatalf("Failed to update the tenant clusters configmap: (%v)", err)
+	}
+	err = c.Get(context.TODO(), types.NamespacedName{
+		Name:      config.TenantClustersConfigMapName,
+		Namespace: config.GetDefaultNamespace(),
+	}, cm)
+	if err != nil {
+		t.Fatalf("Failed to get the tenant clusters configmap: (%v)", err)
+	}
+	if data := cm.Data[config.TenantClustersConfigMapKey]; data != "{}\n" {
+		t.Errorf("(%v) is not the expected: (%v)", data, "{}\n")
+	}
+
+	if err = deleteTenantClustersConfigMap(c); err != nil {
+		t.Fatalf("Failed to delete the tenant clusters configmap: (%v)", err)
+	}
+	err = c.Get(context.TODO(), types.NamespacedName{
+		Name:      config.TenantClustersConfigMapName,
+		Namespace: config.GetDefaultNamespace(),
+	}, &corev1.ConfigMap{})
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("the tenant clusters configmap should be deleted: (%v)", err)
+	}
+	managedClusterListMutex.Lock()
+	managedClusterLabelList = map[string]map[string]string{}
+	managedClusterListMutex.Unlock()
+}
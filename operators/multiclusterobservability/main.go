@@ -35,7 +35,9 @@ import (
 	routev1 "github.com/openshift/api/route/v1"
 	prometheusv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -190,6 +192,12 @@ func main() {
 		appsv1.SchemeGroupVersion.WithKind("StatefulSet"): []filteredcache.Selector{
 			{FieldSelector: fmt.Sprintf("metadata.namespace==%s", config.GetDefaultNamespace())},
 		},
+		autoscalingv2.SchemeGroupVersion.WithKind("HorizontalPodAutoscaler"): []filteredcache.Selector{
+			{FieldSelector: fmt.Sprintf("metadata.namespace==%s", config.GetDefaultNamespace())},
+		},
+		policyv1.SchemeGroupVersion.WithKind("PodDisruptionBudget"): []filteredcache.Selector{
+			{FieldSelector: fmt.Sprintf("metadata.namespace==%s", config.GetDefaultNamespace())},
+		},
 		workv1.SchemeGroupVersion.WithKind("ManifestWork"): []filteredcache.Selector{
 			{LabelSelector: "owner==multicluster-observability-operator"},
 		},
@@ -318,13 +326,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	observabilityv1beta2.ObjStorageConfValidator = config.CheckMetricObjStorageConf
+	observabilityv1beta2.WriteStorageValidator = util.ValidateRemoteWriteEndpoint
+	observabilityv1beta2.Defaulter = &config.MultiClusterObservabilityDefaulter{}
+	observabilityv1beta2.DefaultNamespace = config.GetDefaultNamespace
 	if err = (&observabilityv1beta2.MultiClusterObservability{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "MultiClusterObservability")
 		os.Exit(1)
 	}
 
 	setupLog.Info("add webhook controller to manager")
-	if err := mgr.Add(webhook.NewWebhookController(mgr.GetClient(), nil, config.GetValidatingWebhookConfigurationForMCO())); err != nil {
+	if err := mgr.Add(webhook.NewWebhookController(mgr.GetClient(),
+		config.GetMutatingWebhookConfigurationForMCO(), config.GetValidatingWebhookConfigurationForMCO())); err != nil {
 		setupLog.Error(err, "unable to add webhook controller to manager")
 		os.Exit(1)
 	}
@@ -4,21 +4,83 @@
 package certificates
 
 import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"reflect"
 	"strings"
 
 	certificatesv1 "k8s.io/api/certificates/v1"
 
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 )
 
+// approve approves the CSRs of the managed cluster. The client certificates of the observability signer must be
+// requested for the group of the tenant the managed cluster is mapped to, the write role of the tenant is bound to
+// the group.
 func approve(cluster *clusterv1.ManagedCluster, addon *addonapiv1alpha1.ManagedClusterAddOn,
-	csr *certificatesv1.CertificateSigningRequest) bool {
-	if strings.HasPrefix(csr.Spec.Username, "system:open-cluster-management:"+cluster.Name) {
-		log.Info("CSR approved")
-		return true
-	} else {
+	csr *certificatesv1.CertificateSigningRequest, group string) bool {
+	if hostedCluster, ok := csr.Labels[operatorconfig.HostedClusterLabelKey]; ok {
+		return approveHostedCluster(cluster, hostedCluster, csr, group)
+	}
+	if !strings.HasPrefix(csr.Spec.Username, "system:open-cluster-management:"+cluster.Name) {
 		log.Info("CSR not approved due to illegal requester", "requester", csr.Spec.Username)
 		return false
 	}
+	if csr.Spec.SignerName == operatorconfig.ObservabilitySignerName {
+		request := parseCSR(csr)
+		if request == nil || request.Subject.CommonName != managedClusterUser ||
+			!reflect.DeepEqual(request.Subject.OrganizationalUnit, []string{group}) {
+			log.Info("CSR not approved due to illegal subject", "name", csr.Name)
+			return false
+		}
+	}
+	log.Info("CSR approved")
+	return true
+}
+
+// parseCSR returns the certificate request of the CSR, nil if it is invalid.
+func parseCSR(csr *certificatesv1.CertificateSigningRequest) *x509.CertificateRequest {
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		log.Info("CSR with an invalid request", "name", csr.Name)
+		return nil
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		log.Error(err, "Failed to parse the CSR", "name", csr.Name)
+		return nil
+	}
+	return request
+}
+
+// approveHostedCluster approves the CSR of the metrics-collector of a hosted cluster, it must be
+// requested by the addon agent of the management cluster for the identity of the hosted cluster only,
+// in the group of the tenant of the management cluster.
+func approveHostedCluster(cluster *clusterv1.ManagedCluster, hostedCluster string,
+	csr *certificatesv1.CertificateSigningRequest, group string) bool {
+	agentUser := fmt.Sprintf("system:open-cluster-management:cluster:%s:addon:%s:agent:%s",
+		cluster.Name, addonName, agentName)
+	if csr.Spec.Username != agentUser {
+		log.Info("Hosted cluster CSR not approved due to illegal requester", "requester", csr.Spec.Username)
+		return false
+	}
+	if csr.Spec.SignerName != operatorconfig.ObservabilitySignerName {
+		log.Info("Hosted cluster CSR not approved due to illegal signer", "signer", csr.Spec.SignerName)
+		return false
+	}
+	request := parseCSR(csr)
+	if request == nil {
+		return false
+	}
+	if request.Subject.CommonName != operatorconfig.GetHostedClusterUser(cluster.Name, hostedCluster) ||
+		!reflect.DeepEqual(request.Subject.OrganizationalUnit, []string{group}) {
+		log.Info("Hosted cluster CSR not approved due to illegal subject", "subject", request.Subject.String())
+		return false
+	}
+	log.Info("Hosted cluster CSR approved", "hostedCluster", hostedCluster)
+	return true
 }
@@ -4,12 +4,20 @@
 package certificates
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"testing"
 
 	certificatesv1 "k8s.io/api/certificates/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certutil "k8s.io/client-go/util/cert"
 
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 )
 
 const (
@@ -27,7 +35,7 @@ func TestApprove(t *testing.T) {
 			Username: "system:open-cluster-management:" + clusterName,
 		},
 	}
-	if !approve(cluster, nil, csr) {
+	if !approve(cluster, nil, csr, operatorconfig.ManagedClusterOU) {
 		t.Fatal("csr not approved automatically")
 	}
 	illCsr := &certificatesv1.CertificateSigningRequest{
@@ -35,7 +43,125 @@ func TestApprove(t *testing.T) {
 			Username: "illegal",
 		},
 	}
-	if approve(cluster, nil, illCsr) {
+	if approve(cluster, nil, illCsr, operatorconfig.ManagedClusterOU) {
 		t.Fatal("illegal csr approved automatically")
 	}
 }
+
+func newCSR(t *testing.T, username, commonName, group string) *certificatesv1.CertificateSigningRequest {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the key: (%v)", err)
+	}
+	request, err := certutil.MakeCSR(key, &pkix.Name{
+		CommonName:         commonName,
+		OrganizationalUnit: []string{group},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create the certificate request: (%v)", err)
+	}
+	return &certificatesv1.CertificateSigningRequest{
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Username:   username,
+			SignerName: operatorconfig.ObservabilitySignerName,
+			Request:    request,
+		},
+	}
+}
+
+func TestApproveObservabilitySigner(t *testing.T) {
+	cluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: clusterName,
+		},
+	}
+	username := "system:open-cluster-management:" + clusterName
+	tenantGroup := operatorconfig.ManagedClusterOU + "-tenant-team-a"
+	if !approve(cluster, nil, newCSR(t, username, managedClusterUser, tenantGroup), tenantGroup) {
+		t.Fatal("csr of the tenant group not approved automatically")
+	}
+
+	caseList := []struct {
+		name string
+		csr  *certificatesv1.CertificateSigningRequest
+	}{
+		{
+			name: "group of another tenant",
+			csr:  newCSR(t, username, managedClusterUser, operatorconfig.ManagedClusterOU),
+		},
+		{
+			name: "other common name",
+			csr:  newCSR(t, username, "other", tenantGroup),
+		},
+	}
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			if approve(cluster, nil, c.csr, tenantGroup) {
+				t.Errorf("illegal csr approved automatically")
+			}
+		})
+	}
+}
+
+func newHostedClusterCSR(t *testing.T, username, commonName string) *certificatesv1.CertificateSigningRequest {
+	csr := newCSR(t, username, commonName, operatorconfig.ManagedClusterOU)
+	csr.Labels = map[string]string{operatorconfig.HostedClusterLabelKey: "hosted"}
+	return csr
+}
+
+func TestApproveHostedCluster(t *testing.T) {
+	cluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: clusterName,
+		},
+	}
+	agentUser := "system:open-cluster-management:cluster:" + clusterName +
+		":addon:observability-controller:agent:observability"
+	csr := newHostedClusterCSR(t, agentUser, operatorconfig.GetHostedClusterUser(clusterName, "hosted"))
+	if !approve(cluster, nil, csr, operatorconfig.ManagedClusterOU) {
+		t.Fatal("hosted cluster csr not approved automatically")
+	}
+
+	caseList := []struct {
+		name string
+		csr  *certificatesv1.CertificateSigningRequest
+	}{
+		{
+			name: "other requester of the cluster",
+			csr: newHostedClusterCSR(t, "system:open-cluster-management:"+clusterName,
+				operatorconfig.GetHostedClusterUser(clusterName, "hosted")),
+		},
+		{
+			name: "identity of another hosted cluster",
+			csr:  newHostedClusterCSR(t, agentUser, operatorconfig.GetHostedClusterUser(clusterName, "other")),
+		},
+		{
+			name: "identity of another managed cluster",
+			csr:  newHostedClusterCSR(t, agentUser, operatorconfig.GetHostedClusterUser("other", "hosted")),
+		},
+		{
+			name: "identity of the managed cluster",
+			csr:  newHostedClusterCSR(t, agentUser, managedClusterUser),
+		},
+	}
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			if approve(cluster, nil, c.csr, operatorconfig.ManagedClusterOU) {
+				t.Errorf("illegal hosted cluster csr approved automatically")
+			}
+		})
+	}
+
+	if approve(cluster, nil, csr, operatorconfig.ManagedClusterOU+"-tenant-team-a") {
+		t.Errorf("hosted cluster csr of another tenant group approved automatically")
+	}
+	csr.Spec.SignerName = "other-signer"
+	if approve(cluster, nil, csr, operatorconfig.ManagedClusterOU) {
+		t.Errorf("hosted cluster csr of another signer approved automatically")
+	}
+	csr.Spec.SignerName = operatorconfig.ObservabilitySignerName
+	csr.Spec.Request = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: []byte("invalid")})
+	if approve(cluster, nil, csr, operatorconfig.ManagedClusterOU) {
+		t.Errorf("invalid hosted cluster csr approved automatically")
+	}
+}
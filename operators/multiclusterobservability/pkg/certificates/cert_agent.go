@@ -4,19 +4,33 @@
 package certificates
 
 import (
+	"context"
+
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	certificatesv1 "k8s.io/api/certificates/v1"
 	"open-cluster-management.io/addon-framework/pkg/agent"
 	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 )
 
 const (
 	addonName = "observability-controller"
 	agentName = "observability"
+	// managedClusterUser is the common name of the client certificates of the managed clusters
+	managedClusterUser = "managed-cluster-observability"
 )
 
-type ObservabilityAgent struct{}
+type ObservabilityAgent struct {
+	// client reads the tenants of the MultiClusterObservability CR the managed clusters are mapped to
+	client client.Client
+}
 
 func (o *ObservabilityAgent) Manifests(
 	cluster *clusterv1.ManagedCluster,
@@ -29,8 +43,16 @@ func (o *ObservabilityAgent) GetAgentAddonOptions() agent.AgentAddonOptions {
 	return agent.AgentAddonOptions{
 		AddonName: addonName,
 		Registration: &agent.RegistrationOption{
-			CSRConfigurations: observabilitySignerConfigurations(),
-			CSRApproveCheck:   approve,
+			CSRConfigurations: observabilitySignerConfigurations(o.getClusterGroup),
+			CSRApproveCheck: func(cluster *clusterv1.ManagedCluster, addon *addonapiv1alpha1.ManagedClusterAddOn,
+				csr *certificatesv1.CertificateSigningRequest) bool {
+				group, err := o.getClusterGroup(cluster)
+				if err != nil {
+					log.Error(err, "CSR not approved, failed to get the tenant of the cluster", "cluster", cluster.Name)
+					return false
+				}
+				return approve(cluster, addon, csr, group)
+			},
 			PermissionConfig: func(cluster *clusterv1.ManagedCluster, addon *addonapiv1alpha1.ManagedClusterAddOn) error {
 				return nil
 			},
@@ -39,13 +61,32 @@ func (o *ObservabilityAgent) GetAgentAddonOptions() agent.AgentAddonOptions {
 	}
 }
 
-func observabilitySignerConfigurations() func(cluster *clusterv1.ManagedCluster) []addonapiv1alpha1.RegistrationConfig {
+// getClusterGroup returns the group of the tenant the managed cluster is mapped to, the organization unit of its
+// client certificate.
+func (o *ObservabilityAgent) getClusterGroup(cluster *clusterv1.ManagedCluster) (string, error) {
+	mco := &mcov1beta2.MultiClusterObservability{}
+	if o.client != nil && config.GetMonitoringCRName() != "" {
+		err := o.client.Get(context.TODO(), types.NamespacedName{Name: config.GetMonitoringCRName()}, mco)
+		if err != nil {
+			return "", err
+		}
+	}
+	return config.GetTenantClusterGroup(config.GetClusterTenant(mco.Spec.Tenants, cluster.Labels)), nil
+}
+
+func observabilitySignerConfigurations(getClusterGroup func(cluster *clusterv1.ManagedCluster) (string, error),
+) func(cluster *clusterv1.ManagedCluster) []addonapiv1alpha1.RegistrationConfig {
 	return func(cluster *clusterv1.ManagedCluster) []addonapiv1alpha1.RegistrationConfig {
+		group, err := getClusterGroup(cluster)
+		if err != nil {
+			log.Error(err, "Failed to get the tenant of the cluster", "cluster", cluster.Name)
+			group = operatorconfig.ManagedClusterOU
+		}
 		observabilityConfig := addonapiv1alpha1.RegistrationConfig{
-			SignerName: "open-cluster-management.io/observability-signer",
+			SignerName: operatorconfig.ObservabilitySignerName,
 			Subject: addonapiv1alpha1.Subject{
-				User:              "managed-cluster-observability",
-				OrganizationUnits: []string{"acm"},
+				User:              managedClusterUser,
+				OrganizationUnits: []string{group},
 			},
 		}
 		return append(agent.KubeClientSignerConfigurations(addonName, agentName)(cluster), observabilityConfig)
@@ -4,11 +4,16 @@
 package certificates
 
 import (
+	"reflect"
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 )
 
 func TestCertAgent(t *testing.T) {
@@ -24,4 +29,61 @@ func TestCertAgent(t *testing.T) {
 	if len(configs) != 2 {
 		t.Fatal("Wrong CSRConfigurations")
 	}
+	if !reflect.DeepEqual(configs[1].Subject.OrganizationUnits, []string{operatorconfig.ManagedClusterOU}) {
+		t.Errorf("(%v) is not the expected: (%v)", configs[1].Subject.OrganizationUnits,
+			[]string{operatorconfig.ManagedClusterOU})
+	}
+}
+
+func TestCertAgentTenant(t *testing.T) {
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			Tenants: []mcov1beta2.TenantSpec{
+				{
+					Name: "team-a",
+					ClusterSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"team": "a"},
+					},
+				},
+			},
+		},
+	}
+	agent := &ObservabilityAgent{client: fake.NewFakeClient(mco)}
+	options := agent.GetAgentAddonOptions()
+
+	caseList := []struct {
+		name     string
+		labels   map[string]string
+		expected string
+	}{
+		{
+			name:     "cluster of the tenant",
+			labels:   map[string]string{"team": "a"},
+			expected: operatorconfig.ManagedClusterOU + "-tenant-team-a",
+		},
+		{
+			name:     "cluster of the default tenant",
+			labels:   map[string]string{"team": "b"},
+			expected: operatorconfig.ManagedClusterOU,
+		},
+	}
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   clusterName,
+					Labels: c.labels,
+				},
+			}
+			configs := options.Registration.CSRConfigurations(cluster)
+			if !reflect.DeepEqual(configs[1].Subject.OrganizationUnits, []string{c.expected}) {
+				t.Errorf("(%v) is not the expected: (%v)", configs[1].Subject.OrganizationUnits, []string{c.expected})
+			}
+			csr := newCSR(t, "system:open-cluster-management:"+clusterName, managedClusterUser, c.expected)
+			if !options.Registration.CSRApproveCheck(cluster, nil, csr) {
+				t.Errorf("csr of the tenant group not approved automatically")
+			}
+		})
+	}
 }
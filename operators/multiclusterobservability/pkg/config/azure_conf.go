@@ -4,12 +4,26 @@
 package config
 
 import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
+const azureStorageVersion = "2020-10-02"
+
 func validateAzure(conf Config) error {
 
 	if conf.StorageAccount == "" {
@@ -50,3 +64,132 @@ func IsValidAzureConf(data []byte) (bool, error) {
 
 	return true, nil
 }
+
+// azureBucket calls the Blob service REST API, the requests are signed with the storage account key.
+type azureBucket struct {
+	client   *http.Client
+	endpoint string
+	account  string
+	key      []byte
+	// token is the OAuth token used instead of the key
+	token     string
+	container string
+}
+
+func getAzureEndpoint(conf Config) string {
+	return fmt.Sprintf("https://%s.%s", conf.StorageAccount, conf.Endpoint)
+}
+
+func newAzureBucket(conf Config, httpClient *http.Client, endpoint string) (objBucket, error) {
+	key, err := base64.StdEncoding.DecodeString(conf.StorageAccountKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the storage account key: %w", err)
+	}
+	return &azureBucket{
+		client:    httpClient,
+		endpoint:  endpoint,
+		account:   conf.StorageAccount,
+		key:       key,
+		container: conf.Container,
+	}, nil
+}
+
+// sign sets the Authorization header of the Shared Key authorization.
+func (b *azureBucket) sign(req *http.Request, contentLength int) {
+	length := ""
+	if contentLength > 0 {
+		length = strconv.Itoa(contentLength)
+	}
+	msHeaders := []string{}
+	for name := range req.Header {
+		if name = strings.ToLower(name); strings.HasPrefix(name, "x-ms-") {
+			msHeaders = append(msHeaders, name)
+		}
+	}
+	sort.Strings(msHeaders)
+	canonicalizedHeaders := ""
+	for _, name := range msHeaders {
+		canonicalizedHeaders += name + ":" + req.Header.Get(name) + "\n"
+	}
+	canonicalizedResource := "/" + b.account + req.URL.EscapedPath()
+	query := req.URL.Query()
+	params := []string{}
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		canonicalizedResource += "\n" + strings.ToLower(name) + ":" + strings.Join(query[name], ",")
+	}
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		length,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, x-ms-date is set
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		canonicalizedHeaders + canonicalizedResource,
+	}, "\n")
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write([]byte(stringToSign))
+	req.Header.Set("Authorization",
+		"SharedKey "+b.account+":"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func (b *azureBucket) do(ctx context.Context, method, path, rawQuery string, body []byte,
+	headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, b.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = rawQuery
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureStorageVersion)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	} else {
+		b.sign(req, len(body))
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkHTTPResponse(resp); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (b *azureBucket) blobPath(name string) string {
+	return "/" + url.PathEscape(b.container) + "/" + url.PathEscape(name)
+}
+
+func (b *azureBucket) list(ctx context.Context) error {
+	_, err := b.do(ctx, http.MethodGet, "/"+url.PathEscape(b.container), "comp=list&maxresults=1&restype=container",
+		nil, nil)
+	return err
+}
+
+func (b *azureBucket) put(ctx context.Context, name string, data []byte) error {
+	_, err := b.do(ctx, http.MethodPut, b.blobPath(name), "", data, map[string]string{"x-ms-blob-type": "BlockBlob"})
+	return err
+}
+
+func (b *azureBucket) get(ctx context.Context, name string) ([]byte, error) {
+	return b.do(ctx, http.MethodGet, b.blobPath(name), "", nil, nil)
+}
+
+func (b *azureBucket) delete(ctx context.Context, name string) error {
+	_, err := b.do(ctx, http.MethodDelete, b.blobPath(name), "", nil, nil)
+	return err
+}
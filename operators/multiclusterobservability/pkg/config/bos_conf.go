@@ -0,0 +1,52 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package config
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+func validateBOS(conf Config) error {
+
+	if conf.Bucket == "" {
+		return errors.New("no bucket as bos bucket name in config file")
+	}
+
+	if conf.Endpoint == "" {
+		return errors.New("no endpoint as bos endpoint in config file")
+	}
+
+	if conf.AccessKey == "" {
+		return errors.New("no access_key as bos access key in config file")
+	}
+
+	if conf.SecretKey == "" {
+		return errors.New("no secret_key as bos secret key in config file")
+	}
+
+	return nil
+}
+
+// IsValidBOSConf is used to validate bos configuration
+func IsValidBOSConf(data []byte) (bool, error) {
+	var objectConfg ObjectStorgeConf
+	err := yaml.Unmarshal(data, &objectConfg)
+	if err != nil {
+		return false, err
+	}
+
+	if strings.ToLower(objectConfg.Type) != "bos" {
+		return false, errors.New("invalid type config, only BOS type is supported")
+	}
+
+	err = validateBOS(objectConfg.Config)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
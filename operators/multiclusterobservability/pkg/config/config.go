@@ -27,6 +27,7 @@ import (
 
 	mcoshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
 	observabilityv1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 )
 
 const (
@@ -69,7 +70,7 @@ const (
 	ServerCertCN     = "observability-server-certificate"
 	GrafanaCerts     = "observability-grafana-certs"
 	GrafanaCN        = "grafana"
-	ManagedClusterOU = "acm"
+	ManagedClusterOU = operatorconfig.ManagedClusterOU
 
 	GrafanaRouteName       = "grafana"
 	GrafanaServiceName     = "grafana"
@@ -370,6 +371,117 @@ func GetReplicas(component string, advanced *observabilityv1beta2.AdvancedConfig
 	return replicas
 }
 
+// AutoscaledComponents are the components which can be scaled by a HorizontalPodAutoscaler.
+var AutoscaledComponents = []string{ObservatoriumAPI, ThanosQuery, ThanosQueryFrontend, RBACQueryProxy, Grafana}
+
+// GetCommonSpec returns the common spec of the component, nil if it is not set.
+func GetCommonSpec(component string,
+	advanced *observabilityv1beta2.AdvancedConfig) *observabilityv1beta2.CommonSpec {
+	if advanced == nil {
+		return nil
+	}
+	switch component {
+	case ObservatoriumAPI:
+		return advanced.ObservatoriumAPI
+	case RBACQueryProxy:
+		return advanced.RBACQueryProxy
+	case Grafana:
+		return advanced.Grafana
+	case Alertmanager:
+		return advanced.Alertmanager
+	case ThanosQuery:
+		if advanced.Query != nil {
+			return &advanced.Query.CommonSpec
+		}
+	case ThanosQueryFrontend:
+		if advanced.QueryFrontend != nil {
+			return advanced.QueryFrontend
+		}
+	case ThanosQueryFrontendMemcached:
+		if advanced.QueryFrontendMemcached != nil {
+			return &advanced.QueryFrontendMemcached.CommonSpec
+		}
+	case ThanosReceive:
+		if advanced.Receive != nil {
+			return &advanced.Receive.CommonSpec
+		}
+	case ThanosRule:
+		if advanced.Rule != nil {
+			return &advanced.Rule.CommonSpec
+		}
+	case ThanosStoreShard:
+		if advanced.Store != nil {
+			return &advanced.Store.CommonSpec
+		}
+	case ThanosStoreMemcached:
+		if advanced.StoreMemcached != nil {
+			return &advanced.StoreMemcached.CommonSpec
+		}
+	}
+	return nil
+}
+
+// SchedulingSpec is the scheduling of the pods of a component.
+type SchedulingSpec struct {
+	Affinity                  *corev1.Affinity                  `json:"affinity,omitempty"`
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	PriorityClassName         string                            `json:"priorityClassName,omitempty"`
+}
+
+// ScheduledComponents are the components the pods of which are scheduled per component.
+var ScheduledComponents = []string{
+	RBACQueryProxy, Grafana, Alertmanager,
+	ThanosQuery, ThanosQueryFrontend, ThanosReceive, ThanosRule, ThanosStoreShard, ThanosCompact,
+}
+
+// GetSchedulingSpec returns the scheduling of the pods of the component, nil if it is not set or the pods of the
+// component are not scheduled per component.
+func GetSchedulingSpec(component string, advanced *observabilityv1beta2.AdvancedConfig) *SchedulingSpec {
+	scheduled := false
+	for _, c := range ScheduledComponents {
+		if component == c {
+			scheduled = true
+		}
+	}
+	if advanced == nil || !scheduled {
+		return nil
+	}
+	var spec *SchedulingSpec
+	if component == ThanosCompact {
+		if advanced.Compact != nil {
+			spec = &SchedulingSpec{
+				Affinity:                  advanced.Compact.Affinity,
+				TopologySpreadConstraints: advanced.Compact.TopologySpreadConstraints,
+				PriorityClassName:         advanced.Compact.PriorityClassName,
+			}
+		}
+	} else if common := GetCommonSpec(component, advanced); common != nil {
+		spec = &SchedulingSpec{
+			Affinity:                  common.Affinity,
+			TopologySpreadConstraints: common.TopologySpreadConstraints,
+			PriorityClassName:         common.PriorityClassName,
+		}
+	}
+	if spec == nil || (spec.Affinity == nil && len(spec.TopologySpreadConstraints) == 0 &&
+		spec.PriorityClassName == "") {
+		return nil
+	}
+	return spec
+}
+
+// GetAutoscaling returns the autoscaling spec of the component, nil if the component is not autoscaled.
+func GetAutoscaling(component string,
+	advanced *observabilityv1beta2.AdvancedConfig) *observabilityv1beta2.AutoscalingSpec {
+	for _, autoscaled := range AutoscaledComponents {
+		if component == autoscaled {
+			if spec := GetCommonSpec(component, advanced); spec != nil {
+				return spec.Autoscaling
+			}
+		}
+	}
+	return nil
+}
+
 // GetCrLabelKey returns the key for the CR label injected into the resources created by the operator
 func GetCrLabelKey() string {
 	return crLabelKey
@@ -1161,6 +1273,56 @@ func GetValidatingWebhookConfigurationForMCO() *admissionregistrationv1.Validati
 	}
 }
 
+// GetMutatingWebhookConfigurationForMCO return the MutatingWebhookConfiguration for the MCO defaulting webhook
+func GetMutatingWebhookConfigurationForMCO() *admissionregistrationv1.MutatingWebhookConfiguration {
+	mutatingWebhookPath := "/mutate-observability-open-cluster-management-io-v1beta2-multiclusterobservability"
+	noSideEffects := admissionregistrationv1.SideEffectClassNone
+	allScopeType := admissionregistrationv1.AllScopes
+	webhookServiceNamespace := GetMCONamespace()
+	webhookServicePort := int32(443)
+	return &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: v1.ObjectMeta{
+			Name: MutatingWebhookConfigurationName,
+			Labels: map[string]string{
+				"name": MutatingWebhookConfigurationName,
+			},
+			Annotations: map[string]string{
+				"service.beta.openshift.io/inject-cabundle": "true",
+			},
+		},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{
+			{
+				AdmissionReviewVersions: []string{"v1", "v1beta1"},
+				Name:                    "mmulticlusterobservability.observability.open-cluster-management.io",
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service: &admissionregistrationv1.ServiceReference{
+						Name:      WebhookServiceName,
+						Namespace: webhookServiceNamespace,
+						Path:      &mutatingWebhookPath,
+						Port:      &webhookServicePort,
+					},
+					CABundle: []byte(""),
+				},
+				SideEffects: &noSideEffects,
+				Rules: []admissionregistrationv1.RuleWithOperations{
+					{
+						Operations: []admissionregistrationv1.OperationType{
+							admissionregistrationv1.Create,
+							admissionregistrationv1.Update,
+						},
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{"observability.open-cluster-management.io"},
+							APIVersions: []string{"v1beta2"},
+							Resources:   []string{"multiclusterobservabilities"},
+							Scope:       &allScopeType,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 // GetMulticloudConsoleHost is used to get the URL for multicloud-console route
 func GetMulticloudConsoleHost(client client.Client, isStandalone bool) (string, error) {
 	if multicloudConsoleRouteHost != "" {
@@ -0,0 +1,61 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package config
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+func validateCOS(conf Config) error {
+
+	if conf.SecretID == "" {
+		return errors.New("no secret_id as cos secret id in config file")
+	}
+
+	if conf.SecretKey == "" {
+		return errors.New("no secret_key as cos secret key in config file")
+	}
+
+	// the bucket url is either the endpoint or built from the bucket, the app id and the region
+	if conf.Endpoint != "" {
+		return nil
+	}
+
+	if conf.Bucket == "" {
+		return errors.New("no bucket as cos bucket name in config file")
+	}
+
+	if conf.AppID == "" {
+		return errors.New("no app_id as cos app id in config file")
+	}
+
+	if conf.Region == "" {
+		return errors.New("no region as cos region in config file")
+	}
+
+	return nil
+}
+
+// IsValidCOSConf is used to validate cos configuration
+func IsValidCOSConf(data []byte) (bool, error) {
+	var objectConfg ObjectStorgeConf
+	err := yaml.Unmarshal(data, &objectConfg)
+	if err != nil {
+		return false, err
+	}
+
+	if strings.ToLower(objectConfg.Type) != "cos" {
+		return false, errors.New("invalid type config, only COS type is supported")
+	}
+
+	err = validateCOS(objectConfg.Config)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package config
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	observabilityshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	observabilityv1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+)
+
+const (
+	// AnnotationDefaultingPolicy records the version of the defaulting policy applied to the
+	// MultiClusterObservability CR, the defaults of a CR do not change when the operator is upgraded.
+	AnnotationDefaultingPolicy = "observability.open-cluster-management.io/defaulting-policy"
+	DefaultingPolicyV1         = "v1"
+	// DefaultingPolicy is the defaulting policy applied to the new MultiClusterObservability CRs
+	DefaultingPolicy = DefaultingPolicyV1
+
+	MutatingWebhookConfigurationName = "multicluster-observability-operator"
+
+	DefaultAddonInterval           = int32(300)
+	DefaultAlertmanagerStorageSize = "1Gi"
+	DefaultRuleStorageSize         = "1Gi"
+	DefaultCompactStorageSize      = "100Gi"
+	DefaultReceiveStorageSize      = "100Gi"
+	DefaultStoreStorageSize        = "10Gi"
+	DefaultFilesystemStorageSize   = "100Gi"
+)
+
+// defaultingPolicies are the defaulting functions of each defaulting policy version.
+var defaultingPolicies = map[string]func(mco *observabilityv1beta2.MultiClusterObservability){
+	DefaultingPolicyV1: setDefaultsV1,
+}
+
+// MultiClusterObservabilityDefaulter materializes the defaults into the spec of the
+// MultiClusterObservability CR, so that the stored spec is the effective configuration.
+type MultiClusterObservabilityDefaulter struct{}
+
+var _ admission.CustomDefaulter = &MultiClusterObservabilityDefaulter{}
+
+// Default implements admission.CustomDefaulter so a mutating webhook will be registered for the type
+func (d *MultiClusterObservabilityDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	mco, ok := obj.(*observabilityv1beta2.MultiClusterObservability)
+	if !ok {
+		return fmt.Errorf("expected a MultiClusterObservability but got a %T", obj)
+	}
+	log.Info("default", "name", mco.Name)
+	return SetMultiClusterObservabilityDefaults(mco)
+}
+
+// SetMultiClusterObservabilityDefaults sets the unset fields of the MultiClusterObservability CR with the
+// defaulting policy recorded in its annotations, the current policy is applied to the CRs without one.
+func SetMultiClusterObservabilityDefaults(mco *observabilityv1beta2.MultiClusterObservability) error {
+	if mco.DeletionTimestamp != nil {
+		return nil
+	}
+	policy := mco.Annotations[AnnotationDefaultingPolicy]
+	if policy == "" {
+		policy = DefaultingPolicy
+	}
+	setDefaults, ok := defaultingPolicies[policy]
+	if !ok {
+		return fmt.Errorf("unsupported defaulting policy %s in annotation %s", policy, AnnotationDefaultingPolicy)
+	}
+	setDefaults(mco)
+	if mco.Annotations == nil {
+		mco.Annotations = map[string]string{}
+	}
+	mco.Annotations[AnnotationDefaultingPolicy] = policy
+	return nil
+}
+
+// setDefaultsV1 sets the replicas, the resources, the retention, the storage sizes and the addon interval.
+func setDefaultsV1(mco *observabilityv1beta2.MultiClusterObservability) {
+	if mco.Spec.AdvancedConfig == nil {
+		mco.Spec.AdvancedConfig = &observabilityv1beta2.AdvancedConfig{}
+	}
+	advanced := mco.Spec.AdvancedConfig
+
+	if advanced.ObservatoriumAPI == nil {
+		advanced.ObservatoriumAPI = &observabilityv1beta2.CommonSpec{}
+	}
+	setCommonSpecDefaults(advanced.ObservatoriumAPI, ObservatoriumAPI)
+	if advanced.Query == nil {
+		advanced.Query = &observabilityv1beta2.QuerySpec{}
+	}
+	setCommonSpecDefaults(&advanced.Query.CommonSpec, ThanosQuery)
+	if advanced.QueryFrontend == nil {
+		advanced.QueryFrontend = &observabilityv1beta2.CommonSpec{}
+	}
+	setCommonSpecDefaults(advanced.QueryFrontend, ThanosQueryFrontend)
+	if advanced.QueryFrontendMemcached == nil {
+		advanced.QueryFrontendMemcached = &observabilityv1beta2.CacheConfig{}
+	}
+	setCommonSpecDefaults(&advanced.QueryFrontendMemcached.CommonSpec, ThanosQueryFrontendMemcached)
+	if advanced.Rule == nil {
+		advanced.Rule = &observabilityv1beta2.RuleSpec{}
+	}
+	setCommonSpecDefaults(&advanced.Rule.CommonSpec, ThanosRule)
+	if advanced.Receive == nil {
+		advanced.Receive = &observabilityv1beta2.ReceiveSpec{}
+	}
+	setCommonSpecDefaults(&advanced.Receive.CommonSpec, ThanosReceive)
+	if advanced.StoreMemcached == nil {
+		advanced.StoreMemcached = &observabilityv1beta2.CacheConfig{}
+	}
+	setCommonSpecDefaults(&advanced.StoreMemcached.CommonSpec, ThanosStoreMemcached)
+	if advanced.Store == nil {
+		advanced.Store = &observabilityv1beta2.StoreSpec{}
+	}
+	setCommonSpecDefaults(&advanced.Store.CommonSpec, ThanosStoreShard)
+	if advanced.RBACQueryProxy == nil {
+		advanced.RBACQueryProxy = &observabilityv1beta2.CommonSpec{}
+	}
+	setCommonSpecDefaults(advanced.RBACQueryProxy, RBACQueryProxy)
+	if advanced.Grafana == nil {
+		advanced.Grafana = &observabilityv1beta2.CommonSpec{}
+	}
+	setCommonSpecDefaults(advanced.Grafana, Grafana)
+	if advanced.Alertmanager == nil {
+		advanced.Alertmanager = &observabilityv1beta2.CommonSpec{}
+	}
+	setCommonSpecDefaults(advanced.Alertmanager, Alertmanager)
+	if advanced.Compact == nil {
+		advanced.Compact = &observabilityv1beta2.CompactSpec{}
+	}
+	advanced.Compact.Resources = getResourcesWithDefaults(advanced.Compact.Resources, ThanosCompact)
+
+	if advanced.RetentionConfig == nil {
+		advanced.RetentionConfig = &observabilityv1beta2.RetentionConfig{}
+	}
+	retention := advanced.RetentionConfig
+	setStringDefault(&retention.RetentionResolutionRaw, RetentionResolutionRaw)
+	setStringDefault(&retention.RetentionResolution5m, RetentionResolution5m)
+	setStringDefault(&retention.RetentionResolution1h, RetentionResolution1h)
+	setStringDefault(&retention.RetentionInLocal, RetentionInLocal)
+	setStringDefault(&retention.DeleteDelay, DeleteDelay)
+	setStringDefault(&retention.BlockDuration, BlockDuration)
+
+	if storageConfig := mco.Spec.StorageConfig; storageConfig != nil {
+		setStringDefault(&storageConfig.AlertmanagerStorageSize, DefaultAlertmanagerStorageSize)
+		setStringDefault(&storageConfig.RuleStorageSize, DefaultRuleStorageSize)
+		setStringDefault(&storageConfig.CompactStorageSize, DefaultCompactStorageSize)
+		setStringDefault(&storageConfig.ReceiveStorageSize, DefaultReceiveStorageSize)
+		setStringDefault(&storageConfig.StoreStorageSize, DefaultStoreStorageSize)
+		setStringDefault(&storageConfig.FilesystemStorageSize, DefaultFilesystemStorageSize)
+	}
+
+	if mco.Spec.ObservabilityAddonSpec == nil {
+		mco.Spec.ObservabilityAddonSpec = &observabilityshared.ObservabilityAddonSpec{EnableMetrics: true}
+	}
+	if mco.Spec.ObservabilityAddonSpec.Interval == 0 {
+		mco.Spec.ObservabilityAddonSpec.Interval = DefaultAddonInterval
+	}
+}
+
+func setStringDefault(value *string, defaultValue string) {
+	if *value == "" {
+		*value = defaultValue
+	}
+}
+
+// setCommonSpecDefaults sets the default replicas and resources of the component.
+func setCommonSpecDefaults(spec *observabilityv1beta2.CommonSpec, component string) {
+	if spec.Replicas == nil && Replicas[component] != nil {
+		replicas := *Replicas[component]
+		spec.Replicas = &replicas
+	}
+	spec.Resources = getResourcesWithDefaults(spec.Resources, component)
+}
+
+// getResourcesWithDefaults adds the default cpu and memory of the component to the resources which do not
+// set them, the other resources are kept.
+func getResourcesWithDefaults(resources *corev1.ResourceRequirements,
+	component string) *corev1.ResourceRequirements {
+	defaults := GetResources(component, nil)
+	if len(defaults.Requests) == 0 && len(defaults.Limits) == 0 {
+		return resources
+	}
+	if resources == nil {
+		resources = &corev1.ResourceRequirements{}
+	}
+	resources.Requests = mergeResourceList(resources.Requests, defaults.Requests)
+	resources.Limits = mergeResourceList(resources.Limits, defaults.Limits)
+	return resources
+}
+
+func mergeResourceList(list, defaults corev1.ResourceList) corev1.ResourceList {
+	if len(defaults) == 0 {
+		return list
+	}
+	if list == nil {
+		list = corev1.ResourceList{}
+	}
+	for name, quantity := range defaults {
+		if _, ok := list[name]; !ok {
+			list[name] = quantity
+		}
+	}
+	return list
+}
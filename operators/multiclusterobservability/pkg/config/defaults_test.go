@@ -0,0 +1,99 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package config
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcoshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+)
+
+func TestSetMultiClusterObservabilityDefaults(t *testing.T) {
+	replicas := int32(5)
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability"},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			AdvancedConfig: &mcov1beta2.AdvancedConfig{
+				Query: &mcov1beta2.QuerySpec{
+					CommonSpec: mcov1beta2.CommonSpec{
+						Replicas: &replicas,
+						Resources: &corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("1"),
+							},
+						},
+					},
+				},
+				RetentionConfig: &mcov1beta2.RetentionConfig{
+					RetentionResolutionRaw: "5d",
+				},
+			},
+			StorageConfig: &mcov1beta2.StorageConfig{
+				ReceiveStorageSize: "200Gi",
+			},
+			ObservabilityAddonSpec: &mcoshared.ObservabilityAddonSpec{},
+		},
+	}
+	err := (&MultiClusterObservabilityDefaulter{}).Default(context.TODO(), mco)
+	if err != nil {
+		t.Fatalf("Failed to set the defaults: (%v)", err)
+	}
+
+	if mco.Annotations[AnnotationDefaultingPolicy] != DefaultingPolicy {
+		t.Errorf("defaulting policy (%s) is not the expected: (%s)", mco.Annotations[AnnotationDefaultingPolicy],
+			DefaultingPolicy)
+	}
+	advanced := mco.Spec.AdvancedConfig
+	if *advanced.Query.Replicas != replicas {
+		t.Errorf("the replicas of the query should not be changed: (%v)", *advanced.Query.Replicas)
+	}
+	expected := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1"),
+		corev1.ResourceMemory: resource.MustParse(ThanosQueryMemoryRequets),
+	}
+	if !reflect.DeepEqual(advanced.Query.Resources.Requests, expected) {
+		t.Errorf("the requests of the query (%v) are not the expected: (%v)", advanced.Query.Resources.Requests,
+			expected)
+	}
+	if *advanced.Receive.Replicas != *Replicas[ThanosReceive] {
+		t.Errorf("the replicas of the receive (%v) are not the default", *advanced.Receive.Replicas)
+	}
+	if !reflect.DeepEqual(*advanced.Grafana.Resources, GetResources(Grafana, nil)) {
+		t.Errorf("the resources of grafana (%v) are not the default", *advanced.Grafana.Resources)
+	}
+	if advanced.RetentionConfig.RetentionResolutionRaw != "5d" ||
+		advanced.RetentionConfig.RetentionResolution5m != RetentionResolution5m {
+		t.Errorf("retention config (%v) is not the expected", advanced.RetentionConfig)
+	}
+	if mco.Spec.StorageConfig.ReceiveStorageSize != "200Gi" ||
+		mco.Spec.StorageConfig.StoreStorageSize != DefaultStoreStorageSize {
+		t.Errorf("storage config (%v) is not the expected", mco.Spec.StorageConfig)
+	}
+	if mco.Spec.ObservabilityAddonSpec.Interval != DefaultAddonInterval {
+		t.Errorf("interval (%d) is not the expected: (%d)", mco.Spec.ObservabilityAddonSpec.Interval,
+			DefaultAddonInterval)
+	}
+
+	// the defaults are stable
+	defaulted := mco.DeepCopy()
+	err = SetMultiClusterObservabilityDefaults(mco)
+	if err != nil {
+		t.Fatalf("Failed to set the defaults: (%v)", err)
+	}
+	if !reflect.DeepEqual(mco, defaulted) {
+		t.Errorf("the defaulted CR should not be changed by the defaulting")
+	}
+
+	mco.Annotations[AnnotationDefaultingPolicy] = "v0"
+	if err = SetMultiClusterObservabilityDefaults(mco); err == nil {
+		t.Errorf("unsupported defaulting policy should be rejected")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// validateFilesystem validates the filesystem configuration, it is meant for the single node hubs
+// where the directory is on a persistent volume of the thanos components.
+func validateFilesystem(conf Config) error {
+
+	if conf.Directory == "" {
+		return errors.New("no directory as filesystem directory in config file")
+	}
+
+	if !filepath.IsAbs(conf.Directory) {
+		return errors.New("the filesystem directory in config file is not an absolute path")
+	}
+
+	return nil
+}
+
+// IsValidFilesystemConf is used to validate filesystem configuration
+func IsValidFilesystemConf(data []byte) (bool, error) {
+	var objectConfg ObjectStorgeConf
+	err := yaml.Unmarshal(data, &objectConfg)
+	if err != nil {
+		return false, err
+	}
+
+	if strings.ToLower(objectConfg.Type) != "filesystem" {
+		return false, errors.New("invalid type config, only filesystem type is supported")
+	}
+
+	err = validateFilesystem(objectConfg.Config)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetFilesystemDirectory returns the directory of the filesystem object storage configuration, empty if the
+// object storage is not of the filesystem type.
+func GetFilesystemDirectory(data []byte) string {
+	var objectConfg ObjectStorgeConf
+	if err := yaml.Unmarshal(data, &objectConfg); err != nil {
+		return ""
+	}
+	if strings.ToLower(objectConfg.Type) != "filesystem" {
+		return ""
+	}
+	return objectConfg.Config.Directory
+}
@@ -4,12 +4,25 @@
 package config
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"strings"
 
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
 	"gopkg.in/yaml.v2"
 )
 
+const (
+	gcsEndpoint = "https://storage.googleapis.com"
+	gcsScope    = "https://www.googleapis.com/auth/devstorage.read_write"
+)
+
 func validateGCS(conf Config) error {
 
 	if conf.Bucket == "" {
@@ -42,3 +55,72 @@ func IsValidGCSConf(data []byte) (bool, error) {
 
 	return true, nil
 }
+
+// gcsBucket calls the JSON API of Google Cloud Storage.
+type gcsBucket struct {
+	client   *http.Client
+	endpoint string
+	bucket   string
+}
+
+// newGCSBucket creates the gcs client of the probe, authenticated with the service account of the
+// configuration, the application default credentials are used if it is not set.
+func newGCSBucket(ctx context.Context, conf Config, httpClient *http.Client, endpoint string) (objBucket, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	var creds *google.Credentials
+	var err error
+	if conf.ServiceAccount != "" {
+		creds, err = google.CredentialsFromJSON(ctx, []byte(conf.ServiceAccount), gcsScope)
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, gcsScope)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &gcsBucket{
+		client:   oauth2.NewClient(ctx, creds.TokenSource),
+		endpoint: endpoint,
+		bucket:   conf.Bucket,
+	}, nil
+}
+
+func (b *gcsBucket) do(ctx context.Context, method, url string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkHTTPResponse(resp); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (b *gcsBucket) objectURL(name string) string {
+	return fmt.Sprintf("%s/storage/v1/b/%s/o/%s", b.endpoint, url.PathEscape(b.bucket), url.PathEscape(name))
+}
+
+func (b *gcsBucket) list(ctx context.Context) error {
+	_, err := b.do(ctx, http.MethodGet,
+		fmt.Sprintf("%s/storage/v1/b/%s/o?maxResults=1", b.endpoint, url.PathEscape(b.bucket)), nil)
+	return err
+}
+
+func (b *gcsBucket) put(ctx context.Context, name string, data []byte) error {
+	_, err := b.do(ctx, http.MethodPost, fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		b.endpoint, url.PathEscape(b.bucket), url.QueryEscape(name)), data)
+	return err
+}
+
+func (b *gcsBucket) get(ctx context.Context, name string) ([]byte, error) {
+	return b.do(ctx, http.MethodGet, b.objectURL(name)+"?alt=media", nil)
+}
+
+func (b *gcsBucket) delete(ctx context.Context, name string) error {
+	_, err := b.do(ctx, http.MethodDelete, b.objectURL(name), nil)
+	return err
+}
@@ -12,11 +12,12 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
-// Config is for s3/azure/gcs compatiable configuration
+// Config is for s3/azure/gcs/swift/cos/oci/oss/bos/filesystem compatiable configuration
 type Config struct {
 	// s3 configuration
 	Bucket     string     `yaml:"bucket"`
 	Endpoint   string     `yaml:"endpoint"`
+	Region     string     `yaml:"region"`
 	Insecure   bool       `yaml:"insecure"`
 	AccessKey  string     `yaml:"access_key"`
 	SecretKey  string     `yaml:"secret_key"`
@@ -31,6 +32,55 @@ type Config struct {
 	// gcs configuration
 	// Endpoint  string `yaml:"endpoint"`
 	ServiceAccount string `yaml:"service_account"`
+
+	// swift configuration
+	AuthURL                     string `yaml:"auth_url"`
+	Username                    string `yaml:"username"`
+	UserID                      string `yaml:"user_id"`
+	Password                    string `yaml:"password"`
+	ApplicationCredentialID     string `yaml:"application_credential_id"`
+	ApplicationCredentialSecret string `yaml:"application_credential_secret"`
+	ProjectName                 string `yaml:"project_name"`
+	ProjectID                   string `yaml:"project_id"`
+	DomainName                  string `yaml:"domain_name"`
+	UserDomainName              string `yaml:"user_domain_name"`
+	ProjectDomainName           string `yaml:"project_domain_name"`
+	RegionName                  string `yaml:"region_name"`
+	ContainerName               string `yaml:"container_name"`
+
+	// cos configuration
+	// Bucket    string `yaml:"bucket"`
+	// Region    string `yaml:"region"`
+	// Endpoint  string `yaml:"endpoint"`
+	// SecretKey string `yaml:"secret_key"`
+	AppID    string `yaml:"app_id"`
+	SecretID string `yaml:"secret_id"`
+
+	// oci configuration
+	// Bucket string `yaml:"bucket"`
+	// Region string `yaml:"region"`
+	Provider        string `yaml:"provider"`
+	CompartmentOCID string `yaml:"compartment_ocid"`
+	TenancyOCID     string `yaml:"tenancy_ocid"`
+	UserOCID        string `yaml:"user_ocid"`
+	Fingerprint     string `yaml:"fingerprint"`
+	PrivateKey      string `yaml:"privatekey"`
+	Passphrase      string `yaml:"passphrase"`
+
+	// oss configuration
+	// Bucket   string `yaml:"bucket"`
+	// Endpoint string `yaml:"endpoint"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	AccessKeySecret string `yaml:"access_key_secret"`
+
+	// bos configuration
+	// Bucket    string `yaml:"bucket"`
+	// Endpoint  string `yaml:"endpoint"`
+	// AccessKey string `yaml:"access_key"`
+	// SecretKey string `yaml:"secret_key"`
+
+	// filesystem configuration
+	Directory string `yaml:"directory"`
 }
 
 // HTTPConfig stores the http.Transport configuration for the s3 minio client.
@@ -83,6 +133,24 @@ func CheckObjStorageConf(data []byte) (bool, error) {
 	case "azure":
 		return IsValidAzureConf(data)
 
+	case "swift":
+		return IsValidSwiftConf(data)
+
+	case "cos":
+		return IsValidCOSConf(data)
+
+	case "oci":
+		return IsValidOCIConf(data)
+
+	case "oss":
+		return IsValidOSSConf(data)
+
+	case "bos":
+		return IsValidBOSConf(data)
+
+	case "filesystem":
+		return IsValidFilesystemConf(data)
+
 	default:
 		return false, errors.New("invalid object storage type config")
 	}
@@ -136,6 +136,153 @@ config:
 			expected: false,
 		},
 
+		{
+			conf: []byte(`type: swift
+config:
+  auth_url: https://keystone:5000/v3
+  username: username
+  password: password
+  project_name: project
+  container_name: container`),
+			name:     "valid swift conf",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: swift
+config:
+  auth_url: https://keystone:5000/v3
+  application_credential_id: id
+  application_credential_secret: secret
+  container_name: container`),
+			name:     "valid swift conf with application credential",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: swift
+config:
+  auth_url: https://keystone:5000/v3
+  username: username
+  container_name: container`),
+			name:     "no swift password",
+			expected: false,
+		},
+
+		{
+			conf: []byte(`type: cos
+config:
+  bucket: bucket
+  region: ap-beijing
+  app_id: app_id
+  secret_id: secret_id
+  secret_key: secret_key`),
+			name:     "valid cos conf",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: cos
+config:
+  bucket: bucket
+  secret_id: secret_id
+  secret_key: secret_key`),
+			name:     "no cos endpoint or region",
+			expected: false,
+		},
+
+		{
+			conf: []byte(`type: oci
+config:
+  bucket: bucket
+  compartment_ocid: compartment
+  tenancy_ocid: tenancy
+  user_ocid: user
+  region: us-ashburn-1
+  fingerprint: fingerprint
+  privatekey: privatekey`),
+			name:     "valid oci conf",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: oci
+config:
+  provider: instance-principal
+  bucket: bucket
+  compartment_ocid: compartment`),
+			name:     "valid oci instance principal conf",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: oci
+config:
+  bucket: bucket
+  compartment_ocid: compartment
+  tenancy_ocid: tenancy`),
+			name:     "no oci user",
+			expected: false,
+		},
+
+		{
+			conf: []byte(`type: oss
+config:
+  bucket: bucket
+  endpoint: oss-cn-hangzhou.aliyuncs.com
+  access_key_id: access_key_id
+  access_key_secret: access_key_secret`),
+			name:     "valid oss conf",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: oss
+config:
+  bucket: bucket
+  endpoint: oss-cn-hangzhou.aliyuncs.com
+  access_key_id: access_key_id`),
+			name:     "no oss access_key_secret",
+			expected: false,
+		},
+
+		{
+			conf: []byte(`type: bos
+config:
+  bucket: bucket
+  endpoint: bj.bcebos.com
+  access_key: access_key
+  secret_key: secret_key`),
+			name:     "valid bos conf",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: bos
+config:
+  bucket: bucket
+  access_key: access_key
+  secret_key: secret_key`),
+			name:     "no bos endpoint",
+			expected: false,
+		},
+
+		{
+			conf: []byte(`type: filesystem
+config:
+  directory: /var/thanos/bucket`),
+			name:     "valid filesystem conf",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: filesystem
+config:
+  directory: thanos`),
+			name:     "relative filesystem directory",
+			expected: false,
+		},
+
 		{
 			conf: []byte(`type: test
 config:
@@ -176,3 +323,36 @@ config:
 		})
 	}
 }
+
+func TestGetFilesystemDirectory(t *testing.T) {
+	caseList := []struct {
+		conf     string
+		name     string
+		expected string
+	}{
+		{
+			conf:     "type: filesystem\nconfig:\n  directory: /var/thanos/bucket\n",
+			name:     "filesystem conf",
+			expected: "/var/thanos/bucket",
+		},
+		{
+			conf:     "type: s3\nconfig:\n  bucket: bucket\n  endpoint: endpoint\n",
+			name:     "s3 conf",
+			expected: "",
+		},
+		{
+			conf:     "",
+			name:     "no conf",
+			expected: "",
+		},
+	}
+
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			output := GetFilesystemDirectory([]byte(c.conf))
+			if output != c.expected {
+				t.Errorf("case (%v) output (%v) is not the expected (%v)", c.name, output, c.expected)
+			}
+		})
+	}
+}
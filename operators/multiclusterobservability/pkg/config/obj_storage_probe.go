@@ -0,0 +1,171 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ObjStorageProbeTimeout bounds a probe of the object storage
+	ObjStorageProbeTimeout = 30 * time.Second
+	objStorageCanaryPrefix = "observability-probe-"
+)
+
+// ErrObjStorageProbeNotSupported is returned by the probe of the object storage types it cannot probe.
+var ErrObjStorageProbeNotSupported = errors.New("the probe of the object storage type is not supported")
+
+// objBucket is the subset of the object storage operations the probe performs.
+type objBucket interface {
+	list(ctx context.Context) error
+	put(ctx context.Context, name string, data []byte) error
+	get(ctx context.Context, name string) ([]byte, error)
+	delete(ctx context.Context, name string) error
+}
+
+// ProbeObjStorage checks that the object storage can be used with the configuration: the bucket is listed,
+// then a canary object is uploaded, read back and deleted. tlsFiles holds the content of the files of the
+// TLS config, keyed by file name, as read from the TLS secret of the object storage.
+func ProbeObjStorage(ctx context.Context, data []byte, tlsFiles map[string][]byte) error {
+	var objectConfg ObjectStorgeConf
+	err := yaml.Unmarshal(data, &objectConfg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, ObjStorageProbeTimeout)
+	defer cancel()
+
+	httpClient, err := newObjStorageHTTPClient(objectConfg.Config.HTTPConfig, tlsFiles)
+	if err != nil {
+		return err
+	}
+	var bucket objBucket
+	switch strings.ToLower(objectConfg.Type) {
+	case "s3":
+		bucket, err = newS3Bucket(objectConfg.Config, httpClient, nil)
+	case "gcs":
+		bucket, err = newGCSBucket(ctx, objectConfg.Config, httpClient, gcsEndpoint)
+	case "azure":
+		bucket, err = newAzureBucket(objectConfg.Config, httpClient, getAzureEndpoint(objectConfg.Config))
+	case "swift", "cos", "oci", "oss", "bos", "filesystem":
+		// the filesystem is only mounted in the thanos components
+		return ErrObjStorageProbeNotSupported
+	default:
+		return errors.New("invalid object storage type config")
+	}
+	if err != nil {
+		return err
+	}
+	return probeBucket(ctx, bucket)
+}
+
+func probeBucket(ctx context.Context, bucket objBucket) error {
+	if err := bucket.list(ctx); err != nil {
+		return fmt.Errorf("failed to list the bucket: %w", err)
+	}
+	name := fmt.Sprintf("%s%d", objStorageCanaryPrefix, time.Now().UnixNano())
+	canary := []byte("multicluster-observability object storage probe")
+	if err := bucket.put(ctx, name, canary); err != nil {
+		return fmt.Errorf("failed to upload the object %s: %w", name, err)
+	}
+	data, err := bucket.get(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to get the object %s: %w", name, err)
+	}
+	if !bytes.Equal(data, canary) {
+		return fmt.Errorf("the content of the object %s is not the uploaded one", name)
+	}
+	if err := bucket.delete(ctx, name); err != nil {
+		return fmt.Errorf("failed to delete the object %s: %w", name, err)
+	}
+	return nil
+}
+
+// newObjStorageHTTPClient creates the http client of the probe with the http_config of the object storage.
+func newObjStorageHTTPClient(conf HTTPConfig, tlsFiles map[string][]byte) (*http.Client, error) {
+	tlsConfig, err := newObjStorageTLSConfig(conf, tlsFiles)
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          conf.MaxIdleConns,
+		MaxIdleConnsPerHost:   conf.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       conf.MaxConnsPerHost,
+		IdleConnTimeout:       time.Duration(conf.IdleConnTimeout),
+		ResponseHeaderTimeout: time.Duration(conf.ResponseHeaderTimeout),
+		TLSHandshakeTimeout:   time.Duration(conf.TLSHandshakeTimeout),
+		ExpectContinueTimeout: time.Duration(conf.ExpectContinueTimeout),
+		TLSClientConfig:       tlsConfig,
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+func newObjStorageTLSConfig(conf HTTPConfig, tlsFiles map[string][]byte) (*tls.Config, error) {
+	// #nosec G402 -- the verification is skipped only if it is configured so
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: conf.InsecureSkipVerify || conf.TLSConfig.InsecureSkipVerify,
+		ServerName:         conf.TLSConfig.ServerName,
+	}
+	getFile := func(file string) ([]byte, error) {
+		data, ok := tlsFiles[path.Base(file)]
+		if !ok {
+			return nil, fmt.Errorf("the file %s is not in the TLS secret of the object storage", path.Base(file))
+		}
+		return data, nil
+	}
+	if conf.TLSConfig.CAFile != "" {
+		ca, err := getFile(conf.TLSConfig.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("failed to parse the CA certificate %s", path.Base(conf.TLSConfig.CAFile))
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if conf.TLSConfig.CertFile != "" && conf.TLSConfig.KeyFile != "" {
+		cert, err := getFile(conf.TLSConfig.CertFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := getFile(conf.TLSConfig.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		certificate, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
+
+// checkHTTPResponse returns an error with the status and the body of the failed requests.
+func checkHTTPResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body := make([]byte, 512)
+	n, _ := resp.Body.Read(body)
+	return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body[:n])))
+}
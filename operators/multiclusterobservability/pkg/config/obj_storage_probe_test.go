@@ -0,0 +1,207 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package config
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeObjStore is a minimal in-memory stand-in of an object storage, the objects are keyed by the
+// request path. A bucket is listed with a request on its path.
+type fakeObjStore struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+	// checkAuth verifies the credentials of the request
+	checkAuth func(r *http.Request) bool
+	// objectPath maps a request to the path of the object, the path of the bucket for a list request
+	objectPath func(r *http.Request) string
+}
+
+func (s *fakeObjStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.checkAuth != nil && !s.checkAuth(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	name := s.objectPath(r)
+	if !strings.HasPrefix(name, "/"+s.bucket) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "NoSuchBucket")
+		return
+	}
+	switch {
+	case name == "/"+s.bucket || name == "/"+s.bucket+"/":
+		fmt.Fprint(w, "<ListBucketResult></ListBucketResult>")
+	case r.Method == http.MethodPut || r.Method == http.MethodPost:
+		data, _ := io.ReadAll(r.Body)
+		s.objects[name] = data
+	case r.Method == http.MethodGet:
+		data, ok := s.objects[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(s.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestProbeObjStorageS3(t *testing.T) {
+	store := &fakeObjStore{
+		bucket:  "bucket",
+		objects: map[string][]byte{},
+		checkAuth: func(r *http.Request) bool {
+			return strings.Contains(r.Header.Get("Authorization"), "Credential=access_key/")
+		},
+		objectPath: func(r *http.Request) string { return r.URL.Path },
+	}
+	server := httptest.NewServer(store)
+	defer server.Close()
+	endpoint := strings.TrimPrefix(server.URL, "http://")
+
+	caseList := []struct {
+		name  string
+		conf  string
+		valid bool
+	}{
+		{
+			name: "valid configuration",
+			conf: fmt.Sprintf("type: s3\nconfig:\n  bucket: bucket\n  endpoint: %s\n  insecure: true\n"+
+				"  access_key: access_key\n  secret_key: secret_key\n", endpoint),
+			valid: true,
+		},
+		{
+			name: "missing bucket",
+			conf: fmt.Sprintf("type: s3\nconfig:\n  bucket: missing\n  endpoint: %s\n  insecure: true\n"+
+				"  access_key: access_key\n  secret_key: secret_key\n", endpoint),
+		},
+		{
+			name: "wrong key",
+			conf: fmt.Sprintf("type: s3\nconfig:\n  bucket: bucket\n  endpoint: %s\n  insecure: true\n"+
+				"  access_key: wrong\n  secret_key: secret_key\n", endpoint),
+		},
+		{
+			name: "missing CA file",
+			conf: fmt.Sprintf("type: s3\nconfig:\n  bucket: bucket\n  endpoint: %s\n  insecure: true\n"+
+				"  access_key: access_key\n  secret_key: secret_key\n  http_config:\n    tls_config:\n"+
+				"      ca_file: /etc/minio/certs/ca.crt\n", endpoint),
+		},
+	}
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			err := ProbeObjStorage(context.TODO(), []byte(c.conf), nil)
+			if (err == nil) != c.valid {
+				t.Errorf("probe error (%v) is not the expected, valid: (%v)", err, c.valid)
+			}
+		})
+	}
+	if len(store.objects) != 0 {
+		t.Errorf("the canary objects should be deleted: (%v)", store.objects)
+	}
+}
+
+func TestProbeObjStorageAzure(t *testing.T) {
+	store := &fakeObjStore{
+		bucket:  "container",
+		objects: map[string][]byte{},
+		checkAuth: func(r *http.Request) bool {
+			return strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey account:") &&
+				r.Header.Get("x-ms-date") != "" && r.Header.Get("x-ms-version") == azureStorageVersion
+		},
+		objectPath: func(r *http.Request) string { return r.URL.Path },
+	}
+	server := httptest.NewServer(store)
+	defer server.Close()
+
+	conf := Config{StorageAccount: "account", StorageAccountKey: "a2V5", Container: "container"}
+	bucket, err := newAzureBucket(conf, server.Client(), server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create the azure bucket: (%v)", err)
+	}
+	if err = probeBucket(context.TODO(), bucket); err != nil {
+		t.Errorf("Failed to probe the azure bucket: (%v)", err)
+	}
+
+	conf.Container = "missing"
+	bucket, _ = newAzureBucket(conf, server.Client(), server.URL)
+	if err = probeBucket(context.TODO(), bucket); err == nil {
+		t.Errorf("the probe of a missing container should fail")
+	}
+}
+
+func TestProbeObjStorageGCS(t *testing.T) {
+	store := &fakeObjStore{
+		bucket:  "bucket",
+		objects: map[string][]byte{},
+		checkAuth: func(r *http.Request) bool {
+			return r.URL.Path == "/token" || r.Header.Get("Authorization") == "Bearer token"
+		},
+		objectPath: func(r *http.Request) string {
+			switch {
+			case strings.HasPrefix(r.URL.Path, "/upload/storage/v1/b/"):
+				return strings.TrimPrefix(r.URL.Path, "/upload/storage/v1/b") + "/" + r.URL.Query().Get("name")
+			case strings.HasSuffix(r.URL.Path, "/o"):
+				return strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/storage/v1/b"), "/o")
+			}
+			return strings.TrimPrefix(r.URL.Path, "/storage/v1/b")
+		},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"token","token_type":"Bearer","expires_in":3600}`)
+	})
+	mux.Handle("/", store)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate the service account key: (%v)", err)
+	}
+	serviceAccount, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "probe@project.iam.gserviceaccount.com",
+		"private_key": string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		"token_uri": server.URL + "/token",
+	})
+
+	conf := Config{Bucket: "bucket", ServiceAccount: string(serviceAccount)}
+	bucket, err := newGCSBucket(context.TODO(), conf, server.Client(), server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create the gcs bucket: (%v)", err)
+	}
+	if err = probeBucket(context.TODO(), bucket); err != nil {
+		t.Errorf("Failed to probe the gcs bucket: (%v)", err)
+	}
+	if len(store.objects) != 0 {
+		t.Errorf("the canary objects should be deleted: (%v)", store.objects)
+	}
+}
+
+func TestProbeObjStorageNotSupported(t *testing.T) {
+	conf := "type: filesystem\nconfig:\n  directory: /var/thanos/bucket\n"
+	err := ProbeObjStorage(context.TODO(), []byte(conf), nil)
+	if !errors.Is(err, ErrObjStorageProbeNotSupported) {
+		t.Errorf("probe error (%v) is not the expected: (%v)", err, ErrObjStorageProbeNotSupported)
+	}
+}
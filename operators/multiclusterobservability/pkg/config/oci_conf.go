@@ -0,0 +1,75 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package config
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	ociProviderRaw                 = "raw"
+	ociProviderDefault             = "default"
+	ociProviderInstancePrincipal   = "instance-principal"
+	ociProviderOKEWorkloadIdentity = "oke-workload-identity"
+)
+
+func validateOCI(conf Config) error {
+
+	if conf.Bucket == "" {
+		return errors.New("no bucket as oci bucket name in config file")
+	}
+
+	switch strings.ToLower(conf.Provider) {
+	// the raw provider is the default one
+	case "", ociProviderRaw:
+		if conf.TenancyOCID == "" {
+			return errors.New("no tenancy_ocid as oci tenancy in config file")
+		}
+		if conf.UserOCID == "" {
+			return errors.New("no user_ocid as oci user in config file")
+		}
+		if conf.Region == "" {
+			return errors.New("no region as oci region in config file")
+		}
+		if conf.Fingerprint == "" {
+			return errors.New("no fingerprint as oci api key fingerprint in config file")
+		}
+		if conf.PrivateKey == "" {
+			return errors.New("no privatekey as oci api private key in config file")
+		}
+	case ociProviderDefault, ociProviderInstancePrincipal, ociProviderOKEWorkloadIdentity:
+		// the credentials are read from the environment of the thanos components
+	default:
+		return errors.New("invalid provider as oci configuration provider in config file")
+	}
+
+	if conf.CompartmentOCID == "" {
+		return errors.New("no compartment_ocid as oci compartment in config file")
+	}
+
+	return nil
+}
+
+// IsValidOCIConf is used to validate oci configuration
+func IsValidOCIConf(data []byte) (bool, error) {
+	var objectConfg ObjectStorgeConf
+	err := yaml.Unmarshal(data, &objectConfg)
+	if err != nil {
+		return false, err
+	}
+
+	if strings.ToLower(objectConfg.Type) != "oci" {
+		return false, errors.New("invalid type config, only OCI type is supported")
+	}
+
+	err = validateOCI(objectConfg.Config)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
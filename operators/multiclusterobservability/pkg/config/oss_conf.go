@@ -0,0 +1,52 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package config
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+func validateOSS(conf Config) error {
+
+	if conf.Bucket == "" {
+		return errors.New("no bucket as oss bucket name in config file")
+	}
+
+	if conf.Endpoint == "" {
+		return errors.New("no endpoint as oss endpoint in config file")
+	}
+
+	if conf.AccessKeyID == "" {
+		return errors.New("no access_key_id as oss access key id in config file")
+	}
+
+	if conf.AccessKeySecret == "" {
+		return errors.New("no access_key_secret as oss access key secret in config file")
+	}
+
+	return nil
+}
+
+// IsValidOSSConf is used to validate oss configuration
+func IsValidOSSConf(data []byte) (bool, error) {
+	var objectConfg ObjectStorgeConf
+	err := yaml.Unmarshal(data, &objectConfg)
+	if err != nil {
+		return false, err
+	}
+
+	if strings.ToLower(objectConfg.Type) != "oss" {
+		return false, errors.New("invalid type config, only OSS type is supported")
+	}
+
+	err = validateOSS(objectConfg.Config)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -4,12 +4,22 @@
 package config
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"io"
+	"net/http"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"gopkg.in/yaml.v2"
 )
 
+const defaultS3Region = "us-east-1"
+
 func validateS3(conf Config) error {
 
 	if conf.Bucket == "" {
@@ -42,3 +52,75 @@ func IsValidS3Conf(data []byte) (bool, error) {
 
 	return true, nil
 }
+
+type s3Bucket struct {
+	client *s3.S3
+	bucket string
+}
+
+// newS3Bucket creates the s3 client of the probe with the credentials, if they are nil the static credentials
+// are used if they are set, the default credential chain otherwise.
+func newS3Bucket(conf Config, httpClient *http.Client, creds *credentials.Credentials) (objBucket, error) {
+	scheme := "https://"
+	if conf.Insecure {
+		scheme = "http://"
+	}
+	region := conf.Region
+	if region == "" {
+		region = defaultS3Region
+	}
+	awsConfig := aws.NewConfig().
+		WithEndpoint(scheme + conf.Endpoint).
+		WithRegion(region).
+		WithHTTPClient(httpClient).
+		// the virtual hosted style is only expected from AWS, the compatible stores serve the path style
+		WithS3ForcePathStyle(!strings.Contains(conf.Endpoint, "amazonaws.com"))
+	if creds == nil && conf.AccessKey != "" {
+		creds = credentials.NewStaticCredentials(conf.AccessKey, conf.SecretKey, "")
+	}
+	if creds != nil {
+		awsConfig = awsConfig.WithCredentials(creds)
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Bucket{client: s3.New(sess), bucket: conf.Bucket}, nil
+}
+
+func (b *s3Bucket) list(ctx context.Context) error {
+	_, err := b.client.ListObjectsV2WithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(b.bucket),
+		MaxKeys: aws.Int64(1),
+	})
+	return err
+}
+
+func (b *s3Bucket) put(ctx context.Context, name string, data []byte) error {
+	_, err := b.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(name),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (b *s3Bucket) get(ctx context.Context, name string) ([]byte, error) {
+	out, err := b.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (b *s3Bucket) delete(ctx context.Context, name string) error {
+	_, err := b.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(name),
+	})
+	return err
+}
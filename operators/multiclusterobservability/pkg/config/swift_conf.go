@@ -0,0 +1,60 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package config
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+func validateSwift(conf Config) error {
+
+	if conf.AuthURL == "" {
+		return errors.New("no auth_url as swift identity endpoint in config file")
+	}
+
+	if conf.ContainerName == "" {
+		return errors.New("no container_name as swift container in config file")
+	}
+
+	// either an application credential or a user with its password authenticates to keystone
+	if conf.ApplicationCredentialID != "" {
+		if conf.ApplicationCredentialSecret == "" {
+			return errors.New("no application_credential_secret as swift application credential secret in config file")
+		}
+		return nil
+	}
+
+	if conf.Username == "" && conf.UserID == "" {
+		return errors.New("no username or user_id as swift user in config file")
+	}
+
+	if conf.Password == "" {
+		return errors.New("no password as swift user password in config file")
+	}
+
+	return nil
+}
+
+// IsValidSwiftConf is used to validate swift configuration
+func IsValidSwiftConf(data []byte) (bool, error) {
+	var objectConfg ObjectStorgeConf
+	err := yaml.Unmarshal(data, &objectConfg)
+	if err != nil {
+		return false, err
+	}
+
+	if strings.ToLower(objectConfg.Type) != "swift" {
+		return false, errors.New("invalid type config, only swift type is supported")
+	}
+
+	err = validateSwift(objectConfg.Config)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package config
+
+import (
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	observabilityv1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+)
+
+const (
+	// ClusterSetLabel is the label of the ManagedClusterSet of a managed cluster
+	ClusterSetLabel = "cluster.open-cluster-management.io/clusterset"
+
+	// TenantClustersConfigMapName is the configmap of the managed clusters mapped to each tenant, the
+	// rbac-query-proxy only routes the queries of a tenant for the users of its managed clusters
+	TenantClustersConfigMapName = "observability-tenant-clusters"
+	TenantClustersConfigMapKey  = "tenant_clusters.yaml"
+)
+
+var (
+	// the uids of the tenants other than the default tenant, keyed by tenant name
+	tenantUIDs      = map[string]string{}
+	tenantUIDsMutex sync.Mutex
+)
+
+// GetTenantNames returns the names of the tenants of the MultiClusterObservability CR, the default tenant first.
+func GetTenantNames(mco *observabilityv1beta2.MultiClusterObservability) []string {
+	names := []string{GetDefaultTenantName()}
+	for _, tenant := range mco.Spec.Tenants {
+		names = append(names, tenant.Name)
+	}
+	return names
+}
+
+// GetTenantUIDByName returns the uid of the tenant, the uid of the default tenant is GetTenantUID.
+func GetTenantUIDByName(name string) string {
+	if name == GetDefaultTenantName() {
+		return GetTenantUID()
+	}
+	tenantUIDsMutex.Lock()
+	defer tenantUIDsMutex.Unlock()
+	if tenantUIDs[name] == "" {
+		tenantUIDs[name] = string(uuid.NewUUID())
+	}
+	return tenantUIDs[name]
+}
+
+// GetTenantClusterGroup returns the organization unit of the client certificates of the managed clusters mapped to
+// the tenant, the write role of the tenant is bound to it. The managed clusters of the default tenant keep the
+// organization unit of the managed clusters.
+func GetTenantClusterGroup(name string) string {
+	if name == GetDefaultTenantName() {
+		return ManagedClusterOU
+	}
+	return ManagedClusterOU + "-tenant-" + name
+}
+
+// GetClusterTenant returns the name of the first tenant the managed cluster with the labels is mapped to, by its
+// ManagedClusterSet or by the cluster selector of the tenant, the default tenant if it is not mapped to any tenant.
+func GetClusterTenant(tenants []observabilityv1beta2.TenantSpec, clusterLabels map[string]string) string {
+	clusterSet := clusterLabels[ClusterSetLabel]
+	for _, tenant := range tenants {
+		if clusterSet != "" {
+			for _, set := range tenant.ClusterSets {
+				if set == clusterSet {
+					return tenant.Name
+				}
+			}
+		}
+		if tenant.ClusterSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(tenant.ClusterSelector)
+			if err != nil {
+				log.Error(err, "Invalid cluster selector", "tenant", tenant.Name)
+				continue
+			}
+			if selector.Matches(labels.Set(clusterLabels)) {
+				return tenant.Name
+			}
+		}
+	}
+	return GetDefaultTenantName()
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	observabilityv1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+)
+
+func TestGetClusterTenant(t *testing.T) {
+	tenants := []observabilityv1beta2.TenantSpec{
+		{
+			Name:        "team-a",
+			ClusterSets: []string{"set-a"},
+		},
+		{
+			Name: "team-b",
+			ClusterSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"team": "b"},
+			},
+		},
+	}
+	caseList := []struct {
+		name     string
+		labels   map[string]string
+		expected string
+	}{
+		{
+			name:     "cluster set",
+			labels:   map[string]string{ClusterSetLabel: "set-a", "team": "b"},
+			expected: "team-a",
+		},
+		{
+			name:     "cluster selector",
+			labels:   map[string]string{ClusterSetLabel: "set-c", "team": "b"},
+			expected: "team-b",
+		},
+		{
+			name:     "no tenant",
+			labels:   map[string]string{ClusterSetLabel: "set-c"},
+			expected: GetDefaultTenantName(),
+		},
+		{
+			name:     "no labels",
+			expected: GetDefaultTenantName(),
+		},
+	}
+	for _, c := range caseList {
+		output := GetClusterTenant(tenants, c.labels)
+		if output != c.expected {
+			t.Errorf("case (%v) output (%v) is not the expected (%v)", c.name, output, c.expected)
+		}
+	}
+}
+
+func TestGetTenantNames(t *testing.T) {
+	mco := &observabilityv1beta2.MultiClusterObservability{
+		Spec: observabilityv1beta2.MultiClusterObservabilitySpec{
+			Tenants: []observabilityv1beta2.TenantSpec{{Name: "team-a"}},
+		},
+	}
+	expected := []string{GetDefaultTenantName(), "team-a"}
+	if names := GetTenantNames(mco); !reflect.DeepEqual(names, expected) {
+		t.Errorf("names (%v) are not the expected: (%v)", names, expected)
+	}
+	if GetTenantUIDByName("team-a") != GetTenantUIDByName("team-a") {
+		t.Errorf("the uid of the tenant should not change")
+	}
+	if GetTenantUIDByName(GetDefaultTenantName()) != GetTenantUID() {
+		t.Errorf("the uid of the default tenant is not the expected: (%v)", GetTenantUID())
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"gopkg.in/yaml.v2"
+
+	observabilityv1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+)
+
+const (
+	// the annotations binding a service account to a cloud identity
+	AWSRoleARNAnnotation        = "eks.amazonaws.com/role-arn"
+	GCPServiceAccountAnnotation = "iam.gke.io/gcp-service-account"
+	AzureClientIDAnnotation     = "azure.workload.identity/client-id"
+	AzureTenantIDAnnotation     = "azure.workload.identity/tenant-id"
+	// AzureWorkloadIdentityUseLabel selects the pods the Azure workload identity webhook injects the credentials in
+	AzureWorkloadIdentityUseLabel = "azure.workload.identity/use"
+
+	// the audiences of the service account tokens exchanged for the cloud credentials
+	AWSTokenAudience   = "sts.amazonaws.com"
+	AzureTokenAudience = "api://AzureADTokenExchange"
+
+	workloadIdentitySessionName = "multicluster-observability-probe"
+	azureStorageScope           = "https://storage.azure.com/.default"
+)
+
+// ErrWorkloadIdentityNotUsable is returned by the probe of the object storage when the credentials of the
+// workload identity cannot be obtained.
+var ErrWorkloadIdentityNotUsable = errors.New("the workload identity is not usable")
+
+var (
+	// stsEndpoint is the endpoint of AWS STS, the default endpoint of the region is used if it is empty
+	stsEndpoint = ""
+	// azureAuthorityHost is the host of the Azure Active Directory the tokens are exchanged with
+	azureAuthorityHost = "https://login.microsoftonline.com"
+)
+
+// IsWorkloadIdentityMode returns true if the thanos components use the workload identity to access the metric
+// object storage.
+func IsWorkloadIdentityMode(storageConfig *observabilityv1beta2.StorageConfig) bool {
+	return storageConfig != nil &&
+		storageConfig.CredentialsMode == observabilityv1beta2.CredentialsModeWorkloadIdentity
+}
+
+// GetWorkloadIdentityAnnotations returns the annotations binding the service accounts of the thanos components
+// to the cloud identity of the workloadIdentity credentials mode, nil with the static credentials mode.
+func GetWorkloadIdentityAnnotations(storageConfig *observabilityv1beta2.StorageConfig) map[string]string {
+	if !IsWorkloadIdentityMode(storageConfig) || storageConfig.WorkloadIdentity == nil {
+		return nil
+	}
+	identity := storageConfig.WorkloadIdentity
+	annotations := map[string]string{}
+	if identity.AWSRoleARN != "" {
+		annotations[AWSRoleARNAnnotation] = identity.AWSRoleARN
+	}
+	if identity.GCPServiceAccount != "" {
+		annotations[GCPServiceAccountAnnotation] = identity.GCPServiceAccount
+	}
+	if identity.AzureClientID != "" {
+		annotations[AzureClientIDAnnotation] = identity.AzureClientID
+	}
+	if identity.AzureTenantID != "" {
+		annotations[AzureTenantIDAnnotation] = identity.AzureTenantID
+	}
+	return annotations
+}
+
+// GetWorkloadIdentityPodLabels returns the labels of the pods of the thanos components required by the cloud
+// identity of the workloadIdentity credentials mode, nil if none is required. The Azure workload identity webhook
+// only injects the credentials in the labeled pods.
+func GetWorkloadIdentityPodLabels(storageConfig *observabilityv1beta2.StorageConfig) map[string]string {
+	if !IsWorkloadIdentityMode(storageConfig) || storageConfig.WorkloadIdentity == nil ||
+		storageConfig.WorkloadIdentity.AzureClientID == "" {
+		return nil
+	}
+	return map[string]string{AzureWorkloadIdentityUseLabel: "true"}
+}
+
+// CheckMetricObjStorageConf is used to check/valid the configuration of the metric object storage with the
+// credentials mode of the storage config.
+func CheckMetricObjStorageConf(data []byte, storageConfig *observabilityv1beta2.StorageConfig) (bool, error) {
+	if !IsWorkloadIdentityMode(storageConfig) {
+		return CheckObjStorageConf(data)
+	}
+	var objectConfg ObjectStorgeConf
+	err := yaml.Unmarshal(data, &objectConfg)
+	if err != nil {
+		return false, err
+	}
+	err = validateWorkloadIdentity(objectConfg, storageConfig.WorkloadIdentity)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func validateWorkloadIdentity(objectConfg ObjectStorgeConf,
+	identity *observabilityv1beta2.WorkloadIdentityConfig) error {
+	if identity == nil {
+		return errors.New("no workloadIdentity for the workloadIdentity credentials mode")
+	}
+	conf := objectConfg.Config
+	switch strings.ToLower(objectConfg.Type) {
+	case "s3":
+		if err := validateS3(conf); err != nil {
+			return err
+		}
+		if conf.AccessKey != "" || conf.SecretKey != "" {
+			return errors.New("access_key and secret_key are not allowed with the workloadIdentity credentials mode")
+		}
+		if identity.AWSRoleARN == "" {
+			return errors.New("no awsRoleARN in workloadIdentity for the s3 object storage")
+		}
+
+	case "gcs":
+		if conf.Bucket == "" {
+			return errors.New("no bucket as gcs bucket name in config file")
+		}
+		if conf.ServiceAccount != "" {
+			return errors.New("service_account is not allowed with the workloadIdentity credentials mode")
+		}
+		if identity.GCPServiceAccount == "" {
+			return errors.New("no gcpServiceAccount in workloadIdentity for the gcs object storage")
+		}
+
+	case "azure":
+		if conf.StorageAccount == "" {
+			return errors.New("no storage_account as azure storage account in config file")
+		}
+		if conf.Container == "" {
+			return errors.New("no container as azure container in config file")
+		}
+		if conf.Endpoint == "" {
+			return errors.New("no endpoint as azure endpoint in config file")
+		}
+		if conf.StorageAccountKey != "" {
+			return errors.New("storage_account_key is not allowed with the workloadIdentity credentials mode")
+		}
+		if identity.AzureClientID == "" {
+			return errors.New("no azureClientID in workloadIdentity for the azure object storage")
+		}
+
+	default:
+		return errors.New("the workloadIdentity credentials mode is only supported by the s3, gcs and azure types")
+	}
+	return nil
+}
+
+// GetWorkloadIdentityTokenAudience returns the audience of the service account token exchanged for the
+// credentials of the object storage, empty if the token cannot be exchanged by the operator.
+func GetWorkloadIdentityTokenAudience(data []byte) string {
+	var objectConfg ObjectStorgeConf
+	if err := yaml.Unmarshal(data, &objectConfg); err != nil {
+		return ""
+	}
+	switch strings.ToLower(objectConfg.Type) {
+	case "s3":
+		return AWSTokenAudience
+	case "azure":
+		return AzureTokenAudience
+	}
+	return ""
+}
+
+// ProbeWorkloadIdentityObjStorage probes the object storage like ProbeObjStorage with the credentials the cloud
+// identity is exchanged for. token is a service account token of a thanos component, requested with the audience
+// returned by GetWorkloadIdentityTokenAudience. The GKE workload identity is served by the metadata server to the
+// pods of the thanos components only, it cannot be probed.
+func ProbeWorkloadIdentityObjStorage(ctx context.Context, data []byte, tlsFiles map[string][]byte,
+	identity *observabilityv1beta2.WorkloadIdentityConfig, token []byte) error {
+	var objectConfg ObjectStorgeConf
+	err := yaml.Unmarshal(data, &objectConfg)
+	if err != nil {
+		return err
+	}
+	if err = validateWorkloadIdentity(objectConfg, identity); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, ObjStorageProbeTimeout)
+	defer cancel()
+
+	httpClient, err := newObjStorageHTTPClient(objectConfg.Config.HTTPConfig, tlsFiles)
+	if err != nil {
+		return err
+	}
+	var bucket objBucket
+	switch strings.ToLower(objectConfg.Type) {
+	case "s3":
+		var creds *credentials.Credentials
+		creds, err = newS3WebIdentityCredentials(ctx, objectConfg.Config, identity.AWSRoleARN, token)
+		if err == nil {
+			bucket, err = newS3Bucket(objectConfg.Config, httpClient, creds)
+		}
+	case "azure":
+		if identity.AzureTenantID == "" {
+			return ErrObjStorageProbeNotSupported
+		}
+		var accessToken string
+		accessToken, err = getAzureWorkloadIdentityToken(ctx, identity.AzureTenantID, identity.AzureClientID, token)
+		if err == nil {
+			bucket = &azureBucket{
+				client:    httpClient,
+				endpoint:  getAzureEndpoint(objectConfg.Config),
+				account:   objectConfg.Config.StorageAccount,
+				token:     accessToken,
+				container: objectConfg.Config.Container,
+			}
+		}
+	default:
+		return ErrObjStorageProbeNotSupported
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWorkloadIdentityNotUsable, err)
+	}
+	return probeBucket(ctx, bucket)
+}
+
+type staticTokenFetcher []byte
+
+func (t staticTokenFetcher) FetchToken(credentials.Context) ([]byte, error) {
+	return t, nil
+}
+
+// newS3WebIdentityCredentials returns the credentials of the role assumed with the service account token.
+func newS3WebIdentityCredentials(ctx context.Context, conf Config, roleARN string,
+	token []byte) (*credentials.Credentials, error) {
+	region := conf.Region
+	if region == "" {
+		region = defaultS3Region
+	}
+	awsConfig := aws.NewConfig().WithRegion(region).WithCredentials(credentials.AnonymousCredentials)
+	if stsEndpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(stsEndpoint)
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+	provider := stscreds.NewWebIdentityRoleProviderWithOptions(sts.New(sess), roleARN,
+		workloadIdentitySessionName, staticTokenFetcher(token))
+	creds := credentials.NewCredentials(provider)
+	// the role is assumed before the bucket is probed, to report the failures of the identity
+	if _, err = creds.GetWithContext(ctx); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// getAzureWorkloadIdentityToken exchanges the service account token for an access token of the Azure storage
+// with the client credentials flow of the managed identity.
+func getAzureWorkloadIdentityToken(ctx context.Context, tenantID, clientID string, token []byte) (string, error) {
+	form := url.Values{
+		"client_id":             {clientID},
+		"scope":                 {azureStorageScope},
+		"grant_type":            {"client_credentials"},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {string(token)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/%s/oauth2/v2.0/token", azureAuthorityHost, url.PathEscape(tenantID)),
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkHTTPResponse(resp); err != nil {
+		return "", err
+	}
+	result := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.AccessToken == "" {
+		return "", errors.New("no access token in the response of the azure active directory")
+	}
+	return result.AccessToken, nil
+}
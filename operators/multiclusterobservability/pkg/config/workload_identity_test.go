@@ -0,0 +1,237 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	observabilityv1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+)
+
+func TestCheckMetricObjStorageConf(t *testing.T) {
+	caseList := []struct {
+		name     string
+		conf     string
+		identity *observabilityv1beta2.WorkloadIdentityConfig
+		expected bool
+	}{
+		{
+			name:     "valid s3 conf",
+			conf:     "type: s3\nconfig:\n  bucket: bucket\n  endpoint: s3.us-east-1.amazonaws.com\n",
+			identity: &observabilityv1beta2.WorkloadIdentityConfig{AWSRoleARN: "arn:aws:iam::123:role/thanos"},
+			expected: true,
+		},
+		{
+			name: "s3 conf with keys",
+			conf: "type: s3\nconfig:\n  bucket: bucket\n  endpoint: s3.us-east-1.amazonaws.com\n" +
+				"  access_key: access_key\n  secret_key: secret_key\n",
+			identity: &observabilityv1beta2.WorkloadIdentityConfig{AWSRoleARN: "arn:aws:iam::123:role/thanos"},
+			expected: false,
+		},
+		{
+			name:     "s3 conf without role",
+			conf:     "type: s3\nconfig:\n  bucket: bucket\n  endpoint: s3.us-east-1.amazonaws.com\n",
+			identity: &observabilityv1beta2.WorkloadIdentityConfig{GCPServiceAccount: "thanos@project.iam"},
+			expected: false,
+		},
+		{
+			name:     "valid gcs conf",
+			conf:     "type: gcs\nconfig:\n  bucket: bucket\n",
+			identity: &observabilityv1beta2.WorkloadIdentityConfig{GCPServiceAccount: "thanos@project.iam"},
+			expected: true,
+		},
+		{
+			name:     "gcs conf with service account",
+			conf:     "type: gcs\nconfig:\n  bucket: bucket\n  service_account: key\n",
+			identity: &observabilityv1beta2.WorkloadIdentityConfig{GCPServiceAccount: "thanos@project.iam"},
+			expected: false,
+		},
+		{
+			name: "valid azure conf",
+			conf: "type: azure\nconfig:\n  storage_account: account\n  container: container\n" +
+				"  endpoint: blob.core.windows.net\n",
+			identity: &observabilityv1beta2.WorkloadIdentityConfig{AzureClientID: "client"},
+			expected: true,
+		},
+		{
+			name: "azure conf with key",
+			conf: "type: azure\nconfig:\n  storage_account: account\n  storage_account_key: key\n" +
+				"  container: container\n  endpoint: blob.core.windows.net\n",
+			identity: &observabilityv1beta2.WorkloadIdentityConfig{AzureClientID: "client"},
+			expected: false,
+		},
+		{
+			name:     "unsupported type",
+			conf:     "type: filesystem\nconfig:\n  directory: /var/thanos/bucket\n",
+			identity: &observabilityv1beta2.WorkloadIdentityConfig{AzureClientID: "client"},
+			expected: false,
+		},
+		{
+			name:     "no identity",
+			conf:     "type: gcs\nconfig:\n  bucket: bucket\n",
+			expected: false,
+		},
+	}
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			storageConfig := &observabilityv1beta2.StorageConfig{
+				CredentialsMode:  observabilityv1beta2.CredentialsModeWorkloadIdentity,
+				WorkloadIdentity: c.identity,
+			}
+			output, _ := CheckMetricObjStorageConf([]byte(c.conf), storageConfig)
+			if output != c.expected {
+				t.Errorf("case (%v) output (%v) is not the expected (%v)", c.name, output, c.expected)
+			}
+		})
+	}
+
+	// the keys are required with the static credentials mode
+	output, _ := CheckMetricObjStorageConf([]byte("type: gcs\nconfig:\n  bucket: bucket\n"),
+		&observabilityv1beta2.StorageConfig{})
+	if output {
+		t.Errorf("the gcs conf without service_account should be invalid with the static credentials mode")
+	}
+}
+
+func TestGetWorkloadIdentityAnnotations(t *testing.T) {
+	storageConfig := &observabilityv1beta2.StorageConfig{
+		WorkloadIdentity: &observabilityv1beta2.WorkloadIdentityConfig{
+			AzureClientID: "client",
+			AzureTenantID: "tenant",
+		},
+	}
+	if annotations := GetWorkloadIdentityAnnotations(storageConfig); annotations != nil {
+		t.Errorf("annotations (%v) should be nil with the static credentials mode", annotations)
+	}
+	storageConfig.CredentialsMode = observabilityv1beta2.CredentialsModeWorkloadIdentity
+	expected := map[string]string{
+		AzureClientIDAnnotation: "client",
+		AzureTenantIDAnnotation: "tenant",
+	}
+	if annotations := GetWorkloadIdentityAnnotations(storageConfig); !reflect.DeepEqual(annotations, expected) {
+		t.Errorf("annotations (%v) are not the expected: (%v)", annotations, expected)
+	}
+}
+
+func TestGetWorkloadIdentityPodLabels(t *testing.T) {
+	storageConfig := &observabilityv1beta2.StorageConfig{
+		CredentialsMode: observabilityv1beta2.CredentialsModeWorkloadIdentity,
+		WorkloadIdentity: &observabilityv1beta2.WorkloadIdentityConfig{
+			AWSRoleARN: "arn:aws:iam::123456789012:role/thanos",
+		},
+	}
+	if labels := GetWorkloadIdentityPodLabels(storageConfig); labels != nil {
+		t.Errorf("labels (%v) should be nil without the azure workload identity", labels)
+	}
+	storageConfig.WorkloadIdentity = &observabilityv1beta2.WorkloadIdentityConfig{AzureClientID: "client"}
+	expected := map[string]string{AzureWorkloadIdentityUseLabel: "true"}
+	if labels := GetWorkloadIdentityPodLabels(storageConfig); !reflect.DeepEqual(labels, expected) {
+		t.Errorf("labels (%v) are not the expected: (%v)", labels, expected)
+	}
+}
+
+func TestProbeWorkloadIdentityObjStorageS3(t *testing.T) {
+	store := &fakeObjStore{
+		bucket:  "bucket",
+		objects: map[string][]byte{},
+		checkAuth: func(r *http.Request) bool {
+			return strings.Contains(r.Header.Get("Authorization"), "Credential=sts_key/") &&
+				r.Header.Get("X-Amz-Security-Token") == "session"
+		},
+		objectPath: func(r *http.Request) string { return r.URL.Path },
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sts/", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("Action") != "AssumeRoleWithWebIdentity" || r.Form.Get("WebIdentityToken") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "<ErrorResponse><Error><Code>InvalidIdentityToken</Code></Error></ErrorResponse>")
+			return
+		}
+		fmt.Fprint(w, `<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+<AccessKeyId>sts_key</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken>
+<Expiration>2099-01-01T00:00:00Z</Expiration></Credentials></AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`)
+	})
+	mux.Handle("/", store)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	stsEndpoint = server.URL + "/sts/"
+	defer func() { stsEndpoint = "" }()
+
+	conf := fmt.Sprintf("type: s3\nconfig:\n  bucket: bucket\n  endpoint: %s\n  insecure: true\n",
+		strings.TrimPrefix(server.URL, "http://"))
+	identity := &observabilityv1beta2.WorkloadIdentityConfig{AWSRoleARN: "arn:aws:iam::123:role/thanos"}
+	err := ProbeWorkloadIdentityObjStorage(context.TODO(), []byte(conf), nil, identity, []byte("token"))
+	if err != nil {
+		t.Errorf("Failed to probe the s3 bucket with the workload identity: (%v)", err)
+	}
+	if len(store.objects) != 0 {
+		t.Errorf("the canary objects should be deleted: (%v)", store.objects)
+	}
+
+	err = ProbeWorkloadIdentityObjStorage(context.TODO(), []byte(conf), nil, identity, []byte("wrong"))
+	if !errors.Is(err, ErrWorkloadIdentityNotUsable) {
+		t.Errorf("probe error (%v) is not the expected: (%v)", err, ErrWorkloadIdentityNotUsable)
+	}
+}
+
+func TestProbeWorkloadIdentityObjStorageAzure(t *testing.T) {
+	store := &fakeObjStore{
+		bucket:  "container",
+		objects: map[string][]byte{},
+		checkAuth: func(r *http.Request) bool {
+			return r.Header.Get("Authorization") == "Bearer access_token"
+		},
+		objectPath: func(r *http.Request) string { return r.URL.Path },
+	}
+	storeServer := httptest.NewServer(store)
+	defer storeServer.Close()
+	aadServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.URL.Path != "/tenant/oauth2/v2.0/token" || r.Form.Get("client_id") != "client" ||
+			r.Form.Get("client_assertion") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"access_token","token_type":"Bearer","expires_in":3600}`)
+	}))
+	defer aadServer.Close()
+	azureAuthorityHost = aadServer.URL
+	defer func() { azureAuthorityHost = "https://login.microsoftonline.com" }()
+
+	accessToken, err := getAzureWorkloadIdentityToken(context.TODO(), "tenant", "client", []byte("token"))
+	if err != nil {
+		t.Fatalf("Failed to get the azure access token: (%v)", err)
+	}
+	bucket := &azureBucket{
+		client:    storeServer.Client(),
+		endpoint:  storeServer.URL,
+		account:   "account",
+		token:     accessToken,
+		container: "container",
+	}
+	if err = probeBucket(context.TODO(), bucket); err != nil {
+		t.Errorf("Failed to probe the azure container with the access token: (%v)", err)
+	}
+
+	if _, err = getAzureWorkloadIdentityToken(context.TODO(), "tenant", "other", []byte("token")); err == nil {
+		t.Errorf("the token of an unknown client should be rejected")
+	}
+
+	// the GKE workload identity cannot be probed
+	err = ProbeWorkloadIdentityObjStorage(context.TODO(), []byte("type: gcs\nconfig:\n  bucket: bucket\n"), nil,
+		&observabilityv1beta2.WorkloadIdentityConfig{GCPServiceAccount: "thanos@project.iam"}, nil)
+	if !errors.Is(err, ErrObjStorageProbeNotSupported) {
+		t.Errorf("probe error (%v) is not the expected: (%v)", err, ErrObjStorageProbeNotSupported)
+	}
+}
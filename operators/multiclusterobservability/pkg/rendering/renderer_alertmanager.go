@@ -15,6 +15,7 @@ import (
 
 	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
 	rendererutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/rendering"
+	"github.com/stolostron/multicluster-observability-operator/operators/pkg/rendering/patching"
 	"github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
 )
 
@@ -36,6 +37,10 @@ func (r *MCORenderer) newAlertManagerRenderer() {
 
 func (r *MCORenderer) renderAlertManagerStatefulSet(res *resource.Resource,
 	namespace string, labels map[string]string) (*unstructured.Unstructured, error) {
+	err := patching.ApplyGlobalPatches(res, r.cr)
+	if err != nil {
+		return nil, err
+	}
 	u, err := r.renderer.RenderNamespace(res, namespace, labels)
 	if err != nil {
 		return nil, err
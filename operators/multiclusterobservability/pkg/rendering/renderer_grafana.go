@@ -11,6 +11,7 @@ import (
 
 	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
 	rendererutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/rendering"
+	"github.com/stolostron/multicluster-observability-operator/operators/pkg/rendering/patching"
 	"github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
 )
 
@@ -32,6 +33,10 @@ func (r *MCORenderer) newGranfanaRenderer() {
 
 func (r *MCORenderer) renderGrafanaDeployments(res *resource.Resource,
 	namespace string, labels map[string]string) (*unstructured.Unstructured, error) {
+	err := patching.ApplyGlobalPatches(res, r.cr)
+	if err != nil {
+		return nil, err
+	}
 	u, err := r.renderer.RenderDeployments(res, namespace, labels)
 	if err != nil {
 		return nil, err
@@ -45,6 +50,10 @@ func (r *MCORenderer) renderGrafanaDeployments(res *resource.Resource,
 	dep := obj.(*v1.Deployment)
 	dep.Name = config.GetOperandName(config.Grafana)
 	dep.Spec.Replicas = config.GetReplicas(config.Grafana, r.cr.Spec.AdvancedConfig)
+	if config.GetAutoscaling(config.Grafana, r.cr.Spec.AdvancedConfig) != nil {
+		// the replicas are managed by the HorizontalPodAutoscaler
+		dep.Spec.Replicas = nil
+	}
 
 	spec := &dep.Spec.Template.Spec
 	imagePullPolicy := config.GetImagePullPolicy(r.cr.Spec)
@@ -15,6 +15,7 @@ import (
 	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
 	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
 	rendererutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/rendering"
+	"github.com/stolostron/multicluster-observability-operator/operators/pkg/rendering/patching"
 	"github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
 )
 
@@ -36,6 +37,10 @@ func (r *MCORenderer) newProxyRenderer() {
 
 func (r *MCORenderer) renderProxyDeployment(res *resource.Resource,
 	namespace string, labels map[string]string) (*unstructured.Unstructured, error) {
+	err := patching.ApplyGlobalPatches(res, r.cr)
+	if err != nil {
+		return nil, err
+	}
 	u, err := r.renderer.RenderDeployments(res, namespace, labels)
 	if err != nil {
 		return nil, err
@@ -53,6 +58,10 @@ func (r *MCORenderer) renderProxyDeployment(res *resource.Resource,
 	dep.Spec.Template.ObjectMeta.Labels[crLabelKey] = r.cr.Name
 	dep.Name = mcoconfig.GetOperandName(config.RBACQueryProxy)
 	dep.Spec.Replicas = config.GetReplicas(config.RBACQueryProxy, r.cr.Spec.AdvancedConfig)
+	if config.GetAutoscaling(config.RBACQueryProxy, r.cr.Spec.AdvancedConfig) != nil {
+		// the replicas are managed by the HorizontalPodAutoscaler
+		dep.Spec.Replicas = nil
+	}
 
 	spec := &dep.Spec.Template.Spec
 	imagePullPolicy := config.GetImagePullPolicy(r.cr.Spec)
@@ -1,9 +1,12 @@
 package util
 
 import (
+	"errors"
+	"fmt"
 	"path"
 
 	"github.com/prometheus/common/config"
+	"gopkg.in/yaml.v2"
 )
 
 const MountPath = "/var/run/secrets/"
@@ -91,6 +94,22 @@ type RemoteWriteEndpointWithSecret struct {
 	HttpClientConfig *HTTPClientConfigWithSecret `yaml:"http_client_config,omitempty" json:"http_client_config,omitempty"`
 }
 
+// ValidateRemoteWriteEndpoint checks the remote write endpoint configuration of a write storage secret.
+func ValidateRemoteWriteEndpoint(data []byte) error {
+	ep := &RemoteWriteEndpointWithSecret{}
+	err := yaml.Unmarshal(data, ep)
+	if err != nil {
+		return err
+	}
+	if ep.URL.URL == nil || ep.URL.Host == "" {
+		return errors.New("no url in the remote write endpoint")
+	}
+	if ep.URL.Scheme != "http" && ep.URL.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q in the remote write endpoint url", ep.URL.Scheme)
+	}
+	return nil
+}
+
 func getMountPath(secretName, key string) string {
 	return path.Join(MountPath, secretName, key)
 }
@@ -81,3 +81,37 @@ func TestTransform(t *testing.T) {
 		t.Fatalf("Wrong number of mount secrets: expect 5, get %d", len(names))
 	}
 }
+
+func TestValidateRemoteWriteEndpoint(t *testing.T) {
+	caseList := []struct {
+		name  string
+		data  string
+		valid bool
+	}{
+		{
+			name:  "valid endpoint",
+			data:  "url: https://victoriametrics:8428/api/v1/write\nhttp_client_config:\n  bearer_token: token\n",
+			valid: true,
+		},
+		{
+			name: "missing url",
+			data: "http_client_config:\n  bearer_token: token\n",
+		},
+		{
+			name: "unsupported scheme",
+			data: "url: ftp://victoriametrics:8428/api/v1/write\n",
+		},
+		{
+			name: "malformed yaml",
+			data: "url: [https://victoriametrics:8428\n",
+		},
+	}
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateRemoteWriteEndpoint([]byte(c.data))
+			if (err == nil) != c.valid {
+				t.Errorf("validation error (%v) is not the expected, valid: (%v)", err, c.valid)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ package config
 
 const (
 	ClusterNameKey                  = "cluster-name"
+	ClusterLabelsKey                = "cluster-labels"
 	HubInfoSecretName               = "hub-info-secret"
 	HubInfoSecretKey                = "hub-info.yaml" // #nosec
 	ObservatoriumAPIRemoteWritePath = "/api/metrics/v1/default/api/v1/receive"
@@ -21,6 +22,32 @@ const (
 	MetricsConfigMapKey          = "metrics_list.yaml"
 	UwlMetricsConfigMapKey       = "uwl_metrics_list.yaml"
 	MetricsOcp311ConfigMapKey    = "ocp311_metrics_list.yaml"
+
+	// AllowlistNamespaceLabelKey marks the configmaps containing the uwl allowlist of their namespace.
+	AllowlistNamespaceLabelKey = "observability.open-cluster-management.io/metrics-allowlist"
+	// AllowlistStatusAnnotation reports the accepted and rejected entries of a namespace allowlist.
+	AllowlistStatusAnnotation = "observability.open-cluster-management.io/metrics-allowlist-status"
+
+	// MetricsSourceConditionPrefix prefixes the type of the condition reported for an additional
+	// metrics source, followed by the name of the source.
+	MetricsSourceConditionPrefix = "MetricsSource-"
+	// DiagnosticConditionPrefix prefixes the type of the conditions reported by the self-diagnostics
+	// of the endpoint operator.
+	DiagnosticConditionPrefix = "Diagnostic"
+	// HostedClusterConditionPrefix prefixes the type of the condition reported for the metrics-collector
+	// of a HyperShift hosted cluster, followed by the name of the hosted cluster.
+	HostedClusterConditionPrefix = "HostedCluster-"
+
+	// HostedClusterLabelKey labels the resources of a HyperShift hosted cluster, including the
+	// certificate signing requests of its metrics-collector, with the name of the hosted cluster.
+	HostedClusterLabelKey = "observability.open-cluster-management.io/hosted-cluster"
+	// HostedClusterUserPrefix prefixes the common name of the client certificate issued to the
+	// metrics-collector of a hosted cluster.
+	HostedClusterUserPrefix = "hosted-cluster-observability:"
+	// ObservabilitySignerName signs the client certificates used to upload the metrics to the hub.
+	ObservabilitySignerName = "open-cluster-management.io/observability-signer"
+	// ManagedClusterOU is the organization unit of the client certificates used to upload the metrics.
+	ManagedClusterOU = "acm"
 )
 
 const (
@@ -56,3 +83,14 @@ var (
 		PrometheusConfigmapReloaderKey: PrometheusConfigmapReloaderImgName,
 	}
 )
+
+// GetHostedClusterUser returns the common name of the client certificate of the metrics-collector
+// of a hosted cluster, the name of the hosted cluster is scoped by its management cluster.
+func GetHostedClusterUser(cluster, hostedCluster string) string {
+	return HostedClusterUserPrefix + cluster + ":" + hostedCluster
+}
+
+// GetObservatoriumAPIRemoteWritePath returns the path of the remote write API of the tenant of the observatorium API.
+func GetObservatoriumAPIRemoteWritePath(tenant string) string {
+	return "/api/metrics/v1/" + tenant + "/api/v1/receive"
+}
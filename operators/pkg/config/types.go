@@ -16,6 +16,8 @@ type HubInfo struct {
 	ObservatoriumAPIEndpoint string `yaml:"observatorium-api-endpoint"`
 	AlertmanagerEndpoint     string `yaml:"alertmanager-endpoint"`
 	AlertmanagerRouterCA     string `yaml:"alertmanager-router-ca"`
+	// ClusterLabels are the labels of the managed cluster on the hub
+	ClusterLabels map[string]string `yaml:"cluster-labels,omitempty"`
 }
 
 type RecordingRule struct {
@@ -54,4 +56,9 @@ type MetricsAllowlist struct {
 	RuleList             []RecordingRule    `yaml:"rules"` //deprecated
 	RecordingRuleList    []RecordingRule    `yaml:"recording_rules"`
 	CollectRuleGroupList []CollectRuleGroup `yaml:"collect_rules"`
+	// ExemplarList contains the names of allowlisted metrics whose exemplars are forwarded
+	ExemplarList []string `yaml:"exemplars"`
+	// NamespaceSeriesQuota is the maximum number of uwl series forwarded for each namespace
+	// with its own allowlist configmap, 0 means no limit
+	NamespaceSeriesQuota int `yaml:"namespace_series_quota,omitempty"`
 }
@@ -105,6 +105,11 @@ func (d *Deployer) updateDeployment(desiredObj, runtimeObj *unstructured.Unstruc
 		log.Error(err, fmt.Sprintf("Failed to Unmarshal Deployment %s", runtimeObj.GetName()))
 	}
 
+	// the replicas are not set when they are managed by a HorizontalPodAutoscaler, keep the runtime ones
+	if desiredDepoly.Spec.Replicas == nil {
+		desiredDepoly.Spec.Replicas = runtimeDepoly.Spec.Replicas
+	}
+
 	if !apiequality.Semantic.DeepDerivative(desiredDepoly.Spec, runtimeDepoly.Spec) {
 		log.Info("Update", "Kind:", runtimeObj.GroupVersionKind(), "Name:", runtimeObj.GetName())
 		return d.client.Update(context.TODO(), desiredDepoly)
@@ -322,7 +327,11 @@ func (d *Deployer) updatePrometheus(desiredObj, runtimeObj *unstructured.Unstruc
 		log.Info("Desired Prometheus: AdditionalAlertManagerConfig is null")
 	}
 
-	if !apiequality.Semantic.DeepDerivative(desiredPrometheus.Spec, runtimePrometheus.Spec) {
+	// DeepDerivative ignores the fields unset in the desired Prometheus, the alertmanager config
+	// removed when the alert forwarding is disabled has to be checked on its own
+	alertingRemoved := desiredPrometheus.Spec.AdditionalAlertManagerConfigs == nil &&
+		runtimePrometheus.Spec.AdditionalAlertManagerConfigs != nil
+	if alertingRemoved || !apiequality.Semantic.DeepDerivative(desiredPrometheus.Spec, runtimePrometheus.Spec) {
 		log.Info("Update", "Kind:", runtimeObj.GroupVersionKind(), "Name:", runtimeObj.GetName())
 		return d.client.Update(context.TODO(), desiredPrometheus)
 	} else {
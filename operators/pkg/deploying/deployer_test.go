@@ -120,6 +120,51 @@ func TestDeploy(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "update the autoscaled deployment",
+			createObj: &appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-deployment-3",
+					Namespace: "ns1",
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: &replicas2,
+				},
+			},
+			updateObj: &appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "test-deployment-3",
+					Namespace:       "ns1",
+					ResourceVersion: "1",
+				},
+				Spec: appsv1.DeploymentSpec{
+					MinReadySeconds: 10,
+				},
+			},
+			validateResults: func(client client.Client) {
+				namespacedName := types.NamespacedName{
+					Name:      "test-deployment-3",
+					Namespace: "ns1",
+				}
+				obj := &appsv1.Deployment{}
+				client.Get(context.Background(), namespacedName, obj)
+
+				if obj.Spec.MinReadySeconds != 10 {
+					t.Fatalf("fail to update the deployment")
+				}
+				if obj.Spec.Replicas == nil || *obj.Spec.Replicas != 2 {
+					t.Fatalf("the replicas of the deployment should be kept")
+				}
+			},
+		},
 		{
 			name: "create and update the statefulset",
 			createObj: &appsv1.StatefulSet{
@@ -468,6 +513,49 @@ func TestDeploy(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "remove the alertmanager config of the prometheus",
+			createObj: &prometheusv1.Prometheus{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "monitoring.coreos.com/v1",
+					Kind:       "Prometheus",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-prometheus-alerting",
+					Namespace: "ns1",
+				},
+				Spec: prometheusv1.PrometheusSpec{
+					AdditionalAlertManagerConfigs: &corev1.SecretKeySelector{
+						Key: "old",
+					},
+					Secrets: []string{"hub-alertmanager-router-ca"},
+				},
+			},
+			updateObj: &prometheusv1.Prometheus{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "monitoring.coreos.com/v1",
+					Kind:       "Prometheus",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "test-prometheus-alerting",
+					Namespace:       "ns1",
+					ResourceVersion: "1",
+				},
+				Spec: prometheusv1.PrometheusSpec{},
+			},
+			validateResults: func(client client.Client) {
+				namespacedName := types.NamespacedName{
+					Name:      "test-prometheus-alerting",
+					Namespace: "ns1",
+				}
+				obj := &prometheusv1.Prometheus{}
+				client.Get(context.Background(), namespacedName, obj)
+
+				if obj.Spec.AdditionalAlertManagerConfigs != nil || len(obj.Spec.Secrets) != 0 {
+					t.Fatalf("fail to remove the alertmanager config of the prometheus")
+				}
+			},
+		},
 	}
 
 	scheme := runtime.NewScheme()
@@ -10,6 +10,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/kustomize/v3/k8sdeps/kunstruct"
 	"sigs.k8s.io/kustomize/v3/pkg/ifc"
 	"sigs.k8s.io/kustomize/v3/pkg/resource"
@@ -30,22 +31,23 @@ type patchGenerateFn func(
 
 func ApplyGlobalPatches(res *resource.Resource, mco *mcov1beta2.MultiClusterObservability) error {
 
-	// for _, generate := range []patchGenerateFn{
-	// 	//generateImagePatch,
-	// 	//generateImagePullSecretsPatch,
-	// 	generateNodeSelectorPatch,
-	// } {
-	// 	patch, err := generate(res, mco)
-	// 	if err != nil {
-	// 		return err
-	// 	}
-	// 	if patch == nil {
-	// 		continue
-	// 	}
-	// 	if err = res.Patch(patch); err != nil {
-	// 		return err
-	// 	}
-	// }
+	for _, generate := range []patchGenerateFn{
+		//generateImagePatch,
+		//generateImagePullSecretsPatch,
+		//generateNodeSelectorPatch,
+		generateSchedulingPatch,
+	} {
+		patch, err := generate(res, mco)
+		if err != nil {
+			return err
+		}
+		if patch == nil {
+			continue
+		}
+		if err = res.Patch(patch); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -139,6 +141,54 @@ func generateImagePullSecretsPatch(
 // 	return &kunstruct.UnstructAdapter{Unstructured: u}, err
 // }
 
+// generateSchedulingPatch patches the scheduling of the pods of the component, the component is identified by the
+// name of its workload template. The affinity is merged into the affinity of the template.
+func generateSchedulingPatch(
+	res *resource.Resource,
+	mco *mcov1beta2.MultiClusterObservability) (ifc.Kunstructured, error) {
+	if res.GetKind() != "Deployment" && res.GetKind() != "StatefulSet" {
+		return nil, nil
+	}
+	spec := mcoconfig.GetSchedulingSpec(res.GetName(), mco.Spec.AdvancedConfig)
+	if spec == nil {
+		return nil, nil
+	}
+
+	podSpec := map[string]interface{}{}
+	if spec.Affinity != nil {
+		affinity, err := runtime.DefaultUnstructuredConverter.ToUnstructured(spec.Affinity)
+		if err != nil {
+			return nil, err
+		}
+		podSpec["affinity"] = affinity
+	}
+	if len(spec.TopologySpreadConstraints) != 0 {
+		constraints := []interface{}{}
+		for i := range spec.TopologySpreadConstraints {
+			constraint, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec.TopologySpreadConstraints[i])
+			if err != nil {
+				return nil, err
+			}
+			constraints = append(constraints, constraint)
+		}
+		podSpec["topologySpreadConstraints"] = constraints
+	}
+	if spec.PriorityClassName != "" {
+		podSpec["priorityClassName"] = spec.PriorityClassName
+	}
+	if len(podSpec) == 0 {
+		return nil, nil
+	}
+
+	return kunstruct.NewKunstructuredFactoryImpl().FromMap(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": podSpec,
+			},
+		},
+	}), nil
+}
+
 func generateReplicasPatch(replicas int32) ifc.Kunstructured {
 	return kunstruct.NewKunstructuredFactoryImpl().FromMap(map[string]interface{}{
 		"spec": map[string]interface{}{
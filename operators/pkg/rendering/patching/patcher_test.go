@@ -6,6 +6,7 @@ package patching
 import (
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/kustomize/v3/k8sdeps/kunstruct"
@@ -69,3 +70,82 @@ func TestApplyGlobalPatches(t *testing.T) {
 		t.Fatalf("failed to apply global patches %v", err)
 	}
 }
+
+var grafana = `
+kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: grafana
+spec:
+  template:
+    spec:
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+          - weight: 70
+            podAffinityTerm:
+              topologyKey: topology.kubernetes.io/zone
+      containers:
+      - name: grafana
+        image: "grafana"
+`
+
+func TestApplySchedulingPatch(t *testing.T) {
+	json, err := yaml.YAMLToJSON([]byte(grafana))
+	if err != nil {
+		t.Fatalf("failed to apply global patches %v", err)
+	}
+	var u unstructured.Unstructured
+	u.UnmarshalJSON(json)
+	grafana := factory.FromMap(u.Object)
+
+	mco := &mcov1beta2.MultiClusterObservability{
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			AdvancedConfig: &mcov1beta2.AdvancedConfig{
+				Grafana: &mcov1beta2.CommonSpec{
+					Affinity: &corev1.Affinity{
+						NodeAffinity: &corev1.NodeAffinity{
+							RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+								NodeSelectorTerms: []corev1.NodeSelectorTerm{
+									{
+										MatchExpressions: []corev1.NodeSelectorRequirement{
+											{Key: "node-role.kubernetes.io/infra", Operator: corev1.NodeSelectorOpExists},
+										},
+									},
+								},
+							},
+						},
+					},
+					TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+						{
+							MaxSkew:           1,
+							TopologyKey:       "topology.kubernetes.io/zone",
+							WhenUnsatisfiable: corev1.ScheduleAnyway,
+						},
+					},
+					PriorityClassName: "system-cluster-critical",
+				},
+			},
+		},
+	}
+
+	err = ApplyGlobalPatches(grafana, mco)
+	if err != nil {
+		t.Fatalf("failed to apply global patches %v", err)
+	}
+	priorityClassName, err := grafana.GetString("spec.template.spec.priorityClassName")
+	if err != nil || priorityClassName != "system-cluster-critical" {
+		t.Errorf("priorityClassName (%v) is not the expected: (system-cluster-critical)", priorityClassName)
+	}
+	topologyKey, err := grafana.GetString("spec.template.spec.topologySpreadConstraints[0].topologyKey")
+	if err != nil || topologyKey != "topology.kubernetes.io/zone" {
+		t.Errorf("topologyKey (%v) is not the expected: (topology.kubernetes.io/zone)", topologyKey)
+	}
+	// the node affinity is merged into the pod anti-affinity of the template
+	if _, err = grafana.GetFieldValue("spec.template.spec.affinity.nodeAffinity"); err != nil {
+		t.Errorf("failed to find the node affinity: %v", err)
+	}
+	if _, err = grafana.GetFieldValue("spec.template.spec.affinity.podAntiAffinity"); err != nil {
+		t.Errorf("failed to find the pod anti-affinity: %v", err)
+	}
+}
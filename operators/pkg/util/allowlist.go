@@ -61,6 +61,7 @@ func MergeAllowlist(allowlist, customAllowlist, ocp3Allowlist, uwlAllowlist,
 	*operatorconfig.MetricsAllowlist, *operatorconfig.MetricsAllowlist) {
 	allowlist.NameList = mergeMetrics(allowlist.NameList, customAllowlist.NameList)
 	allowlist.MatchList = mergeMetrics(allowlist.MatchList, customAllowlist.MatchList)
+	allowlist.ExemplarList = mergeMetrics(allowlist.ExemplarList, customAllowlist.ExemplarList)
 	allowlist.CollectRuleGroupList = mergeCollectorRuleGroupList(allowlist.CollectRuleGroupList,
 		customAllowlist.CollectRuleGroupList)
 	if customAllowlist.RecordingRuleList != nil {
@@ -82,7 +83,11 @@ func MergeAllowlist(allowlist, customAllowlist, ocp3Allowlist, uwlAllowlist,
 	}
 	uwlAllowlist.NameList = mergeMetrics(uwlAllowlist.NameList, customUwlAllowlist.NameList)
 	uwlAllowlist.MatchList = mergeMetrics(uwlAllowlist.MatchList, customUwlAllowlist.MatchList)
+	uwlAllowlist.ExemplarList = mergeMetrics(uwlAllowlist.ExemplarList, customUwlAllowlist.ExemplarList)
 	uwlAllowlist.RuleList = append(uwlAllowlist.RuleList, customUwlAllowlist.RuleList...)
+	if customUwlAllowlist.NamespaceSeriesQuota != 0 {
+		uwlAllowlist.NamespaceSeriesQuota = customUwlAllowlist.NamespaceSeriesQuota
+	}
 	for k, v := range customUwlAllowlist.RenameMap {
 		uwlAllowlist.RenameMap[k] = v
 	}
@@ -19,6 +19,10 @@ const (
 	ManagedClusterLabelAllowListNamespace     = "open-cluster-management-observability"
 
 	RBACProxyLabelMetricName = "acm_label_names"
+
+	// the configmap of the managed clusters mapped to each tenant, written by the operator
+	TenantClustersConfigMapName = "observability-tenant-clusters"
+	TenantClustersConfigMapKey  = "tenant_clusters.yaml"
 )
 
 var (
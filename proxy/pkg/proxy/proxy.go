@@ -14,6 +14,7 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"regexp"
 	"strings"
 
 	"k8s.io/klog"
@@ -25,6 +26,7 @@ import (
 
 const (
 	basePath        = "/api/metrics/v1/default"
+	tenantsPath     = "/tenants/"
 	projectsAPIPath = "/apis/project.openshift.io/v1/projects"
 	userAPIPath     = "/apis/user.openshift.io/v1/users/~"
 )
@@ -32,8 +34,31 @@ const (
 var (
 	serverScheme = ""
 	serverHost   = ""
+
+	tenantNameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
 )
 
+// getTenantPath returns the path of the request on the observatorium API. The queries of /tenants/<tenant>/... are
+// routed to the tenant, the other queries to the default tenant. It returns false if the user of the projects is not
+// a member of the tenant, a member can access a managed cluster mapped to the tenant.
+func getTenantPath(reqPath string, projectList []string) (string, bool) {
+	if strings.HasPrefix(reqPath, tenantsPath) {
+		parts := strings.SplitN(strings.TrimPrefix(reqPath, tenantsPath), "/", 2)
+		if tenantNameRegexp.MatchString(parts[0]) {
+			if !util.CanAccessTenant(parts[0], projectList) {
+				return "", false
+			}
+			tenantPath := "/"
+			if len(parts) == 2 {
+				// the path cannot leave the API of the tenant
+				tenantPath = path.Clean("/" + parts[1])
+			}
+			return path.Join("/api/metrics/v1", parts[0], tenantPath), true
+		}
+	}
+	return path.Join(basePath, reqPath), true
+}
+
 func shouldModifyAPISeriesResponse(res http.ResponseWriter, req *http.Request) bool {
 	if strings.HasSuffix(req.URL.Path, "/api/v1/series") {
 		body, err := ioutil.ReadAll(req.Body)
@@ -79,6 +104,14 @@ func HandleRequestAndRedirect(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	projectList, _ := util.GetUserProjectList(req.Header.Get("X-Forwarded-Access-Token"))
+	tenantPath, ok := getTenantPath(req.URL.Path, projectList)
+	if !ok {
+		klog.Infof("user %s is not a member of the tenant of %s", req.Header.Get("X-Forwarded-User"), req.URL.Path)
+		res.WriteHeader(http.StatusForbidden)
+		return
+	}
+
 	if ok := shouldModifyAPISeriesResponse(res, req); ok {
 		return
 	}
@@ -103,7 +136,7 @@ func HandleRequestAndRedirect(res http.ResponseWriter, req *http.Request) {
 
 	req.Header.Set("X-Forwarded-Host", req.Header.Get("Host"))
 	req.Host = serverURL.Host
-	req.URL.Path = path.Join(basePath, req.URL.Path)
+	req.URL.Path = tenantPath
 	util.ModifyMetricsQueryParams(req, config.GetConfigOrDie().Host+projectsAPIPath)
 	proxy.ServeHTTP(res, req)
 }
@@ -186,6 +219,7 @@ func proxyRequest(r *http.Request) {
 	if r.Method == http.MethodGet {
 		if strings.HasSuffix(r.URL.Path, "/api/v1/query") ||
 			strings.HasSuffix(r.URL.Path, "/api/v1/query_range") ||
+			strings.HasSuffix(r.URL.Path, "/api/v1/query_exemplars") ||
 			strings.HasSuffix(r.URL.Path, "/api/v1/series") {
 			r.Method = http.MethodPost
 			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -14,6 +14,10 @@ import (
 	"strings"
 	"testing"
 
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/config"
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/util"
 )
@@ -158,6 +162,7 @@ func TestProxyRequest(t *testing.T) {
 	pathList := []string{
 		"/api/v1/query",
 		"/api/v1/query_range",
+		"/api/v1/query_exemplars",
 		"/api/v1/series",
 	}
 
@@ -217,3 +222,32 @@ func TestModifyAPISeriesResponse(t *testing.T) {
 		t.Errorf("case (%v) output: (%v) is not the expected: (%v)", testCase.name, ok, !testCase.expected)
 	}
 }
+
+func TestGetTenantPath(t *testing.T) {
+	caseList := map[string]string{
+		"/api/v1/query":                      "/api/metrics/v1/default/api/v1/query",
+		"/tenants/team-a/api/v1/query":       "/api/metrics/v1/team-a/api/v1/query",
+		"/tenants/team-a":                    "/api/metrics/v1/team-a",
+		"/tenants/Team_A/api/v1/query":       "/api/metrics/v1/default/tenants/Team_A/api/v1/query",
+		"/tenants/../admin/api/v1/query":     "/api/metrics/v1/default/admin/api/v1/query",
+		"/tenants/team-a/../b/api/v1/series": "/api/metrics/v1/team-a/b/api/v1/series",
+		"/tenants/default/api/v1/query":      "/api/metrics/v1/default/api/v1/query",
+	}
+	util.InitAllManagedClusterNames()
+	util.GetAllManagedClusterNames()["c1"] = "c1"
+	util.GetAllManagedClusterNames()["c2"] = "c2"
+	util.GetManagedClusterLabelAllowListEventHandler(fake.NewSimpleClientset()).AddFunc(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.TenantClustersConfigMapName},
+		Data:       map[string]string{config.TenantClustersConfigMapKey: "team-a:\n- c1\nteam-b:\n- c2\n"},
+	})
+	for reqPath, expected := range caseList {
+		if output, ok := getTenantPath(reqPath, []string{"c1"}); !ok || output != expected {
+			t.Errorf("path of (%v): (%v) is not the expected: (%v)", reqPath, output, expected)
+		}
+	}
+
+	// the user cannot access the clusters of the tenant
+	if _, ok := getTenantPath("/tenants/team-b/api/v1/query", []string{"c1"}); ok {
+		t.Errorf("user of (%v) is not a member of the tenant: (%v)", []string{"c1"}, "team-b")
+	}
+}
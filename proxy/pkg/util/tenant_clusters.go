@@ -0,0 +1,52 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package util
+
+import (
+	"sync"
+
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+
+	proxyconfig "github.com/stolostron/multicluster-observability-operator/proxy/pkg/config"
+)
+
+const defaultTenantName = "default"
+
+var tenantClusters = struct {
+	sync.RWMutex
+	clusters map[string][]string
+}{clusters: map[string][]string{}}
+
+// updateTenantClusters saves the managed clusters mapped to each tenant from the configmap of the operator.
+func updateTenantClusters(cm *v1.ConfigMap) {
+	clusters := map[string][]string{}
+	if cm != nil {
+		err := yaml.Unmarshal([]byte(cm.Data[proxyconfig.TenantClustersConfigMapKey]), &clusters)
+		if err != nil {
+			klog.Errorf("failed to unmarshal the tenant clusters: %v", err)
+			return
+		}
+	}
+	tenantClusters.Lock()
+	defer tenantClusters.Unlock()
+	tenantClusters.clusters = clusters
+}
+
+// CanAccessTenant checks the user can access a managed cluster mapped to the tenant. Every user can access the
+// default tenant, its queries are restricted to the clusters of the user.
+func CanAccessTenant(tenant string, projectList []string) bool {
+	if tenant == defaultTenantName || canAccessAllClusters(projectList) {
+		return true
+	}
+	tenantClusters.RLock()
+	defer tenantClusters.RUnlock()
+	for _, cluster := range tenantClusters.clusters[tenant] {
+		if Contains(projectList, cluster) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2022 Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+
+package util
+
+import (
+	"testing"
+
+	proxyconfig "github.com/stolostron/multicluster-observability-operator/proxy/pkg/config"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCanAccessTenant(t *testing.T) {
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      proxyconfig.TenantClustersConfigMapName,
+			Namespace: proxyconfig.ManagedClusterLabelAllowListNamespace,
+		},
+		Data: map[string]string{
+			proxyconfig.TenantClustersConfigMapKey: "team-a:\n- c1\nteam-b:\n- c2\n",
+		},
+	}
+	allManagedClusterNames = map[string]string{"c1": "c1", "c2": "c2", "c3": "c3"}
+	eventHandler := GetManagedClusterLabelAllowListEventHandler(fake.NewSimpleClientset())
+	eventHandler.AddFunc(cm)
+
+	testCaseList := []struct {
+		name        string
+		tenant      string
+		projectList []string
+		expected    bool
+	}{
+		{"cluster of the tenant", "team-a", []string{"c1"}, true},
+		{"cluster of another tenant", "team-a", []string{"c2", "c3"}, false},
+		{"default tenant", "default", []string{"c3"}, true},
+		{"all clusters", "team-c", []string{"c1", "c2", "c3"}, true},
+		{"unknown tenant", "team-c", []string{"c1"}, false},
+	}
+	for _, c := range testCaseList {
+		output := CanAccessTenant(c.tenant, c.projectList)
+		if output != c.expected {
+			t.Errorf("case (%v) output: (%v) is not the expected: (%v)", c.name, output, c.expected)
+		}
+	}
+
+	eventHandler.DeleteFunc(cm)
+	if output := CanAccessTenant("team-a", []string{"c1"}); output {
+		t.Errorf("case (%v) output: (%v) is not the expected: (%v)", "deleted configmap", output, false)
+	}
+}
@@ -335,6 +335,9 @@ func GetManagedClusterLabelAllowListEventHandler(kubeClient kubernetes.Interface
 					}
 				}
 			}
+			if obj.(*v1.ConfigMap).Name == proxyconfig.TenantClustersConfigMapName {
+				updateTenantClusters(obj.(*v1.ConfigMap))
+			}
 		},
 
 		DeleteFunc: func(obj interface{}) {
@@ -342,6 +345,9 @@ func GetManagedClusterLabelAllowListEventHandler(kubeClient kubernetes.Interface
 				klog.Warningf("deleted configmap: %s", proxyconfig.GetManagedClusterLabelAllowListConfigMapName())
 				StopScheduleManagedClusterLabelAllowlistResync()
 			}
+			if obj.(*v1.ConfigMap).Name == proxyconfig.TenantClustersConfigMapName {
+				updateTenantClusters(nil)
+			}
 		},
 
 		UpdateFunc: func(oldObj, newObj interface{}) {
@@ -361,6 +367,9 @@ func GetManagedClusterLabelAllowListEventHandler(kubeClient kubernetes.Interface
 
 				updateAllManagedClusterLabelNames(managedLabelList)
 			}
+			if newObj.(*v1.ConfigMap).Name == proxyconfig.TenantClustersConfigMapName {
+				updateTenantClusters(newObj.(*v1.ConfigMap))
+			}
 		},
 	}
 }